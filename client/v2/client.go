@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"time"
 
 	"github.com/influxdata/influxdb/models"
@@ -467,6 +468,11 @@ type Query struct {
 	Command   string
 	Database  string
 	Precision string
+
+	// Parameters are substituted for $name placeholders in the command.
+	// Values may be strings, numbers, booleans, time.Time, time.Duration
+	// or *regexp.Regexp.
+	Parameters map[string]interface{}
 }
 
 // NewQuery returns a query object
@@ -480,6 +486,43 @@ func NewQuery(command, database, precision string) Query {
 	}
 }
 
+// NewQueryWithParameters returns a query object with bound parameters.
+// database and precision strings can be empty strings if they are not needed
+// for the query.
+func NewQueryWithParameters(command, database, precision string, parameters map[string]interface{}) Query {
+	return Query{
+		Command:    command,
+		Database:   database,
+		Precision:  precision,
+		Parameters: parameters,
+	}
+}
+
+// encodeParameters returns the JSON encoding of the query parameters.
+// Durations, times and regexes are encoded as typed objects so that the
+// server binds them to the correct literal type.
+func encodeParameters(parameters map[string]interface{}) (string, error) {
+	m := make(map[string]interface{}, len(parameters))
+	for k, v := range parameters {
+		switch v := v.(type) {
+		case time.Duration:
+			m[k] = map[string]interface{}{"duration": int64(v)}
+		case time.Time:
+			m[k] = map[string]interface{}{"time": v.UTC().Format(time.RFC3339Nano)}
+		case *regexp.Regexp:
+			m[k] = map[string]interface{}{"regex": v.String()}
+		default:
+			m[k] = v
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 // Response represents a list of statement results.
 type Response struct {
 	Results []Result
@@ -531,6 +574,13 @@ func (c *client) Query(q Query) (*Response, error) {
 	if q.Precision != "" {
 		params.Set("epoch", q.Precision)
 	}
+	if len(q.Parameters) > 0 {
+		encoded, err := encodeParameters(q.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("params", encoded)
+	}
 	req.URL.RawQuery = params.Encode()
 
 	resp, err := c.httpClient.Do(req)
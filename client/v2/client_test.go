@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -90,6 +91,36 @@ func TestClient_Query(t *testing.T) {
 	}
 }
 
+func TestClient_Query_Parameters(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query().Get("params")
+		exp := `{"host":"server01","interval":{"duration":10000000000},"re":{"regex":"^cpu"},"start":{"time":"2000-01-01T00:00:00Z"},"value":2.5}`
+		if params != exp {
+			t.Errorf("unexpected params, expected %s, actual %s", exp, params)
+		}
+
+		var data Response
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(data)
+	}))
+	defer ts.Close()
+
+	config := HTTPConfig{Addr: ts.URL}
+	c, _ := NewHTTPClient(config)
+	defer c.Close()
+
+	query := NewQueryWithParameters("SELECT value FROM cpu WHERE host = $host", "db0", "", map[string]interface{}{
+		"host":     "server01",
+		"value":    2.5,
+		"interval": 10 * time.Second,
+		"start":    time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		"re":       regexp.MustCompile(`^cpu`),
+	})
+	if _, err := c.Query(query); err != nil {
+		t.Errorf("unexpected error.  expected %v, actual %v", nil, err)
+	}
+}
+
 func TestClient_BasicAuth(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		u, p, ok := r.BasicAuth()
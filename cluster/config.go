@@ -28,6 +28,10 @@ type Config struct {
 	ShardWriterTimeout        toml.Duration `toml:"shard-writer-timeout"`
 	MaxRemoteWriteConnections int           `toml:"max-remote-write-connections"`
 	ShardMapperTimeout        toml.Duration `toml:"shard-mapper-timeout"`
+
+	// Zone is the rack or zone of the node. Replicas of a shard are placed
+	// in different zones where possible.
+	Zone string `toml:"zone"`
 }
 
 // NewConfig returns an instance of Config with defaults.
@@ -14,6 +14,7 @@ func TestConfig_Parse(t *testing.T) {
 	if _, err := toml.Decode(`
 shard-writer-timeout = "10s"
 write-timeout = "20s"
+zone = "us-east-1a"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -23,5 +24,7 @@ write-timeout = "20s"
 		t.Fatalf("unexpected shard-writer timeout: %s", c.ShardWriterTimeout)
 	} else if time.Duration(c.WriteTimeout) != 20*time.Second {
 		t.Fatalf("unexpected write timeout s: %s", c.WriteTimeout)
+	} else if c.Zone != "us-east-1a" {
+		t.Fatalf("unexpected zone: %s", c.Zone)
 	}
 }
@@ -40,7 +40,7 @@ func (ic *IteratorCreator) CreateIterator(opt influxql.IteratorOptions) (influxq
 }
 
 // FieldDimensions returns the unique fields and dimensions across a list of sources.
-func (ic *IteratorCreator) FieldDimensions(sources influxql.Sources) (fields, dimensions map[string]struct{}, err error) {
+func (ic *IteratorCreator) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
 	// FIXME(benbjohnson): Integrate remote execution.
 	return ic.TSDBStore.FieldDimensions(sources)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tcp"
 )
 
 const (
@@ -25,6 +26,9 @@ type ShardWriter struct {
 	MetaClient interface {
 		DataNode(id uint64) (ni *meta.NodeInfo, err error)
 	}
+
+	// Dialer secures connections to other nodes. Connections are plain if nil.
+	Dialer *tcp.Dialer
 }
 
 // NewShardWriter returns a new instance of ShardWriter.
@@ -94,7 +98,7 @@ func (w *ShardWriter) dial(nodeID uint64) (net.Conn, error) {
 	// If we don't have a connection pool for that addr yet, create one
 	_, ok := w.pool.getPool(nodeID)
 	if !ok {
-		factory := &connFactory{nodeID: nodeID, clientPool: w.pool, timeout: w.timeout}
+		factory := &connFactory{nodeID: nodeID, clientPool: w.pool, timeout: w.timeout, dialer: w.Dialer}
 		factory.metaClient = w.MetaClient
 
 		p, err := NewBoundedPool(1, w.maxConnections, w.timeout, factory.dial)
@@ -126,6 +130,7 @@ var errMaxConnectionsExceeded = fmt.Errorf("can not exceed max connections of %d
 type connFactory struct {
 	nodeID  uint64
 	timeout time.Duration
+	dialer  *tcp.Dialer
 
 	clientPool interface {
 		size() int
@@ -150,17 +155,5 @@ func (c *connFactory) dial() (net.Conn, error) {
 		return nil, fmt.Errorf("node %d does not exist", c.nodeID)
 	}
 
-	conn, err := net.DialTimeout("tcp", ni.TCPHost, c.timeout)
-	if err != nil {
-		return nil, err
-	}
-
-	// Write a marker byte for cluster messages.
-	_, err = conn.Write([]byte{MuxHeader})
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
-
-	return conn, nil
+	return c.dialer.DialTimeout("tcp", ni.TCPHost, MuxHeader, c.timeout)
 }
@@ -43,6 +43,7 @@ type Command struct {
 	host     string
 	path     string
 	database string
+	dialer   *tcp.Dialer
 }
 
 // NewCommand returns a new instance of Command with default settings.
@@ -99,6 +100,11 @@ func (cmd *Command) parseFlags(args []string) (retentionPolicy, shardID string,
 	fs.StringVar(&shardID, "shard", "", "")
 	var sinceArg string
 	fs.StringVar(&sinceArg, "since", "", "")
+	var mux tcp.Config
+	fs.StringVar(&mux.CA, "ca", "", "")
+	fs.StringVar(&mux.Certificate, "cert", "", "")
+	fs.StringVar(&mux.PrivateKey, "key", "", "")
+	fs.StringVar(&mux.SharedSecret, "secret", "", "")
 
 	fs.SetOutput(cmd.Stderr)
 	fs.Usage = cmd.printUsage
@@ -114,6 +120,16 @@ func (cmd *Command) parseFlags(args []string) (retentionPolicy, shardID string,
 		}
 	}
 
+	// Connect over TLS if a certificate is given.
+	mux.TLSEnabled = mux.CA != "" || mux.Certificate != ""
+	if err = mux.Validate(); err != nil {
+		return
+	}
+	cmd.dialer, err = mux.Dialer()
+	if err != nil {
+		return
+	}
+
 	// Ensure that only one arg is specified.
 	if fs.NArg() == 0 {
 		return "", "", time.Unix(0, 0), errors.New("backup destination path required")
@@ -291,7 +307,7 @@ func (cmd *Command) download(req *snapshotter.Request, path string) error {
 	defer f.Close()
 
 	// Connect to snapshotter service.
-	conn, err := tcp.Dial("tcp", cmd.host, snapshotter.MuxHeader)
+	conn, err := cmd.dialer.Dial("tcp", cmd.host, snapshotter.MuxHeader)
 	if err != nil {
 		return err
 	}
@@ -313,7 +329,7 @@ func (cmd *Command) download(req *snapshotter.Request, path string) error {
 // requestInfo will request the database or retention policy information from the host
 func (cmd *Command) requestInfo(request *snapshotter.Request) (*snapshotter.Response, error) {
 	// Connect to snapshotter service.
-	conn, err := tcp.Dial("tcp", cmd.host, snapshotter.MuxHeader)
+	conn, err := cmd.dialer.Dial("tcp", cmd.host, snapshotter.MuxHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -351,6 +367,16 @@ Options:
   -since <2015-12-24T08:12:23>
         Optional. Do an incremental backup since the passed in RFC3339
         formatted time.
+  -ca <path>
+        Optional. The CA certificate to verify the host with. Required to
+        connect to a host with mux TLS enabled.
+  -cert <path>
+        Optional. The client certificate presented to the host.
+  -key <path>
+        Optional. The private key of the client certificate. Defaults to
+        the certificate file.
+  -secret <secret>
+        Optional. The shared secret of the host's mux.
 
 `)
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/influxdb/cmd/influxd/backup"
 	"github.com/influxdata/influxdb/cmd/influxd/help"
+	"github.com/influxdata/influxdb/cmd/influxd/meta"
 	"github.com/influxdata/influxdb/cmd/influxd/restore"
 	"github.com/influxdata/influxdb/cmd/influxd/run"
 )
@@ -119,6 +120,11 @@ func (m *Main) Run(args ...string) error {
 		if err := name.Run(args...); err != nil {
 			return fmt.Errorf("backup: %s", err)
 		}
+	case "meta":
+		name := meta.NewCommand()
+		if err := name.Run(args...); err != nil {
+			return fmt.Errorf("meta: %s", err)
+		}
 	case "restore":
 		name := restore.NewCommand()
 		if err := name.Run(args...); err != nil {
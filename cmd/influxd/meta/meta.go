@@ -0,0 +1,335 @@
+// Package meta implements the "influxd meta" command for backing up,
+// restoring and inspecting the metastore.
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/influxdata/influxdb/cmd/influxd/backup"
+	"github.com/influxdata/influxdb/cmd/influxd/restore"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/services/snapshotter"
+	"github.com/influxdata/influxdb/tcp"
+)
+
+// Command represents the program execution for "influxd meta".
+type Command struct {
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewCommand returns a new instance of Command with default settings.
+func NewCommand() *Command {
+	return &Command{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Run executes the sub-command named by the first argument.
+func (cmd *Command) Run(args ...string) error {
+	var name string
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	switch name {
+	case "backup":
+		return cmd.backup(args)
+	case "restore":
+		return cmd.restore(args)
+	case "dump":
+		return cmd.dump(args)
+	case "", "-h", "help":
+		cmd.printUsage()
+		return nil
+	default:
+		return fmt.Errorf(`unknown meta command "%s"`, name)
+	}
+}
+
+// backup downloads a snapshot of the metastore from a data node and saves
+// it to the backup path.
+func (cmd *Command) backup(args []string) error {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	host := fs.String("host", "localhost:8088", "")
+	mux := muxFlags(fs)
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() != 1 {
+		return errors.New("backup destination path required")
+	}
+	path := fs.Arg(0)
+
+	b, err := download(*host, mux)
+	if err != nil {
+		return err
+	}
+	data, _, err := restore.ParseMetaBackup(b)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return err
+	}
+	filename, err := nextPath(filepath.Join(path, backup.Metafile))
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a partial backup is never picked
+	// up by restore.
+	if err := ioutil.WriteFile(filename+backup.Suffix, b, 0600); err != nil {
+		return err
+	} else if err := os.Rename(filename+backup.Suffix, filename); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.Stdout, "backed up metastore at index %d (term %d) to %s\n", data.Index, data.Term, filename)
+	return nil
+}
+
+// restore rebuilds the metastore in a meta dir from the newest backup, or
+// the newest backup at or before a raft index.
+func (cmd *Command) restore(args []string) error {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	metadir := fs.String("metadir", "", "")
+	index := fs.Uint64("index", 0, "")
+	httpBindAddress := fs.String("http-bind-address", meta.DefaultHTTPBindAddress, "")
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() != 1 {
+		return errors.New("path with backup files required")
+	} else if *metadir == "" {
+		return errors.New("-metadir is required to restore")
+	}
+
+	c := meta.NewConfig()
+	c.Dir = *metadir
+	c.HTTPBindAddress = *httpBindAddress
+
+	// Ensure influxd isn't running.
+	ln, err := net.Listen("tcp", c.BindAddress)
+	if err != nil {
+		fmt.Fprintln(cmd.Stderr, "influxd cannot be running during a restore.  Please stop any running instances and try again.")
+		return fmt.Errorf("influxd running on %s: aborting.", c.BindAddress)
+	}
+	ln.Close()
+
+	filename, data, node, err := findBackup(fs.Arg(0), *index)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.Stdout, "Using metastore snapshot: %s (index %d, term %d)\n", filename, data.Index, data.Term)
+
+	// Keep only this node so it can start on its own and the other meta
+	// nodes can join it.
+	if err := keepMetaNode(data, c.DefaultedHTTPBindAddress()); err != nil {
+		return err
+	}
+
+	if err := restore.RestoreMeta(c, data, node); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.Stdout, "Metastore restored. Start this node first, then start any other meta nodes with empty meta dirs joining it.")
+	return nil
+}
+
+// dump prints the metadata of a backup, or of a data node if no backup is
+// given, as JSON. Password hashes are omitted.
+func (cmd *Command) dump(args []string) error {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	host := fs.String("host", "localhost:8088", "")
+	index := fs.Uint64("index", 0, "")
+	mux := muxFlags(fs)
+	fs.SetOutput(cmd.Stderr)
+	fs.Usage = cmd.printUsage
+	if err := fs.Parse(args); err != nil {
+		return err
+	} else if fs.NArg() > 1 {
+		return errors.New("only one backup path allowed")
+	}
+
+	var data *meta.Data
+	if path := fs.Arg(0); path != "" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if fi.IsDir() {
+			_, data, _, err = findBackup(path, *index)
+		} else {
+			data, _, err = restore.ReadMetaBackup(path)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		b, err := download(*host, mux)
+		if err != nil {
+			return err
+		}
+		if data, _, err = restore.ParseMetaBackup(b); err != nil {
+			return err
+		}
+	}
+
+	for i := range data.Users {
+		data.Users[i].Hash = ""
+	}
+
+	buf, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.Stdout, string(buf))
+	return nil
+}
+
+// printUsage prints the usage message to STDERR.
+func (cmd *Command) printUsage() {
+	fmt.Fprintf(cmd.Stderr, `usage: influxd meta <command> [flags] [PATH]
+
+The commands are:
+
+    backup               downloads a snapshot of the metastore and saves it to PATH
+    restore              rebuilds a meta dir from a snapshot in PATH
+    dump                 prints the metadata of a snapshot or a data node as JSON
+
+Options for backup and dump:
+  -host <host:port>
+        The data node to download the snapshot from. Defaults to
+        localhost:8088. Ignored by dump if PATH is given.
+  -ca <path>, -cert <path>, -key <path>, -secret <secret>
+        Optional. Mux TLS and shared secret settings of the host.
+
+Options for restore and dump:
+  -index <index>
+        Optional. Use the newest snapshot in PATH at or before the raft
+        index instead of the newest snapshot.
+
+Options for restore:
+  -metadir <path>
+        The meta dir to restore the metastore to. The restored node starts
+        as a single meta node; other meta nodes rejoin it with empty meta
+        dirs to rebuild the cluster.
+  -http-bind-address <host:port>
+        The meta HTTP bind address of this node, used to find this node
+        among the meta nodes of the snapshot. Defaults to :8091.
+
+`)
+}
+
+// muxFlags registers the flags for connecting to a secured mux.
+func muxFlags(fs *flag.FlagSet) *tcp.Config {
+	var c tcp.Config
+	fs.StringVar(&c.CA, "ca", "", "")
+	fs.StringVar(&c.Certificate, "cert", "", "")
+	fs.StringVar(&c.PrivateKey, "key", "", "")
+	fs.StringVar(&c.SharedSecret, "secret", "", "")
+	return &c
+}
+
+// download returns a metastore backup from the snapshotter of host.
+func download(host string, c *tcp.Config) ([]byte, error) {
+	c.TLSEnabled = c.CA != "" || c.Certificate != ""
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	d, err := c.Dialer()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.Dial("tcp", host, snapshotter.MuxHeader)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(&snapshotter.Request{Type: snapshotter.RequestMetastoreBackup}); err != nil {
+		return nil, fmt.Errorf("encode snapshot request: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, conn); err != nil {
+		return nil, fmt.Errorf("copy backup: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// findBackup returns the metastore backup in path with the highest raft
+// index. If index is not zero, backups after it are ignored.
+func findBackup(path string, index uint64) (filename string, data *meta.Data, node []byte, err error) {
+	files, err := filepath.Glob(filepath.Join(path, backup.Metafile+".*"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	for _, fn := range files {
+		if filepath.Ext(fn) == backup.Suffix {
+			continue
+		}
+
+		d, n, err := restore.ReadMetaBackup(fn)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("%s: %s", fn, err)
+		} else if index != 0 && d.Index > index {
+			continue
+		} else if data != nil && d.Index <= data.Index {
+			continue
+		}
+		filename, data, node = fn, d, n
+	}
+
+	if data == nil && index != 0 {
+		return "", nil, nil, fmt.Errorf("no metastore backups at or before index %d in %s", index, path)
+	} else if data == nil {
+		return "", nil, nil, fmt.Errorf("no metastore backups in %s", path)
+	}
+	return filename, data, node, nil
+}
+
+// keepMetaNode removes all meta nodes from data except the one with the HTTP
+// address addr. Snapshots without meta nodes are left as they are.
+func keepMetaNode(data *meta.Data, addr string) error {
+	if len(data.MetaNodes) == 0 {
+		return nil
+	}
+
+	for _, n := range data.MetaNodes {
+		if n.Host == addr {
+			data.MetaNodes = []meta.NodeInfo{n}
+			return nil
+		}
+	}
+	return fmt.Errorf("no meta node in snapshot with address %s", addr)
+}
+
+// nextPath returns the next file in the series of incremental backups at path.
+func nextPath(path string) (string, error) {
+	for i := 0; ; i++ {
+		s := fmt.Sprintf(path+".%02d", i)
+		if _, err := os.Stat(s); os.IsNotExist(err) {
+			return s, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package meta_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	metacmd "github.com/influxdata/influxdb/cmd/influxd/meta"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/services/snapshotter"
+)
+
+// Ensure dump prints the newest backup in a directory without password hashes.
+func TestCommand_Dump(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	MustWriteBackup(filepath.Join(dir, "meta.00"), 10, "db0")
+	MustWriteBackup(filepath.Join(dir, "meta.01"), 30, "db1")
+	MustWriteBackup(filepath.Join(dir, "meta.02"), 20, "db2")
+
+	data := MustDump(t, dir)
+	if data.Index != 30 {
+		t.Fatalf("unexpected index: %d", data.Index)
+	} else if len(data.Databases) != 1 || data.Databases[0].Name != "db1" {
+		t.Fatalf("unexpected databases: %+v", data.Databases)
+	} else if len(data.Users) != 1 || data.Users[0].Name != "admin" || data.Users[0].Hash != "" {
+		t.Fatalf("unexpected users: %+v", data.Users)
+	}
+}
+
+// Ensure dump selects the newest backup at or before an index.
+func TestCommand_Dump_Index(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	MustWriteBackup(filepath.Join(dir, "meta.00"), 10, "db0")
+	MustWriteBackup(filepath.Join(dir, "meta.01"), 30, "db1")
+	MustWriteBackup(filepath.Join(dir, "meta.02"), 20, "db2")
+
+	if data := MustDump(t, "-index", "25", dir); data.Index != 20 {
+		t.Fatalf("unexpected index: %d", data.Index)
+	}
+
+	cmd := metacmd.NewCommand()
+	cmd.Stdout = ioutil.Discard
+	if err := cmd.Run("dump", "-index", "5", dir); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// Ensure restore fails if no meta node of the backup has the local address.
+func TestCommand_Restore_ErrMetaNodeNotFound(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	data := &meta.Data{Index: 10, Term: 1}
+	if err := data.CreateMetaNode("host0:8091", "host0:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateMetaNode("host1:8091", "host1:8088"); err != nil {
+		t.Fatal(err)
+	}
+	MustWriteData(filepath.Join(dir, "meta.00"), data)
+
+	cmd := metacmd.NewCommand()
+	cmd.Stdout = ioutil.Discard
+	if err := cmd.Run("restore", "-metadir", filepath.Join(dir, "meta"), "-http-bind-address", "host2:8091", dir); err == nil || err.Error() != "no meta node in snapshot with address host2:8091" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure an unknown sub-command returns an error.
+func TestCommand_Run_ErrUnknownCommand(t *testing.T) {
+	cmd := metacmd.NewCommand()
+	if err := cmd.Run("foo"); err == nil || err.Error() != `unknown meta command "foo"` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// MustDump runs dump with args and returns the decoded metadata.
+func MustDump(t *testing.T, args ...string) *meta.Data {
+	var buf bytes.Buffer
+	cmd := metacmd.NewCommand()
+	cmd.Stdout = &buf
+	if err := cmd.Run(append([]string{"dump"}, args...)...); err != nil {
+		t.Fatal(err)
+	}
+
+	var data meta.Data
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	return &data
+}
+
+// MustWriteBackup writes a metastore backup at a raft index with a database
+// and an admin user. Panic on error.
+func MustWriteBackup(path string, index uint64, database string) {
+	data := &meta.Data{Index: index, Term: 1}
+	if err := data.CreateDatabase(database); err != nil {
+		panic(err)
+	} else if err := data.CreateUser("admin", "hash", true); err != nil {
+		panic(err)
+	}
+	MustWriteData(path, data)
+}
+
+// MustWriteData writes data as a metastore backup. Panic on error.
+func MustWriteData(path string, data *meta.Data) {
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	node := []byte(`{"ID":1}`)
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint64(snapshotter.BackupMagicHeader))
+	binary.Write(&b, binary.BigEndian, uint64(len(buf)))
+	b.Write(buf)
+	binary.Write(&b, binary.BigEndian, uint64(len(node)))
+	b.Write(node)
+
+	if err := ioutil.WriteFile(path, b.Bytes(), 0600); err != nil {
+		panic(err)
+	}
+}
+
+// MustTempDir returns a temporary directory. Panic on error.
+func MustTempDir() string {
+	dir, err := ioutil.TempDir("", "influxd-meta-")
+	if err != nil {
+		panic(err)
+	}
+	return dir
+}
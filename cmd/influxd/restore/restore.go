@@ -2,8 +2,8 @@ package restore
 
 import (
 	"archive/tar"
-	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -14,11 +14,13 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/influxdata/influxdb/cmd/influxd/backup"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/services/snapshotter"
+	"github.com/influxdata/influxdb/tcp"
 )
 
 // Command represents the program execution for "influxd restore".
@@ -33,6 +35,13 @@ type Command struct {
 	retention       string
 	shard           string
 
+	// Online restore settings.
+	online bool
+	host   string
+	newdb  string
+	newrp  string
+	dialer *tcp.Dialer
+
 	// TODO: when the new meta stuff is done this should not be exported or be gone
 	MetaConfig *meta.Config
 }
@@ -52,6 +61,10 @@ func (cmd *Command) Run(args ...string) error {
 		return err
 	}
 
+	if cmd.online {
+		return cmd.restoreOnline()
+	}
+
 	if err := cmd.ensureStopped(); err != nil {
 		fmt.Fprintln(cmd.Stderr, "influxd cannot be running during a restore.  Please stop any running instances and try again.")
 		return err
@@ -81,6 +94,15 @@ func (cmd *Command) parseFlags(args []string) error {
 	fs.StringVar(&cmd.database, "database", "", "")
 	fs.StringVar(&cmd.retention, "retention", "", "")
 	fs.StringVar(&cmd.shard, "shard", "", "")
+	fs.BoolVar(&cmd.online, "online", false, "")
+	fs.StringVar(&cmd.host, "host", "localhost:8088", "")
+	fs.StringVar(&cmd.newdb, "newdb", "", "")
+	fs.StringVar(&cmd.newrp, "newrp", "", "")
+	var mux tcp.Config
+	fs.StringVar(&mux.CA, "ca", "", "")
+	fs.StringVar(&mux.Certificate, "cert", "", "")
+	fs.StringVar(&mux.PrivateKey, "key", "", "")
+	fs.StringVar(&mux.SharedSecret, "secret", "", "")
 	fs.SetOutput(cmd.Stdout)
 	fs.Usage = cmd.printUsage
 	if err := fs.Parse(args); err != nil {
@@ -96,6 +118,12 @@ func (cmd *Command) parseFlags(args []string) error {
 		return fmt.Errorf("path with backup files required")
 	}
 
+	if cmd.online {
+		return cmd.validateOnline(&mux)
+	} else if cmd.newdb != "" || cmd.newrp != "" {
+		return fmt.Errorf("-newdb and -newrp require -online")
+	}
+
 	// validate the arguments
 	if cmd.metadir == "" && cmd.database == "" {
 		return fmt.Errorf("-metadir or -database are required to restore")
@@ -119,6 +147,32 @@ func (cmd *Command) parseFlags(args []string) error {
 	return nil
 }
 
+// validateOnline validates the arguments of an online restore and sets up
+// the dialer for the mux settings in c.
+func (cmd *Command) validateOnline(c *tcp.Config) error {
+	if cmd.metadir != "" || cmd.datadir != "" {
+		return fmt.Errorf("-metadir and -datadir cannot be used with -online")
+	} else if cmd.database == "" {
+		return fmt.Errorf("-database is required to restore online")
+	} else if cmd.shard != "" && cmd.retention == "" {
+		return fmt.Errorf("-retention is required to restore shard")
+	} else if cmd.newrp != "" && cmd.retention == "" {
+		return fmt.Errorf("-retention is required to restore to a new retention policy")
+	}
+
+	// Connect over TLS if a certificate is given.
+	c.TLSEnabled = c.CA != "" || c.Certificate != ""
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	d, err := c.Dialer()
+	if err != nil {
+		return err
+	}
+	cmd.dialer = d
+	return nil
+}
+
 func (cmd *Command) ensureStopped() error {
 	ln, err := net.Listen("tcp", cmd.MetaConfig.BindAddress)
 	if err != nil {
@@ -144,30 +198,39 @@ func (cmd *Command) unpackMeta() error {
 	latest := metaFiles[len(metaFiles)-1]
 
 	fmt.Fprintf(cmd.Stdout, "Using metastore snapshot: %v\n", latest)
-	// Read the metastore backup
-	f, err := os.Open(latest)
+	data, node, err := ReadMetaBackup(latest)
 	if err != nil {
 		return err
 	}
+	return RestoreMeta(cmd.MetaConfig, data, node)
+}
 
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, f); err != nil {
-		return fmt.Errorf("copy: %s", err)
+// ReadMetaBackup reads a metastore backup file and returns the metadata and
+// the node.json of the node the backup was taken from.
+func ReadMetaBackup(path string) (*meta.Data, []byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
 	}
+	return ParseMetaBackup(b)
+}
 
-	b := buf.Bytes()
+// ParseMetaBackup parses the contents of a metastore backup.
+func ParseMetaBackup(b []byte) (*meta.Data, []byte, error) {
 	var i int
 
 	// Make sure the file is actually a meta store backup file
-	magic := btou64(b[:8])
-	if magic != snapshotter.BackupMagicHeader {
-		return fmt.Errorf("invalid metadata file")
+	if len(b) < 16 || btou64(b[:8]) != snapshotter.BackupMagicHeader {
+		return nil, nil, fmt.Errorf("invalid metadata file")
 	}
 	i += 8
 
 	// Size of the meta store bytes
 	length := int(btou64(b[i : i+8]))
 	i += 8
+	if len(b) < i+length+8 {
+		return nil, nil, fmt.Errorf("invalid metadata file")
+	}
 	metaBytes := b[i : i+length]
 	i += int(length)
 
@@ -179,21 +242,25 @@ func (cmd *Command) unpackMeta() error {
 	// Unpack into metadata.
 	var data meta.Data
 	if err := data.UnmarshalBinary(metaBytes); err != nil {
-		return fmt.Errorf("unmarshal: %s", err)
+		return nil, nil, fmt.Errorf("unmarshal: %s", err)
 	}
+	return &data, nodeBytes, nil
+}
 
-	// Copy meta config and remove peers so it starts in single mode.
-	c := cmd.MetaConfig
+// RestoreMeta initializes a single node raft cluster in the meta dir of c and
+// replaces its metadata with data.
+func RestoreMeta(c *meta.Config, data *meta.Data, node []byte) error {
+	// Remove peers so it starts in single mode.
 	c.JoinPeers = nil
 	c.LoggingEnabled = false
 
 	// Create the meta dir
-	if os.MkdirAll(c.Dir, 0700); err != nil {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
 		return err
 	}
 
 	// Write node.json back to meta dir
-	if err := ioutil.WriteFile(filepath.Join(c.Dir, "node.json"), nodeBytes, 0655); err != nil {
+	if err := ioutil.WriteFile(filepath.Join(c.Dir, "node.json"), node, 0655); err != nil {
 		return err
 	}
 
@@ -222,7 +289,7 @@ func (cmd *Command) unpackMeta() error {
 	defer client.Close()
 
 	// Force set the full metadata.
-	if err := client.SetData(&data); err != nil {
+	if err := client.SetData(data); err != nil {
 		return fmt.Errorf("set data: %s", err)
 	}
 	return nil
@@ -343,13 +410,139 @@ func (cmd *Command) unpackFile(tr *tar.Reader, fileName string) error {
 	return nil
 }
 
+// restoreOnline imports the backed up shards of a database, retention policy
+// or shard into a running server. The shard groups of the shards are read
+// from the newest metastore backup in the backup path.
+func (cmd *Command) restoreOnline() error {
+	var shardID uint64
+	if cmd.shard != "" {
+		id, err := strconv.ParseUint(cmd.shard, 10, 64)
+		if err != nil {
+			return err
+		}
+		shardID = id
+	}
+
+	metaFiles, err := filepath.Glob(filepath.Join(cmd.backupFilesPath, backup.Metafile+".*"))
+	if err != nil {
+		return err
+	} else if len(metaFiles) == 0 {
+		return fmt.Errorf("no metastore backups in %s", cmd.backupFilesPath)
+	}
+	data, _, err := ReadMetaBackup(metaFiles[len(metaFiles)-1])
+	if err != nil {
+		return err
+	}
+
+	db := data.Database(cmd.database)
+	if db == nil {
+		return fmt.Errorf("database %s not found in metastore backup", cmd.database)
+	}
+
+	newdb := cmd.newdb
+	if newdb == "" {
+		newdb = cmd.database
+	}
+
+	var n int
+	for _, rpi := range db.RetentionPolicies {
+		if cmd.retention != "" && rpi.Name != cmd.retention {
+			continue
+		}
+
+		newrp := cmd.newrp
+		if newrp == "" {
+			newrp = rpi.Name
+		}
+
+		for _, sgi := range rpi.ShardGroups {
+			for _, sh := range sgi.Shards {
+				if shardID != 0 && sh.ID != shardID {
+					continue
+				}
+
+				pat := filepath.Join(cmd.backupFilesPath, fmt.Sprintf(backup.BackupFilePattern, cmd.database, rpi.Name, sh.ID))
+				files, err := filepath.Glob(pat + ".*")
+				if err != nil {
+					return err
+				}
+
+				for _, fn := range files {
+					if filepath.Ext(fn) == backup.Suffix {
+						continue
+					}
+
+					req := &snapshotter.Request{
+						Type:            snapshotter.RequestShardRestore,
+						Database:        newdb,
+						RetentionPolicy: newrp,
+						ShardID:         sh.ID,
+						Time:            sgi.StartTime,
+						RetentionPolicyInfo: &meta.RetentionPolicyInfo{
+							Name:               newrp,
+							ReplicaN:           rpi.ReplicaN,
+							Duration:           rpi.Duration,
+							ShardGroupDuration: rpi.ShardGroupDuration,
+						},
+					}
+					if err := cmd.uploadShard(fn, req); err != nil {
+						return fmt.Errorf("restore %s: %s", fn, err)
+					}
+					n++
+				}
+			}
+		}
+	}
+
+	if n == 0 {
+		return fmt.Errorf("no shard backups for %s in %s", cmd.database, cmd.backupFilesPath)
+	}
+	return nil
+}
+
+// uploadShard sends a shard backup file to the snapshotter of the host.
+func (cmd *Command) uploadShard(path string, req *snapshotter.Request) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	conn, err := cmd.dialer.Dial("tcp", cmd.host, snapshotter.MuxHeader)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("encode restore request: %s", err)
+	}
+
+	// A failed restore is reported in the response even if the host stops
+	// reading the archive early.
+	_, copyErr := io.Copy(conn, f)
+
+	var res snapshotter.Response
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		if copyErr != nil {
+			return copyErr
+		}
+		return fmt.Errorf("decode response: %s", err)
+	} else if res.Err != "" {
+		return errors.New(res.Err)
+	}
+
+	fmt.Fprintf(cmd.Stdout, "restored %s to %s\n", path, strings.Join(res.Paths, ", "))
+	return nil
+}
+
 // printUsage prints the usage message to STDERR.
 func (cmd *Command) printUsage() {
 	fmt.Fprintf(cmd.Stdout, `usage: influxd restore [flags] PATH
 
 Restore uses backups from the PATH to restore the metastore, databases,
 retention policies, or specific shards. The InfluxDB process must not be
-running during restore.
+running during restore unless -online is given.
 
 Options:
   -metadir <path>
@@ -366,6 +559,22 @@ Options:
   -shard <id>
     Optional. If given, database and retention are required. Will restore the shard's
     TSM files.
+  -online
+        Optional. Restore the database, retention policy or shard into a
+        running server instead. Backed up files are added to a shard that
+        already exists for the same time. Retention policies with a
+        replication factor greater than 1 can't be restored. Cannot be used
+        with -metadir or -datadir.
+  -host <host:port>
+        The host to restore to with -online. Defaults to localhost:8088.
+  -newdb <name>
+        Optional. Restore into this database with -online. Created if it
+        doesn't exist.
+  -newrp <name>
+        Optional. If given, retention is required. Restore into this
+        retention policy with -online. Created if it doesn't exist.
+  -ca <path>, -cert <path>, -key <path>, -secret <secret>
+        Optional. Mux TLS and shared secret settings of the host.
 
 `)
 }
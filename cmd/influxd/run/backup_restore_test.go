@@ -99,6 +99,49 @@ func TestServer_BackupAndRestore(t *testing.T) {
 	}
 }
 
+// Ensure a backed up database can be restored into a running server under a
+// new name.
+func TestServer_BackupAndRestore_Online(t *testing.T) {
+	config := NewConfig()
+	config.Data.Engine = "tsm1"
+	config.Data.CacheSnapshotMemorySize = 1
+	config.Meta.BindAddress = freePort()
+	config.Meta.HTTPBindAddress = freePort()
+
+	backupDir, _ := ioutil.TempDir("", "backup")
+	defer os.RemoveAll(backupDir)
+
+	s := OpenServer(config, "")
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("mydb", newRetentionPolicyInfo("forever", 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	s.MustWrite("mydb", "forever", "myseries,host=A value=23 1000000", nil)
+
+	// wait for the snapshot to write
+	time.Sleep(time.Second)
+
+	hostAddress, _ := meta.DefaultHost(run.DefaultHostname, config.Meta.BindAddress)
+	if err := backup.NewCommand().Run("-host", hostAddress, "-database", "mydb", backupDir); err != nil {
+		t.Fatalf("error backing up: %s", err.Error())
+	}
+
+	cmd := restore.NewCommand()
+	cmd.Stdout = ioutil.Discard
+	if err := cmd.Run("-online", "-host", hostAddress, "-database", "mydb", "-newdb", "mydb2", backupDir); err != nil {
+		t.Fatalf("error restoring: %s", err.Error())
+	}
+
+	expected := `{"results":[{"series":[{"name":"myseries","columns":["time","host","value"],"values":[["1970-01-01T00:00:00.001Z","A",23]]}]}]}`
+	res, err := s.Query(`select * from "mydb2"."forever"."myseries"`)
+	if err != nil {
+		t.Fatalf("error querying: %s", err.Error())
+	} else if res != expected {
+		t.Fatalf("query results wrong:\n\texp: %s\n\tgot: %s", expected, res)
+	}
+}
+
 func freePort() string {
 	l, _ := net.Listen("tcp", "")
 	defer l.Close()
@@ -14,17 +14,22 @@ import (
 	"github.com/influxdata/influxdb/cluster"
 	"github.com/influxdata/influxdb/monitor"
 	"github.com/influxdata/influxdb/services/admin"
+	"github.com/influxdata/influxdb/services/antientropy"
 	"github.com/influxdata/influxdb/services/collectd"
 	"github.com/influxdata/influxdb/services/continuous_querier"
+	"github.com/influxdata/influxdb/services/decommission"
 	"github.com/influxdata/influxdb/services/graphite"
 	"github.com/influxdata/influxdb/services/hh"
 	"github.com/influxdata/influxdb/services/httpd"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/services/opentsdb"
 	"github.com/influxdata/influxdb/services/precreator"
+	"github.com/influxdata/influxdb/services/rebalancer"
 	"github.com/influxdata/influxdb/services/retention"
+	"github.com/influxdata/influxdb/services/statsd"
 	"github.com/influxdata/influxdb/services/subscriber"
 	"github.com/influxdata/influxdb/services/udp"
+	"github.com/influxdata/influxdb/tcp"
 	"github.com/influxdata/influxdb/tsdb"
 )
 
@@ -44,6 +49,10 @@ type Config struct {
 	Cluster    cluster.Config    `toml:"cluster"`
 	Retention  retention.Config  `toml:"retention"`
 	Precreator precreator.Config `toml:"shard-precreation"`
+	Rebalancer rebalancer.Config `toml:"rebalancer"`
+
+	AntiEntropy  antientropy.Config  `toml:"anti-entropy"`
+	Decommission decommission.Config `toml:"decommission"`
 
 	Admin      admin.Config      `toml:"admin"`
 	Monitor    monitor.Config    `toml:"monitor"`
@@ -53,6 +62,7 @@ type Config struct {
 	Collectd   collectd.Config   `toml:"collectd"`
 	OpenTSDB   opentsdb.Config   `toml:"opentsdb"`
 	UDPs       []udp.Config      `toml:"udp"`
+	Statsds    []statsd.Config   `toml:"statsd"`
 
 	ContinuousQuery continuous_querier.Config `toml:"continuous_queries"`
 	HintedHandoff   hh.Config                 `toml:"hinted-handoff"`
@@ -62,6 +72,9 @@ type Config struct {
 
 	// BindAddress is the address that all TCP services use (Raft, Snapshot, Cluster, etc.)
 	BindAddress string `toml:"bind-address"`
+
+	// Mux secures the connections to BindAddress and to other nodes.
+	Mux tcp.Config `toml:"mux"`
 }
 
 // NewConfig returns an instance of Config with reasonable defaults.
@@ -71,6 +84,9 @@ func NewConfig() *Config {
 	c.Data = tsdb.NewConfig()
 	c.Cluster = cluster.NewConfig()
 	c.Precreator = precreator.NewConfig()
+	c.Rebalancer = rebalancer.NewConfig()
+	c.AntiEntropy = antientropy.NewConfig()
+	c.Decommission = decommission.NewConfig()
 
 	c.Admin = admin.NewConfig()
 	c.Monitor = monitor.NewConfig()
@@ -83,6 +99,7 @@ func NewConfig() *Config {
 	c.Retention = retention.NewConfig()
 	c.HintedHandoff = hh.NewConfig()
 	c.BindAddress = DefaultBindAddress
+	c.Mux = tcp.NewConfig()
 
 	return c
 }
@@ -124,6 +141,9 @@ func (c *Config) Validate() error {
 	if err := c.Data.Validate(); err != nil {
 		return err
 	}
+	if err := c.Mux.Validate(); err != nil {
+		return err
+	}
 	if c.Data.Enabled {
 		if err := c.HintedHandoff.Validate(); err != nil {
 			return err
@@ -133,6 +153,14 @@ func (c *Config) Validate() error {
 				return fmt.Errorf("invalid graphite config: %v", err)
 			}
 		}
+		for _, c := range c.Statsds {
+			if err := c.Validate(); err != nil {
+				return fmt.Errorf("invalid statsd config: %v", err)
+			}
+		}
+		if err := c.Collectd.Validate(); err != nil {
+			return fmt.Errorf("invalid collectd config: %v", err)
+		}
 	}
 
 	return nil
@@ -42,6 +42,9 @@ bind-address = ":2000"
 [[udp]]
 bind-address = ":4444"
 
+[[statsd]]
+bind-address = ":8126"
+
 [monitoring]
 enabled = true
 
@@ -75,6 +78,8 @@ enabled = true
 		t.Fatalf("unexpected opentsdb bind address: %s", c.OpenTSDB.BindAddress)
 	} else if c.UDPs[0].BindAddress != ":4444" {
 		t.Fatalf("unexpected udp bind address: %s", c.UDPs[0].BindAddress)
+	} else if c.Statsds[0].BindAddress != ":8126" {
+		t.Fatalf("unexpected statsd bind address: %s", c.Statsds[0].BindAddress)
 	} else if c.Subscriber.Enabled != true {
 		t.Fatalf("unexpected subscriber enabled: %v", c.Subscriber.Enabled)
 	} else if c.ContinuousQuery.Enabled != true {
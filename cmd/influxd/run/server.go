@@ -1,6 +1,7 @@
 package run
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
@@ -15,17 +16,21 @@ import (
 	"github.com/influxdata/influxdb/cluster"
 	"github.com/influxdata/influxdb/monitor"
 	"github.com/influxdata/influxdb/services/admin"
+	"github.com/influxdata/influxdb/services/antientropy"
 	"github.com/influxdata/influxdb/services/collectd"
 	"github.com/influxdata/influxdb/services/continuous_querier"
 	"github.com/influxdata/influxdb/services/copier"
+	"github.com/influxdata/influxdb/services/decommission"
 	"github.com/influxdata/influxdb/services/graphite"
 	"github.com/influxdata/influxdb/services/hh"
 	"github.com/influxdata/influxdb/services/httpd"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/services/opentsdb"
 	"github.com/influxdata/influxdb/services/precreator"
+	"github.com/influxdata/influxdb/services/rebalancer"
 	"github.com/influxdata/influxdb/services/retention"
 	"github.com/influxdata/influxdb/services/snapshotter"
+	"github.com/influxdata/influxdb/services/statsd"
 	"github.com/influxdata/influxdb/services/subscriber"
 	"github.com/influxdata/influxdb/services/udp"
 	"github.com/influxdata/influxdb/tcp"
@@ -55,6 +60,11 @@ type Server struct {
 	BindAddress string
 	Listener    net.Listener
 
+	// Dialer secures connections to other nodes. muxTLSConfig secures
+	// connections accepted on Listener.
+	Dialer       *tcp.Dialer
+	muxTLSConfig *tls.Config
+
 	Node *influxdb.Node
 
 	MetaClient  *meta.Client
@@ -74,6 +84,7 @@ type Server struct {
 	ClusterService     *cluster.Service
 	SnapshotterService *snapshotter.Service
 	CopierService      *copier.Service
+	AntiEntropyService *antientropy.Service
 
 	Monitor *monitor.Monitor
 
@@ -171,6 +182,11 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 		return nil, err
 	}
 
+	muxTLSConfig, err := c.Mux.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Server{
 		buildInfo: *buildInfo,
 		err:       make(chan error),
@@ -178,6 +194,9 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 
 		BindAddress: bind,
 
+		Dialer:       &tcp.Dialer{TLSConfig: muxTLSConfig, Secret: c.Mux.SharedSecret},
+		muxTLSConfig: muxTLSConfig,
+
 		Node: node,
 
 		Monitor: monitor.New(c.Monitor),
@@ -195,6 +214,7 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 
 	if c.Meta.Enabled {
 		s.MetaService = meta.NewService(c.Meta)
+		s.MetaService.RaftDialer = s.Dialer
 	}
 
 	if c.Data.Enabled {
@@ -216,10 +236,12 @@ func NewServer(c *Config, buildInfo *BuildInfo) (*Server, error) {
 		// Set the shard writer
 		s.ShardWriter = cluster.NewShardWriter(time.Duration(c.Cluster.ShardWriterTimeout),
 			c.Cluster.MaxRemoteWriteConnections)
+		s.ShardWriter.Dialer = s.Dialer
 
 		// Create the hinted handoff service
 		s.HintedHandoff = hh.NewService(c.HintedHandoff, s.ShardWriter, s.MetaClient)
 		s.HintedHandoff.Monitor = s.Monitor
+		s.QueryExecutor.HintedHandoffStatementExecutor = &hh.StatementExecutor{Service: s.HintedHandoff}
 
 		// Create the Subscriber service
 		s.Subscriber = subscriber.NewService(c.Subscriber)
@@ -271,6 +293,67 @@ func (s *Server) appendCopierService() {
 	s.CopierService = srv
 }
 
+func (s *Server) appendAntiEntropyService(c antientropy.Config) {
+	// The service always serves replica reads for queries with a read
+	// consistency of quorum or all. Repair only runs if it's enabled.
+	srv := antientropy.NewService(c)
+	srv.MetaClient = s.MetaClient
+	srv.TSDBStore = s.TSDBStore
+	srv.ShardWriter = s.ShardWriter
+	srv.Dialer = s.Dialer
+	srv.Node = s.Node
+	s.Services = append(s.Services, srv)
+	s.AntiEntropyService = srv
+	s.QueryExecutor.ShardReader = srv
+	if !c.Enabled {
+		return
+	}
+	s.QueryExecutor.AntiEntropyStatementExecutor = &antientropy.StatementExecutor{
+		Service: srv,
+		Node:    s.Node,
+	}
+}
+
+func (s *Server) appendRebalancerService(c rebalancer.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := rebalancer.NewService(c)
+	srv.MetaClient = s.MetaClient
+	srv.TSDBStore = s.TSDBStore
+	srv.RemoteDiskSize = func(host string) (int64, error) {
+		c := copier.NewClient(host)
+		c.Dialer = s.Dialer
+		return c.DiskSize()
+	}
+	srv.ShardMover = &copier.StatementExecutor{
+		MetaClient: s.MetaClient,
+		TSDBStore:  s.TSDBStore,
+		Node:       s.Node,
+		Dialer:     s.Dialer,
+	}
+	srv.Node = s.Node
+	s.Services = append(s.Services, srv)
+	s.QueryExecutor.RebalancerStatementExecutor = &rebalancer.StatementExecutor{Service: srv}
+}
+
+func (s *Server) appendDecommissionService(c decommission.Config) {
+	if !c.Enabled {
+		return
+	}
+	srv := decommission.NewService(c)
+	srv.MetaClient = s.MetaClient
+	srv.TSDBStore = s.TSDBStore
+	srv.ShardMover = &copier.StatementExecutor{
+		MetaClient: s.MetaClient,
+		TSDBStore:  s.TSDBStore,
+		Node:       s.Node,
+		Dialer:     s.Dialer,
+	}
+	srv.Node = s.Node
+	s.Services = append(s.Services, srv)
+}
+
 func (s *Server) appendRetentionPolicyService(c retention.Config) {
 	if !c.Enabled {
 		return
@@ -329,6 +412,7 @@ func (s *Server) appendOpenTSDBService(c opentsdb.Config) error {
 	}
 	srv.PointsWriter = s.PointsWriter
 	srv.MetaClient = s.MetaClient
+	srv.QueryExecutor = s.QueryExecutor
 	s.Services = append(s.Services, srv)
 	return nil
 }
@@ -373,6 +457,21 @@ func (s *Server) appendUDPService(c udp.Config) {
 	s.Services = append(s.Services, srv)
 }
 
+func (s *Server) appendStatsdService(c statsd.Config) error {
+	if !c.Enabled {
+		return nil
+	}
+	srv, err := statsd.NewService(c)
+	if err != nil {
+		return err
+	}
+
+	srv.PointsWriter = s.PointsWriter
+	srv.MetaClient = s.MetaClient
+	s.Services = append(s.Services, srv)
+	return nil
+}
+
 func (s *Server) appendContinuousQueryService(c continuous_querier.Config) {
 	if !c.Enabled {
 		return
@@ -400,6 +499,8 @@ func (s *Server) Open() error {
 
 	// Multiplex listener.
 	mux := tcp.NewMux()
+	mux.TLSConfig = s.muxTLSConfig
+	mux.Secret = s.config.Mux.SharedSecret
 	go mux.Serve(ln)
 
 	if s.MetaService != nil {
@@ -417,11 +518,20 @@ func (s *Server) Open() error {
 	}
 
 	if s.TSDBStore != nil {
+		// Set the zone used to spread shard owners. The node may be missing
+		// from the metastore, such as after a restore.
+		if err := s.MetaClient.SetDataNodeZone(s.Node.ID, s.config.Cluster.Zone); err != nil {
+			log.Printf("unable to set data node zone: %s", err.Error())
+		}
+
 		// Append services.
 		s.appendClusterService(s.config.Cluster)
 		s.appendPrecreatorService(s.config.Precreator)
 		s.appendSnapshotterService()
 		s.appendCopierService()
+		s.appendRebalancerService(s.config.Rebalancer)
+		s.appendDecommissionService(s.config.Decommission)
+		s.appendAntiEntropyService(s.config.AntiEntropy)
 		s.appendAdminService(s.config.Admin)
 		s.appendContinuousQueryService(s.config.ContinuousQuery)
 		s.appendHTTPDService(s.config.HTTPD)
@@ -438,9 +548,20 @@ func (s *Server) Open() error {
 				return err
 			}
 		}
+		for _, c := range s.config.Statsds {
+			if err := s.appendStatsdService(c); err != nil {
+				return err
+			}
+		}
 
 		s.Subscriber.MetaClient = s.MetaClient
 		s.QueryExecutor.MetaClient = s.MetaClient
+		s.QueryExecutor.CopierStatementExecutor = &copier.StatementExecutor{
+			MetaClient: s.MetaClient,
+			TSDBStore:  s.TSDBStore,
+			Node:       s.Node,
+			Dialer:     s.Dialer,
+		}
 		s.ShardWriter.MetaClient = s.MetaClient
 		s.HintedHandoff.MetaClient = s.MetaClient
 		s.Subscriber.MetaClient = s.MetaClient
@@ -450,6 +571,7 @@ func (s *Server) Open() error {
 		s.ClusterService.Listener = mux.Listen(cluster.MuxHeader)
 		s.SnapshotterService.Listener = mux.Listen(snapshotter.MuxHeader)
 		s.CopierService.Listener = mux.Listen(copier.MuxHeader)
+		s.AntiEntropyService.Listener = mux.Listen(antientropy.MuxHeader)
 
 		// Open TSDB store.
 		if err := s.TSDBStore.Open(); err != nil {
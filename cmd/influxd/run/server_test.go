@@ -900,9 +900,9 @@ func TestServer_Query_Count(t *testing.T) {
 			exp:     `{"results":[{}]}`,
 		},
 		&Query{
-			name:    "selecting count(*) should error",
+			name:    "selecting count(*) should count every field",
 			command: `SELECT count(*) FROM db0.rp0.cpu`,
-			exp:     `{"error":"error parsing query: expected field argument in count()"}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 	}...)
 
@@ -4473,6 +4473,61 @@ func TestServer_Query_Fill(t *testing.T) {
 	}
 }
 
+// Ensure queries with a read consistency above one are served when the
+// anti-entropy repair is disabled.
+func TestServer_Query_ReadConsistency(t *testing.T) {
+	t.Parallel()
+	c := NewConfig()
+	c.AntiEntropy.Enabled = false
+	s := OpenServer(c, "")
+	defer s.Close()
+
+	if err := s.CreateDatabaseAndRetentionPolicy("db0", newRetentionPolicyInfo("rp0", 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: fmt.Sprintf(`cpu value=1.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano())},
+	}
+
+	test.addQueries([]*Query{
+		&Query{
+			name:    "quorum read",
+			params:  url.Values{"db": []string{"db0"}, "consistency": []string{"quorum"}},
+			command: `SELECT value FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		&Query{
+			name:    "all read",
+			params:  url.Values{"db": []string{"db0"}, "consistency": []string{"all"}},
+			command: `SELECT value FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		&Query{
+			name:    "shard differences require anti-entropy",
+			command: `SHOW SHARD DIFFERENCES`,
+			exp:     `{"results":[{"error":"anti-entropy is not enabled"}]}`,
+		},
+	}...)
+
+	if err := test.init(s); err != nil {
+		t.Fatalf("test init failed: %s", err)
+	}
+
+	for _, query := range test.queries {
+		if query.skip {
+			t.Logf("SKIP:: %s", query.name)
+			continue
+		}
+		if err := query.Execute(s); err != nil {
+			t.Error(query.Error(err))
+		} else if !query.success() {
+			t.Error(query.failureMessage())
+		}
+	}
+}
+
 func TestServer_Query_Chunk(t *testing.T) {
 	t.Parallel()
 	s := OpenServer(NewConfig(), "")
@@ -4762,9 +4817,9 @@ func TestServer_Query_ShowSeries(t *testing.T) {
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
-			name:    `show series with WHERE time should fail`,
+			name:    `show series with WHERE time`,
 			command: "SHOW SERIES WHERE time > now() - 1h",
-			exp:     `{"results":[{"error":"SHOW SERIES doesn't support time in WHERE clause"}]}`,
+			exp:     `{"results":[{}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
@@ -4858,9 +4913,9 @@ func TestServer_Query_ShowMeasurements(t *testing.T) {
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
-			name:    `show measurements with time in WHERE clauses errors`,
+			name:    `show measurements with time in WHERE clause`,
 			command: `SHOW MEASUREMENTS WHERE time > now() - 1h`,
-			exp:     `{"results":[{"error":"SHOW MEASUREMENTS doesn't support time in WHERE clause"}]}`,
+			exp:     `{"results":[{}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
@@ -4978,9 +5033,15 @@ func TestServer_Query_ShowTagKeys(t *testing.T) {
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
-			name:    `show tag values with key and time in WHERE clause should error`,
+			name:    `show tag values with key and time in WHERE clause`,
 			command: `SHOW TAG VALUES WITH KEY = host WHERE time > now() - 1h`,
-			exp:     `{"results":[{"error":"SHOW TAG VALUES doesn't support time in WHERE clause"}]}`,
+			exp:     `{"results":[{}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		&Query{
+			name:    `show tag values with key and time range including the data`,
+			command: `SHOW TAG VALUES FROM /[cg]pu/ WITH KEY = host WHERE time > '2009-11-10T00:00:00Z' AND time < '2009-11-11T00:00:00Z'`,
+			exp:     `{"results":[{"series":[{"name":"hostTagValues","columns":["host"],"values":[["server01"],["server02"],["server03"]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
@@ -31,6 +31,10 @@ const (
 	Time = 5
 	// Duration means the data type is a duration of time.
 	Duration = 6
+	// Tag means the reference is to a tag rather than a field.
+	Tag = 7
+	// AnyField means the reference is to a field of any type.
+	AnyField = 8
 )
 
 // InspectDataType returns the data type of a given value.
@@ -75,6 +79,10 @@ func (d DataType) String() string {
 		return "time"
 	case Duration:
 		return "duration"
+	case Tag:
+		return "tag"
+	case AnyField:
+		return "field"
 	}
 	return "unknown"
 }
@@ -88,44 +96,59 @@ type Node interface {
 func (*Query) node()     {}
 func (Statements) node() {}
 
-func (*AlterRetentionPolicyStatement) node()  {}
-func (*CreateContinuousQueryStatement) node() {}
-func (*CreateDatabaseStatement) node()        {}
-func (*CreateRetentionPolicyStatement) node() {}
-func (*CreateSubscriptionStatement) node()    {}
-func (*CreateUserStatement) node()            {}
-func (*Distinct) node()                       {}
-func (*DeleteStatement) node()                {}
-func (*DropContinuousQueryStatement) node()   {}
-func (*DropDatabaseStatement) node()          {}
-func (*DropMeasurementStatement) node()       {}
-func (*DropRetentionPolicyStatement) node()   {}
-func (*DropSeriesStatement) node()            {}
-func (*DropServerStatement) node()            {}
-func (*DropSubscriptionStatement) node()      {}
-func (*DropUserStatement) node()              {}
-func (*GrantStatement) node()                 {}
-func (*GrantAdminStatement) node()            {}
-func (*RevokeStatement) node()                {}
-func (*RevokeAdminStatement) node()           {}
-func (*SelectStatement) node()                {}
-func (*SetPasswordUserStatement) node()       {}
-func (*ShowContinuousQueriesStatement) node() {}
-func (*ShowGrantsForUserStatement) node()     {}
-func (*ShowServersStatement) node()           {}
-func (*ShowDatabasesStatement) node()         {}
-func (*ShowFieldKeysStatement) node()         {}
-func (*ShowRetentionPoliciesStatement) node() {}
-func (*ShowMeasurementsStatement) node()      {}
-func (*ShowSeriesStatement) node()            {}
-func (*ShowShardGroupsStatement) node()       {}
-func (*ShowShardsStatement) node()            {}
-func (*ShowStatsStatement) node()             {}
-func (*ShowSubscriptionsStatement) node()     {}
-func (*ShowDiagnosticsStatement) node()       {}
-func (*ShowTagKeysStatement) node()           {}
-func (*ShowTagValuesStatement) node()         {}
-func (*ShowUsersStatement) node()             {}
+func (*AlterRetentionPolicyStatement) node()       {}
+func (*CopyShardStatement) node()                  {}
+func (*CreateContinuousQueryStatement) node()      {}
+func (*CreateDatabaseStatement) node()             {}
+func (*CreateRetentionPolicyStatement) node()      {}
+func (*CreateSubscriptionStatement) node()         {}
+func (*CreateUserStatement) node()                 {}
+func (*Distinct) node()                            {}
+func (*DecommissionServerStatement) node()         {}
+func (*DeleteStatement) node()                     {}
+func (*DropContinuousQueryStatement) node()        {}
+func (*DropDatabaseStatement) node()               {}
+func (*DropMeasurementStatement) node()            {}
+func (*DropRetentionPolicyStatement) node()        {}
+func (*DropSeriesStatement) node()                 {}
+func (*DropServerStatement) node()                 {}
+func (*DropSubscriptionStatement) node()           {}
+func (*DropUserStatement) node()                   {}
+func (*ExplainStatement) node()                    {}
+func (*GrantStatement) node()                      {}
+func (*GrantAdminStatement) node()                 {}
+func (*PauseHintedHandoffStatement) node()         {}
+func (*PurgeHintedHandoffStatement) node()         {}
+func (*RemoveShardStatement) node()                {}
+func (*ResumeHintedHandoffStatement) node()        {}
+func (*RevokeStatement) node()                     {}
+func (*RevokeAdminStatement) node()                {}
+func (*SelectStatement) node()                     {}
+func (*SetPasswordUserStatement) node()            {}
+func (*ShowContinuousQueriesStatement) node()      {}
+func (*ShowGrantsForUserStatement) node()          {}
+func (*ShowHintedHandoffStatement) node()          {}
+func (*ShowServersStatement) node()                {}
+func (*ShowDatabasesStatement) node()              {}
+func (*ShowFieldKeysStatement) node()              {}
+func (*ShowFieldKeyCardinalityStatement) node()    {}
+func (*ShowRetentionPoliciesStatement) node()      {}
+func (*ShowMeasurementsStatement) node()           {}
+func (*ShowMeasurementCardinalityStatement) node() {}
+func (*ShowSeriesStatement) node()                 {}
+func (*ShowSeriesCardinalityStatement) node()      {}
+func (*ShowShardDifferencesStatement) node()       {}
+func (*ShowShardGroupsStatement) node()            {}
+func (*ShowRebalanceStatement) node()              {}
+func (*ShowShardsStatement) node()                 {}
+func (*ShowStatsStatement) node()                  {}
+func (*ShowSubscriptionsStatement) node()          {}
+func (*ShowDiagnosticsStatement) node()            {}
+func (*ShowTagKeysStatement) node()                {}
+func (*ShowTagKeyCardinalityStatement) node()      {}
+func (*ShowTagValuesStatement) node()              {}
+func (*ShowTagValuesCardinalityStatement) node()   {}
+func (*ShowUsersStatement) node()                  {}
 
 func (*BinaryExpr) node()      {}
 func (*BooleanLiteral) node()  {}
@@ -200,43 +223,58 @@ type ExecutionPrivilege struct {
 // ExecutionPrivileges is a list of privileges required to execute a statement.
 type ExecutionPrivileges []ExecutionPrivilege
 
-func (*AlterRetentionPolicyStatement) stmt()  {}
-func (*CreateContinuousQueryStatement) stmt() {}
-func (*CreateDatabaseStatement) stmt()        {}
-func (*CreateRetentionPolicyStatement) stmt() {}
-func (*CreateSubscriptionStatement) stmt()    {}
-func (*CreateUserStatement) stmt()            {}
-func (*DeleteStatement) stmt()                {}
-func (*DropContinuousQueryStatement) stmt()   {}
-func (*DropDatabaseStatement) stmt()          {}
-func (*DropMeasurementStatement) stmt()       {}
-func (*DropRetentionPolicyStatement) stmt()   {}
-func (*DropSeriesStatement) stmt()            {}
-func (*DropServerStatement) stmt()            {}
-func (*DropSubscriptionStatement) stmt()      {}
-func (*DropUserStatement) stmt()              {}
-func (*GrantStatement) stmt()                 {}
-func (*GrantAdminStatement) stmt()            {}
-func (*ShowContinuousQueriesStatement) stmt() {}
-func (*ShowGrantsForUserStatement) stmt()     {}
-func (*ShowServersStatement) stmt()           {}
-func (*ShowDatabasesStatement) stmt()         {}
-func (*ShowFieldKeysStatement) stmt()         {}
-func (*ShowMeasurementsStatement) stmt()      {}
-func (*ShowRetentionPoliciesStatement) stmt() {}
-func (*ShowSeriesStatement) stmt()            {}
-func (*ShowShardGroupsStatement) stmt()       {}
-func (*ShowShardsStatement) stmt()            {}
-func (*ShowStatsStatement) stmt()             {}
-func (*ShowSubscriptionsStatement) stmt()     {}
-func (*ShowDiagnosticsStatement) stmt()       {}
-func (*ShowTagKeysStatement) stmt()           {}
-func (*ShowTagValuesStatement) stmt()         {}
-func (*ShowUsersStatement) stmt()             {}
-func (*RevokeStatement) stmt()                {}
-func (*RevokeAdminStatement) stmt()           {}
-func (*SelectStatement) stmt()                {}
-func (*SetPasswordUserStatement) stmt()       {}
+func (*AlterRetentionPolicyStatement) stmt()       {}
+func (*CopyShardStatement) stmt()                  {}
+func (*CreateContinuousQueryStatement) stmt()      {}
+func (*CreateDatabaseStatement) stmt()             {}
+func (*CreateRetentionPolicyStatement) stmt()      {}
+func (*CreateSubscriptionStatement) stmt()         {}
+func (*CreateUserStatement) stmt()                 {}
+func (*DecommissionServerStatement) stmt()         {}
+func (*DeleteStatement) stmt()                     {}
+func (*DropContinuousQueryStatement) stmt()        {}
+func (*DropDatabaseStatement) stmt()               {}
+func (*DropMeasurementStatement) stmt()            {}
+func (*DropRetentionPolicyStatement) stmt()        {}
+func (*DropSeriesStatement) stmt()                 {}
+func (*DropServerStatement) stmt()                 {}
+func (*DropSubscriptionStatement) stmt()           {}
+func (*DropUserStatement) stmt()                   {}
+func (*ExplainStatement) stmt()                    {}
+func (*GrantStatement) stmt()                      {}
+func (*GrantAdminStatement) stmt()                 {}
+func (*PauseHintedHandoffStatement) stmt()         {}
+func (*PurgeHintedHandoffStatement) stmt()         {}
+func (*ShowContinuousQueriesStatement) stmt()      {}
+func (*ShowGrantsForUserStatement) stmt()          {}
+func (*ShowHintedHandoffStatement) stmt()          {}
+func (*ShowServersStatement) stmt()                {}
+func (*ShowDatabasesStatement) stmt()              {}
+func (*ShowFieldKeysStatement) stmt()              {}
+func (*ShowFieldKeyCardinalityStatement) stmt()    {}
+func (*ShowMeasurementsStatement) stmt()           {}
+func (*ShowMeasurementCardinalityStatement) stmt() {}
+func (*ShowRetentionPoliciesStatement) stmt()      {}
+func (*ShowSeriesStatement) stmt()                 {}
+func (*ShowSeriesCardinalityStatement) stmt()      {}
+func (*ShowShardDifferencesStatement) stmt()       {}
+func (*ShowShardGroupsStatement) stmt()            {}
+func (*ShowRebalanceStatement) stmt()              {}
+func (*ShowShardsStatement) stmt()                 {}
+func (*ShowStatsStatement) stmt()                  {}
+func (*ShowSubscriptionsStatement) stmt()          {}
+func (*ShowDiagnosticsStatement) stmt()            {}
+func (*ShowTagKeysStatement) stmt()                {}
+func (*ShowTagKeyCardinalityStatement) stmt()      {}
+func (*ShowTagValuesStatement) stmt()              {}
+func (*ShowTagValuesCardinalityStatement) stmt()   {}
+func (*ShowUsersStatement) stmt()                  {}
+func (*RemoveShardStatement) stmt()                {}
+func (*ResumeHintedHandoffStatement) stmt()        {}
+func (*RevokeStatement) stmt()                     {}
+func (*RevokeAdminStatement) stmt()                {}
+func (*SelectStatement) stmt()                     {}
+func (*SetPasswordUserStatement) stmt()            {}
 
 // Expr represents an expression that can be evaluated to a value.
 type Expr interface {
@@ -351,6 +389,12 @@ func (field *SortField) String() string {
 	return buf.String()
 }
 
+// IsTime returns true if the field sorts by time. A sort field without a
+// name, such as "ORDER BY DESC", sorts by time.
+func (field *SortField) IsTime() bool {
+	return field.Name == "" || field.Name == "time"
+}
+
 // SortFields represents an ordered list of ORDER BY fields
 type SortFields []*SortField
 
@@ -868,7 +912,23 @@ func (s *SelectStatement) HasSimpleCount() bool {
 
 // TimeAscending returns true if the time field is sorted in chronological order.
 func (s *SelectStatement) TimeAscending() bool {
-	return len(s.SortFields) == 0 || s.SortFields[0].Ascending
+	for _, f := range s.SortFields {
+		if f.IsTime() {
+			return f.Ascending
+		}
+	}
+	return true
+}
+
+// ValueSortField returns the ORDER BY field that sorts results by the value
+// of a selected field. Returns nil if results are only sorted by time.
+func (s *SelectStatement) ValueSortField() *SortField {
+	for _, f := range s.SortFields {
+		if !f.IsTime() {
+			return f
+		}
+	}
+	return nil
 }
 
 // Clone returns a deep copy of the statement.
@@ -936,12 +996,14 @@ func cloneSource(s Source) Source {
 
 // RewriteWildcards returns the re-written form of the select statement. Any wildcard query
 // fields are replaced with the supplied fields, and any wildcard GROUP BY fields are replaced
-// with the supplied dimensions.
+// with the supplied dimensions. Wildcard call arguments, such as mean(*), are replaced with
+// a call for every field the call can read.
 func (s *SelectStatement) RewriteWildcards(ic IteratorCreator) (*SelectStatement, error) {
 	// Ignore if there are no wildcards.
 	hasFieldWildcard := s.HasFieldWildcard()
+	hasCallWildcard := s.hasCallWildcard()
 	hasDimensionWildcard := s.HasDimensionWildcard()
-	if !hasFieldWildcard && !hasDimensionWildcard {
+	if !hasFieldWildcard && !hasCallWildcard && !hasDimensionWildcard {
 		return s, nil
 	}
 
@@ -951,14 +1013,27 @@ func (s *SelectStatement) RewriteWildcards(ic IteratorCreator) (*SelectStatement
 		return s, err
 	}
 
+	// Only fields can be aggregated so find them before tags are merged in.
+	callFields := make([]string, 0, len(fieldSet))
+	for k := range fieldSet {
+		callFields = append(callFields, k)
+	}
+	sort.Strings(callFields)
+
 	// If there are no dimension wildcards then merge dimensions to fields.
 	if !hasDimensionWildcard {
 		for k := range dimensionSet {
-			fieldSet[k] = struct{}{}
+			if _, ok := fieldSet[k]; !ok {
+				fieldSet[k] = Unknown
+			}
 		}
 		dimensionSet = nil
 	}
-	fields := stringSetSlice(fieldSet)
+	fields := make([]string, 0, len(fieldSet))
+	for k := range fieldSet {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
 	dimensions := stringSetSlice(dimensionSet)
 
 	other := s.Clone()
@@ -966,10 +1041,33 @@ func (s *SelectStatement) RewriteWildcards(ic IteratorCreator) (*SelectStatement
 	// Rewrite all wildcard query fields
 	rwFields := make(Fields, 0, len(s.Fields))
 	for _, f := range s.Fields {
-		switch f.Expr.(type) {
+		switch expr := f.Expr.(type) {
 		case *Wildcard:
 			for _, name := range fields {
-				rwFields = append(rwFields, &Field{Expr: &VarRef{Val: name}})
+				ref := &VarRef{Val: name}
+
+				// Tags are grouped by so a tag with the same name as a
+				// field in another measurement is not read as a field.
+				if hasDimensionWildcard {
+					ref.Type = AnyField
+				}
+				rwFields = append(rwFields, &Field{Expr: ref})
+			}
+		case *Call:
+			if !isCallWildcard(expr) {
+				rwFields = append(rwFields, f)
+				continue
+			}
+
+			// Name each call after the original field name and the field it reads.
+			for _, name := range callFields {
+				if !wildcardCallReads(expr.Name, fieldSet[name]) {
+					continue
+				}
+				rwFields = append(rwFields, &Field{
+					Expr:  &Call{Name: expr.Name, Args: []Expr{&VarRef{Val: name}}},
+					Alias: f.Name() + "_" + name,
+				})
 			}
 		default:
 			rwFields = append(rwFields, f)
@@ -1000,6 +1098,28 @@ func (s *SelectStatement) RewriteWildcards(ic IteratorCreator) (*SelectStatement
 	return other, nil
 }
 
+// isCallWildcard returns true if call reads every field, such as mean(*).
+func isCallWildcard(call *Call) bool {
+	if len(call.Args) != 1 {
+		return false
+	}
+	_, ok := call.Args[0].(*Wildcard)
+	return ok
+}
+
+// wildcardCallReads returns true if a wildcard call reads fields of type typ.
+// Returns false for calls that can't be used with a wildcard.
+func wildcardCallReads(name string, typ DataType) bool {
+	switch name {
+	case "count":
+		return true
+	case "min", "max", "sum", "first", "last", "mean", "median", "stddev", "spread":
+		return typ == Float || typ == Integer
+	default:
+		return false
+	}
+}
+
 // RewriteDistinct rewrites the expression to be a call for map/reduce to work correctly
 // This method assumes all validation has passed
 func (s *SelectStatement) RewriteDistinct() {
@@ -1155,6 +1275,17 @@ func (s *SelectStatement) HasFieldWildcard() bool {
 	return false
 }
 
+// hasCallWildcard returns whether or not the select statement has at least 1 call
+// with a wildcard argument in the fields.
+func (s *SelectStatement) hasCallWildcard() bool {
+	for _, f := range s.Fields {
+		if call, ok := f.Expr.(*Call); ok && isCallWildcard(call) {
+			return true
+		}
+	}
+	return false
+}
+
 // HasDimensionWildcard returns whether or not the select statement has
 // at least 1 wildcard in the dimensions aka `GROUP BY`
 func (s *SelectStatement) HasDimensionWildcard() bool {
@@ -1177,6 +1308,10 @@ func (s *SelectStatement) validate(tr targetRequirement) error {
 		return err
 	}
 
+	if err := s.validateStringFunctions(); err != nil {
+		return err
+	}
+
 	if err := s.validateDistinct(); err != nil {
 		return err
 	}
@@ -1193,6 +1328,10 @@ func (s *SelectStatement) validate(tr targetRequirement) error {
 		return err
 	}
 
+	if err := s.validateSortFields(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -1204,6 +1343,21 @@ func (s *SelectStatement) validateFields() error {
 	return nil
 }
 
+// validateStringFunctions ensures string functions in fields and conditions
+// have valid arguments.
+func (s *SelectStatement) validateStringFunctions() (err error) {
+	validate := func(n Node) {
+		if call, ok := n.(*Call); ok && err == nil && IsStringFunction(call.Name) {
+			err = validateStringFunction(call)
+		}
+	}
+	WalkFunc(s.Fields, validate)
+	if s.Condition != nil {
+		WalkFunc(s.Condition, validate)
+	}
+	return err
+}
+
 func (s *SelectStatement) validateDimensions() error {
 	var dur time.Duration
 	for _, dim := range s.Dimensions {
@@ -1372,8 +1526,13 @@ func (s *SelectStatement) validateAggregates(tr targetRequirement) error {
 				switch fc := expr.Args[0].(type) {
 				case *VarRef:
 					// do nothing
+				case *Wildcard:
+					if !wildcardCallReads(expr.Name, Float) {
+						return fmt.Errorf("expected field argument in %s()", expr.Name)
+					}
 				case *Call:
-					if fc.Name != "distinct" {
+					// Distinct values can be taken from string functions that don't return booleans.
+					if fc.Name != "distinct" && (expr.Name != "distinct" || !IsStringFunction(fc.Name) || stringFunctions[fc.Name] == Boolean) {
 						return fmt.Errorf("expected field argument in %s()", expr.Name)
 					}
 				case *Distinct:
@@ -1539,6 +1698,29 @@ func (s *SelectStatement) validateDerivative() error {
 	return nil
 }
 
+// validateSortFields ensures results are sorted by at most one field value
+// and that time is only used to order rows with the same field value.
+func (s *SelectStatement) validateSortFields() error {
+	var hasTime, hasField bool
+	for _, f := range s.SortFields {
+		if f.IsTime() {
+			if hasTime {
+				return errors.New("time can only be used once in ORDER BY")
+			}
+			hasTime = true
+			continue
+		}
+
+		if hasField {
+			return errors.New("only one field can be used in ORDER BY")
+		} else if hasTime {
+			return errors.New("time must be the last field in ORDER BY")
+		}
+		hasField = true
+	}
+	return nil
+}
+
 // GroupByIterval extracts the time interval, if specified.
 func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
 	// return if we've already pulled it out
@@ -1751,21 +1933,22 @@ func walkNames(exp Expr) []string {
 	return nil
 }
 
-// ExprNames returns a list of non-"time" field names from an expression.
-func ExprNames(expr Expr) []string {
-	m := make(map[string]struct{})
-	for _, name := range walkNames(expr) {
-		if name == "time" {
+// ExprNames returns a list of non-"time" variable references from an expression.
+// References to the same name with different types are returned separately.
+func ExprNames(expr Expr) []VarRef {
+	m := make(map[VarRef]struct{})
+	for _, ref := range walkRefs(expr) {
+		if ref.Val == "time" {
 			continue
 		}
-		m[name] = struct{}{}
+		m[*ref] = struct{}{}
 	}
 
-	a := make([]string, 0, len(m))
-	for k := range m {
-		a = append(a, k)
+	a := make(VarRefs, 0, len(m))
+	for ref := range m {
+		a = append(a, ref)
 	}
-	sort.Strings(a)
+	sort.Sort(a)
 
 	return a
 }
@@ -1794,6 +1977,15 @@ func walkFunctionCalls(exp Expr) []*Call {
 	case *VarRef:
 		return nil
 	case *Call:
+		// String functions are applied to each point so only their arguments
+		// can contain aggregates.
+		if IsStringFunction(expr.Name) {
+			var ret []*Call
+			for _, arg := range expr.Args {
+				ret = append(ret, walkFunctionCalls(arg)...)
+			}
+			return ret
+		}
 		return []*Call{expr}
 	case *BinaryExpr:
 		var ret []*Call
@@ -1882,6 +2074,32 @@ func (t *Target) String() string {
 	return buf.String()
 }
 
+// ExplainStatement represents a command for describing how a SELECT statement
+// will be executed.
+type ExplainStatement struct {
+	// The statement to explain.
+	Statement *SelectStatement
+
+	// Executes the statement and reports runtime statistics.
+	Analyze bool
+}
+
+// String returns a string representation of the explain statement.
+func (s *ExplainStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("EXPLAIN ")
+	if s.Analyze {
+		_, _ = buf.WriteString("ANALYZE ")
+	}
+	_, _ = buf.WriteString(s.Statement.String())
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute an ExplainStatement.
+func (s *ExplainStatement) RequiredPrivileges() ExecutionPrivileges {
+	return s.Statement.RequiredPrivileges()
+}
+
 // DeleteStatement represents a command for removing data from the database.
 type DeleteStatement struct {
 	// Data source that values are removed from.
@@ -2019,6 +2237,78 @@ func (s *DropServerStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// DecommissionServerStatement represents a command for gracefully removing a
+// data node from the cluster once its shards have been copied to other nodes.
+type DecommissionServerStatement struct {
+	// ID of the node to be decommissioned.
+	NodeID uint64
+}
+
+// String returns a string representation of the decommission server statement.
+func (s *DecommissionServerStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("DECOMMISSION SERVER ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.NodeID, 10))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a DecommissionServerStatement.
+func (s *DecommissionServerStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// CopyShardStatement represents a command for copying a shard from one data
+// node to another.
+type CopyShardStatement struct {
+	// ID of the shard to be copied.
+	ShardID uint64
+
+	// IDs of the data nodes the shard is copied from and to.
+	Source      uint64
+	Destination uint64
+}
+
+// String returns a string representation of the copy shard statement.
+func (s *CopyShardStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("COPY SHARD ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.ShardID, 10))
+	_, _ = buf.WriteString(" FROM ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.Source, 10))
+	_, _ = buf.WriteString(" TO ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.Destination, 10))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a CopyShardStatement.
+func (s *CopyShardStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// RemoveShardStatement represents a command for removing a shard from a data node.
+type RemoveShardStatement struct {
+	// ID of the shard to be removed.
+	ShardID uint64
+
+	// ID of the data node the shard is removed from.
+	Source uint64
+}
+
+// String returns a string representation of the remove shard statement.
+func (s *RemoveShardStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("REMOVE SHARD ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.ShardID, 10))
+	_, _ = buf.WriteString(" FROM ")
+	_, _ = buf.WriteString(strconv.FormatUint(s.Source, 10))
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a RemoveShardStatement.
+func (s *RemoveShardStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowContinuousQueriesStatement represents a command for listing continuous queries.
 type ShowContinuousQueriesStatement struct{}
 
@@ -2284,6 +2574,18 @@ func (s *ShowStatsStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowShardDifferencesStatement represents a command for comparing the local
+// shards with their replicas on other nodes.
+type ShowShardDifferencesStatement struct{}
+
+// String returns a string representation.
+func (s *ShowShardDifferencesStatement) String() string { return "SHOW SHARD DIFFERENCES" }
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowShardDifferencesStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowShardGroupsStatement represents a command for displaying shard groups in the cluster.
 type ShowShardGroupsStatement struct{}
 
@@ -2306,6 +2608,87 @@ func (s *ShowShardsStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowHintedHandoffStatement represents a command for displaying the hinted
+// handoff queue of every node.
+type ShowHintedHandoffStatement struct{}
+
+// String returns a string representation.
+func (s *ShowHintedHandoffStatement) String() string { return "SHOW HINTED HANDOFF" }
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowHintedHandoffStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// PurgeHintedHandoffStatement represents a command for deleting the hinted
+// handoff queue of a node without sending it.
+type PurgeHintedHandoffStatement struct {
+	// ID of the node whose queue is deleted.
+	NodeID uint64
+}
+
+// String returns a string representation of the purge hinted handoff statement.
+func (s *PurgeHintedHandoffStatement) String() string {
+	return "PURGE HINTED HANDOFF FOR " + strconv.FormatUint(s.NodeID, 10)
+}
+
+// RequiredPrivileges returns the privilege required to execute a PurgeHintedHandoffStatement.
+func (s *PurgeHintedHandoffStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// PauseHintedHandoffStatement represents a command for pausing the replay of
+// hinted handoff queues.
+type PauseHintedHandoffStatement struct {
+	// ID of the node whose replay is paused. Zero pauses all nodes.
+	NodeID uint64
+}
+
+// String returns a string representation of the pause hinted handoff statement.
+func (s *PauseHintedHandoffStatement) String() string {
+	if s.NodeID == 0 {
+		return "PAUSE HINTED HANDOFF"
+	}
+	return "PAUSE HINTED HANDOFF FOR " + strconv.FormatUint(s.NodeID, 10)
+}
+
+// RequiredPrivileges returns the privilege required to execute a PauseHintedHandoffStatement.
+func (s *PauseHintedHandoffStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// ResumeHintedHandoffStatement represents a command for resuming the replay
+// of hinted handoff queues.
+type ResumeHintedHandoffStatement struct {
+	// ID of the node whose replay is resumed. Zero resumes all nodes.
+	NodeID uint64
+}
+
+// String returns a string representation of the resume hinted handoff statement.
+func (s *ResumeHintedHandoffStatement) String() string {
+	if s.NodeID == 0 {
+		return "RESUME HINTED HANDOFF"
+	}
+	return "RESUME HINTED HANDOFF FOR " + strconv.FormatUint(s.NodeID, 10)
+}
+
+// RequiredPrivileges returns the privilege required to execute a ResumeHintedHandoffStatement.
+func (s *ResumeHintedHandoffStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowRebalanceStatement represents a command for displaying the shard moves
+// of the rebalancer.
+type ShowRebalanceStatement struct{}
+
+// String returns a string representation.
+func (s *ShowRebalanceStatement) String() string { return "SHOW REBALANCE" }
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowRebalanceStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowDiagnosticsStatement represents a command for show node diagnostics.
 type ShowDiagnosticsStatement struct {
 	// Module
@@ -2581,6 +2964,167 @@ func (s *ShowFieldKeysStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Admin: false, Name: "", Privilege: ReadPrivilege}}
 }
 
+// ShowSeriesCardinalityStatement represents a command for counting the series in a database.
+type ShowSeriesCardinalityStatement struct {
+	// Database to count series in. Uses the default database if blank.
+	Database string
+
+	// Counts the series exactly instead of estimating.
+	Exact bool
+
+	// Measurement(s) to count series for. A count is returned per measurement.
+	Sources Sources
+}
+
+// String returns a string representation of the statement.
+func (s *ShowSeriesCardinalityStatement) String() string {
+	return cardinalityString("SHOW SERIES", s.Exact, s.Database, s.Sources)
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowSeriesCardinalityStatement.
+func (s *ShowSeriesCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}
+}
+
+// DefaultDatabase returns the database set by the ON clause.
+func (s *ShowSeriesCardinalityStatement) DefaultDatabase() string { return s.Database }
+
+// ShowMeasurementCardinalityStatement represents a command for counting the measurements in a database.
+type ShowMeasurementCardinalityStatement struct {
+	// Database to count measurements in. Uses the default database if blank.
+	Database string
+
+	// Counts the measurements exactly instead of estimating.
+	Exact bool
+}
+
+// String returns a string representation of the statement.
+func (s *ShowMeasurementCardinalityStatement) String() string {
+	return cardinalityString("SHOW MEASUREMENT", s.Exact, s.Database, nil)
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowMeasurementCardinalityStatement.
+func (s *ShowMeasurementCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}
+}
+
+// DefaultDatabase returns the database set by the ON clause.
+func (s *ShowMeasurementCardinalityStatement) DefaultDatabase() string { return s.Database }
+
+// ShowTagKeyCardinalityStatement represents a command for counting the tag keys of each measurement.
+type ShowTagKeyCardinalityStatement struct {
+	// Database to count tag keys in. Uses the default database if blank.
+	Database string
+
+	// Counts the tag keys exactly instead of estimating.
+	Exact bool
+
+	// Measurement(s) to count tag keys for. Counts all measurements if empty.
+	Sources Sources
+}
+
+// String returns a string representation of the statement.
+func (s *ShowTagKeyCardinalityStatement) String() string {
+	return cardinalityString("SHOW TAG KEY", s.Exact, s.Database, s.Sources)
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowTagKeyCardinalityStatement.
+func (s *ShowTagKeyCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}
+}
+
+// DefaultDatabase returns the database set by the ON clause.
+func (s *ShowTagKeyCardinalityStatement) DefaultDatabase() string { return s.Database }
+
+// ShowTagValuesCardinalityStatement represents a command for counting the
+// values of tag keys in each measurement.
+type ShowTagValuesCardinalityStatement struct {
+	// Database to count tag values in. Uses the default database if blank.
+	Database string
+
+	// Counts the tag values exactly instead of estimating.
+	Exact bool
+
+	// Measurement(s) to count tag values for. Counts all measurements if empty.
+	Sources Sources
+
+	// Tag keys to count values for.
+	TagKeys []string
+}
+
+// String returns a string representation of the statement.
+func (s *ShowTagValuesCardinalityStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString(cardinalityString("SHOW TAG VALUES", s.Exact, s.Database, s.Sources))
+	if len(s.TagKeys) == 1 {
+		_, _ = buf.WriteString(" WITH KEY = ")
+		_, _ = buf.WriteString(QuoteIdent(s.TagKeys[0]))
+	} else if len(s.TagKeys) > 1 {
+		_, _ = buf.WriteString(" WITH KEY IN (")
+		for i, k := range s.TagKeys {
+			if i > 0 {
+				_, _ = buf.WriteString(", ")
+			}
+			_, _ = buf.WriteString(QuoteIdent(k))
+		}
+		_, _ = buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowTagValuesCardinalityStatement.
+func (s *ShowTagValuesCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}
+}
+
+// DefaultDatabase returns the database set by the ON clause.
+func (s *ShowTagValuesCardinalityStatement) DefaultDatabase() string { return s.Database }
+
+// ShowFieldKeyCardinalityStatement represents a command for counting the field keys of each measurement.
+type ShowFieldKeyCardinalityStatement struct {
+	// Database to count field keys in. Uses the default database if blank.
+	Database string
+
+	// Counts the field keys exactly instead of estimating.
+	Exact bool
+
+	// Measurement(s) to count field keys for. Counts all measurements if empty.
+	Sources Sources
+}
+
+// String returns a string representation of the statement.
+func (s *ShowFieldKeyCardinalityStatement) String() string {
+	return cardinalityString("SHOW FIELD KEY", s.Exact, s.Database, s.Sources)
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowFieldKeyCardinalityStatement.
+func (s *ShowFieldKeyCardinalityStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Admin: false, Name: s.Database, Privilege: ReadPrivilege}}
+}
+
+// DefaultDatabase returns the database set by the ON clause.
+func (s *ShowFieldKeyCardinalityStatement) DefaultDatabase() string { return s.Database }
+
+// cardinalityString returns the string representation of a cardinality statement.
+func cardinalityString(prefix string, exact bool, database string, sources Sources) string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString(prefix)
+	if exact {
+		_, _ = buf.WriteString(" EXACT")
+	}
+	_, _ = buf.WriteString(" CARDINALITY")
+
+	if database != "" {
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(QuoteIdent(database))
+	}
+	if sources != nil {
+		_, _ = buf.WriteString(" FROM ")
+		_, _ = buf.WriteString(sources.String())
+	}
+	return buf.String()
+}
+
 // Fields represents a list of fields.
 type Fields []*Field
 
@@ -2594,6 +3138,17 @@ func (a Fields) AliasNames() []string {
 	return names
 }
 
+// index returns the position of the field with the given alias or name.
+// Returns -1 if no field has the name.
+func (a Fields) index(name string) int {
+	for i, f := range a {
+		if f.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // Names returns a list of field names.
 func (a Fields) Names() []string {
 	names := []string{}
@@ -2749,12 +3304,47 @@ func (m *Measurement) String() string {
 
 // VarRef represents a reference to a variable.
 type VarRef struct {
-	Val string
+	Val  string
+	Type DataType
+
+	// Qualifier is the first identifier of a dotted reference, such as the
+	// measurement of cpu.usage. Empty if the reference is a single
+	// identifier, even if the identifier contains a dot.
+	Qualifier string
 }
 
 // String returns a string representation of the variable reference.
 func (r *VarRef) String() string {
-	return QuoteIdent(r.Val)
+	s := QuoteIdent(r.Val)
+	if r.Qualifier != "" {
+		s = QuoteIdent(r.Qualifier, strings.TrimPrefix(r.Val, r.Qualifier+"."))
+	}
+
+	if r.Type != Unknown {
+		return s + "::" + r.Type.String()
+	}
+	return s
+}
+
+// VarRefs represents a list of variable references.
+type VarRefs []VarRef
+
+func (a VarRefs) Len() int      { return len(a) }
+func (a VarRefs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a VarRefs) Less(i, j int) bool {
+	if a[i].Val != a[j].Val {
+		return a[i].Val < a[j].Val
+	}
+	return a[i].Type < a[j].Type
+}
+
+// Strings returns the string representation of each reference.
+func (a VarRefs) Strings() []string {
+	s := make([]string, len(a))
+	for i, ref := range a {
+		s[i] = ref.String()
+	}
+	return s
 }
 
 // Call represents a function call.
@@ -2991,7 +3581,7 @@ func CloneExpr(expr Expr) Expr {
 	case *TimeLiteral:
 		return &TimeLiteral{Val: expr.Val}
 	case *VarRef:
-		return &VarRef{Val: expr.Val}
+		return &VarRef{Val: expr.Val, Type: expr.Type, Qualifier: expr.Qualifier}
 	case *Wildcard:
 		return &Wildcard{}
 	}
@@ -3169,6 +3759,9 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Sources)
 		Walk(v, n.Condition)
 
+	case *ExplainStatement:
+		Walk(v, n.Statement)
+
 	case *Field:
 		Walk(v, n.Expr)
 
@@ -3195,6 +3788,18 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Sources)
 		Walk(v, n.Condition)
 
+	case *ShowSeriesCardinalityStatement:
+		Walk(v, n.Sources)
+
+	case *ShowTagKeyCardinalityStatement:
+		Walk(v, n.Sources)
+
+	case *ShowTagValuesCardinalityStatement:
+		Walk(v, n.Sources)
+
+	case *ShowFieldKeyCardinalityStatement:
+		Walk(v, n.Sources)
+
 	case *ShowTagKeysStatement:
 		Walk(v, n.Sources)
 		Walk(v, n.Condition)
@@ -3323,7 +3928,12 @@ func Eval(expr Expr, m map[string]interface{}) interface{} {
 	case *StringLiteral:
 		return expr.Val
 	case *VarRef:
-		return m[expr.Val]
+		return castValue(m[expr.Val], expr.Type)
+	case *Call:
+		if IsStringFunction(expr.Name) {
+			return evalStringFunction(expr, m)
+		}
+		return nil
 	default:
 		return nil
 	}
@@ -3687,14 +4297,14 @@ func reduceParenExpr(expr *ParenExpr, valuer Valuer) Expr {
 func reduceVarRef(expr *VarRef, valuer Valuer) Expr {
 	// Ignore if there is no valuer.
 	if valuer == nil {
-		return &VarRef{Val: expr.Val}
+		return &VarRef{Val: expr.Val, Type: expr.Type, Qualifier: expr.Qualifier}
 	}
 
 	// Retrieve the value of the ref.
 	// Ignore if the value doesn't exist.
 	v, ok := valuer.Value(expr.Val)
 	if !ok {
-		return &VarRef{Val: expr.Val}
+		return &VarRef{Val: expr.Val, Type: expr.Type, Qualifier: expr.Qualifier}
 	}
 
 	// Return the value as a literal.
@@ -3745,8 +4355,11 @@ type containsVarRefVisitor struct {
 }
 
 func (v *containsVarRefVisitor) Visit(n Node) Visitor {
-	switch n.(type) {
+	switch n := n.(type) {
 	case *Call:
+		if IsStringFunction(n.Name) {
+			return v
+		}
 		return nil
 	case *VarRef:
 		v.contains = true
@@ -85,21 +85,21 @@ func TestSelectStatement_Substatement(t *testing.T) {
 		{
 			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM aa, bb WHERE aa.host = 'servera' AND bb.host = 'serverb'`,
 			expr: &influxql.VarRef{Val: "bb.value"},
-			sub:  `SELECT "bb.value" FROM bb WHERE "bb.host" = 'serverb'`,
+			sub:  `SELECT "bb.value" FROM bb WHERE "bb".host = 'serverb'`,
 		},
 
 		// 4. Join with complex condition
 		{
 			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM aa, bb WHERE aa.host = 'servera' AND (bb.host = 'serverb' OR bb.host = 'serverc') AND 1 = 2`,
 			expr: &influxql.VarRef{Val: "bb.value"},
-			sub:  `SELECT "bb.value" FROM bb WHERE ("bb.host" = 'serverb' OR "bb.host" = 'serverc') AND 1.000 = 2.000`,
+			sub:  `SELECT "bb.value" FROM bb WHERE ("bb".host = 'serverb' OR "bb".host = 'serverc') AND 1.000 = 2.000`,
 		},
 
 		// 5. 4 with different condition order
 		{
 			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM aa, bb WHERE ((bb.host = 'serverb' OR bb.host = 'serverc') AND aa.host = 'servera') AND 1 = 2`,
 			expr: &influxql.VarRef{Val: "bb.value"},
-			sub:  `SELECT "bb.value" FROM bb WHERE (("bb.host" = 'serverb' OR "bb.host" = 'serverc')) AND 1.000 = 2.000`,
+			sub:  `SELECT "bb.value" FROM bb WHERE (("bb".host = 'serverb' OR "bb".host = 'serverc')) AND 1.000 = 2.000`,
 		},
 	}
 
@@ -412,7 +412,19 @@ func TestSelectStatement_RewriteWildcards(t *testing.T) {
 		// Combo
 		{
 			stmt:    `SELECT * FROM cpu GROUP BY *`,
-			rewrite: `SELECT value1, value2 FROM cpu GROUP BY host, region`,
+			rewrite: `SELECT value1::field, value2::field FROM cpu GROUP BY host, region`,
+		},
+
+		// Call wildcard
+		{
+			stmt:    `SELECT mean(*) FROM cpu WHERE time < now() GROUP BY time(1h), *`,
+			rewrite: `SELECT mean(value1) AS mean_value1, mean(value2) AS mean_value2 FROM cpu WHERE time < now() GROUP BY time(1h), host, region`,
+		},
+
+		// Call wildcard with alias
+		{
+			stmt:    `SELECT count(*) AS n, max(value1) FROM cpu`,
+			rewrite: `SELECT count(value1) AS n_value1, count(value2) AS n_value2, max(value1) FROM cpu`,
 		},
 	}
 
@@ -424,8 +436,8 @@ func TestSelectStatement_RewriteWildcards(t *testing.T) {
 		}
 
 		var ic IteratorCreator
-		ic.FieldDimensionsFn = func(sources influxql.Sources) (fields, dimensions map[string]struct{}, err error) {
-			fields = map[string]struct{}{"value1": struct{}{}, "value2": struct{}{}}
+		ic.FieldDimensionsFn = func(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+			fields = map[string]influxql.DataType{"value1": influxql.Float, "value2": influxql.Integer}
 			dimensions = map[string]struct{}{"host": struct{}{}, "region": struct{}{}}
 			return
 		}
@@ -858,6 +870,9 @@ func TestParseString(t *testing.T) {
 		{
 			stmt: `SELECT * FROM myseries`,
 		},
+		{
+			stmt: `SELECT "cpu.idle", "cpu.load".value FROM "cpu.load"`,
+		},
 		{
 			stmt: `DROP DATABASE "!"`,
 		},
@@ -969,6 +984,19 @@ func TestEval(t *testing.T) {
 		{in: `foo = 'bar'`, out: true, data: map[string]interface{}{"foo": "bar"}},
 		{in: `foo = 'bar'`, out: nil, data: map[string]interface{}{"foo": nil}},
 		{in: `foo <> 'bar'`, out: true, data: map[string]interface{}{"foo": "xxx"}},
+
+		// String functions.
+		{in: `strlen(msg)`, out: int64(5), data: map[string]interface{}{"msg": "héllo"}},
+		{in: `lower(msg) = 'error'`, out: true, data: map[string]interface{}{"msg": "ERROR"}},
+		{in: `upper(msg)`, out: "ERROR", data: map[string]interface{}{"msg": "error"}},
+		{in: `substr(msg, 2)`, out: "llo", data: map[string]interface{}{"msg": "hello"}},
+		{in: `substr(msg, 1, 3)`, out: "ell", data: map[string]interface{}{"msg": "hello"}},
+		{in: `substr(msg, 10, 3)`, out: "", data: map[string]interface{}{"msg": "hello"}},
+		{in: `concat(host, ':', msg)`, out: "a:b", data: map[string]interface{}{"host": "a", "msg": "b"}},
+		{in: `contains(msg, 'err')`, out: true, data: map[string]interface{}{"msg": "an error"}},
+		{in: `regex_extract(msg, /code=(\d+)/, 1)`, out: "404", data: map[string]interface{}{"msg": "code=404 path=/"}},
+		{in: `regex_extract(msg, /code=(\d+)/, 1)`, out: nil, data: map[string]interface{}{"msg": "ok"}},
+		{in: `lower(msg)`, out: nil, data: map[string]interface{}{"msg": float64(1)}},
 	} {
 		// Evaluate expression.
 		out := influxql.Eval(MustParseExpr(tt.in), tt.data)
@@ -63,11 +63,22 @@ func newCountIterator(input Iterator, opt IteratorOptions) Iterator {
 		return &floatReduceIterator{input: newBufFloatIterator(input), opt: opt, fn: floatCountReduce}
 	case IntegerIterator:
 		return &integerReduceIterator{input: newBufIntegerIterator(input), opt: opt, fn: integerCountReduce}
+	case StringIterator, BooleanIterator:
+		// Count every non-nil value as an integer point.
+		return newCountIterator(&integerFuncIterator{inputs: []Iterator{input}, fn: countValue}, opt)
 	default:
 		panic(fmt.Sprintf("unsupported count iterator type: %T", input))
 	}
 }
 
+// countValue returns 1 for every value so it can be counted by an integer reducer.
+func countValue(values []interface{}) interface{} {
+	if values[0] == nil {
+		return nil
+	}
+	return int64(1)
+}
+
 // floatCountReduce returns the count of points.
 func floatCountReduce(prev, curr *FloatPoint, opt *reduceOptions) (int64, float64, []interface{}) {
 	if prev == nil {
@@ -259,7 +270,9 @@ func floatDistinctReduceSlice(a []FloatPoint, opt *reduceOptions) []FloatPoint {
 func integerDistinctReduceSlice(a []IntegerPoint, opt *reduceOptions) []IntegerPoint {
 	m := make(map[int64]IntegerPoint)
 	for _, p := range a {
-		if _, ok := m[p.Value]; !ok {
+		if p.Nil {
+			continue
+		} else if _, ok := m[p.Value]; !ok {
 			m[p.Value] = p
 		}
 	}
@@ -276,7 +289,9 @@ func integerDistinctReduceSlice(a []IntegerPoint, opt *reduceOptions) []IntegerP
 func stringDistinctReduceSlice(a []StringPoint, opt *reduceOptions) []StringPoint {
 	m := make(map[string]StringPoint)
 	for _, p := range a {
-		if _, ok := m[p.Value]; !ok {
+		if p.Nil {
+			continue
+		} else if _, ok := m[p.Value]; !ok {
 			m[p.Value] = p
 		}
 	}
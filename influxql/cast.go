@@ -1,5 +1,10 @@
 package influxql
 
+import (
+	"math"
+	"strconv"
+)
+
 func castToFloat(v interface{}) float64 {
 	switch v := v.(type) {
 	case float64:
@@ -39,3 +44,74 @@ func castToBoolean(v interface{}) bool {
 		return false
 	}
 }
+
+// castValue converts v to typ for a type cast in a variable reference.
+// Floats and integers can be converted to each other and strings are parsed
+// as either. Any other value is only returned if it already has the
+// requested type. Tag and field specifiers do not change the value. Returns
+// nil if v cannot be cast, such as a float outside the range of an integer.
+func castValue(v interface{}, typ DataType) interface{} {
+	switch typ {
+	case Float:
+		switch v := v.(type) {
+		case float64:
+			return v
+		case int64:
+			return float64(v)
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case Integer:
+		switch v := v.(type) {
+		case float64:
+			// NaN fails both comparisons.
+			if v >= math.MinInt64 && v < -math.MinInt64 {
+				return int64(v)
+			}
+		case int64:
+			return v
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i
+			}
+		}
+	case String:
+		if v, ok := v.(string); ok {
+			return v
+		}
+	case Boolean:
+		if v, ok := v.(bool); ok {
+			return v
+		}
+	default:
+		return v
+	}
+	return nil
+}
+
+// NewCastIterator returns an iterator that converts the points of input to typ.
+// Points with a value that cannot be cast are dropped. Returns input if it
+// already has the requested type or if typ is not a cast type.
+func NewCastIterator(input Iterator, typ DataType) Iterator {
+	switch typ {
+	case Float:
+		if _, ok := input.(FloatIterator); !ok {
+			return &floatCastIterator{input: input}
+		}
+	case Integer:
+		if _, ok := input.(IntegerIterator); !ok {
+			return &integerCastIterator{input: input}
+		}
+	case String:
+		if _, ok := input.(StringIterator); !ok {
+			return &stringCastIterator{input: input}
+		}
+	case Boolean:
+		if _, ok := input.(BooleanIterator); !ok {
+			return &booleanCastIterator{input: input}
+		}
+	}
+	return input
+}
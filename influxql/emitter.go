@@ -1,7 +1,6 @@
 package influxql
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/models"
@@ -174,26 +173,5 @@ func (e *Emitter) readIterator(itr Iterator) Point {
 	if itr == nil {
 		return nil
 	}
-
-	switch itr := itr.(type) {
-	case FloatIterator:
-		if p := itr.Next(); p != nil {
-			return p
-		}
-	case IntegerIterator:
-		if p := itr.Next(); p != nil {
-			return p
-		}
-	case StringIterator:
-		if p := itr.Next(); p != nil {
-			return p
-		}
-	case BooleanIterator:
-		if p := itr.Next(); p != nil {
-			return p
-		}
-	default:
-		panic(fmt.Sprintf("unsupported iterator: %T", itr))
-	}
-	return nil
+	return readPoint(itr)
 }
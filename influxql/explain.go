@@ -0,0 +1,542 @@
+package influxql
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// IteratorCost represents an estimate of the work required to read an iterator.
+type IteratorCost struct {
+	// Total number of shards and series that will be read.
+	NumShards int64
+	NumSeries int64
+
+	// Number of values held in the in-memory cache.
+	CachedValues int64
+
+	// Number of files, blocks and block bytes that will be read from disk.
+	NumFiles   int64
+	BlocksRead int64
+	BlockSize  int64
+}
+
+// Combine returns the sum of two costs.
+func (c IteratorCost) Combine(other IteratorCost) IteratorCost {
+	return IteratorCost{
+		NumShards:    c.NumShards + other.NumShards,
+		NumSeries:    c.NumSeries + other.NumSeries,
+		CachedValues: c.CachedValues + other.CachedValues,
+		NumFiles:     c.NumFiles + other.NumFiles,
+		BlocksRead:   c.BlocksRead + other.BlocksRead,
+		BlockSize:    c.BlockSize + other.BlockSize,
+	}
+}
+
+// IteratorCostEstimator represents an IteratorCreator that can estimate the
+// cost of an iterator without creating it.
+type IteratorCostEstimator interface {
+	IteratorCost(opt IteratorOptions) (IteratorCost, error)
+}
+
+// IteratorStats represents runtime statistics collected while reading an iterator.
+// Counters may be updated by the storage engine so they are modified atomically.
+type IteratorStats struct {
+	// Number of series opened by the storage engine.
+	SeriesN int64
+
+	// Number of points returned by the iterator.
+	PointN int64
+
+	// Number of blocks decoded from disk.
+	BlockN int64
+
+	// Number of values read from the cache and from files.
+	CacheN int64
+	FileN  int64
+
+	// Time spent inside the iterator.
+	Elapsed time.Duration
+}
+
+// AddSeries increments the number of series opened. Safe to call on a nil stats.
+func (s *IteratorStats) AddSeries(n int64) {
+	if s != nil {
+		atomic.AddInt64(&s.SeriesN, n)
+	}
+}
+
+// AddBlocks increments the number of blocks decoded. Safe to call on a nil stats.
+func (s *IteratorStats) AddBlocks(n int64) {
+	if s != nil {
+		atomic.AddInt64(&s.BlockN, n)
+	}
+}
+
+// AddCacheValues increments the number of values read from the cache.
+// Safe to call on a nil stats.
+func (s *IteratorStats) AddCacheValues(n int64) {
+	if s != nil {
+		atomic.AddInt64(&s.CacheN, n)
+	}
+}
+
+// AddFileValues increments the number of values read from files.
+// Safe to call on a nil stats.
+func (s *IteratorStats) AddFileValues(n int64) {
+	if s != nil {
+		atomic.AddInt64(&s.FileN, n)
+	}
+}
+
+// ExplainNode represents a single stage in the execution plan of a SELECT statement.
+type ExplainNode struct {
+	Name     string
+	Details  []string
+	Children []*ExplainNode
+}
+
+// Lines returns the node and its children as a list of indented lines.
+func (n *ExplainNode) Lines() []string {
+	return n.lines(0)
+}
+
+func (n *ExplainNode) lines(depth int) []string {
+	indent := strings.Repeat("    ", depth)
+	a := []string{indent + n.Name}
+	for _, d := range n.Details {
+		a = append(a, indent+"  "+d)
+	}
+	for _, child := range n.Children {
+		a = append(a, child.lines(depth+1)...)
+	}
+	return a
+}
+
+// ExplainSelect returns the plan that Select would build for stmt without
+// creating any iterators. If ic implements IteratorCostEstimator then each
+// storage iterator in the plan includes an estimate of its cost.
+//
+// Statements should have all rewriting performed before calling ExplainSelect().
+func ExplainSelect(stmt *SelectStatement, ic IteratorCreator, sopt *SelectOptions) ([]*ExplainNode, error) {
+	fields, opt, aux, err := selectFields(stmt, sopt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results sorted by a field are limited after they are sorted.
+	sortField, sortOpt := stmt.ValueSortField(), opt
+	if sortField != nil {
+		if fields.index(sortField.Name) == -1 {
+			return nil, fmt.Errorf("unknown field in ORDER BY: %s", sortField.Name)
+		}
+		opt.Limit, opt.Offset = 0, 0
+	}
+
+	// Joins read each field from a single source.
+	if join := newJoinInfo(stmt); join != nil {
+		if err := join.validate(fields); err != nil {
+			return nil, err
+		}
+		ic = join.iteratorCreator(ic)
+		opt.Aux, aux = nil, false
+	}
+
+	nodes := make([]*ExplainNode, len(fields))
+	if aux {
+		// All fields are read from a single combined auxiliary iterator.
+		input, err := explainCreateIterator(ic, opt)
+		if err != nil {
+			return nil, err
+		}
+		if opt.Dedupe {
+			input = &ExplainNode{Name: "dedupe", Children: []*ExplainNode{input}}
+		}
+		input = explainLimit(input, opt)
+
+		for i, f := range fields {
+			node, err := explainAuxExpr(Reduce(f.Expr, nil), input)
+			if err != nil {
+				return nil, err
+			}
+			node = explainSort(node, sortField, sortOpt)
+			nodes[i] = &ExplainNode{Name: "EXPRESSION: " + f.String(), Children: []*ExplainNode{node}}
+		}
+		return nodes, nil
+	}
+
+	for i, f := range fields {
+		node, err := explainExpr(Reduce(f.Expr, nil), ic, opt)
+		if err != nil {
+			return nil, err
+		}
+		node = explainSort(explainLimit(node, opt), sortField, sortOpt)
+		nodes[i] = &ExplainNode{Name: "EXPRESSION: " + f.String(), Children: []*ExplainNode{node}}
+	}
+	return nodes, nil
+}
+
+// explainExpr returns the plan for the iterator built by buildExprIterator().
+func explainExpr(expr Expr, ic IteratorCreator, opt IteratorOptions) (*ExplainNode, error) {
+	opt.Expr = expr
+
+	switch expr := expr.(type) {
+	case *VarRef:
+		return explainCreateIterator(ic, opt)
+	case *Call:
+		if IsStringFunction(expr.Name) {
+			return explainStringFunction(expr, func(arg Expr) (*ExplainNode, error) {
+				return explainExpr(arg, ic, opt)
+			})
+		}
+
+		var node *ExplainNode
+		switch expr.Name {
+		case "count":
+			if arg, ok := expr.Args[0].(*Call); ok && arg.Name == "distinct" {
+				input, err := explainExpr(arg, ic, opt)
+				if err != nil {
+					return nil, err
+				}
+				node = &ExplainNode{Name: "count()", Children: []*ExplainNode{input}}
+				break
+			}
+
+			input, err := explainCreateIterator(ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			node = input
+		case "min", "max", "sum", "first", "last":
+			// These calls are pushed down into the storage engine.
+			input, err := explainCreateIterator(ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			node = input
+		case "distinct":
+			input, err := explainExpr(expr.Args[0], ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			return &ExplainNode{Name: "distinct()", Children: []*ExplainNode{input}}, nil
+		case "derivative", "non_negative_derivative":
+			input, err := explainExpr(expr.Args[0], ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			return &ExplainNode{
+				Name:     expr.Name + "()",
+				Details:  []string{"INTERVAL: " + opt.DerivativeInterval().Duration.String()},
+				Children: []*ExplainNode{input},
+			}, nil
+		default:
+			node = &ExplainNode{Name: expr.Name + "()"}
+			for _, arg := range expr.Args {
+				if _, ok := arg.(Literal); ok {
+					continue
+				}
+				input, err := explainExpr(arg, ic, opt)
+				if err != nil {
+					return nil, err
+				}
+				node.Children = append(node.Children, input)
+			}
+		}
+
+		if !opt.Interval.IsZero() && opt.Fill != NoFill {
+			node = &ExplainNode{
+				Name:     "fill(" + fillString(opt.Fill, opt.FillValue) + ")",
+				Details:  []string{"INTERVAL: " + opt.Interval.Duration.String()},
+				Children: []*ExplainNode{node},
+			}
+		}
+		return node, nil
+	case *BinaryExpr:
+		node := &ExplainNode{Name: "binary_expr(" + expr.Op.String() + ")"}
+		for _, arg := range []Expr{expr.LHS, expr.RHS} {
+			if lit, ok := arg.(Literal); ok {
+				node.Children = append(node.Children, &ExplainNode{Name: "literal(" + lit.String() + ")"})
+				continue
+			}
+			input, err := explainExpr(arg, ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, input)
+		}
+		return node, nil
+	case *ParenExpr:
+		return explainExpr(expr.Expr, ic, opt)
+	default:
+		return nil, fmt.Errorf("invalid expression type: %T", expr)
+	}
+}
+
+// explainAuxExpr returns the plan for a field read from an auxiliary iterator.
+func explainAuxExpr(expr Expr, input *ExplainNode) (*ExplainNode, error) {
+	switch expr := expr.(type) {
+	case *VarRef:
+		return &ExplainNode{Name: "aux(" + expr.String() + ")", Children: []*ExplainNode{input}}, nil
+	case *BinaryExpr:
+		node := &ExplainNode{Name: "binary_expr(" + expr.Op.String() + ")"}
+		for _, arg := range []Expr{expr.LHS, expr.RHS} {
+			if lit, ok := arg.(Literal); ok {
+				node.Children = append(node.Children, &ExplainNode{Name: "literal(" + lit.String() + ")"})
+				continue
+			}
+			child, err := explainAuxExpr(arg, input)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+		return node, nil
+	case *Call:
+		if !IsStringFunction(expr.Name) {
+			return nil, fmt.Errorf("invalid function in auxiliary field: %s", expr.Name)
+		}
+		return explainStringFunction(expr, func(arg Expr) (*ExplainNode, error) {
+			return explainAuxExpr(arg, input)
+		})
+	case *ParenExpr:
+		return explainAuxExpr(expr.Expr, input)
+	default:
+		return nil, fmt.Errorf("invalid expression type: %T", expr)
+	}
+}
+
+// explainStringFunction returns the plan for a string function.
+// Literal arguments are listed as details of the node.
+func explainStringFunction(call *Call, fn func(arg Expr) (*ExplainNode, error)) (*ExplainNode, error) {
+	node := &ExplainNode{Name: call.Name + "()"}
+	for _, arg := range call.Args {
+		if lit, ok := arg.(Literal); ok {
+			node.Details = append(node.Details, "ARGUMENT: "+lit.String())
+			continue
+		}
+		child, err := fn(arg)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
+
+// explainCreateIterator returns the plan for an iterator created by the storage engine.
+func explainCreateIterator(ic IteratorCreator, opt IteratorOptions) (*ExplainNode, error) {
+	node := &ExplainNode{Name: "create_iterator"}
+	if opt.Expr != nil {
+		node.Details = append(node.Details, "EXPRESSION: "+opt.Expr.String())
+	}
+	if len(opt.Aux) > 0 {
+		node.Details = append(node.Details, "AUXILIARY FIELDS: "+strings.Join(VarRefs(opt.Aux).Strings(), ", "))
+	}
+	node.Details = append(node.Details, "SOURCES: "+Sources(opt.Sources).String())
+	if opt.Condition != nil {
+		node.Details = append(node.Details, "CONDITION: "+opt.Condition.String())
+	}
+	if len(opt.Dimensions) > 0 {
+		node.Details = append(node.Details, "DIMENSIONS: "+strings.Join(opt.Dimensions, ", "))
+	}
+
+	if est, ok := ic.(IteratorCostEstimator); ok {
+		cost, err := est.IteratorCost(opt)
+		if err != nil {
+			return nil, err
+		}
+		node.Details = append(node.Details,
+			fmt.Sprintf("NUMBER OF SHARDS: %d", cost.NumShards),
+			fmt.Sprintf("NUMBER OF SERIES: %d", cost.NumSeries),
+			fmt.Sprintf("CACHED VALUES: %d", cost.CachedValues),
+			fmt.Sprintf("NUMBER OF FILES: %d", cost.NumFiles),
+			fmt.Sprintf("NUMBER OF BLOCKS: %d", cost.BlocksRead),
+			fmt.Sprintf("SIZE OF BLOCKS: %d", cost.BlockSize),
+		)
+	}
+	return node, nil
+}
+
+// explainLimit wraps node in a limit stage if opt has a limit or offset.
+func explainLimit(node *ExplainNode, opt IteratorOptions) *ExplainNode {
+	if opt.Limit == 0 && opt.Offset == 0 {
+		return node
+	}
+	return &ExplainNode{
+		Name:     "limit",
+		Details:  []string{fmt.Sprintf("LIMIT: %d", opt.Limit), fmt.Sprintf("OFFSET: %d", opt.Offset)},
+		Children: []*ExplainNode{node},
+	}
+}
+
+// explainSort wraps node in the sort applied by Select when results are
+// ordered by a field. The limit and offset are applied to the sorted rows.
+func explainSort(node *ExplainNode, field *SortField, opt IteratorOptions) *ExplainNode {
+	if field == nil {
+		return node
+	}
+
+	details := []string{"ORDER BY: " + field.String()}
+	if opt.Limit > 0 || opt.Offset > 0 {
+		details = append(details, fmt.Sprintf("LIMIT: %d", opt.Limit), fmt.Sprintf("OFFSET: %d", opt.Offset))
+	}
+	return &ExplainNode{Name: "sort", Details: details, Children: []*ExplainNode{node}}
+}
+
+// fillString returns the textual representation of a fill option.
+func fillString(fill FillOption, value interface{}) string {
+	switch fill {
+	case NullFill:
+		return "null"
+	case NoFill:
+		return "none"
+	case NumberFill:
+		return fmt.Sprintf("%v", value)
+	case PreviousFill:
+		return "previous"
+	default:
+		return "unknown"
+	}
+}
+
+// AnalyzeSelect executes stmt against ic, reads every iterator to completion
+// and returns the plan annotated with the statistics collected along the way.
+// Execution stops early if closing is closed.
+//
+// Statements should have all rewriting performed before calling AnalyzeSelect().
+func AnalyzeSelect(stmt *SelectStatement, ic IteratorCreator, sopt *SelectOptions, closing <-chan struct{}) ([]*ExplainNode, error) {
+	aic := &analyzeIteratorCreator{IteratorCreator: ic}
+
+	start := time.Now()
+	itrs, err := Select(stmt, aic, sopt)
+	if err != nil {
+		return nil, err
+	}
+	planningTime := time.Since(start)
+
+	// Track each field's output separately from the storage iterators.
+	fieldStats := make([]*IteratorStats, len(itrs))
+	for i := range itrs {
+		fieldStats[i] = &IteratorStats{}
+		itrs[i] = newAnalyzeIterator(itrs[i], fieldStats[i])
+	}
+
+	em := NewEmitter(itrs, stmt.TimeAscending())
+	em.Columns = stmt.ColumnNames()
+	em.OmitTime = stmt.OmitTime
+	defer em.Close()
+
+	var rowN, valueN int
+	start = time.Now()
+	func() {
+		for {
+			select {
+			case <-closing:
+				return
+			default:
+			}
+
+			row := em.Emit()
+			if row == nil {
+				return
+			}
+			rowN++
+			valueN += len(row.Values)
+		}
+	}()
+	executionTime := time.Since(start)
+
+	root := &ExplainNode{
+		Name: "EXECUTION",
+		Details: []string{
+			"PLANNING TIME: " + planningTime.String(),
+			"EXECUTION TIME: " + executionTime.String(),
+			fmt.Sprintf("SERIES RETURNED: %d", rowN),
+			fmt.Sprintf("ROWS RETURNED: %d", valueN),
+		},
+	}
+	for i, f := range stmt.Fields {
+		if i >= len(fieldStats) {
+			break
+		}
+		root.Children = append(root.Children, &ExplainNode{
+			Name:    "EXPRESSION: " + f.String(),
+			Details: fieldStats[i].details(),
+		})
+	}
+	for _, n := range aic.nodes {
+		n.node.Details = append(n.node.Details, n.stats.details()...)
+		root.Children = append(root.Children, n.node)
+	}
+	return []*ExplainNode{root}, nil
+}
+
+// details returns the statistics as lines for an ExplainNode.
+func (s *IteratorStats) details() []string {
+	a := []string{
+		"ELAPSED: " + s.Elapsed.String(),
+		fmt.Sprintf("POINTS: %d", atomic.LoadInt64(&s.PointN)),
+	}
+	if n := atomic.LoadInt64(&s.SeriesN); n > 0 {
+		a = append(a, fmt.Sprintf("SERIES: %d", n))
+	}
+	if n := atomic.LoadInt64(&s.BlockN); n > 0 {
+		a = append(a, fmt.Sprintf("BLOCKS DECODED: %d", n))
+	}
+	if n := atomic.LoadInt64(&s.CacheN); n > 0 {
+		a = append(a, fmt.Sprintf("CACHE VALUES: %d", n))
+	}
+	if n := atomic.LoadInt64(&s.FileN); n > 0 {
+		a = append(a, fmt.Sprintf("FILE VALUES: %d", n))
+	}
+	return a
+}
+
+// analyzeIteratorCreator wraps an IteratorCreator and records statistics
+// for every iterator that is created.
+type analyzeIteratorCreator struct {
+	IteratorCreator
+	nodes []analyzeNode
+}
+
+type analyzeNode struct {
+	node  *ExplainNode
+	stats *IteratorStats
+}
+
+// CreateIterator creates an iterator from the underlying creator and wraps it
+// so that its runtime statistics are recorded.
+func (ic *analyzeIteratorCreator) CreateIterator(opt IteratorOptions) (Iterator, error) {
+	stats := &IteratorStats{}
+	opt.Stats = stats
+
+	node, err := explainCreateIterator(ic.IteratorCreator, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	itr, err := ic.IteratorCreator.CreateIterator(opt)
+	if err != nil {
+		return nil, err
+	}
+	ic.nodes = append(ic.nodes, analyzeNode{node: node, stats: stats})
+	return newAnalyzeIterator(itr, stats), nil
+}
+
+// newAnalyzeIterator returns an iterator that records its runtime statistics in stats.
+func newAnalyzeIterator(input Iterator, stats *IteratorStats) Iterator {
+	switch input := input.(type) {
+	case FloatIterator:
+		return &floatAnalyzeIterator{input: input, stats: stats}
+	case IntegerIterator:
+		return &integerAnalyzeIterator{input: input, stats: stats}
+	case StringIterator:
+		return &stringAnalyzeIterator{input: input, stats: stats}
+	case BooleanIterator:
+		return &booleanAnalyzeIterator{input: input, stats: stats}
+	default:
+		return input
+	}
+}
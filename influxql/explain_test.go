@@ -0,0 +1,149 @@
+package influxql_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Ensure a SELECT statement can be explained without creating iterators.
+func TestExplainSelect(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		plan []string
+	}{
+		{
+			s: `SELECT max(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s) fill(none)`,
+			plan: []string{
+				`EXPRESSION: max(value)`,
+				`    create_iterator`,
+				`      EXPRESSION: max(value)`,
+				`      SOURCES: cpu`,
+				`      CONDITION: time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z'`,
+			},
+		},
+		{
+			s: `SELECT mean(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s) fill(0) LIMIT 2`,
+			plan: []string{
+				`EXPRESSION: mean(value)`,
+				`    limit`,
+				`      LIMIT: 2`,
+				`      OFFSET: 0`,
+				`        fill(0)`,
+				`          INTERVAL: 10s`,
+				`            mean()`,
+				`                create_iterator`,
+				`                  EXPRESSION: value`,
+				`                  SOURCES: cpu`,
+				`                  CONDITION: time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z'`,
+			},
+		},
+		{
+			s: `SELECT value + 2 FROM cpu`,
+			plan: []string{
+				`EXPRESSION: value + 2.000`,
+				`    binary_expr(+)`,
+				`        aux(value)`,
+				`            create_iterator`,
+				`              AUXILIARY FIELDS: value`,
+				`              SOURCES: cpu`,
+				`        literal(2.000)`,
+			},
+		},
+		{
+			s: `SELECT max(value) FROM cpu GROUP BY host ORDER BY max DESC LIMIT 10`,
+			plan: []string{
+				`EXPRESSION: max(value)`,
+				`    sort`,
+				`      ORDER BY: max DESC`,
+				`      LIMIT: 10`,
+				`      OFFSET: 0`,
+				`        create_iterator`,
+				`          EXPRESSION: max(value)`,
+				`          SOURCES: cpu`,
+				`          DIMENSIONS: host`,
+			},
+		},
+	} {
+		var ic IteratorCreator
+		ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+			t.Fatal("unexpected call to CreateIterator")
+			return nil, nil
+		}
+
+		nodes, err := influxql.ExplainSelect(MustParseSelectStatement(tt.s), &ic, nil)
+		if err != nil {
+			t.Errorf("%s: %s", tt.s, err)
+			continue
+		}
+
+		var plan []string
+		for _, n := range nodes {
+			plan = append(plan, n.Lines()...)
+		}
+		if !reflect.DeepEqual(plan, tt.plan) {
+			t.Errorf("%s: unexpected plan:\n\n%s\n\nexpected:\n\n%s", tt.s, strings.Join(plan, "\n"), strings.Join(tt.plan, "\n"))
+		}
+	}
+}
+
+// Ensure the cost estimate is included when the creator supports it.
+func TestExplainSelect_IteratorCost(t *testing.T) {
+	ic := &CostIteratorCreator{
+		IteratorCostFn: func(opt influxql.IteratorOptions) (influxql.IteratorCost, error) {
+			return influxql.IteratorCost{NumShards: 2, NumSeries: 10, BlocksRead: 4, BlockSize: 1024}, nil
+		},
+	}
+
+	nodes, err := influxql.ExplainSelect(MustParseSelectStatement(`SELECT count(value) FROM cpu`), ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := strings.Join(nodes[0].Lines(), "\n")
+	for _, s := range []string{"NUMBER OF SHARDS: 2", "NUMBER OF SERIES: 10", "NUMBER OF BLOCKS: 4", "SIZE OF BLOCKS: 1024"} {
+		if !strings.Contains(plan, s) {
+			t.Fatalf("expected %q in plan:\n%s", s, plan)
+		}
+	}
+}
+
+// Ensure an analyzed statement reports the statistics of each iterator.
+func TestAnalyzeSelect(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if opt.Stats == nil {
+			t.Fatal("expected stats to be set")
+		}
+		opt.Stats.AddSeries(1)
+		return influxql.NewCallIterator(&FloatIterator{Points: []influxql.FloatPoint{
+			{Name: "cpu", Time: 0 * Second, Value: 1},
+			{Name: "cpu", Time: 5 * Second, Value: 2},
+			{Name: "cpu", Time: 9 * Second, Value: 3},
+		}}, opt), nil
+	}
+
+	nodes, err := influxql.AnalyzeSelect(MustParseSelectStatement(`SELECT sum(value) FROM cpu`), &ic, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := strings.Join(nodes[0].Lines(), "\n")
+	for _, s := range []string{"ROWS RETURNED: 1", "POINTS: 1", "SERIES: 1"} {
+		if !strings.Contains(plan, s) {
+			t.Fatalf("expected %q in plan:\n%s", s, plan)
+		}
+	}
+}
+
+// CostIteratorCreator is a mock IteratorCreator that estimates iterator costs.
+type CostIteratorCreator struct {
+	IteratorCreator
+	IteratorCostFn func(opt influxql.IteratorOptions) (influxql.IteratorCost, error)
+}
+
+func (ic *CostIteratorCreator) IteratorCost(opt influxql.IteratorOptions) (influxql.IteratorCost, error) {
+	return ic.IteratorCostFn(opt)
+}
@@ -0,0 +1,273 @@
+package influxql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringFunctions maps the names of scalar string functions to the type
+// of the value they return. Scalar functions are applied to every point
+// instead of aggregating points.
+var stringFunctions = map[string]DataType{
+	"strlen":        Integer,
+	"lower":         String,
+	"upper":         String,
+	"substr":        String,
+	"concat":        String,
+	"contains":      Boolean,
+	"regex_extract": String,
+}
+
+// IsStringFunction returns true if name is a scalar string function.
+func IsStringFunction(name string) bool {
+	_, ok := stringFunctions[name]
+	return ok
+}
+
+// validateStringFunction ensures a string function has valid arguments.
+func validateStringFunction(call *Call) error {
+	// Every function except concat reads a single expression as its first argument.
+	if call.Name == "concat" {
+		if len(call.Args) < 2 {
+			return fmt.Errorf("invalid number of arguments for concat, expected at least 2, got %d", len(call.Args))
+		}
+		n := 0
+		for _, arg := range call.Args {
+			if _, ok := arg.(*StringLiteral); ok {
+				continue
+			} else if !isStringFunctionInput(arg) {
+				return fmt.Errorf("expected field or string argument in concat(), found %s", arg)
+			}
+			n++
+		}
+		if n == 0 {
+			return fmt.Errorf("expected field argument in concat()")
+		}
+		return nil
+	}
+
+	var min, max int
+	switch call.Name {
+	case "strlen", "lower", "upper":
+		min, max = 1, 1
+	case "substr":
+		min, max = 2, 3
+	case "contains":
+		min, max = 2, 2
+	case "regex_extract":
+		min, max = 3, 3
+	}
+	if got := len(call.Args); got < min || got > max {
+		if min == max {
+			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", call.Name, min, got)
+		}
+		return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", call.Name, min, max, got)
+	}
+
+	if !isStringFunctionInput(call.Args[0]) {
+		return fmt.Errorf("expected field argument in %s()", call.Name)
+	}
+
+	switch call.Name {
+	case "substr":
+		for _, arg := range call.Args[1:] {
+			if lit, ok := arg.(*NumberLiteral); !ok || lit.Val < 0 || lit.Val != float64(int(lit.Val)) {
+				return fmt.Errorf("expected non-negative integer argument in substr(), found %s", arg)
+			}
+		}
+	case "contains":
+		if _, ok := call.Args[1].(*StringLiteral); !ok {
+			return fmt.Errorf("expected string argument in contains(), found %s", call.Args[1])
+		}
+	case "regex_extract":
+		re, ok := call.Args[1].(*RegexLiteral)
+		if !ok {
+			return fmt.Errorf("expected regex argument in regex_extract(), found %s", call.Args[1])
+		}
+		lit, ok := call.Args[2].(*NumberLiteral)
+		if !ok || lit.Val < 0 || lit.Val != float64(int(lit.Val)) {
+			return fmt.Errorf("expected non-negative integer argument in regex_extract(), found %s", call.Args[2])
+		} else if int(lit.Val) > re.Val.NumSubexp() {
+			return fmt.Errorf("regex_extract() group %d does not exist in %s", int(lit.Val), re)
+		}
+	}
+	return nil
+}
+
+// isStringFunctionInput returns true if expr can be read by a string function.
+// Inputs are fields or the result of another string function.
+func isStringFunctionInput(expr Expr) bool {
+	switch expr := expr.(type) {
+	case *VarRef:
+		return true
+	case *Call:
+		return IsStringFunction(expr.Name)
+	case *ParenExpr:
+		return isStringFunctionInput(expr.Expr)
+	default:
+		return false
+	}
+}
+
+// stringFunctionArg returns the value of a literal argument to a string function.
+func stringFunctionArg(expr Expr, m map[string]interface{}) interface{} {
+	if re, ok := expr.(*RegexLiteral); ok {
+		return re.Val
+	}
+	return Eval(expr, m)
+}
+
+// evalStringFunction evaluates a string function against the values in m.
+func evalStringFunction(call *Call, m map[string]interface{}) interface{} {
+	args := make([]interface{}, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = stringFunctionArg(arg, m)
+	}
+	return callStringFunction(call.Name, args)
+}
+
+// callStringFunction applies a string function to its argument values.
+// Returns nil if an input is not a string or the function has no result.
+func callStringFunction(name string, args []interface{}) interface{} {
+	if name == "concat" {
+		var buf []string
+		for _, arg := range args {
+			s, ok := arg.(string)
+			if !ok {
+				return nil
+			}
+			buf = append(buf, s)
+		}
+		return strings.Join(buf, "")
+	}
+
+	s, ok := args[0].(string)
+	if !ok {
+		return nil
+	}
+
+	switch name {
+	case "strlen":
+		return int64(utf8.RuneCountInString(s))
+	case "lower":
+		return strings.ToLower(s)
+	case "upper":
+		return strings.ToUpper(s)
+	case "substr":
+		r := []rune(s)
+		start, _ := args[1].(float64)
+		if int(start) >= len(r) {
+			return ""
+		}
+		r = r[int(start):]
+		if len(args) > 2 {
+			if n, _ := args[2].(float64); int(n) < len(r) {
+				r = r[:int(n)]
+			}
+		}
+		return string(r)
+	case "contains":
+		sub, _ := args[1].(string)
+		return strings.Contains(s, sub)
+	case "regex_extract":
+		re, _ := args[1].(*regexp.Regexp)
+		group, _ := args[2].(float64)
+		if re == nil {
+			return nil
+		}
+		m := re.FindStringSubmatch(s)
+		if m == nil {
+			return nil
+		}
+		return m[int(group)]
+	}
+	return nil
+}
+
+// buildStringFunctionIterator creates an iterator that applies a string
+// function to the points of its field arguments. Field arguments are read
+// in lockstep and points without a result are returned as nil points.
+//
+// If the arguments reference more than one field, the fields are read
+// through a single auxiliary iterator so the values passed to the function
+// come from the same point.
+func buildStringFunctionIterator(call *Call, ic IteratorCreator, opt IteratorOptions) (Iterator, error) {
+	var aitr AuxIterator
+	if refs := ExprNames(call); len(refs) > 1 {
+		if _, ok := ic.(AuxIterator); !ok {
+			var err error
+			if aitr, err = newFieldsAuxIterator(refs, ic, opt); err != nil {
+				return nil, err
+			}
+			ic = aitr
+		}
+	}
+
+	args := make([]interface{}, len(call.Args))
+	var inputs []Iterator
+	var indexes []int
+	for i, arg := range call.Args {
+		if _, ok := arg.(Literal); ok {
+			args[i] = stringFunctionArg(arg, nil)
+			continue
+		}
+
+		input, err := buildExprIterator(arg, ic, opt)
+		if err != nil {
+			Iterators(inputs).Close()
+			if aitr != nil {
+				aitr.Close()
+			}
+			return nil, err
+		}
+		inputs = append(inputs, input)
+		indexes = append(indexes, i)
+	}
+
+	if aitr != nil {
+		aitr.Start()
+
+		// Drain primary aux iterator since there is no reader for it.
+		go drainIterator(aitr)
+	}
+
+	fn := func(values []interface{}) interface{} {
+		a := make([]interface{}, len(args))
+		copy(a, args)
+		for i, index := range indexes {
+			a[index] = values[i]
+		}
+		return callStringFunction(call.Name, a)
+	}
+
+	switch stringFunctions[call.Name] {
+	case Integer:
+		return &integerFuncIterator{inputs: inputs, fn: fn}, nil
+	case Boolean:
+		return &booleanFuncIterator{inputs: inputs, fn: fn}, nil
+	default:
+		return &stringFuncIterator{inputs: inputs, fn: fn}, nil
+	}
+}
+
+// newFieldsAuxIterator returns an auxiliary iterator that reads refs from
+// the raw points of ic.
+func newFieldsAuxIterator(refs []VarRef, ic IteratorCreator, opt IteratorOptions) (AuxIterator, error) {
+	opt.Expr = nil
+	opt.Aux = refs
+	opt.Limit, opt.Offset = 0, 0
+
+	input, err := ic.CreateIterator(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesKeys, err := ic.SeriesKeys(opt)
+	if err != nil {
+		input.Close()
+		return nil, err
+	}
+	return NewAuxIterator(input, seriesKeys, opt), nil
+}
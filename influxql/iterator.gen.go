@@ -10,6 +10,8 @@ import (
 	"log"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 )
@@ -355,6 +357,26 @@ type floatSortedMergeHeapItem struct {
 	ascending bool
 }
 
+// floatAnalyzeIterator represents an iterator that records runtime statistics.
+type floatAnalyzeIterator struct {
+	input FloatIterator
+	stats *IteratorStats
+}
+
+// Close closes the underlying iterator.
+func (itr *floatAnalyzeIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator and records its statistics.
+func (itr *floatAnalyzeIterator) Next() *FloatPoint {
+	start := time.Now()
+	p := itr.input.Next()
+	itr.stats.Elapsed += time.Since(start)
+	if p != nil {
+		atomic.AddInt64(&itr.stats.PointN, 1)
+	}
+	return p
+}
+
 // floatLimitIterator represents an iterator that limits points per group.
 type floatLimitIterator struct {
 	input FloatIterator
@@ -443,7 +465,7 @@ func newFloatFillIterator(input FloatIterator, expr Expr, opt IteratorOptions) *
 		startTime, _ = opt.Window(opt.StartTime)
 		_, endTime = opt.Window(opt.EndTime)
 	} else {
-		_, startTime = opt.Window(opt.EndTime)
+		startTime, _ = opt.Window(opt.EndTime)
 		endTime, _ = opt.Window(opt.StartTime)
 	}
 
@@ -507,7 +529,7 @@ func (itr *floatFillIterator) Next() *FloatPoint {
 	}
 
 	// Check if the point is our next expected point.
-	if p == nil || p.Time > itr.window.time {
+	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -562,10 +584,12 @@ func newFloatAuxIterator(input FloatIterator, seriesKeys SeriesList, opt Iterato
 	}
 }
 
-func (itr *floatAuxIterator) Start()                        { go itr.stream() }
-func (itr *floatAuxIterator) Close() error                  { return itr.input.Close() }
-func (itr *floatAuxIterator) Next() *FloatPoint             { return <-itr.output }
-func (itr *floatAuxIterator) Iterator(name string) Iterator { return itr.fields.iterator(name) }
+func (itr *floatAuxIterator) Start()            { go itr.stream() }
+func (itr *floatAuxIterator) Close() error      { return itr.input.Close() }
+func (itr *floatAuxIterator) Next() *FloatPoint { return <-itr.output }
+func (itr *floatAuxIterator) Iterator(name string, typ DataType) Iterator {
+	return itr.fields.iterator(name, typ)
+}
 
 func (itr *floatAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, error) {
 	expr := opt.Expr
@@ -575,13 +599,13 @@ func (itr *floatAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, erro
 
 	switch expr := expr.(type) {
 	case *VarRef:
-		return itr.Iterator(expr.Val), nil
+		return itr.Iterator(expr.Val, expr.Type), nil
 	default:
 		panic(fmt.Sprintf("invalid expression type for an aux iterator: %T", expr))
 	}
 }
 
-func (itr *floatAuxIterator) FieldDimensions(sources Sources) (fields, dimensions map[string]struct{}, err error) {
+func (itr *floatAuxIterator) FieldDimensions(sources Sources) (fields map[string]DataType, dimensions map[string]struct{}, err error) {
 	return nil, nil, errors.New("not implemented")
 }
 
@@ -619,6 +643,67 @@ func (itr *floatChanIterator) Close() error {
 
 func (itr *floatChanIterator) Next() *FloatPoint { return <-itr.c }
 
+// floatFuncIterator applies a scalar function to the aligned points of its inputs.
+// A nil point is returned if the function has no float result for a point.
+type floatFuncIterator struct {
+	inputs []Iterator
+	fn     func(values []interface{}) interface{}
+}
+
+// Close closes the underlying iterators.
+func (itr *floatFuncIterator) Close() error { return Iterators(itr.inputs).Close() }
+
+// Next returns the next point with a function result.
+func (itr *floatFuncIterator) Next() *FloatPoint {
+	var p Point
+	values := make([]interface{}, len(itr.inputs))
+	for i, input := range itr.inputs {
+		curr := readPoint(input)
+		if curr == nil {
+			return nil
+		} else if p == nil {
+			p = curr
+		}
+		values[i] = curr.value()
+	}
+
+	v, ok := itr.fn(values).(float64)
+	if !ok {
+		return &FloatPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+	}
+	return &FloatPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: v, Aux: p.aux()}
+}
+
+// floatCastIterator converts the points of an iterator of another type to float points.
+// Points with a value that cannot be cast to a float are dropped.
+type floatCastIterator struct {
+	input Iterator
+}
+
+// Close closes the underlying iterator.
+func (itr *floatCastIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator that can be cast.
+func (itr *floatCastIterator) Next() *FloatPoint {
+	for {
+		p := readPoint(itr.input)
+		if p == nil {
+			return nil
+		}
+
+		v := p.value()
+		if v == nil {
+			return &FloatPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+		}
+
+		value, ok := castValue(v, Float).(float64)
+		if !ok {
+			continue
+		}
+		return &FloatPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: value, Aux: p.aux()}
+	}
+}
+
 // floatReduceIterator executes a reducer for every interval and buffers the result.
 type floatReduceIterator struct {
 	input  *bufFloatIterator
@@ -660,6 +745,11 @@ func (itr *floatReduceIterator) reduce() []*FloatPoint {
 	// Create points by tags.
 	m := make(map[string]*FloatPoint)
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		curr := itr.input.NextInWindow(startTime, endTime)
 		if curr == nil {
@@ -755,6 +845,11 @@ func (itr *floatReduceSliceIterator) reduce() []FloatPoint {
 		points []FloatPoint
 	})
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		p := itr.input.NextInWindow(startTime, endTime)
 		if p == nil {
@@ -858,6 +953,181 @@ func (itr *floatBoolTransformIterator) Next() *BooleanPoint {
 // new point if possible.
 type floatBoolTransformFunc func(p *FloatPoint) *BooleanPoint
 
+// floatJoinIterator aligns the points of multiple inputs by tag set and time.
+// Inputs must be sorted by tag set and then time. If an input has no point
+// for a tag set and time then a point is created based on the fill option.
+type floatJoinIterator struct {
+	inputs []*bufFloatIterator
+	opt    IteratorOptions
+	name   string
+	closed bool
+
+	// Last point read from each input. Used by fill(previous).
+	prev []*FloatPoint
+
+	// Aligned points that have not been read by each output.
+	bufs [][]*FloatPoint
+}
+
+// newFloatJoinIterator returns a new instance of floatJoinIterator.
+// Every aligned point is renamed to name.
+func newFloatJoinIterator(inputs []FloatIterator, name string, opt IteratorOptions) *floatJoinIterator {
+	itr := &floatJoinIterator{
+		inputs: make([]*bufFloatIterator, len(inputs)),
+		opt:    opt,
+		name:   name,
+		prev:   make([]*FloatPoint, len(inputs)),
+		bufs:   make([][]*FloatPoint, len(inputs)),
+	}
+	for i, input := range inputs {
+		itr.inputs[i] = newBufFloatIterator(input)
+	}
+	return itr
+}
+
+// Close closes all inputs.
+func (itr *floatJoinIterator) Close() error {
+	if itr.closed {
+		return nil
+	}
+	itr.closed = true
+
+	for _, input := range itr.inputs {
+		input.Close()
+	}
+	return nil
+}
+
+// Outputs returns an iterator for each input that reads its aligned points.
+// The outputs must be read in lockstep.
+func (itr *floatJoinIterator) Outputs() []FloatIterator {
+	a := make([]FloatIterator, len(itr.inputs))
+	for i := range a {
+		a[i] = &floatJoinOutputIterator{join: itr, i: i}
+	}
+	return a
+}
+
+// next reads the next aligned set of points into the output buffers.
+// Returns false once all inputs are exhausted.
+func (itr *floatJoinIterator) next() bool {
+	for {
+		// Find the next tag set and time across all inputs.
+		var key *FloatPoint
+		for _, input := range itr.inputs {
+			if p := input.peek(); p != nil && (key == nil || itr.less(p, key)) {
+				key = p
+			}
+		}
+		if key == nil {
+			return false
+		}
+		tags, t := key.Tags, key.Time
+
+		// Read the point from each input that matches the key and fill the rest.
+		a := make([]*FloatPoint, len(itr.inputs))
+		filled := false
+		for i, input := range itr.inputs {
+			if p := input.peek(); p != nil && p.Time == t && p.Tags.Equals(&tags) {
+				a[i] = input.Next()
+				itr.prev[i] = &FloatPoint{Tags: a[i].Tags, Value: a[i].Value, Nil: a[i].Nil}
+				continue
+			}
+			a[i] = itr.fill(i, tags, t)
+			filled = true
+		}
+
+		// Only emit points that exist in every input when fill is disabled.
+		if filled && itr.opt.Fill == NoFill {
+			continue
+		}
+
+		for i, p := range a {
+			p.Name = itr.name
+			itr.bufs[i] = append(itr.bufs[i], p)
+		}
+		return true
+	}
+}
+
+// less returns true if p sorts before key based on tag set and then time.
+func (itr *floatJoinIterator) less(p, key *FloatPoint) bool {
+	if !p.Tags.Equals(&key.Tags) {
+		if itr.opt.Ascending {
+			return p.Tags.ID() < key.Tags.ID()
+		}
+		return p.Tags.ID() > key.Tags.ID()
+	}
+
+	if itr.opt.Ascending {
+		return p.Time < key.Time
+	}
+	return p.Time > key.Time
+}
+
+// fill returns a point for an input that has no point at the given tag set and time.
+func (itr *floatJoinIterator) fill(i int, tags Tags, t int64) *FloatPoint {
+	p := &FloatPoint{Tags: tags, Time: t}
+	switch itr.opt.Fill {
+	case NumberFill:
+		p.Value = castToFloat(itr.opt.FillValue)
+	case PreviousFill:
+		if prev := itr.prev[i]; prev != nil && prev.Tags.Equals(&tags) {
+			p.Value, p.Nil = prev.Value, prev.Nil
+		} else {
+			p.Nil = true
+		}
+	default:
+		p.Nil = true
+	}
+	return p
+}
+
+// floatJoinOutputIterator reads the aligned points of a single join input.
+type floatJoinOutputIterator struct {
+	join *floatJoinIterator
+	i    int
+}
+
+// Close closes the join iterator.
+func (itr *floatJoinOutputIterator) Close() error { return itr.join.Close() }
+
+// Next returns the next aligned point for the input.
+func (itr *floatJoinOutputIterator) Next() *FloatPoint {
+	if len(itr.join.bufs[itr.i]) == 0 && !itr.join.next() {
+		return nil
+	}
+
+	p := itr.join.bufs[itr.i][0]
+	itr.join.bufs[itr.i] = itr.join.bufs[itr.i][1:]
+	return p
+}
+
+// floatRowSortIterator returns the points of a single input of a rowSorter.
+type floatRowSortIterator struct {
+	sort *rowSorter
+	i    int
+	n    int
+}
+
+// Close closes the sorter.
+func (itr *floatRowSortIterator) Close() error { return itr.sort.Close() }
+
+// Next returns the point of the input for the next sorted row. Returns a nil
+// point if the input has no point for the row.
+func (itr *floatRowSortIterator) Next() *FloatPoint {
+	row := itr.sort.row(itr.n)
+	if row == nil {
+		return nil
+	}
+	itr.n++
+
+	if p, ok := row.points[itr.i].(*FloatPoint); ok {
+		return p
+	}
+	return &FloatPoint{Name: row.name, Tags: row.tags, Time: row.time, Nil: true}
+}
+
 // floatDedupeIterator only outputs unique points.
 // This differs from the DistinctIterator in that it compares all aux fields too.
 // This iterator is relatively inefficient and should only be used on small
@@ -1243,6 +1513,26 @@ type integerSortedMergeHeapItem struct {
 	ascending bool
 }
 
+// integerAnalyzeIterator represents an iterator that records runtime statistics.
+type integerAnalyzeIterator struct {
+	input IntegerIterator
+	stats *IteratorStats
+}
+
+// Close closes the underlying iterator.
+func (itr *integerAnalyzeIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator and records its statistics.
+func (itr *integerAnalyzeIterator) Next() *IntegerPoint {
+	start := time.Now()
+	p := itr.input.Next()
+	itr.stats.Elapsed += time.Since(start)
+	if p != nil {
+		atomic.AddInt64(&itr.stats.PointN, 1)
+	}
+	return p
+}
+
 // integerLimitIterator represents an iterator that limits points per group.
 type integerLimitIterator struct {
 	input IntegerIterator
@@ -1331,7 +1621,7 @@ func newIntegerFillIterator(input IntegerIterator, expr Expr, opt IteratorOption
 		startTime, _ = opt.Window(opt.StartTime)
 		_, endTime = opt.Window(opt.EndTime)
 	} else {
-		_, startTime = opt.Window(opt.EndTime)
+		startTime, _ = opt.Window(opt.EndTime)
 		endTime, _ = opt.Window(opt.StartTime)
 	}
 
@@ -1395,7 +1685,7 @@ func (itr *integerFillIterator) Next() *IntegerPoint {
 	}
 
 	// Check if the point is our next expected point.
-	if p == nil || p.Time > itr.window.time {
+	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -1450,10 +1740,12 @@ func newIntegerAuxIterator(input IntegerIterator, seriesKeys SeriesList, opt Ite
 	}
 }
 
-func (itr *integerAuxIterator) Start()                        { go itr.stream() }
-func (itr *integerAuxIterator) Close() error                  { return itr.input.Close() }
-func (itr *integerAuxIterator) Next() *IntegerPoint           { return <-itr.output }
-func (itr *integerAuxIterator) Iterator(name string) Iterator { return itr.fields.iterator(name) }
+func (itr *integerAuxIterator) Start()              { go itr.stream() }
+func (itr *integerAuxIterator) Close() error        { return itr.input.Close() }
+func (itr *integerAuxIterator) Next() *IntegerPoint { return <-itr.output }
+func (itr *integerAuxIterator) Iterator(name string, typ DataType) Iterator {
+	return itr.fields.iterator(name, typ)
+}
 
 func (itr *integerAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, error) {
 	expr := opt.Expr
@@ -1463,13 +1755,13 @@ func (itr *integerAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, er
 
 	switch expr := expr.(type) {
 	case *VarRef:
-		return itr.Iterator(expr.Val), nil
+		return itr.Iterator(expr.Val, expr.Type), nil
 	default:
 		panic(fmt.Sprintf("invalid expression type for an aux iterator: %T", expr))
 	}
 }
 
-func (itr *integerAuxIterator) FieldDimensions(sources Sources) (fields, dimensions map[string]struct{}, err error) {
+func (itr *integerAuxIterator) FieldDimensions(sources Sources) (fields map[string]DataType, dimensions map[string]struct{}, err error) {
 	return nil, nil, errors.New("not implemented")
 }
 
@@ -1507,6 +1799,67 @@ func (itr *integerChanIterator) Close() error {
 
 func (itr *integerChanIterator) Next() *IntegerPoint { return <-itr.c }
 
+// integerFuncIterator applies a scalar function to the aligned points of its inputs.
+// A nil point is returned if the function has no integer result for a point.
+type integerFuncIterator struct {
+	inputs []Iterator
+	fn     func(values []interface{}) interface{}
+}
+
+// Close closes the underlying iterators.
+func (itr *integerFuncIterator) Close() error { return Iterators(itr.inputs).Close() }
+
+// Next returns the next point with a function result.
+func (itr *integerFuncIterator) Next() *IntegerPoint {
+	var p Point
+	values := make([]interface{}, len(itr.inputs))
+	for i, input := range itr.inputs {
+		curr := readPoint(input)
+		if curr == nil {
+			return nil
+		} else if p == nil {
+			p = curr
+		}
+		values[i] = curr.value()
+	}
+
+	v, ok := itr.fn(values).(int64)
+	if !ok {
+		return &IntegerPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+	}
+	return &IntegerPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: v, Aux: p.aux()}
+}
+
+// integerCastIterator converts the points of an iterator of another type to integer points.
+// Points with a value that cannot be cast to a integer are dropped.
+type integerCastIterator struct {
+	input Iterator
+}
+
+// Close closes the underlying iterator.
+func (itr *integerCastIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator that can be cast.
+func (itr *integerCastIterator) Next() *IntegerPoint {
+	for {
+		p := readPoint(itr.input)
+		if p == nil {
+			return nil
+		}
+
+		v := p.value()
+		if v == nil {
+			return &IntegerPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+		}
+
+		value, ok := castValue(v, Integer).(int64)
+		if !ok {
+			continue
+		}
+		return &IntegerPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: value, Aux: p.aux()}
+	}
+}
+
 // integerReduceIterator executes a reducer for every interval and buffers the result.
 type integerReduceIterator struct {
 	input  *bufIntegerIterator
@@ -1548,6 +1901,11 @@ func (itr *integerReduceIterator) reduce() []*IntegerPoint {
 	// Create points by tags.
 	m := make(map[string]*IntegerPoint)
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		curr := itr.input.NextInWindow(startTime, endTime)
 		if curr == nil {
@@ -1643,6 +2001,11 @@ func (itr *integerReduceSliceIterator) reduce() []IntegerPoint {
 		points []IntegerPoint
 	})
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		p := itr.input.NextInWindow(startTime, endTime)
 		if p == nil {
@@ -1746,6 +2109,181 @@ func (itr *integerBoolTransformIterator) Next() *BooleanPoint {
 // new point if possible.
 type integerBoolTransformFunc func(p *IntegerPoint) *BooleanPoint
 
+// integerJoinIterator aligns the points of multiple inputs by tag set and time.
+// Inputs must be sorted by tag set and then time. If an input has no point
+// for a tag set and time then a point is created based on the fill option.
+type integerJoinIterator struct {
+	inputs []*bufIntegerIterator
+	opt    IteratorOptions
+	name   string
+	closed bool
+
+	// Last point read from each input. Used by fill(previous).
+	prev []*IntegerPoint
+
+	// Aligned points that have not been read by each output.
+	bufs [][]*IntegerPoint
+}
+
+// newIntegerJoinIterator returns a new instance of integerJoinIterator.
+// Every aligned point is renamed to name.
+func newIntegerJoinIterator(inputs []IntegerIterator, name string, opt IteratorOptions) *integerJoinIterator {
+	itr := &integerJoinIterator{
+		inputs: make([]*bufIntegerIterator, len(inputs)),
+		opt:    opt,
+		name:   name,
+		prev:   make([]*IntegerPoint, len(inputs)),
+		bufs:   make([][]*IntegerPoint, len(inputs)),
+	}
+	for i, input := range inputs {
+		itr.inputs[i] = newBufIntegerIterator(input)
+	}
+	return itr
+}
+
+// Close closes all inputs.
+func (itr *integerJoinIterator) Close() error {
+	if itr.closed {
+		return nil
+	}
+	itr.closed = true
+
+	for _, input := range itr.inputs {
+		input.Close()
+	}
+	return nil
+}
+
+// Outputs returns an iterator for each input that reads its aligned points.
+// The outputs must be read in lockstep.
+func (itr *integerJoinIterator) Outputs() []IntegerIterator {
+	a := make([]IntegerIterator, len(itr.inputs))
+	for i := range a {
+		a[i] = &integerJoinOutputIterator{join: itr, i: i}
+	}
+	return a
+}
+
+// next reads the next aligned set of points into the output buffers.
+// Returns false once all inputs are exhausted.
+func (itr *integerJoinIterator) next() bool {
+	for {
+		// Find the next tag set and time across all inputs.
+		var key *IntegerPoint
+		for _, input := range itr.inputs {
+			if p := input.peek(); p != nil && (key == nil || itr.less(p, key)) {
+				key = p
+			}
+		}
+		if key == nil {
+			return false
+		}
+		tags, t := key.Tags, key.Time
+
+		// Read the point from each input that matches the key and fill the rest.
+		a := make([]*IntegerPoint, len(itr.inputs))
+		filled := false
+		for i, input := range itr.inputs {
+			if p := input.peek(); p != nil && p.Time == t && p.Tags.Equals(&tags) {
+				a[i] = input.Next()
+				itr.prev[i] = &IntegerPoint{Tags: a[i].Tags, Value: a[i].Value, Nil: a[i].Nil}
+				continue
+			}
+			a[i] = itr.fill(i, tags, t)
+			filled = true
+		}
+
+		// Only emit points that exist in every input when fill is disabled.
+		if filled && itr.opt.Fill == NoFill {
+			continue
+		}
+
+		for i, p := range a {
+			p.Name = itr.name
+			itr.bufs[i] = append(itr.bufs[i], p)
+		}
+		return true
+	}
+}
+
+// less returns true if p sorts before key based on tag set and then time.
+func (itr *integerJoinIterator) less(p, key *IntegerPoint) bool {
+	if !p.Tags.Equals(&key.Tags) {
+		if itr.opt.Ascending {
+			return p.Tags.ID() < key.Tags.ID()
+		}
+		return p.Tags.ID() > key.Tags.ID()
+	}
+
+	if itr.opt.Ascending {
+		return p.Time < key.Time
+	}
+	return p.Time > key.Time
+}
+
+// fill returns a point for an input that has no point at the given tag set and time.
+func (itr *integerJoinIterator) fill(i int, tags Tags, t int64) *IntegerPoint {
+	p := &IntegerPoint{Tags: tags, Time: t}
+	switch itr.opt.Fill {
+	case NumberFill:
+		p.Value = castToInteger(itr.opt.FillValue)
+	case PreviousFill:
+		if prev := itr.prev[i]; prev != nil && prev.Tags.Equals(&tags) {
+			p.Value, p.Nil = prev.Value, prev.Nil
+		} else {
+			p.Nil = true
+		}
+	default:
+		p.Nil = true
+	}
+	return p
+}
+
+// integerJoinOutputIterator reads the aligned points of a single join input.
+type integerJoinOutputIterator struct {
+	join *integerJoinIterator
+	i    int
+}
+
+// Close closes the join iterator.
+func (itr *integerJoinOutputIterator) Close() error { return itr.join.Close() }
+
+// Next returns the next aligned point for the input.
+func (itr *integerJoinOutputIterator) Next() *IntegerPoint {
+	if len(itr.join.bufs[itr.i]) == 0 && !itr.join.next() {
+		return nil
+	}
+
+	p := itr.join.bufs[itr.i][0]
+	itr.join.bufs[itr.i] = itr.join.bufs[itr.i][1:]
+	return p
+}
+
+// integerRowSortIterator returns the points of a single input of a rowSorter.
+type integerRowSortIterator struct {
+	sort *rowSorter
+	i    int
+	n    int
+}
+
+// Close closes the sorter.
+func (itr *integerRowSortIterator) Close() error { return itr.sort.Close() }
+
+// Next returns the point of the input for the next sorted row. Returns a nil
+// point if the input has no point for the row.
+func (itr *integerRowSortIterator) Next() *IntegerPoint {
+	row := itr.sort.row(itr.n)
+	if row == nil {
+		return nil
+	}
+	itr.n++
+
+	if p, ok := row.points[itr.i].(*IntegerPoint); ok {
+		return p
+	}
+	return &IntegerPoint{Name: row.name, Tags: row.tags, Time: row.time, Nil: true}
+}
+
 // integerDedupeIterator only outputs unique points.
 // This differs from the DistinctIterator in that it compares all aux fields too.
 // This iterator is relatively inefficient and should only be used on small
@@ -2131,6 +2669,26 @@ type stringSortedMergeHeapItem struct {
 	ascending bool
 }
 
+// stringAnalyzeIterator represents an iterator that records runtime statistics.
+type stringAnalyzeIterator struct {
+	input StringIterator
+	stats *IteratorStats
+}
+
+// Close closes the underlying iterator.
+func (itr *stringAnalyzeIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator and records its statistics.
+func (itr *stringAnalyzeIterator) Next() *StringPoint {
+	start := time.Now()
+	p := itr.input.Next()
+	itr.stats.Elapsed += time.Since(start)
+	if p != nil {
+		atomic.AddInt64(&itr.stats.PointN, 1)
+	}
+	return p
+}
+
 // stringLimitIterator represents an iterator that limits points per group.
 type stringLimitIterator struct {
 	input StringIterator
@@ -2219,7 +2777,7 @@ func newStringFillIterator(input StringIterator, expr Expr, opt IteratorOptions)
 		startTime, _ = opt.Window(opt.StartTime)
 		_, endTime = opt.Window(opt.EndTime)
 	} else {
-		_, startTime = opt.Window(opt.EndTime)
+		startTime, _ = opt.Window(opt.EndTime)
 		endTime, _ = opt.Window(opt.StartTime)
 	}
 
@@ -2283,7 +2841,7 @@ func (itr *stringFillIterator) Next() *StringPoint {
 	}
 
 	// Check if the point is our next expected point.
-	if p == nil || p.Time > itr.window.time {
+	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -2338,10 +2896,12 @@ func newStringAuxIterator(input StringIterator, seriesKeys SeriesList, opt Itera
 	}
 }
 
-func (itr *stringAuxIterator) Start()                        { go itr.stream() }
-func (itr *stringAuxIterator) Close() error                  { return itr.input.Close() }
-func (itr *stringAuxIterator) Next() *StringPoint            { return <-itr.output }
-func (itr *stringAuxIterator) Iterator(name string) Iterator { return itr.fields.iterator(name) }
+func (itr *stringAuxIterator) Start()             { go itr.stream() }
+func (itr *stringAuxIterator) Close() error       { return itr.input.Close() }
+func (itr *stringAuxIterator) Next() *StringPoint { return <-itr.output }
+func (itr *stringAuxIterator) Iterator(name string, typ DataType) Iterator {
+	return itr.fields.iterator(name, typ)
+}
 
 func (itr *stringAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, error) {
 	expr := opt.Expr
@@ -2351,13 +2911,13 @@ func (itr *stringAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, err
 
 	switch expr := expr.(type) {
 	case *VarRef:
-		return itr.Iterator(expr.Val), nil
+		return itr.Iterator(expr.Val, expr.Type), nil
 	default:
 		panic(fmt.Sprintf("invalid expression type for an aux iterator: %T", expr))
 	}
 }
 
-func (itr *stringAuxIterator) FieldDimensions(sources Sources) (fields, dimensions map[string]struct{}, err error) {
+func (itr *stringAuxIterator) FieldDimensions(sources Sources) (fields map[string]DataType, dimensions map[string]struct{}, err error) {
 	return nil, nil, errors.New("not implemented")
 }
 
@@ -2395,6 +2955,67 @@ func (itr *stringChanIterator) Close() error {
 
 func (itr *stringChanIterator) Next() *StringPoint { return <-itr.c }
 
+// stringFuncIterator applies a scalar function to the aligned points of its inputs.
+// A nil point is returned if the function has no string result for a point.
+type stringFuncIterator struct {
+	inputs []Iterator
+	fn     func(values []interface{}) interface{}
+}
+
+// Close closes the underlying iterators.
+func (itr *stringFuncIterator) Close() error { return Iterators(itr.inputs).Close() }
+
+// Next returns the next point with a function result.
+func (itr *stringFuncIterator) Next() *StringPoint {
+	var p Point
+	values := make([]interface{}, len(itr.inputs))
+	for i, input := range itr.inputs {
+		curr := readPoint(input)
+		if curr == nil {
+			return nil
+		} else if p == nil {
+			p = curr
+		}
+		values[i] = curr.value()
+	}
+
+	v, ok := itr.fn(values).(string)
+	if !ok {
+		return &StringPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+	}
+	return &StringPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: v, Aux: p.aux()}
+}
+
+// stringCastIterator converts the points of an iterator of another type to string points.
+// Points with a value that cannot be cast to a string are dropped.
+type stringCastIterator struct {
+	input Iterator
+}
+
+// Close closes the underlying iterator.
+func (itr *stringCastIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator that can be cast.
+func (itr *stringCastIterator) Next() *StringPoint {
+	for {
+		p := readPoint(itr.input)
+		if p == nil {
+			return nil
+		}
+
+		v := p.value()
+		if v == nil {
+			return &StringPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+		}
+
+		value, ok := castValue(v, String).(string)
+		if !ok {
+			continue
+		}
+		return &StringPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: value, Aux: p.aux()}
+	}
+}
+
 // stringReduceIterator executes a reducer for every interval and buffers the result.
 type stringReduceIterator struct {
 	input  *bufStringIterator
@@ -2436,6 +3057,11 @@ func (itr *stringReduceIterator) reduce() []*StringPoint {
 	// Create points by tags.
 	m := make(map[string]*StringPoint)
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		curr := itr.input.NextInWindow(startTime, endTime)
 		if curr == nil {
@@ -2531,6 +3157,11 @@ func (itr *stringReduceSliceIterator) reduce() []StringPoint {
 		points []StringPoint
 	})
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		p := itr.input.NextInWindow(startTime, endTime)
 		if p == nil {
@@ -2634,6 +3265,181 @@ func (itr *stringBoolTransformIterator) Next() *BooleanPoint {
 // new point if possible.
 type stringBoolTransformFunc func(p *StringPoint) *BooleanPoint
 
+// stringJoinIterator aligns the points of multiple inputs by tag set and time.
+// Inputs must be sorted by tag set and then time. If an input has no point
+// for a tag set and time then a point is created based on the fill option.
+type stringJoinIterator struct {
+	inputs []*bufStringIterator
+	opt    IteratorOptions
+	name   string
+	closed bool
+
+	// Last point read from each input. Used by fill(previous).
+	prev []*StringPoint
+
+	// Aligned points that have not been read by each output.
+	bufs [][]*StringPoint
+}
+
+// newStringJoinIterator returns a new instance of stringJoinIterator.
+// Every aligned point is renamed to name.
+func newStringJoinIterator(inputs []StringIterator, name string, opt IteratorOptions) *stringJoinIterator {
+	itr := &stringJoinIterator{
+		inputs: make([]*bufStringIterator, len(inputs)),
+		opt:    opt,
+		name:   name,
+		prev:   make([]*StringPoint, len(inputs)),
+		bufs:   make([][]*StringPoint, len(inputs)),
+	}
+	for i, input := range inputs {
+		itr.inputs[i] = newBufStringIterator(input)
+	}
+	return itr
+}
+
+// Close closes all inputs.
+func (itr *stringJoinIterator) Close() error {
+	if itr.closed {
+		return nil
+	}
+	itr.closed = true
+
+	for _, input := range itr.inputs {
+		input.Close()
+	}
+	return nil
+}
+
+// Outputs returns an iterator for each input that reads its aligned points.
+// The outputs must be read in lockstep.
+func (itr *stringJoinIterator) Outputs() []StringIterator {
+	a := make([]StringIterator, len(itr.inputs))
+	for i := range a {
+		a[i] = &stringJoinOutputIterator{join: itr, i: i}
+	}
+	return a
+}
+
+// next reads the next aligned set of points into the output buffers.
+// Returns false once all inputs are exhausted.
+func (itr *stringJoinIterator) next() bool {
+	for {
+		// Find the next tag set and time across all inputs.
+		var key *StringPoint
+		for _, input := range itr.inputs {
+			if p := input.peek(); p != nil && (key == nil || itr.less(p, key)) {
+				key = p
+			}
+		}
+		if key == nil {
+			return false
+		}
+		tags, t := key.Tags, key.Time
+
+		// Read the point from each input that matches the key and fill the rest.
+		a := make([]*StringPoint, len(itr.inputs))
+		filled := false
+		for i, input := range itr.inputs {
+			if p := input.peek(); p != nil && p.Time == t && p.Tags.Equals(&tags) {
+				a[i] = input.Next()
+				itr.prev[i] = &StringPoint{Tags: a[i].Tags, Value: a[i].Value, Nil: a[i].Nil}
+				continue
+			}
+			a[i] = itr.fill(i, tags, t)
+			filled = true
+		}
+
+		// Only emit points that exist in every input when fill is disabled.
+		if filled && itr.opt.Fill == NoFill {
+			continue
+		}
+
+		for i, p := range a {
+			p.Name = itr.name
+			itr.bufs[i] = append(itr.bufs[i], p)
+		}
+		return true
+	}
+}
+
+// less returns true if p sorts before key based on tag set and then time.
+func (itr *stringJoinIterator) less(p, key *StringPoint) bool {
+	if !p.Tags.Equals(&key.Tags) {
+		if itr.opt.Ascending {
+			return p.Tags.ID() < key.Tags.ID()
+		}
+		return p.Tags.ID() > key.Tags.ID()
+	}
+
+	if itr.opt.Ascending {
+		return p.Time < key.Time
+	}
+	return p.Time > key.Time
+}
+
+// fill returns a point for an input that has no point at the given tag set and time.
+func (itr *stringJoinIterator) fill(i int, tags Tags, t int64) *StringPoint {
+	p := &StringPoint{Tags: tags, Time: t}
+	switch itr.opt.Fill {
+	case NumberFill:
+		p.Value = castToString(itr.opt.FillValue)
+	case PreviousFill:
+		if prev := itr.prev[i]; prev != nil && prev.Tags.Equals(&tags) {
+			p.Value, p.Nil = prev.Value, prev.Nil
+		} else {
+			p.Nil = true
+		}
+	default:
+		p.Nil = true
+	}
+	return p
+}
+
+// stringJoinOutputIterator reads the aligned points of a single join input.
+type stringJoinOutputIterator struct {
+	join *stringJoinIterator
+	i    int
+}
+
+// Close closes the join iterator.
+func (itr *stringJoinOutputIterator) Close() error { return itr.join.Close() }
+
+// Next returns the next aligned point for the input.
+func (itr *stringJoinOutputIterator) Next() *StringPoint {
+	if len(itr.join.bufs[itr.i]) == 0 && !itr.join.next() {
+		return nil
+	}
+
+	p := itr.join.bufs[itr.i][0]
+	itr.join.bufs[itr.i] = itr.join.bufs[itr.i][1:]
+	return p
+}
+
+// stringRowSortIterator returns the points of a single input of a rowSorter.
+type stringRowSortIterator struct {
+	sort *rowSorter
+	i    int
+	n    int
+}
+
+// Close closes the sorter.
+func (itr *stringRowSortIterator) Close() error { return itr.sort.Close() }
+
+// Next returns the point of the input for the next sorted row. Returns a nil
+// point if the input has no point for the row.
+func (itr *stringRowSortIterator) Next() *StringPoint {
+	row := itr.sort.row(itr.n)
+	if row == nil {
+		return nil
+	}
+	itr.n++
+
+	if p, ok := row.points[itr.i].(*StringPoint); ok {
+		return p
+	}
+	return &StringPoint{Name: row.name, Tags: row.tags, Time: row.time, Nil: true}
+}
+
 // stringDedupeIterator only outputs unique points.
 // This differs from the DistinctIterator in that it compares all aux fields too.
 // This iterator is relatively inefficient and should only be used on small
@@ -3019,6 +3825,26 @@ type booleanSortedMergeHeapItem struct {
 	ascending bool
 }
 
+// booleanAnalyzeIterator represents an iterator that records runtime statistics.
+type booleanAnalyzeIterator struct {
+	input BooleanIterator
+	stats *IteratorStats
+}
+
+// Close closes the underlying iterator.
+func (itr *booleanAnalyzeIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator and records its statistics.
+func (itr *booleanAnalyzeIterator) Next() *BooleanPoint {
+	start := time.Now()
+	p := itr.input.Next()
+	itr.stats.Elapsed += time.Since(start)
+	if p != nil {
+		atomic.AddInt64(&itr.stats.PointN, 1)
+	}
+	return p
+}
+
 // booleanLimitIterator represents an iterator that limits points per group.
 type booleanLimitIterator struct {
 	input BooleanIterator
@@ -3107,7 +3933,7 @@ func newBooleanFillIterator(input BooleanIterator, expr Expr, opt IteratorOption
 		startTime, _ = opt.Window(opt.StartTime)
 		_, endTime = opt.Window(opt.EndTime)
 	} else {
-		_, startTime = opt.Window(opt.EndTime)
+		startTime, _ = opt.Window(opt.EndTime)
 		endTime, _ = opt.Window(opt.StartTime)
 	}
 
@@ -3171,7 +3997,7 @@ func (itr *booleanFillIterator) Next() *BooleanPoint {
 	}
 
 	// Check if the point is our next expected point.
-	if p == nil || p.Time > itr.window.time {
+	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -3226,10 +4052,12 @@ func newBooleanAuxIterator(input BooleanIterator, seriesKeys SeriesList, opt Ite
 	}
 }
 
-func (itr *booleanAuxIterator) Start()                        { go itr.stream() }
-func (itr *booleanAuxIterator) Close() error                  { return itr.input.Close() }
-func (itr *booleanAuxIterator) Next() *BooleanPoint           { return <-itr.output }
-func (itr *booleanAuxIterator) Iterator(name string) Iterator { return itr.fields.iterator(name) }
+func (itr *booleanAuxIterator) Start()              { go itr.stream() }
+func (itr *booleanAuxIterator) Close() error        { return itr.input.Close() }
+func (itr *booleanAuxIterator) Next() *BooleanPoint { return <-itr.output }
+func (itr *booleanAuxIterator) Iterator(name string, typ DataType) Iterator {
+	return itr.fields.iterator(name, typ)
+}
 
 func (itr *booleanAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, error) {
 	expr := opt.Expr
@@ -3239,13 +4067,13 @@ func (itr *booleanAuxIterator) CreateIterator(opt IteratorOptions) (Iterator, er
 
 	switch expr := expr.(type) {
 	case *VarRef:
-		return itr.Iterator(expr.Val), nil
+		return itr.Iterator(expr.Val, expr.Type), nil
 	default:
 		panic(fmt.Sprintf("invalid expression type for an aux iterator: %T", expr))
 	}
 }
 
-func (itr *booleanAuxIterator) FieldDimensions(sources Sources) (fields, dimensions map[string]struct{}, err error) {
+func (itr *booleanAuxIterator) FieldDimensions(sources Sources) (fields map[string]DataType, dimensions map[string]struct{}, err error) {
 	return nil, nil, errors.New("not implemented")
 }
 
@@ -3283,6 +4111,67 @@ func (itr *booleanChanIterator) Close() error {
 
 func (itr *booleanChanIterator) Next() *BooleanPoint { return <-itr.c }
 
+// booleanFuncIterator applies a scalar function to the aligned points of its inputs.
+// A nil point is returned if the function has no boolean result for a point.
+type booleanFuncIterator struct {
+	inputs []Iterator
+	fn     func(values []interface{}) interface{}
+}
+
+// Close closes the underlying iterators.
+func (itr *booleanFuncIterator) Close() error { return Iterators(itr.inputs).Close() }
+
+// Next returns the next point with a function result.
+func (itr *booleanFuncIterator) Next() *BooleanPoint {
+	var p Point
+	values := make([]interface{}, len(itr.inputs))
+	for i, input := range itr.inputs {
+		curr := readPoint(input)
+		if curr == nil {
+			return nil
+		} else if p == nil {
+			p = curr
+		}
+		values[i] = curr.value()
+	}
+
+	v, ok := itr.fn(values).(bool)
+	if !ok {
+		return &BooleanPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+	}
+	return &BooleanPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: v, Aux: p.aux()}
+}
+
+// booleanCastIterator converts the points of an iterator of another type to boolean points.
+// Points with a value that cannot be cast to a boolean are dropped.
+type booleanCastIterator struct {
+	input Iterator
+}
+
+// Close closes the underlying iterator.
+func (itr *booleanCastIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point from the underlying iterator that can be cast.
+func (itr *booleanCastIterator) Next() *BooleanPoint {
+	for {
+		p := readPoint(itr.input)
+		if p == nil {
+			return nil
+		}
+
+		v := p.value()
+		if v == nil {
+			return &BooleanPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Nil: true, Aux: p.aux()}
+		}
+
+		value, ok := castValue(v, Boolean).(bool)
+		if !ok {
+			continue
+		}
+		return &BooleanPoint{Name: p.name(), Tags: p.tags(), Time: p.time(), Value: value, Aux: p.aux()}
+	}
+}
+
 // booleanReduceIterator executes a reducer for every interval and buffers the result.
 type booleanReduceIterator struct {
 	input  *bufBooleanIterator
@@ -3324,6 +4213,11 @@ func (itr *booleanReduceIterator) reduce() []*BooleanPoint {
 	// Create points by tags.
 	m := make(map[string]*BooleanPoint)
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		curr := itr.input.NextInWindow(startTime, endTime)
 		if curr == nil {
@@ -3419,6 +4313,11 @@ func (itr *booleanReduceSliceIterator) reduce() []BooleanPoint {
 		points []BooleanPoint
 	})
 	for {
+		// Stop reducing if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		// Read next point.
 		p := itr.input.NextInWindow(startTime, endTime)
 		if p == nil {
@@ -3522,6 +4421,181 @@ func (itr *booleanBoolTransformIterator) Next() *BooleanPoint {
 // new point if possible.
 type booleanBoolTransformFunc func(p *BooleanPoint) *BooleanPoint
 
+// booleanJoinIterator aligns the points of multiple inputs by tag set and time.
+// Inputs must be sorted by tag set and then time. If an input has no point
+// for a tag set and time then a point is created based on the fill option.
+type booleanJoinIterator struct {
+	inputs []*bufBooleanIterator
+	opt    IteratorOptions
+	name   string
+	closed bool
+
+	// Last point read from each input. Used by fill(previous).
+	prev []*BooleanPoint
+
+	// Aligned points that have not been read by each output.
+	bufs [][]*BooleanPoint
+}
+
+// newBooleanJoinIterator returns a new instance of booleanJoinIterator.
+// Every aligned point is renamed to name.
+func newBooleanJoinIterator(inputs []BooleanIterator, name string, opt IteratorOptions) *booleanJoinIterator {
+	itr := &booleanJoinIterator{
+		inputs: make([]*bufBooleanIterator, len(inputs)),
+		opt:    opt,
+		name:   name,
+		prev:   make([]*BooleanPoint, len(inputs)),
+		bufs:   make([][]*BooleanPoint, len(inputs)),
+	}
+	for i, input := range inputs {
+		itr.inputs[i] = newBufBooleanIterator(input)
+	}
+	return itr
+}
+
+// Close closes all inputs.
+func (itr *booleanJoinIterator) Close() error {
+	if itr.closed {
+		return nil
+	}
+	itr.closed = true
+
+	for _, input := range itr.inputs {
+		input.Close()
+	}
+	return nil
+}
+
+// Outputs returns an iterator for each input that reads its aligned points.
+// The outputs must be read in lockstep.
+func (itr *booleanJoinIterator) Outputs() []BooleanIterator {
+	a := make([]BooleanIterator, len(itr.inputs))
+	for i := range a {
+		a[i] = &booleanJoinOutputIterator{join: itr, i: i}
+	}
+	return a
+}
+
+// next reads the next aligned set of points into the output buffers.
+// Returns false once all inputs are exhausted.
+func (itr *booleanJoinIterator) next() bool {
+	for {
+		// Find the next tag set and time across all inputs.
+		var key *BooleanPoint
+		for _, input := range itr.inputs {
+			if p := input.peek(); p != nil && (key == nil || itr.less(p, key)) {
+				key = p
+			}
+		}
+		if key == nil {
+			return false
+		}
+		tags, t := key.Tags, key.Time
+
+		// Read the point from each input that matches the key and fill the rest.
+		a := make([]*BooleanPoint, len(itr.inputs))
+		filled := false
+		for i, input := range itr.inputs {
+			if p := input.peek(); p != nil && p.Time == t && p.Tags.Equals(&tags) {
+				a[i] = input.Next()
+				itr.prev[i] = &BooleanPoint{Tags: a[i].Tags, Value: a[i].Value, Nil: a[i].Nil}
+				continue
+			}
+			a[i] = itr.fill(i, tags, t)
+			filled = true
+		}
+
+		// Only emit points that exist in every input when fill is disabled.
+		if filled && itr.opt.Fill == NoFill {
+			continue
+		}
+
+		for i, p := range a {
+			p.Name = itr.name
+			itr.bufs[i] = append(itr.bufs[i], p)
+		}
+		return true
+	}
+}
+
+// less returns true if p sorts before key based on tag set and then time.
+func (itr *booleanJoinIterator) less(p, key *BooleanPoint) bool {
+	if !p.Tags.Equals(&key.Tags) {
+		if itr.opt.Ascending {
+			return p.Tags.ID() < key.Tags.ID()
+		}
+		return p.Tags.ID() > key.Tags.ID()
+	}
+
+	if itr.opt.Ascending {
+		return p.Time < key.Time
+	}
+	return p.Time > key.Time
+}
+
+// fill returns a point for an input that has no point at the given tag set and time.
+func (itr *booleanJoinIterator) fill(i int, tags Tags, t int64) *BooleanPoint {
+	p := &BooleanPoint{Tags: tags, Time: t}
+	switch itr.opt.Fill {
+	case NumberFill:
+		p.Value = castToBoolean(itr.opt.FillValue)
+	case PreviousFill:
+		if prev := itr.prev[i]; prev != nil && prev.Tags.Equals(&tags) {
+			p.Value, p.Nil = prev.Value, prev.Nil
+		} else {
+			p.Nil = true
+		}
+	default:
+		p.Nil = true
+	}
+	return p
+}
+
+// booleanJoinOutputIterator reads the aligned points of a single join input.
+type booleanJoinOutputIterator struct {
+	join *booleanJoinIterator
+	i    int
+}
+
+// Close closes the join iterator.
+func (itr *booleanJoinOutputIterator) Close() error { return itr.join.Close() }
+
+// Next returns the next aligned point for the input.
+func (itr *booleanJoinOutputIterator) Next() *BooleanPoint {
+	if len(itr.join.bufs[itr.i]) == 0 && !itr.join.next() {
+		return nil
+	}
+
+	p := itr.join.bufs[itr.i][0]
+	itr.join.bufs[itr.i] = itr.join.bufs[itr.i][1:]
+	return p
+}
+
+// booleanRowSortIterator returns the points of a single input of a rowSorter.
+type booleanRowSortIterator struct {
+	sort *rowSorter
+	i    int
+	n    int
+}
+
+// Close closes the sorter.
+func (itr *booleanRowSortIterator) Close() error { return itr.sort.Close() }
+
+// Next returns the point of the input for the next sorted row. Returns a nil
+// point if the input has no point for the row.
+func (itr *booleanRowSortIterator) Next() *BooleanPoint {
+	row := itr.sort.row(itr.n)
+	if row == nil {
+		return nil
+	}
+	itr.n++
+
+	if p, ok := row.points[itr.i].(*BooleanPoint); ok {
+		return p
+	}
+	return &BooleanPoint{Name: row.name, Tags: row.tags, Time: row.time, Nil: true}
+}
+
 // booleanDedupeIterator only outputs unique points.
 // This differs from the DistinctIterator in that it compares all aux fields too.
 // This iterator is relatively inefficient and should only be used on small
@@ -7,8 +7,16 @@ import (
 	"time"
 )
 
-// ErrUnknownCall is returned when operating on an unknown function call.
-var ErrUnknownCall = errors.New("unknown call")
+var (
+	// ErrUnknownCall is returned when operating on an unknown function call.
+	ErrUnknownCall = errors.New("unknown call")
+
+	// ErrQueryInterrupted is returned when a query is stopped before it completes.
+	ErrQueryInterrupted = errors.New("query interrupted")
+
+	// ErrQueryTimeout is returned when a query runs longer than its timeout.
+	ErrQueryTimeout = errors.New("query timeout reached")
+)
 
 const (
 	// MinTime is used as the minimum time value when computing an unbounded range.
@@ -207,7 +215,7 @@ type AuxIterator interface {
 	IteratorCreator
 
 	// Auxilary iterator
-	Iterator(name string) Iterator
+	Iterator(name string, typ DataType) Iterator
 
 	// Start starts writing to the created iterators.
 	Start()
@@ -231,8 +239,8 @@ func NewAuxIterator(input Iterator, seriesKeys SeriesList, opt IteratorOptions)
 
 // auxIteratorField represents an auxilary field within an AuxIterator.
 type auxIteratorField struct {
-	name string     // field name
-	typ  DataType   // detected data type
+	ref  VarRef     // field reference
+	typ  DataType   // detected or cast data type
 	itrs []Iterator // auxillary iterators
 	mu   sync.Mutex
 	opt  IteratorOptions
@@ -254,11 +262,11 @@ func (f *auxIteratorField) close() {
 
 type auxIteratorFields []*auxIteratorField
 
-// newAuxIteratorFields returns a new instance of auxIteratorFields from a list of field names.
+// newAuxIteratorFields returns a new instance of auxIteratorFields from a list of field references.
 func newAuxIteratorFields(seriesKeys SeriesList, opt IteratorOptions) auxIteratorFields {
 	fields := make(auxIteratorFields, len(opt.Aux))
-	for i, name := range opt.Aux {
-		fields[i] = &auxIteratorField{name: name, opt: opt}
+	for i, ref := range opt.Aux {
+		fields[i] = &auxIteratorField{ref: ref, opt: opt}
 		for _, s := range seriesKeys {
 			aux := s.Aux[i]
 			if aux == Unknown {
@@ -269,6 +277,14 @@ func newAuxIteratorFields(seriesKeys SeriesList, opt IteratorOptions) auxIterato
 				fields[i].typ = aux
 			}
 		}
+
+		// Cast the values of the field if a type was requested.
+		if fields[i].typ != Unknown {
+			switch ref.Type {
+			case Float, Integer, String, Boolean:
+				fields[i].typ = ref.Type
+			}
+		}
 	}
 	return fields
 }
@@ -280,11 +296,11 @@ func (a auxIteratorFields) close() {
 }
 
 // iterator creates a new iterator for a named auxilary field.
-func (a auxIteratorFields) iterator(name string) Iterator {
+func (a auxIteratorFields) iterator(name string, typ DataType) Iterator {
 	for _, f := range a {
-		// Skip field if it's name doesn't match.
+		// Skip field if it's name or type doesn't match.
 		// Exit if no points were received by the iterator.
-		if f.name != name {
+		if f.ref.Val != name || f.ref.Type != typ {
 			continue
 		}
 
@@ -318,7 +334,7 @@ func (a auxIteratorFields) iterator(name string) Iterator {
 func (a auxIteratorFields) send(p Point) {
 	values := p.aux()
 	for i, f := range a {
-		v := values[i]
+		v := castValue(values[i], f.ref.Type)
 
 		tags := p.tags()
 		tags = tags.Subset(f.opt.Dimensions)
@@ -364,6 +380,31 @@ func (a auxIteratorFields) send(p Point) {
 	}
 }
 
+// readPoint reads the next point from itr. Returns nil once itr is exhausted.
+func readPoint(itr Iterator) Point {
+	switch itr := itr.(type) {
+	case FloatIterator:
+		if p := itr.Next(); p != nil {
+			return p
+		}
+	case IntegerIterator:
+		if p := itr.Next(); p != nil {
+			return p
+		}
+	case StringIterator:
+		if p := itr.Next(); p != nil {
+			return p
+		}
+	case BooleanIterator:
+		if p := itr.Next(); p != nil {
+			return p
+		}
+	default:
+		panic(fmt.Sprintf("unsupported iterator: %T", itr))
+	}
+	return nil
+}
+
 // drainIterator reads all points from an iterator.
 func drainIterator(itr Iterator) {
 	for {
@@ -395,8 +436,8 @@ type IteratorCreator interface {
 	// Creates a simple iterator for use in an InfluxQL query.
 	CreateIterator(opt IteratorOptions) (Iterator, error)
 
-	// Returns the unique fields and dimensions across a list of sources.
-	FieldDimensions(sources Sources) (fields, dimensions map[string]struct{}, err error)
+	// Returns the unique fields, with their types, and dimensions across a list of sources.
+	FieldDimensions(sources Sources) (fields map[string]DataType, dimensions map[string]struct{}, err error)
 
 	// Returns the series keys that will be returned by this iterator.
 	SeriesKeys(opt IteratorOptions) (SeriesList, error)
@@ -409,7 +450,7 @@ type IteratorOptions struct {
 	Expr Expr
 
 	// Auxilary tags or values to also retrieve for the point.
-	Aux []string
+	Aux []VarRef
 
 	// Data sources from which to retrieve data.
 	Sources []Source
@@ -440,6 +481,14 @@ type IteratorOptions struct {
 
 	// Removes duplicate rows from raw queries.
 	Dedupe bool
+
+	// Runtime statistics collected by the storage engine.
+	// Only set when the statement is being analyzed.
+	Stats *IteratorStats
+
+	// Closed when the query is interrupted. Iterators stop reading and
+	// return no more points once the channel is closed.
+	InterruptCh <-chan struct{}
 }
 
 // newIteratorOptionsStmt creates the iterator options from stmt.
@@ -492,9 +541,23 @@ func newIteratorOptionsStmt(stmt *SelectStatement, sopt *SelectOptions) (opt Ite
 	opt.Limit, opt.Offset = stmt.Limit, stmt.Offset
 	opt.SLimit, opt.SOffset = stmt.SLimit, stmt.SOffset
 
+	if sopt != nil {
+		opt.InterruptCh = sopt.InterruptCh
+	}
+
 	return opt, nil
 }
 
+// Interrupted returns true if the query has been interrupted.
+func (opt IteratorOptions) Interrupted() bool {
+	select {
+	case <-opt.InterruptCh:
+		return true
+	default:
+		return false
+	}
+}
+
 // MergeSorted returns true if the options require a sorted merge.
 // This is only needed when the expression is a variable reference or there is no expr.
 func (opt IteratorOptions) MergeSorted() bool {
@@ -566,6 +629,10 @@ func newSelectInfo(stmt *SelectStatement) *selectInfo {
 func (v *selectInfo) Visit(n Node) Visitor {
 	switch n := n.(type) {
 	case *Call:
+		// String functions read the fields of their arguments from each point.
+		if IsStringFunction(n.Name) {
+			return v
+		}
 		v.calls[n] = struct{}{}
 		return nil
 	case *VarRef:
@@ -599,6 +599,66 @@ func TestLimitIterator_Boolean(t *testing.T) {
 	}
 }
 
+// Ensure a cast iterator converts numeric points and drops points of other types.
+func TestCastIterator(t *testing.T) {
+	itr := influxql.NewCastIterator(&IntegerIterator{Points: []influxql.IntegerPoint{
+		{Name: "cpu", Time: 0, Value: 1},
+		{Name: "cpu", Time: 1, Nil: true},
+		{Name: "cpu", Time: 2, Value: 3},
+	}}, influxql.Float)
+	if a := Iterators([]influxql.Iterator{itr}).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.FloatPoint{Name: "cpu", Time: 0, Value: 1}},
+		{&influxql.FloatPoint{Name: "cpu", Time: 1, Nil: true}},
+		{&influxql.FloatPoint{Name: "cpu", Time: 2, Value: 3}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+
+	itr = influxql.NewCastIterator(&StringIterator{Points: []influxql.StringPoint{
+		{Name: "cpu", Time: 0, Value: "a"},
+	}}, influxql.Integer)
+	if p := itr.(influxql.IntegerIterator).Next(); p != nil {
+		t.Fatalf("unexpected point: %s", spew.Sdump(p))
+	}
+
+	// Strings are parsed as numbers.
+	itr = influxql.NewCastIterator(&StringIterator{Points: []influxql.StringPoint{
+		{Name: "cpu", Time: 0, Value: "1.5"},
+		{Name: "cpu", Time: 1, Value: "a"},
+		{Name: "cpu", Time: 2, Value: "-2"},
+	}}, influxql.Float)
+	if a := Iterators([]influxql.Iterator{itr}).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.FloatPoint{Name: "cpu", Time: 0, Value: 1.5}},
+		{&influxql.FloatPoint{Name: "cpu", Time: 2, Value: -2}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+
+	itr = influxql.NewCastIterator(&StringIterator{Points: []influxql.StringPoint{
+		{Name: "cpu", Time: 0, Value: "1.5"},
+		{Name: "cpu", Time: 1, Value: "200"},
+	}}, influxql.Integer)
+	if a := Iterators([]influxql.Iterator{itr}).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.IntegerPoint{Name: "cpu", Time: 1, Value: 200}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+
+	// Floats outside the range of an integer are dropped.
+	itr = influxql.NewCastIterator(&FloatIterator{Points: []influxql.FloatPoint{
+		{Name: "cpu", Time: 0, Value: -1.5},
+		{Name: "cpu", Time: 1, Value: 1e19},
+		{Name: "cpu", Time: 2, Value: -1e19},
+		{Name: "cpu", Time: 3, Value: math.NaN()},
+		{Name: "cpu", Time: 4, Value: math.Inf(1)},
+	}}, influxql.Integer)
+	if a := Iterators([]influxql.Iterator{itr}).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.IntegerPoint{Name: "cpu", Time: 0, Value: -1}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
 // Ensure auxilary iterators can be created for auxilary fields.
 func TestFloatAuxIterator(t *testing.T) {
 	itr := influxql.NewAuxIterator(
@@ -609,14 +669,14 @@ func TestFloatAuxIterator(t *testing.T) {
 		[]influxql.Series{
 			{Aux: []influxql.DataType{influxql.Float, influxql.Float}},
 		},
-		influxql.IteratorOptions{Aux: []string{"f0", "f1"}},
+		influxql.IteratorOptions{Aux: []influxql.VarRef{{Val: "f0"}, {Val: "f1"}}},
 	)
 
 	itrs := []influxql.Iterator{
 		itr,
-		itr.Iterator("f0"),
-		itr.Iterator("f1"),
-		itr.Iterator("f0"),
+		itr.Iterator("f0", influxql.Unknown),
+		itr.Iterator("f1", influxql.Unknown),
+		itr.Iterator("f0", influxql.Unknown),
 	}
 	itr.Start()
 
@@ -863,7 +923,7 @@ func TestIteratorOptions_DerivativeInterval_Call(t *testing.T) {
 // IteratorCreator is a mockable implementation of SelectStatementExecutor.IteratorCreator.
 type IteratorCreator struct {
 	CreateIteratorFn  func(opt influxql.IteratorOptions) (influxql.Iterator, error)
-	FieldDimensionsFn func(sources influxql.Sources) (fields, dimensions map[string]struct{}, err error)
+	FieldDimensionsFn func(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error)
 	SeriesKeysFn      func(opt influxql.IteratorOptions) (influxql.SeriesList, error)
 }
 
@@ -871,7 +931,7 @@ func (ic *IteratorCreator) CreateIterator(opt influxql.IteratorOptions) (influxq
 	return ic.CreateIteratorFn(opt)
 }
 
-func (ic *IteratorCreator) FieldDimensions(sources influxql.Sources) (fields, dimensions map[string]struct{}, err error) {
+func (ic *IteratorCreator) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
 	return ic.FieldDimensionsFn(sources)
 }
 
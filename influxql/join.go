@@ -0,0 +1,342 @@
+package influxql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// joinInfo describes a SELECT statement that joins the fields of multiple
+// measurements on time and tag set, such as:
+//
+//	SELECT cpu.usage / mem.used FROM cpu, mem GROUP BY time(1m), host
+type joinInfo struct {
+	// Name of the joined series.
+	name string
+
+	// Sources by measurement name.
+	sources map[string]*Measurement
+}
+
+// newJoinInfo returns the join information for stmt. Returns nil if stmt
+// does not select fields qualified by measurement from multiple sources.
+func newJoinInfo(stmt *SelectStatement) *joinInfo {
+	if len(stmt.Sources) < 2 {
+		return nil
+	}
+
+	j := &joinInfo{sources: make(map[string]*Measurement, len(stmt.Sources))}
+	names := make([]string, 0, len(stmt.Sources))
+	for _, src := range stmt.Sources {
+		m, ok := src.(*Measurement)
+		if !ok || m.Name == "" || m.Regex != nil {
+			return nil
+		}
+		j.sources[m.Name] = m
+		names = append(names, m.Name)
+	}
+	j.name = strings.Join(names, "_")
+
+	// Only treat the statement as a join if a field is qualified by a source.
+	for _, f := range stmt.Fields {
+		for _, ref := range walkRefs(f.Expr) {
+			if m, _ := j.split(ref); m != nil {
+				return j
+			}
+		}
+	}
+	return nil
+}
+
+// split returns the source and field name for a qualified field reference.
+// Returns a nil source if the reference is not qualified by a source.
+func (j *joinInfo) split(ref *VarRef) (*Measurement, string) {
+	if ref.Qualifier == "" {
+		return nil, ref.Val
+	}
+	if m := j.sources[ref.Qualifier]; m != nil {
+		return m, strings.TrimPrefix(ref.Val, ref.Qualifier+".")
+	}
+	return nil, ref.Val
+}
+
+// validate returns an error if a field in fields is not qualified by a source.
+func (j *joinInfo) validate(fields Fields) error {
+	for _, f := range fields {
+		for _, ref := range walkRefs(f.Expr) {
+			if m, _ := j.split(ref); m == nil {
+				return fmt.Errorf("field %s must be qualified by a measurement in a join", ref.Val)
+			}
+		}
+	}
+	return nil
+}
+
+// iteratorOptions returns the options for reading the single source used by
+// opt.Expr. Qualified references are replaced by the field name and conditions
+// on fields from other sources are removed.
+func (j *joinInfo) iteratorOptions(opt IteratorOptions) (IteratorOptions, error) {
+	var src *Measurement
+	for _, ref := range walkRefs(opt.Expr) {
+		m, _ := j.split(ref)
+		if m == nil {
+			return opt, fmt.Errorf("field %s must be qualified by a measurement in a join", ref.Val)
+		} else if src != nil && src != m {
+			return opt, fmt.Errorf("cannot combine fields from %s and %s in a single iterator", src.Name, m.Name)
+		}
+		src = m
+	}
+	if src == nil {
+		return opt, fmt.Errorf("invalid join expression: %s", opt.Expr)
+	}
+
+	cond, err := j.condition(opt.Condition, src)
+	if err != nil {
+		return opt, err
+	}
+
+	opt.Expr = j.unqualify(CloneExpr(opt.Expr), src)
+	opt.Sources = []Source{src}
+	opt.Condition = cond
+	opt.Aux = nil
+	return opt, nil
+}
+
+// unqualify replaces references qualified by src with the field name.
+func (j *joinInfo) unqualify(expr Expr, src *Measurement) Expr {
+	return RewriteFunc(expr, func(n Node) Node {
+		if ref, ok := n.(*VarRef); ok {
+			if m, name := j.split(ref); m == src {
+				return &VarRef{Val: name, Type: ref.Type}
+			}
+		}
+		return n
+	}).(Expr)
+}
+
+// condition returns the condition for reading src. Comparisons against
+// fields of other sources are replaced with true so they are not applied.
+// Returns an error if a comparison or OR uses fields of several sources since
+// it can only be evaluated after the join.
+func (j *joinInfo) condition(cond Expr, src *Measurement) (Expr, error) {
+	if cond == nil {
+		return nil, nil
+	}
+	if err := j.validateCondition(cond); err != nil {
+		return nil, err
+	}
+
+	cond = RewriteFunc(CloneExpr(cond), func(n Node) Node {
+		expr, ok := n.(*BinaryExpr)
+		if !ok || expr.Op == AND || expr.Op == OR {
+			return n
+		}
+		for _, ref := range walkRefs(expr) {
+			if m, _ := j.split(ref); m != nil && m != src {
+				return &BooleanLiteral{Val: true}
+			}
+		}
+		return n
+	}).(Expr)
+	cond = Reduce(j.unqualify(cond, src), nil)
+	if lit, ok := cond.(*BooleanLiteral); ok && lit.Val {
+		return nil, nil
+	}
+	return cond, nil
+}
+
+// validateCondition returns an error if an expression other than AND in cond
+// uses fields of more than one source.
+func (j *joinInfo) validateCondition(cond Expr) error {
+	var err error
+	WalkFunc(cond, func(n Node) {
+		expr, ok := n.(*BinaryExpr)
+		if !ok || expr.Op == AND || err != nil {
+			return
+		}
+
+		var src *Measurement
+		for _, ref := range walkRefs(expr) {
+			m, _ := j.split(ref)
+			if m == nil {
+				continue
+			} else if src != nil && src != m {
+				err = fmt.Errorf("condition %s cannot use fields of both %s and %s in a join", expr, src.Name, m.Name)
+				return
+			}
+			src = m
+		}
+	})
+	return err
+}
+
+// iteratorCreator wraps ic so that each iterator reads from a single source.
+// The returned creator estimates costs if ic supports estimation.
+func (j *joinInfo) iteratorCreator(ic IteratorCreator) IteratorCreator {
+	jic := &joinIteratorCreator{IteratorCreator: ic, join: j}
+	if est, ok := ic.(IteratorCostEstimator); ok {
+		return &joinCostIteratorCreator{joinIteratorCreator: jic, est: est}
+	}
+	return jic
+}
+
+// joinIteratorCreator creates iterators for the single source used by each expression.
+type joinIteratorCreator struct {
+	IteratorCreator
+	join *joinInfo
+}
+
+// CreateIterator creates an iterator for the source referenced by opt.Expr.
+func (ic *joinIteratorCreator) CreateIterator(opt IteratorOptions) (Iterator, error) {
+	opt, err := ic.join.iteratorOptions(opt)
+	if err != nil {
+		return nil, err
+	}
+	return ic.IteratorCreator.CreateIterator(opt)
+}
+
+// joinCostIteratorCreator is a joinIteratorCreator that can estimate iterator costs.
+type joinCostIteratorCreator struct {
+	*joinIteratorCreator
+	est IteratorCostEstimator
+}
+
+// IteratorCost estimates the cost of the iterator for the source referenced by opt.Expr.
+func (ic *joinCostIteratorCreator) IteratorCost(opt IteratorOptions) (IteratorCost, error) {
+	opt, err := ic.join.iteratorOptions(opt)
+	if err != nil {
+		return IteratorCost{}, err
+	}
+	return ic.est.IteratorCost(opt)
+}
+
+// buildJoinIterators creates an iterator for each field expression of a join.
+// The iterators of each field are renamed to the join name so that the
+// emitter aligns them into a single series.
+func buildJoinIterators(fields Fields, ic IteratorCreator, opt IteratorOptions, join *joinInfo) ([]Iterator, error) {
+	if err := join.validate(fields); err != nil {
+		return nil, err
+	} else if opt.Condition != nil {
+		if err := join.validateCondition(opt.Condition); err != nil {
+			return nil, err
+		}
+	}
+	jic := &joinIteratorCreator{IteratorCreator: ic, join: join}
+	opt.Aux = nil
+
+	itrs := make([]Iterator, 0, len(fields))
+	for _, f := range fields {
+		itr, err := buildJoinExprIterator(Reduce(f.Expr, nil), jic, opt)
+		if err != nil {
+			Iterators(itrs).Close()
+			return nil, fmt.Errorf("error constructing iterator for field '%s': %s", f.String(), err)
+		}
+
+		// Rename the output so all fields are emitted in the same series.
+		if outputs := newJoinIterator([]Iterator{itr}, join.name, opt); outputs != nil {
+			itr = outputs[0]
+		}
+
+		// If there is a limit or offset then apply it.
+		if opt.Limit > 0 || opt.Offset > 0 {
+			itr = NewLimitIterator(itr, opt)
+		}
+		itrs = append(itrs, itr)
+	}
+	return itrs, nil
+}
+
+// buildJoinExprIterator creates an iterator for an expression in a join.
+// Both sides of a binary expression are aligned by a join iterator before
+// they are combined.
+func buildJoinExprIterator(expr Expr, ic *joinIteratorCreator, opt IteratorOptions) (Iterator, error) {
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		if rhs, ok := expr.RHS.(Literal); ok {
+			if lhs, ok := expr.LHS.(Literal); ok {
+				return nil, fmt.Errorf("unable to construct an iterator from two literals: LHS: %T, RHS: %T", lhs, rhs)
+			}
+
+			lhs, err := buildJoinExprIterator(expr.LHS, ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			return buildRHSTransformIterator(lhs, rhs, expr.Op, ic, opt)
+		} else if lhs, ok := expr.LHS.(Literal); ok {
+			rhs, err := buildJoinExprIterator(expr.RHS, ic, opt)
+			if err != nil {
+				return nil, err
+			}
+			return buildLHSTransformIterator(lhs, rhs, expr.Op, ic, opt)
+		}
+
+		lhs, err := buildJoinExprIterator(expr.LHS, ic, opt)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := buildJoinExprIterator(expr.RHS, ic, opt)
+		if err != nil {
+			lhs.Close()
+			return nil, err
+		}
+
+		outputs := newJoinIterator([]Iterator{lhs, rhs}, ic.join.name, opt)
+		if outputs == nil {
+			return nil, fmt.Errorf("unable to join %T and %T", lhs, rhs)
+		}
+		itr, err := buildTransformIterator(outputs[0], outputs[1], expr.Op, ic, opt)
+		if err != nil {
+			outputs[0].Close()
+			return nil, err
+		}
+		return itr, nil
+	case *ParenExpr:
+		return buildJoinExprIterator(expr.Expr, ic, opt)
+	default:
+		return buildExprIterator(expr, ic, opt)
+	}
+}
+
+// newJoinIterator aligns inputs by tag set and time and returns an iterator
+// for each input. Inputs are cast to a common type. Returns nil if the inputs
+// cannot be cast to a common type.
+func newJoinIterator(inputs []Iterator, name string, opt IteratorOptions) []Iterator {
+	var outputs []Iterator
+	switch inputs := Iterators(inputs).cast().(type) {
+	case []FloatIterator:
+		for _, itr := range newFloatJoinIterator(inputs, name, opt).Outputs() {
+			outputs = append(outputs, itr)
+		}
+	case []IntegerIterator:
+		for _, itr := range newIntegerJoinIterator(inputs, name, opt).Outputs() {
+			outputs = append(outputs, itr)
+		}
+	case []StringIterator:
+		for _, itr := range newStringJoinIterator(inputs, name, opt).Outputs() {
+			outputs = append(outputs, itr)
+		}
+	case []BooleanIterator:
+		for _, itr := range newBooleanJoinIterator(inputs, name, opt).Outputs() {
+			outputs = append(outputs, itr)
+		}
+	}
+
+	// Inputs that could not be cast are dropped so the outputs won't align.
+	if len(outputs) != len(inputs) {
+		for _, itr := range outputs {
+			itr.Close()
+		}
+		return nil
+	}
+	return outputs
+}
+
+// walkRefs returns all variable references in expr.
+func walkRefs(expr Expr) []*VarRef {
+	var a []*VarRef
+	WalkFunc(expr, func(n Node) {
+		if ref, ok := n.(*VarRef); ok {
+			a = append(a, ref)
+		}
+	})
+	return a
+}
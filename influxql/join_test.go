@@ -0,0 +1,204 @@
+package influxql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/pkg/deep"
+)
+
+// Ensure fields from multiple measurements are joined on time and tag set.
+func TestSelect_Join(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if len(opt.Sources) != 1 {
+			t.Fatalf("unexpected sources: %s", opt.Sources)
+		}
+		switch name := opt.Sources[0].(*influxql.Measurement).Name; name {
+		case "cpu":
+			if s := opt.Expr.String(); s != "usage" {
+				t.Fatalf("unexpected expr: %s", s)
+			}
+			return &FloatIterator{Points: []influxql.FloatPoint{
+				{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 20},
+				{Name: "cpu", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 40},
+				{Name: "cpu", Tags: ParseTags("host=B"), Time: 0 * Second, Value: 30},
+			}}, nil
+		case "mem":
+			if s := opt.Expr.String(); s != "total" {
+				t.Fatalf("unexpected expr: %s", s)
+			}
+			return &IntegerIterator{Points: []influxql.IntegerPoint{
+				{Name: "mem", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 2},
+				{Name: "mem", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 4},
+				{Name: "mem", Tags: ParseTags("host=B"), Time: 0 * Second, Value: 10},
+			}}, nil
+		default:
+			t.Fatalf("unexpected source: %s", name)
+			return nil, nil
+		}
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT cpu.usage / mem.total FROM cpu, mem GROUP BY host`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.FloatPoint{Name: "cpu_mem", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 10}},
+		{&influxql.FloatPoint{Name: "cpu_mem", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 10}},
+		{&influxql.FloatPoint{Name: "cpu_mem", Tags: ParseTags("host=B"), Time: 0 * Second, Value: 3}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure a missing side of a join is filled with the fill value.
+func TestSelect_Join_Fill(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		switch opt.Sources[0].(*influxql.Measurement).Name {
+		case "cpu":
+			return influxql.NewCallIterator(&FloatIterator{Points: []influxql.FloatPoint{
+				{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 20},
+				{Name: "cpu", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 40},
+			}}, opt), nil
+		default:
+			return influxql.NewCallIterator(&FloatIterator{Points: []influxql.FloatPoint{
+				{Name: "mem", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 4},
+			}}, opt), nil
+		}
+	}
+
+	for _, tt := range []struct {
+		s      string
+		points [][]influxql.Point
+	}{
+		{
+			s: `SELECT sum(cpu.usage) + sum(mem.total) FROM cpu, mem WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z' GROUP BY time(10s), host fill(1)`,
+			points: [][]influxql.Point{
+				{&influxql.FloatPoint{Name: "cpu_mem", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 21}},
+				{&influxql.FloatPoint{Name: "cpu_mem", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 44}},
+			},
+		},
+		{
+			s: `SELECT sum(cpu.usage) + sum(mem.total) FROM cpu, mem WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z' GROUP BY time(10s), host fill(none)`,
+			points: [][]influxql.Point{
+				{&influxql.FloatPoint{Name: "cpu_mem", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 44}},
+			},
+		},
+	} {
+		itrs, err := influxql.Select(MustParseSelectStatement(tt.s), &ic, nil)
+		if err != nil {
+			t.Errorf("%s: %s", tt.s, err)
+		} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, tt.points) {
+			t.Errorf("%s: unexpected points: %s", tt.s, spew.Sdump(a))
+		}
+	}
+}
+
+// Ensure conditions on the fields of other measurements are not pushed down.
+func TestSelect_Join_Condition(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		switch name := opt.Sources[0].(*influxql.Measurement).Name; name {
+		case "cpu":
+			if s := opt.Condition.String(); s != `usage > 10.000` {
+				t.Fatalf("unexpected condition: %s", s)
+			}
+		case "mem":
+			if opt.Condition != nil {
+				t.Fatalf("unexpected condition: %s", opt.Condition)
+			}
+		}
+		return &FloatIterator{}, nil
+	}
+
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT cpu.usage - mem.total FROM cpu, mem WHERE cpu.usage > 10`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	influxql.Iterators(itrs).Close()
+}
+
+// Ensure conditions using the fields of several measurements are rejected.
+func TestSelect_Join_ErrConditionSpansSources(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `SELECT cpu.usage - mem.total FROM cpu, mem WHERE cpu.usage > mem.total`, err: `condition "cpu".usage > "mem".total cannot use fields of both cpu and mem in a join`},
+		{s: `SELECT cpu.usage - mem.total FROM cpu, mem WHERE cpu.usage > 10 OR mem.total < 5`, err: `condition "cpu".usage > 10.000 OR "mem".total < 5.000 cannot use fields of both cpu and mem in a join`},
+	} {
+		var ic IteratorCreator
+		ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+			return &FloatIterator{}, nil
+		}
+
+		_, err := influxql.Select(MustParseSelectStatement(tt.s), &ic, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: unexpected error: %v", tt.s, err)
+		}
+	}
+}
+
+// Ensure a field name containing a dot is not treated as a join.
+func TestSelect_Join_DottedField(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if len(opt.Sources) != 2 {
+			t.Fatalf("unexpected sources: %s", opt.Sources)
+		} else if s := opt.Expr.String(); s != `sum("cpu.idle")` {
+			t.Fatalf("unexpected expr: %s", s)
+		}
+		return &FloatIterator{}, nil
+	}
+
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT sum("cpu.idle") FROM cpu, mem`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	influxql.Iterators(itrs).Close()
+}
+
+// Ensure fields can be qualified by a measurement name containing a dot.
+func TestSelect_Join_DottedMeasurement(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if s := opt.Expr.String(); s != "value" {
+			t.Fatalf("unexpected expr: %s", s)
+		}
+		switch name := opt.Sources[0].(*influxql.Measurement).Name; name {
+		case "cpu.load":
+			return &FloatIterator{Points: []influxql.FloatPoint{
+				{Name: "cpu.load", Time: 0 * Second, Value: 2},
+			}}, nil
+		case "mem":
+			return &FloatIterator{Points: []influxql.FloatPoint{
+				{Name: "mem", Time: 0 * Second, Value: 3},
+			}}, nil
+		default:
+			t.Fatalf("unexpected source: %s", name)
+			return nil, nil
+		}
+	}
+
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT "cpu.load".value * mem.value FROM "cpu.load", mem`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.FloatPoint{Name: "cpu.load_mem", Time: 0 * Second, Value: 6}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure every field of a join must be qualified by a measurement.
+func TestSelect_Join_ErrUnqualifiedField(t *testing.T) {
+	var ic IteratorCreator
+	_, err := influxql.Select(MustParseSelectStatement(`SELECT cpu.usage / total FROM cpu, mem`), &ic, nil)
+	if err == nil || !strings.Contains(err.Error(), "field total must be qualified by a measurement in a join") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package influxql
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -23,7 +24,8 @@ const (
 
 // Parser represents an InfluxQL parser.
 type Parser struct {
-	s *bufScanner
+	s      *bufScanner
+	params map[string]interface{}
 }
 
 // NewParser returns a new instance of Parser.
@@ -31,6 +33,17 @@ func NewParser(r io.Reader) *Parser {
 	return &Parser{s: newBufScanner(r)}
 }
 
+// SetParams sets the values substituted for bound parameters such as $host.
+//
+// Strings, numbers and booleans bind to their equivalent literals. Strings
+// that look like dates are bound as time literals, the same as quoted strings.
+// Other literal types can be bound using a Go time.Time, time.Duration or
+// *regexp.Regexp, or an object with a single "string", "number", "boolean",
+// "time", "duration" or "regex" key, which is the form used by JSON clients.
+func (p *Parser) SetParams(params map[string]interface{}) {
+	p.params = params
+}
+
 // ParseQuery parses a query string and returns its AST representation.
 func ParseQuery(s string) (*Query, error) { return NewParser(strings.NewReader(s)).ParseQuery() }
 
@@ -91,6 +104,8 @@ func (p *Parser) ParseStatement() (Statement, error) {
 		return p.parseSelectStatement(targetNotRequired)
 	case DELETE:
 		return p.parseDeleteStatement()
+	case EXPLAIN:
+		return p.parseExplainStatement()
 	case SHOW:
 		return p.parseShowStatement()
 	case CREATE:
@@ -105,9 +120,24 @@ func (p *Parser) ParseStatement() (Statement, error) {
 		return p.parseAlterStatement()
 	case SET:
 		return p.parseSetPasswordUserStatement()
-	default:
-		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET"}, pos)
+	case IDENT:
+		// Cluster statements start with words that aren't reserved.
+		switch strings.ToUpper(lit) {
+		case "COPY":
+			return p.parseCopyShardStatement()
+		case "REMOVE":
+			return p.parseRemoveShardStatement()
+		case "DECOMMISSION":
+			return p.parseDecommissionServerStatement()
+		case "PURGE":
+			return p.parsePurgeHintedHandoffStatement()
+		case "PAUSE":
+			return p.parsePauseHintedHandoffStatement()
+		case "RESUME":
+			return p.parseResumeHintedHandoffStatement()
+		}
 	}
+	return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET", "EXPLAIN", "COPY", "REMOVE", "DECOMMISSION", "PURGE", "PAUSE", "RESUME"}, pos)
 }
 
 // parseShowStatement parses a string and returns a list statement.
@@ -127,8 +157,12 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == KEYS {
 			return p.parseShowFieldKeysStatement()
+		} else if tok == KEY {
+			return p.parseShowFieldKeyCardinalityStatement()
 		}
-		return nil, newParseError(tokstr(tok, lit), []string{"KEYS"}, pos)
+		return nil, newParseError(tokstr(tok, lit), []string{"KEY", "KEYS"}, pos)
+	case MEASUREMENT:
+		return p.parseShowMeasurementCardinalityStatement()
 	case MEASUREMENTS:
 		return p.parseShowMeasurementsStatement()
 	case RETENTION:
@@ -138,13 +172,18 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		}
 		return nil, newParseError(tokstr(tok, lit), []string{"POLICIES"}, pos)
 	case SERIES:
+		if p.peekCardinality() {
+			return p.parseShowSeriesCardinalityStatement()
+		}
 		return p.parseShowSeriesStatement()
 	case SHARD:
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == GROUPS {
 			return p.parseShowShardGroupsStatement()
+		} else if isKeyword(tok, lit, "DIFFERENCES") {
+			return p.parseShowShardDifferencesStatement()
 		}
-		return nil, newParseError(tokstr(tok, lit), []string{"GROUPS"}, pos)
+		return nil, newParseError(tokstr(tok, lit), []string{"DIFFERENCES", "GROUPS"}, pos)
 	case SHARDS:
 		return p.parseShowShardsStatement()
 	case STATS:
@@ -155,14 +194,27 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == KEYS {
 			return p.parseShowTagKeysStatement()
+		} else if tok == KEY {
+			return p.parseShowTagKeyCardinalityStatement()
 		} else if tok == VALUES {
+			if p.peekCardinality() {
+				return p.parseShowTagValuesCardinalityStatement()
+			}
 			return p.parseShowTagValuesStatement()
 		}
-		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
+		return nil, newParseError(tokstr(tok, lit), []string{"KEY", "KEYS", "VALUES"}, pos)
 	case USERS:
 		return p.parseShowUsersStatement()
 	case SUBSCRIPTIONS:
 		return p.parseShowSubscriptionsStatement()
+	case IDENT:
+		// Some SHOW statements use words that aren't reserved.
+		switch strings.ToUpper(lit) {
+		case "REBALANCE":
+			return p.parseShowRebalanceStatement()
+		case "HINTED":
+			return p.parseShowHintedHandoffStatement()
+		}
 	}
 
 	showQueryKeywords := []string{
@@ -170,6 +222,7 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		"DATABASES",
 		"FIELD",
 		"GRANTS",
+		"MEASUREMENT",
 		"MEASUREMENTS",
 		"RETENTION",
 		"SERIES",
@@ -181,6 +234,8 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		"SHARD",
 		"SHARDS",
 		"SUBSCRIPTIONS",
+		"REBALANCE",
+		"HINTED",
 	}
 	sort.Strings(showQueryKeywords)
 
@@ -890,7 +945,7 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 	// Set if the query is a raw data query or one with an aggregate
 	stmt.IsRawQuery = true
 	WalkFunc(stmt.Fields, func(n Node) {
-		if _, ok := n.(*Call); ok {
+		if call, ok := n.(*Call); ok && !IsStringFunction(call.Name) {
 			stmt.IsRawQuery = false
 		}
 	})
@@ -983,6 +1038,30 @@ func (p *Parser) parseDeleteStatement() (*DeleteStatement, error) {
 	//return stmt, nil
 }
 
+// parseExplainStatement parses a string and returns an ExplainStatement.
+// This function assumes the EXPLAIN token has already been consumed.
+func (p *Parser) parseExplainStatement() (*ExplainStatement, error) {
+	stmt := &ExplainStatement{}
+
+	if tok, _, lit := p.scanIgnoreWhitespace(); isKeyword(tok, lit, "ANALYZE") {
+		stmt.Analyze = true
+	} else {
+		p.unscan()
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SELECT {
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	s, err := p.parseSelectStatement(targetNotRequired)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Statement = s
+
+	return stmt, nil
+}
+
 // parseShowSeriesStatement parses a string and returns a ShowSeriesStatement.
 // This function assumes the "SHOW SERIES" tokens have already been consumed.
 func (p *Parser) parseShowSeriesStatement() (*ShowSeriesStatement, error) {
@@ -1004,7 +1083,7 @@ func (p *Parser) parseShowSeriesStatement() (*ShowSeriesStatement, error) {
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseTimeOrderBy(); err != nil {
 		return nil, err
 	}
 
@@ -1021,6 +1100,121 @@ func (p *Parser) parseShowSeriesStatement() (*ShowSeriesStatement, error) {
 	return stmt, nil
 }
 
+// peekCardinality returns true if the next token starts a cardinality clause.
+func (p *Parser) peekCardinality() bool {
+	tok, _, lit := p.scanIgnoreWhitespace()
+	p.unscan()
+	return isKeyword(tok, lit, "EXACT") || isKeyword(tok, lit, "CARDINALITY")
+}
+
+// parseCardinality parses the "[EXACT] CARDINALITY [ON <db>]" clause of a cardinality statement.
+func (p *Parser) parseCardinality() (exact bool, database string, err error) {
+	if tok, _, lit := p.scanIgnoreWhitespace(); isKeyword(tok, lit, "EXACT") {
+		exact = true
+	} else {
+		p.unscan()
+	}
+	if err := p.parseKeywords("CARDINALITY"); err != nil {
+		return false, "", err
+	}
+
+	// Parse optional ON clause.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ON {
+		if database, err = p.parseIdent(); err != nil {
+			return false, "", err
+		}
+	} else {
+		p.unscan()
+	}
+	return exact, database, nil
+}
+
+// parseOptionalSources parses an optional "FROM <sources>" clause.
+func (p *Parser) parseOptionalSources() (Sources, error) {
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok != FROM {
+		p.unscan()
+		return nil, nil
+	}
+	return p.parseSources()
+}
+
+// parseShowSeriesCardinalityStatement parses a string and returns a ShowSeriesCardinalityStatement.
+// This function assumes the "SHOW SERIES" tokens have already been consumed.
+func (p *Parser) parseShowSeriesCardinalityStatement() (*ShowSeriesCardinalityStatement, error) {
+	stmt := &ShowSeriesCardinalityStatement{}
+	var err error
+
+	if stmt.Exact, stmt.Database, err = p.parseCardinality(); err != nil {
+		return nil, err
+	}
+	if stmt.Sources, err = p.parseOptionalSources(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseShowMeasurementCardinalityStatement parses a string and returns a ShowMeasurementCardinalityStatement.
+// This function assumes the "SHOW MEASUREMENT" tokens have already been consumed.
+func (p *Parser) parseShowMeasurementCardinalityStatement() (*ShowMeasurementCardinalityStatement, error) {
+	stmt := &ShowMeasurementCardinalityStatement{}
+	var err error
+
+	if stmt.Exact, stmt.Database, err = p.parseCardinality(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseShowTagKeyCardinalityStatement parses a string and returns a ShowTagKeyCardinalityStatement.
+// This function assumes the "SHOW TAG KEY" tokens have already been consumed.
+func (p *Parser) parseShowTagKeyCardinalityStatement() (*ShowTagKeyCardinalityStatement, error) {
+	stmt := &ShowTagKeyCardinalityStatement{}
+	var err error
+
+	if stmt.Exact, stmt.Database, err = p.parseCardinality(); err != nil {
+		return nil, err
+	}
+	if stmt.Sources, err = p.parseOptionalSources(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseShowTagValuesCardinalityStatement parses a string and returns a ShowTagValuesCardinalityStatement.
+// This function assumes the "SHOW TAG VALUES" tokens have already been consumed.
+func (p *Parser) parseShowTagValuesCardinalityStatement() (*ShowTagValuesCardinalityStatement, error) {
+	stmt := &ShowTagValuesCardinalityStatement{}
+	var err error
+
+	if stmt.Exact, stmt.Database, err = p.parseCardinality(); err != nil {
+		return nil, err
+	}
+	if stmt.Sources, err = p.parseOptionalSources(); err != nil {
+		return nil, err
+	}
+
+	// Parse required WITH KEY.
+	if stmt.TagKeys, err = p.parseTagKeys(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseShowFieldKeyCardinalityStatement parses a string and returns a ShowFieldKeyCardinalityStatement.
+// This function assumes the "SHOW FIELD KEY" tokens have already been consumed.
+func (p *Parser) parseShowFieldKeyCardinalityStatement() (*ShowFieldKeyCardinalityStatement, error) {
+	stmt := &ShowFieldKeyCardinalityStatement{}
+	var err error
+
+	if stmt.Exact, stmt.Database, err = p.parseCardinality(); err != nil {
+		return nil, err
+	}
+	if stmt.Sources, err = p.parseOptionalSources(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
 // parseShowMeasurementsStatement parses a string and returns a ShowSeriesStatement.
 // This function assumes the "SHOW MEASUREMENTS" tokens have already been consumed.
 func (p *Parser) parseShowMeasurementsStatement() (*ShowMeasurementsStatement, error) {
@@ -1056,7 +1250,7 @@ func (p *Parser) parseShowMeasurementsStatement() (*ShowMeasurementsStatement, e
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseTimeOrderBy(); err != nil {
 		return nil, err
 	}
 
@@ -1114,7 +1308,7 @@ func (p *Parser) parseShowTagKeysStatement() (*ShowTagKeysStatement, error) {
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseTimeOrderBy(); err != nil {
 		return nil, err
 	}
 
@@ -1167,7 +1361,7 @@ func (p *Parser) parseShowTagValuesStatement() (*ShowTagValuesStatement, error)
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseTimeOrderBy(); err != nil {
 		return nil, err
 	}
 
@@ -1254,7 +1448,7 @@ func (p *Parser) parseShowFieldKeysStatement() (*ShowFieldKeysStatement, error)
 	}
 
 	// Parse sort: "ORDER BY FIELD+".
-	if stmt.SortFields, err = p.parseOrderBy(); err != nil {
+	if stmt.SortFields, err = p.parseTimeOrderBy(); err != nil {
 		return nil, err
 	}
 
@@ -1339,6 +1533,84 @@ func (p *Parser) parseDropServerStatement(tok Token) (*DropServerStatement, erro
 	return s, nil
 }
 
+// parseDecommissionServerStatement parses a string and returns a DecommissionServerStatement.
+// This function assumes the DECOMMISSION keyword has already been consumed.
+func (p *Parser) parseDecommissionServerStatement() (*DecommissionServerStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SERVER {
+		return nil, newParseError(tokstr(tok, lit), []string{"SERVER"}, pos)
+	}
+
+	s := &DecommissionServerStatement{}
+	var err error
+
+	// Parse the server's ID.
+	if s.NodeID, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// parseCopyShardStatement parses a string and returns a CopyShardStatement.
+// This function assumes the COPY keyword has already been consumed.
+func (p *Parser) parseCopyShardStatement() (*CopyShardStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SHARD {
+		return nil, newParseError(tokstr(tok, lit), []string{"SHARD"}, pos)
+	}
+
+	s := &CopyShardStatement{}
+	var err error
+
+	// Parse the shard's ID.
+	if s.ShardID, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	// Parse the source node's ID.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+	if s.Source, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	// Parse the destination node's ID.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != TO {
+		return nil, newParseError(tokstr(tok, lit), []string{"TO"}, pos)
+	}
+	if s.Destination, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// parseRemoveShardStatement parses a string and returns a RemoveShardStatement.
+// This function assumes the REMOVE keyword has already been consumed.
+func (p *Parser) parseRemoveShardStatement() (*RemoveShardStatement, error) {
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != SHARD {
+		return nil, newParseError(tokstr(tok, lit), []string{"SHARD"}, pos)
+	}
+
+	s := &RemoveShardStatement{}
+	var err error
+
+	// Parse the shard's ID.
+	if s.ShardID, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	// Parse the node's ID.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != FROM {
+		return nil, newParseError(tokstr(tok, lit), []string{"FROM"}, pos)
+	}
+	if s.Source, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
 // parseShowContinuousQueriesStatement parses a string and returns a ShowContinuousQueriesStatement.
 // This function assumes the "SHOW CONTINUOUS" tokens have already been consumed.
 func (p *Parser) parseShowContinuousQueriesStatement() (*ShowContinuousQueriesStatement, error) {
@@ -1709,6 +1981,12 @@ func (p *Parser) parseRetentionPolicy() (name string, dfault bool, err error) {
 	return
 }
 
+// parseShowShardDifferencesStatement parses a string for "SHOW SHARD DIFFERENCES" statement.
+// This function assumes the "SHOW SHARD DIFFERENCES" tokens have already been consumed.
+func (p *Parser) parseShowShardDifferencesStatement() (*ShowShardDifferencesStatement, error) {
+	return &ShowShardDifferencesStatement{}, nil
+}
+
 // parseShowShardGroupsStatement parses a string for "SHOW SHARD GROUPS" statement.
 // This function assumes the "SHOW SHARD GROUPS" tokens have already been consumed.
 func (p *Parser) parseShowShardGroupsStatement() (*ShowShardGroupsStatement, error) {
@@ -1721,6 +1999,75 @@ func (p *Parser) parseShowShardsStatement() (*ShowShardsStatement, error) {
 	return &ShowShardsStatement{}, nil
 }
 
+// parseShowRebalanceStatement parses a string and returns a ShowRebalanceStatement.
+// This function assumes the "SHOW REBALANCE" tokens have already been consumed.
+func (p *Parser) parseShowRebalanceStatement() (*ShowRebalanceStatement, error) {
+	return &ShowRebalanceStatement{}, nil
+}
+
+// parseShowHintedHandoffStatement parses a string and returns a ShowHintedHandoffStatement.
+// This function assumes the "SHOW HINTED" tokens have already been consumed.
+func (p *Parser) parseShowHintedHandoffStatement() (*ShowHintedHandoffStatement, error) {
+	if err := p.parseKeywords("HANDOFF"); err != nil {
+		return nil, err
+	}
+	return &ShowHintedHandoffStatement{}, nil
+}
+
+// parsePurgeHintedHandoffStatement parses a string and returns a PurgeHintedHandoffStatement.
+// This function assumes the PURGE keyword has already been consumed.
+func (p *Parser) parsePurgeHintedHandoffStatement() (*PurgeHintedHandoffStatement, error) {
+	if err := p.parseKeywords("HINTED", "HANDOFF"); err != nil {
+		return nil, err
+	}
+	if err := p.parseTokens([]Token{FOR}); err != nil {
+		return nil, err
+	}
+
+	s := &PurgeHintedHandoffStatement{}
+	var err error
+
+	// Parse the node's ID.
+	if s.NodeID, err = p.parseUInt64(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// parsePauseHintedHandoffStatement parses a string and returns a PauseHintedHandoffStatement.
+// This function assumes the PAUSE keyword has already been consumed.
+func (p *Parser) parsePauseHintedHandoffStatement() (*PauseHintedHandoffStatement, error) {
+	id, err := p.parseHintedHandoffNode()
+	if err != nil {
+		return nil, err
+	}
+	return &PauseHintedHandoffStatement{NodeID: id}, nil
+}
+
+// parseResumeHintedHandoffStatement parses a string and returns a ResumeHintedHandoffStatement.
+// This function assumes the RESUME keyword has already been consumed.
+func (p *Parser) parseResumeHintedHandoffStatement() (*ResumeHintedHandoffStatement, error) {
+	id, err := p.parseHintedHandoffNode()
+	if err != nil {
+		return nil, err
+	}
+	return &ResumeHintedHandoffStatement{NodeID: id}, nil
+}
+
+// parseHintedHandoffNode parses the "HINTED HANDOFF" keywords and an optional
+// "FOR <node>" clause. Returns zero if no node is specified.
+func (p *Parser) parseHintedHandoffNode() (uint64, error) {
+	if err := p.parseKeywords("HINTED", "HANDOFF"); err != nil {
+		return 0, err
+	}
+
+	if !p.parseTokenMaybe(FOR) {
+		return 0, nil
+	}
+	return p.parseUInt64()
+}
+
 // parseShowStatsStatement parses a string and returns a ShowStatsStatement.
 // This function assumes the "SHOW STATS" tokens have already been consumed.
 func (p *Parser) parseShowStatsStatement() (*ShowStatsStatement, error) {
@@ -1905,6 +2252,15 @@ func (p *Parser) peekRune() rune {
 	return r
 }
 
+// peekRegex returns true if the next non-whitespace character starts a regex.
+// Leading whitespace is consumed.
+func (p *Parser) peekRegex() bool {
+	if isWhitespace(p.peekRune()) {
+		p.consumeWhitespace()
+	}
+	return p.peekRune() == '/'
+}
+
 func (p *Parser) parseSource() (Source, error) {
 	m := &Measurement{}
 
@@ -2110,6 +2466,20 @@ func (p *Parser) parseOrderBy() (SortFields, error) {
 	return fields, nil
 }
 
+// parseTimeOrderBy parses the "ORDER BY" clause of a query that can only be
+// sorted by time, if it exists.
+func (p *Parser) parseTimeOrderBy() (SortFields, error) {
+	fields, err := p.parseOrderBy()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) > 1 || (len(fields) == 1 && fields[0].Name != "" && fields[0].Name != "time") {
+		return nil, errors.New("only ORDER BY time supported at this time")
+	}
+	return fields, nil
+}
+
 // parseSortFields parses the sort fields for an ORDER BY clause.
 func (p *Parser) parseSortFields() (SortFields, error) {
 	var fields SortFields
@@ -2128,10 +2498,6 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 			return nil, err
 		}
 
-		if lit != "time" {
-			return nil, errors.New("only ORDER BY time supported at this time")
-		}
-
 		fields = append(fields, field)
 	// Parse error...
 	default:
@@ -2155,10 +2521,6 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 		fields = append(fields, field)
 	}
 
-	if len(fields) > 1 {
-		return nil, errors.New("only ORDER BY time supported at this time")
-	}
-
 	return fields, nil
 }
 
@@ -2193,7 +2555,38 @@ func (p *Parser) parseVarRef() (*VarRef, error) {
 	}
 
 	vr := &VarRef{Val: strings.Join(segments, ".")}
+	if len(segments) > 1 {
+		vr.Qualifier = segments[0]
+	}
+
+	// Parse an optional type cast or tag/field specifier.
+	if tok, _, _ := p.scan(); tok != DOUBLECOLON {
+		p.unscan()
+		return vr, nil
+	}
 
+	tok, pos, lit := p.scan()
+	switch tok {
+	case IDENT:
+		switch strings.ToLower(lit) {
+		case "float":
+			vr.Type = Float
+		case "integer":
+			vr.Type = Integer
+		case "string":
+			vr.Type = String
+		case "boolean":
+			vr.Type = Boolean
+		default:
+			return nil, newParseError(tokstr(tok, lit), []string{"float", "integer", "string", "boolean", "field", "tag"}, pos)
+		}
+	case FIELD:
+		vr.Type = AnyField
+	case TAG:
+		vr.Type = Tag
+	default:
+		return nil, newParseError(tokstr(tok, lit), []string{"float", "integer", "string", "boolean", "field", "tag"}, pos)
+	}
 	return vr, nil
 }
 
@@ -2303,26 +2696,7 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 
 		return nil, newParseError(tokstr(tok0, lit), []string{"(", "identifier"}, pos)
 	case STRING:
-		// If literal looks like a date time then parse it as a time literal.
-		if isDateTimeString(lit) {
-			t, err := time.Parse(DateTimeFormat, lit)
-			if err != nil {
-				// try to parse it as an RFCNano time
-				t, err := time.Parse(time.RFC3339Nano, lit)
-				if err != nil {
-					return nil, &ParseError{Message: "unable to parse datetime", Pos: pos}
-				}
-				return &TimeLiteral{Val: t}, nil
-			}
-			return &TimeLiteral{Val: t}, nil
-		} else if isDateString(lit) {
-			t, err := time.Parse(DateFormat, lit)
-			if err != nil {
-				return nil, &ParseError{Message: "unable to parse date", Pos: pos}
-			}
-			return &TimeLiteral{Val: t}, nil
-		}
-		return &StringLiteral{Val: lit}, nil
+		return parseStringLiteral(lit, pos)
 	case NUMBER:
 		v, err := strconv.ParseFloat(lit, 64)
 		if err != nil {
@@ -2342,11 +2716,185 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 			return nil, &ParseError{Message: err.Error(), Pos: pos}
 		}
 		return &RegexLiteral{Val: re}, nil
+	case BOUNDPARAM:
+		return p.bindParam(lit, pos)
 	default:
 		return nil, newParseError(tokstr(tok, lit), []string{"identifier", "string", "number", "bool"}, pos)
 	}
 }
 
+// parseStringLiteral returns a time literal if s looks like a date or a date
+// time. Otherwise it returns a string literal.
+func parseStringLiteral(s string, pos Pos) (Expr, error) {
+	if isDateTimeString(s) {
+		t, err := time.Parse(DateTimeFormat, s)
+		if err != nil {
+			// try to parse it as an RFCNano time
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, &ParseError{Message: "unable to parse datetime", Pos: pos}
+			}
+			return &TimeLiteral{Val: t}, nil
+		}
+		return &TimeLiteral{Val: t}, nil
+	} else if isDateString(s) {
+		t, err := time.Parse(DateFormat, s)
+		if err != nil {
+			return nil, &ParseError{Message: "unable to parse date", Pos: pos}
+		}
+		return &TimeLiteral{Val: t}, nil
+	}
+	return &StringLiteral{Val: s}, nil
+}
+
+// bindParam returns the literal for the bound parameter lit, such as $host.
+func (p *Parser) bindParam(lit string, pos Pos) (Expr, error) {
+	name := strings.TrimPrefix(lit, "$")
+	v, ok := p.params[name]
+	if !ok {
+		return nil, &ParseError{Message: fmt.Sprintf("missing parameter: %s", name), Pos: pos}
+	}
+
+	expr, err := bindParamValue(v)
+	if err != nil {
+		return nil, &ParseError{Message: fmt.Sprintf("unable to bind parameter %s: %s", name, err), Pos: pos}
+	}
+	return expr, nil
+}
+
+// bindParamValue converts a parameter value to its literal.
+func bindParamValue(v interface{}) (Expr, error) {
+	switch v := v.(type) {
+	case string:
+		expr, err := parseStringLiteral(v, Pos{})
+		if err != nil {
+			return nil, errors.New(err.(*ParseError).Message)
+		}
+		return expr, nil
+	case bool:
+		return &BooleanLiteral{Val: v}, nil
+	case time.Time:
+		return &TimeLiteral{Val: v}, nil
+	case time.Duration:
+		return &DurationLiteral{Val: v}, nil
+	case *regexp.Regexp:
+		return &RegexLiteral{Val: v}, nil
+	case map[string]interface{}:
+		return bindTypedParamValue(v)
+	}
+
+	f, err := paramNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	return &NumberLiteral{Val: f}, nil
+}
+
+// bindTypedParamValue converts an object such as {"duration": "10s"} to its literal.
+func bindTypedParamValue(m map[string]interface{}) (Expr, error) {
+	if len(m) != 1 {
+		return nil, errors.New("typed parameter must have exactly one key")
+	}
+
+	for typ, v := range m {
+		switch typ {
+		case "string":
+			if s, ok := v.(string); ok {
+				return &StringLiteral{Val: s}, nil
+			}
+		case "number":
+			f, err := paramNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			return &NumberLiteral{Val: f}, nil
+		case "boolean":
+			if b, ok := v.(bool); ok {
+				return &BooleanLiteral{Val: b}, nil
+			}
+		case "time":
+			if s, ok := v.(string); ok {
+				if expr, err := parseStringLiteral(s, Pos{}); err == nil {
+					if lit, ok := expr.(*TimeLiteral); ok {
+						return lit, nil
+					}
+				}
+				return nil, fmt.Errorf("invalid time: %s", s)
+			}
+			n, err := paramInteger(v)
+			if err != nil {
+				return nil, err
+			}
+			return &TimeLiteral{Val: time.Unix(0, n).UTC()}, nil
+		case "duration":
+			if s, ok := v.(string); ok {
+				d, err := ParseDuration(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid duration: %s", s)
+				}
+				return &DurationLiteral{Val: d}, nil
+			}
+			n, err := paramInteger(v)
+			if err != nil {
+				return nil, err
+			}
+			return &DurationLiteral{Val: time.Duration(n)}, nil
+		case "regex":
+			if s, ok := v.(string); ok {
+				re, err := regexp.Compile(s)
+				if err != nil {
+					return nil, err
+				}
+				return &RegexLiteral{Val: re}, nil
+			}
+		default:
+			return nil, fmt.Errorf("unknown parameter type: %s", typ)
+		}
+		return nil, fmt.Errorf("invalid %s value: %v", typ, v)
+	}
+	panic("unreachable")
+}
+
+// paramNumber converts a numeric parameter value to a float.
+func paramNumber(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	default:
+		return 0, fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+// paramInteger converts a numeric parameter value to an integer. Integers
+// are converted without going through a float so nanosecond timestamps keep
+// their precision.
+func paramInteger(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+	}
+
+	f, err := paramNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	return int64(f), nil
+}
+
 // parseRegex parses a regular expression.
 func (p *Parser) parseRegex() (*RegexLiteral, error) {
 	nextRune := p.peekRune()
@@ -2354,8 +2902,13 @@ func (p *Parser) parseRegex() (*RegexLiteral, error) {
 		p.consumeWhitespace()
 	}
 
-	// If the next character is not a '/', then return nils.
+	// A bound parameter in a regex position is always bound as a regex.
 	nextRune = p.peekRune()
+	if nextRune == '$' {
+		return p.parseRegexParam()
+	}
+
+	// If the next character is not a '/', then return nils.
 	if nextRune != '/' {
 		return nil, nil
 	}
@@ -2380,6 +2933,34 @@ func (p *Parser) parseRegex() (*RegexLiteral, error) {
 	return &RegexLiteral{Val: re}, nil
 }
 
+// parseRegexParam parses a bound parameter as a regular expression.
+func (p *Parser) parseRegexParam() (*RegexLiteral, error) {
+	tok, pos, lit := p.scan()
+	if tok != BOUNDPARAM {
+		return nil, newParseError(tokstr(tok, lit), []string{"regex"}, pos)
+	}
+
+	// Plain strings are compiled rather than bound as string literals.
+	name := strings.TrimPrefix(lit, "$")
+	if s, ok := p.params[name].(string); ok {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, &ParseError{Message: fmt.Sprintf("unable to bind parameter %s: %s", name, err), Pos: pos}
+		}
+		return &RegexLiteral{Val: re}, nil
+	}
+
+	expr, err := p.bindParam(lit, pos)
+	if err != nil {
+		return nil, err
+	}
+	re, ok := expr.(*RegexLiteral)
+	if !ok {
+		return nil, &ParseError{Message: fmt.Sprintf("parameter %s is not a regex", name), Pos: pos}
+	}
+	return re, nil
+}
+
 // parseCall parses a function call.
 // This function assumes the function name and LPAREN have been consumed.
 func (p *Parser) parseCall(name string) (*Call, error) {
@@ -2393,8 +2974,15 @@ func (p *Parser) parseCall(name string) (*Call, error) {
 	// Otherwise parse function call arguments.
 	var args []Expr
 	for {
-		// Parse an expression argument.
-		arg, err := p.ParseExpr()
+		// Parse an expression argument. Arguments after the first can
+		// also be regular expressions.
+		var arg Expr
+		var err error
+		if len(args) > 0 && p.peekRegex() {
+			arg, err = p.parseRegex()
+		} else {
+			arg, err = p.ParseExpr()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -2558,6 +3146,24 @@ func (p *Parser) parseTokens(toks []Token) error {
 	return nil
 }
 
+// parseKeywords consumes identifiers spelled as words, such as the HINTED
+// HANDOFF of a hinted handoff statement.
+func (p *Parser) parseKeywords(words ...string) error {
+	for _, word := range words {
+		if tok, pos, lit := p.scanIgnoreWhitespace(); !isKeyword(tok, lit, word) {
+			return newParseError(tokstr(tok, lit), []string{word}, pos)
+		}
+	}
+	return nil
+}
+
+// isKeyword returns true if tok is an identifier spelled as word. Keywords
+// only used by a few statements aren't reserved so they can still be used
+// as identifiers elsewhere.
+func isKeyword(tok Token, lit, word string) bool {
+	return tok == IDENT && strings.EqualFold(lit, word)
+}
+
 // parseTokenMaybe consumes the next token if it matches the expected one and
 // does nothing if the next token is not the next one.
 func (p *Parser) parseTokenMaybe(expected Token) bool {
@@ -232,6 +232,22 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement ordered by a field value
+		{
+			s: `SELECT mean(value) AS m FROM cpu GROUP BY host ORDER BY m DESC, time LIMIT 10`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: false,
+				Fields:     []*influxql.Field{{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}, Alias: "m"}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.VarRef{Val: "host"}}},
+				SortFields: []*influxql.SortField{
+					{Name: "m", Ascending: false},
+					{Name: "time", Ascending: true},
+				},
+				Limit: 10,
+			},
+		},
+
 		// SELECT statement with multiple ORDER BY fields
 		{
 			skip: true,
@@ -261,6 +277,74 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT with type casts and tag/field specifiers
+		{
+			s: `SELECT value::integer, host::tag, host::field FROM cpu WHERE value::float > 1 AND region::tag = 'west'`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.VarRef{Val: "value", Type: influxql.Integer}},
+					{Expr: &influxql.VarRef{Val: "host", Type: influxql.Tag}},
+					{Expr: &influxql.VarRef{Val: "host", Type: influxql.AnyField}},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Condition: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.GT,
+						LHS: &influxql.VarRef{Val: "value", Type: influxql.Float},
+						RHS: &influxql.NumberLiteral{Val: 1},
+					},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.EQ,
+						LHS: &influxql.VarRef{Val: "region", Type: influxql.Tag},
+						RHS: &influxql.StringLiteral{Val: "west"},
+					},
+				},
+			},
+		},
+
+		// SELECT with string functions
+		{
+			s: `SELECT lower(message), strlen(message) FROM logs WHERE contains(message, 'error')`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.Call{Name: "lower", Args: []influxql.Expr{&influxql.VarRef{Val: "message"}}}},
+					{Expr: &influxql.Call{Name: "strlen", Args: []influxql.Expr{&influxql.VarRef{Val: "message"}}}},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "logs"}},
+				Condition: &influxql.Call{Name: "contains", Args: []influxql.Expr{
+					&influxql.VarRef{Val: "message"},
+					&influxql.StringLiteral{Val: "error"},
+				}},
+			},
+		},
+
+		// SELECT with a wildcard in a function call
+		{
+			s: `SELECT mean(*) FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.Wildcard{}}}},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		// SELECT with a type cast in a function call
+		{
+			s: `SELECT mean("value"::float) FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: false,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value", Type: influxql.Float}}}},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
 		// SELECT * FROM cpu WHERE host = 'serverC' AND region =~ /.*west.*/
 		{
 			s: `SELECT * FROM cpu WHERE host = 'serverC' AND region =~ /.*west.*/`,
@@ -644,6 +728,41 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// EXPLAIN statement
+		{
+			s: `EXPLAIN SELECT value FROM cpu`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					IsRawQuery: true,
+					Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				},
+			},
+		},
+
+		// EXPLAIN ANALYZE statement
+		{
+			s: `EXPLAIN ANALYZE SELECT value FROM cpu`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					IsRawQuery: true,
+					Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				},
+				Analyze: true,
+			},
+		},
+
+		// ANALYZE isn't reserved.
+		{
+			s: `SELECT analyze FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "analyze"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
 		// See issues https://github.com/influxdata/influxdb/issues/1647
 		// and https://github.com/influxdata/influxdb/issues/4404
 		// DELETE statement
@@ -981,6 +1100,106 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowUsersStatement{},
 		},
 
+		// SHOW ... CARDINALITY
+		{
+			s:    `SHOW SERIES CARDINALITY`,
+			stmt: &influxql.ShowSeriesCardinalityStatement{},
+		},
+		{
+			s: `SHOW SERIES EXACT CARDINALITY ON db0 FROM cpu, mem`,
+			stmt: &influxql.ShowSeriesCardinalityStatement{
+				Database: "db0",
+				Exact:    true,
+				Sources:  []influxql.Source{&influxql.Measurement{Name: "cpu"}, &influxql.Measurement{Name: "mem"}},
+			},
+		},
+		{
+			s:    `SHOW MEASUREMENT EXACT CARDINALITY ON db0`,
+			stmt: &influxql.ShowMeasurementCardinalityStatement{Database: "db0", Exact: true},
+		},
+		{
+			s:    `SHOW TAG KEY CARDINALITY FROM cpu`,
+			stmt: &influxql.ShowTagKeyCardinalityStatement{Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}}},
+		},
+		{
+			s: `SHOW TAG VALUES EXACT CARDINALITY FROM cpu WITH KEY IN (host, region)`,
+			stmt: &influxql.ShowTagValuesCardinalityStatement{
+				Exact:   true,
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				TagKeys: []string{"host", "region"},
+			},
+		},
+		{
+			s:    `SHOW FIELD KEY CARDINALITY ON db0`,
+			stmt: &influxql.ShowFieldKeyCardinalityStatement{Database: "db0"},
+		},
+
+		// Hinted handoff keywords aren't reserved.
+		{
+			s: `SELECT hinted, handoff FROM pause`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.VarRef{Val: "hinted"}},
+					{Expr: &influxql.VarRef{Val: "handoff"}},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "pause"}},
+			},
+		},
+
+		// DECOMMISSION isn't reserved.
+		{
+			s: `SELECT decommission FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "decommission"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		// DIFFERENCES isn't reserved.
+		{
+			s: `SELECT differences FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "differences"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		// REBALANCE isn't reserved.
+		{
+			s: `SELECT rebalance FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "rebalance"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
+		// COPY and REMOVE aren't reserved.
+		{
+			s: `SELECT copy FROM remove`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "copy"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "remove"}},
+			},
+		},
+
+		// CARDINALITY and EXACT aren't reserved.
+		{
+			s: `SELECT cardinality, exact FROM "exact"`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*influxql.Field{
+					{Expr: &influxql.VarRef{Val: "cardinality"}},
+					{Expr: &influxql.VarRef{Val: "exact"}},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "exact"}},
+			},
+		},
+
 		// SHOW FIELD KEYS
 		{
 			skip: true,
@@ -1043,6 +1262,56 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.DropServerStatement{NodeID: 123, Meta: false},
 		},
 
+		// DECOMMISSION SERVER statement
+		{
+			s:    `DECOMMISSION SERVER 2`,
+			stmt: &influxql.DecommissionServerStatement{NodeID: 2},
+		},
+
+		// SHOW HINTED HANDOFF statement
+		{
+			s:    `SHOW HINTED HANDOFF`,
+			stmt: &influxql.ShowHintedHandoffStatement{},
+		},
+
+		// PURGE HINTED HANDOFF statement
+		{
+			s:    `PURGE HINTED HANDOFF FOR 2`,
+			stmt: &influxql.PurgeHintedHandoffStatement{NodeID: 2},
+		},
+
+		// PAUSE HINTED HANDOFF statement
+		{
+			s:    `PAUSE HINTED HANDOFF`,
+			stmt: &influxql.PauseHintedHandoffStatement{},
+		},
+		{
+			s:    `PAUSE HINTED HANDOFF FOR 2`,
+			stmt: &influxql.PauseHintedHandoffStatement{NodeID: 2},
+		},
+
+		// RESUME HINTED HANDOFF statement
+		{
+			s:    `RESUME HINTED HANDOFF`,
+			stmt: &influxql.ResumeHintedHandoffStatement{},
+		},
+		{
+			s:    `RESUME HINTED HANDOFF FOR 2`,
+			stmt: &influxql.ResumeHintedHandoffStatement{NodeID: 2},
+		},
+
+		// COPY SHARD statement
+		{
+			s:    `COPY SHARD 14 FROM 1 TO 3`,
+			stmt: &influxql.CopyShardStatement{ShardID: 14, Source: 1, Destination: 3},
+		},
+
+		// REMOVE SHARD statement
+		{
+			s:    `REMOVE SHARD 14 FROM 1`,
+			stmt: &influxql.RemoveShardStatement{ShardID: 14, Source: 1},
+		},
+
 		// SHOW CONTINUOUS QUERIES statement
 		{
 			s:    `SHOW CONTINUOUS QUERIES`,
@@ -1602,6 +1871,12 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SHOW SHARD DIFFERENCES
+		{
+			s:    `SHOW SHARD DIFFERENCES`,
+			stmt: &influxql.ShowShardDifferencesStatement{},
+		},
+
 		// SHOW SHARD GROUPS
 		{
 			s:    `SHOW SHARD GROUPS`,
@@ -1614,6 +1889,12 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowShardsStatement{},
 		},
 
+		// SHOW REBALANCE
+		{
+			s:    `SHOW REBALANCE`,
+			stmt: &influxql.ShowRebalanceStatement{},
+		},
+
 		// SHOW DIAGNOSTICS
 		{
 			s:    `SHOW DIAGNOSTICS`,
@@ -1655,13 +1936,22 @@ func TestParser_ParseStatement(t *testing.T) {
 		},
 
 		// Errors
-		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET, EXPLAIN, COPY, REMOVE, DECOMMISSION, PURGE, PAUSE, RESUME at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
 		{s: `SELECT time FROM myseries`, err: `at least 1 non-time field must be queried`},
-		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET, EXPLAIN, COPY, REMOVE, DECOMMISSION, PURGE, PAUSE, RESUME at line 1, char 1`},
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
+		{s: `SELECT lower(message, 1) FROM logs`, err: `invalid number of arguments for lower, expected 1, got 2`},
+		{s: `SELECT substr(message, 'a') FROM logs`, err: `expected non-negative integer argument in substr(), found 'a'`},
+		{s: `SELECT regex_extract(message, /a(b)/, 2) FROM logs`, err: `regex_extract() group 2 does not exist in /a(b)/`},
+		{s: `SELECT concat('a', 'b') FROM logs`, err: `expected field argument in concat()`},
+		{s: `SELECT mean(lower(message)) FROM logs`, err: `expected field argument in mean()`},
+		{s: `SELECT distinct(contains(message, 'error')) FROM logs`, err: `expected field argument in distinct()`},
+		{s: `SELECT distinct(*) FROM cpu`, err: `expected field argument in distinct()`},
+		{s: `SELECT value::time FROM cpu`, err: `found time, expected float, integer, string, boolean, field, tag at line 1, char 15`},
+		{s: `SELECT value::`, err: `found EOF, expected float, integer, string, boolean, field, tag at line 1, char 15`},
 		{s: `SELECT field1 FROM myseries LIMIT`, err: `found EOF, expected number at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT 10.5`, err: `fractional parts not allowed in LIMIT at line 1, char 35`},
 		{s: `SELECT top() FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 0`},
@@ -1686,7 +1976,10 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `found /, expected identifier, ASC, DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `found 1, expected identifier, ASC, DESC at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries ORDER BY time ASC,`, err: `found EOF, expected identifier at line 1, char 47`},
-		{s: `SELECT field1 FROM myseries ORDER BY time, field1`, err: `only ORDER BY time supported at this time`},
+		{s: `SELECT field1 FROM myseries ORDER BY time, field1`, err: `time must be the last field in ORDER BY`},
+		{s: `SELECT field1 FROM myseries ORDER BY field1, field2`, err: `only one field can be used in ORDER BY`},
+		{s: `SELECT field1 FROM myseries ORDER BY field1, time, time`, err: `time can only be used once in ORDER BY`},
+		{s: `SHOW MEASUREMENTS ORDER BY field1`, err: `only ORDER BY time supported at this time`},
 		{s: `SELECT field1 AS`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SELECT field1 FROM foo group by time(1s)`, err: `GROUP BY requires at least one aggregate function`},
 		{s: `SELECT count(value), value FROM foo`, err: `mixing aggregate and non-aggregate queries is not supported`},
@@ -1739,20 +2032,37 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `DELETE`, err: `DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead`},
 		{s: `DELETE FROM`, err: `DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead`},
 		{s: `DELETE FROM myseries WHERE`, err: `DELETE FROM is currently not supported. Use DROP SERIES or DROP MEASUREMENT instead`},
+		{s: `EXPLAIN`, err: `found EOF, expected SELECT at line 1, char 9`},
+		{s: `EXPLAIN ANALYZE SHOW DATABASES`, err: `found SHOW, expected SELECT at line 1, char 17`},
 		{s: `DROP MEASUREMENT`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP SERIES`, err: `found EOF, expected FROM, WHERE at line 1, char 13`},
 		{s: `DROP SERIES FROM`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP SERIES FROM src WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
 		{s: `DROP META SERVER`, err: `found EOF, expected number at line 1, char 18`},
 		{s: `DROP DATA SERVER abc`, err: `found abc, expected number at line 1, char 18`},
+		{s: `DECOMMISSION 2`, err: `found 2, expected SERVER at line 1, char 14`},
+		{s: `DECOMMISSION SERVER`, err: `found EOF, expected number at line 1, char 21`},
+		{s: `SHOW HINTED`, err: `found EOF, expected HANDOFF at line 1, char 13`},
+		{s: `PURGE HINTED HANDOFF`, err: `found EOF, expected FOR at line 1, char 22`},
+		{s: `PURGE HINTED HANDOFF FOR abc`, err: `found abc, expected number at line 1, char 26`},
+		{s: `PAUSE HANDOFF`, err: `found HANDOFF, expected HINTED at line 1, char 7`},
+		{s: `RESUME HINTED HANDOFF FOR`, err: `found EOF, expected number at line 1, char 27`},
+		{s: `COPY 14`, err: `found 14, expected SHARD at line 1, char 6`},
+		{s: `COPY SHARD abc`, err: `found abc, expected number at line 1, char 12`},
+		{s: `COPY SHARD 14 TO 3`, err: `found TO, expected FROM at line 1, char 15`},
+		{s: `COPY SHARD 14 FROM 1`, err: `found EOF, expected TO at line 1, char 21`},
+		{s: `COPY SHARD 14 FROM 1 TO`, err: `found EOF, expected number at line 1, char 25`},
+		{s: `REMOVE SHARD`, err: `found EOF, expected number at line 1, char 14`},
+		{s: `REMOVE SHARD 14`, err: `found EOF, expected FROM at line 1, char 16`},
+		{s: `REMOVE SHARD 14 FROM abc`, err: `found abc, expected number at line 1, char 22`},
 		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION ON`, err: `found ON, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION POLICIES`, err: `found EOF, expected ON at line 1, char 25`},
 		{s: `SHOW RETENTION POLICIES mydb`, err: `found mydb, expected ON at line 1, char 25`},
 		{s: `SHOW RETENTION POLICIES ON`, err: `found EOF, expected identifier at line 1, char 28`},
-		{s: `SHOW SHARD`, err: `found EOF, expected GROUPS at line 1, char 12`},
-		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, DIAGNOSTICS, FIELD, GRANTS, MEASUREMENTS, RETENTION, SERIES, SERVERS, SHARD, SHARDS, STATS, SUBSCRIPTIONS, TAG, USERS at line 1, char 6`},
+		{s: `SHOW SHARD`, err: `found EOF, expected DIFFERENCES, GROUPS at line 1, char 12`},
+		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, DIAGNOSTICS, FIELD, GRANTS, HINTED, MEASUREMENT, MEASUREMENTS, REBALANCE, RETENTION, SERIES, SERVERS, SHARD, SHARDS, STATS, SUBSCRIPTIONS, TAG, USERS at line 1, char 6`},
 		{s: `SHOW STATS FOR`, err: `found EOF, expected string at line 1, char 16`},
 		{s: `SHOW DIAGNOSTICS FOR`, err: `found EOF, expected string at line 1, char 22`},
 		{s: `SHOW GRANTS`, err: `found EOF, expected FOR at line 1, char 13`},
@@ -1887,6 +2197,10 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `ALTER RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 24`},
 		{s: `ALTER RETENTION POLICY policy1`, err: `found EOF, expected ON at line 1, char 32`}, {s: `ALTER RETENTION POLICY policy1 ON`, err: `found EOF, expected identifier at line 1, char 35`},
 		{s: `ALTER RETENTION POLICY policy1 ON testdb`, err: `found EOF, expected DURATION, RETENTION, DEFAULT at line 1, char 42`},
+		{s: `SHOW MEASUREMENT`, err: `found EOF, expected CARDINALITY at line 1, char 18`},
+		{s: `SHOW SERIES EXACT`, err: `found EOF, expected CARDINALITY at line 1, char 19`},
+		{s: `SHOW TAG VALUES CARDINALITY FROM cpu`, err: `found EOF, expected WITH at line 1, char 38`},
+		{s: `SHOW FIELD FOO`, err: `found FOO, expected KEY, KEYS at line 1, char 12`},
 		{s: `SET`, err: `found EOF, expected PASSWORD at line 1, char 5`},
 		{s: `SET PASSWORD`, err: `found EOF, expected FOR at line 1, char 14`},
 		{s: `SET PASSWORD something`, err: `found something, expected FOR at line 1, char 14`},
@@ -1921,6 +2235,82 @@ func TestParser_ParseStatement(t *testing.T) {
 	}
 }
 
+// Ensure the parser can substitute bound parameters into statements.
+func TestParser_ParseStatement_BoundParams(t *testing.T) {
+	var tests = []struct {
+		s      string
+		params map[string]interface{}
+		stmt   string
+		err    string
+	}{
+		{
+			s:      `SELECT value FROM cpu WHERE host = $host AND value > $value`,
+			params: map[string]interface{}{"host": "server01", "value": 10.5},
+			stmt:   `SELECT value FROM cpu WHERE host = 'server01' AND value > 10.500`,
+		},
+		{
+			s:      `SELECT value FROM cpu WHERE host = $"host name" AND region = $region`,
+			params: map[string]interface{}{"host name": "server'01", "region": map[string]interface{}{"string": "2000-01-01"}},
+			stmt:   `SELECT value FROM cpu WHERE host = 'server\'01' AND region = '2000-01-01'`,
+		},
+		{
+			s:      `SELECT value FROM cpu WHERE time > $start AND time < $end`,
+			params: map[string]interface{}{"start": "2000-01-01T00:00:00Z", "end": map[string]interface{}{"time": json.Number("946771200000000000")}},
+			stmt:   `SELECT value FROM cpu WHERE time > '2000-01-01T00:00:00Z' AND time < '2000-01-02T00:00:00Z'`,
+		},
+		{
+			s:      `SELECT value FROM cpu WHERE time > $start`,
+			params: map[string]interface{}{"start": map[string]interface{}{"time": json.Number("946771200000000001")}},
+			stmt:   `SELECT value FROM cpu WHERE time > '2000-01-02T00:00:00.000000001Z'`,
+		},
+		{
+			s:      `SELECT mean(value) FROM cpu WHERE time > now() - $ago GROUP BY time($interval)`,
+			params: map[string]interface{}{"ago": map[string]interface{}{"duration": "1h"}, "interval": 10 * time.Second},
+			stmt:   `SELECT mean(value) FROM cpu WHERE time > now() - 1h GROUP BY time(10s)`,
+		},
+		{
+			s:      `SELECT value FROM $m WHERE host =~ $host AND enabled = $enabled`,
+			params: map[string]interface{}{"m": "^cpu", "host": map[string]interface{}{"regex": "server0[12]"}, "enabled": true},
+			stmt:   `SELECT value FROM /^cpu/ WHERE host =~ /server0[12]/ AND enabled = true`,
+		},
+		{
+			s:      `SHOW MEASUREMENTS WITH MEASUREMENT =~ $m`,
+			params: map[string]interface{}{"m": regexp.MustCompile(`^cpu`)},
+			stmt:   `SHOW MEASUREMENTS WITH MEASUREMENT =~ /^cpu/`,
+		},
+		{
+			s:   `SELECT value FROM cpu WHERE host = $host`,
+			err: `missing parameter: host at line 1, char 36`,
+		},
+		{
+			s:      `SELECT value FROM cpu WHERE host = $host`,
+			params: map[string]interface{}{"host": []string{"a"}},
+			err:    `unable to bind parameter host: unsupported type: []string at line 1, char 36`,
+		},
+		{
+			s:      `SELECT value FROM cpu WHERE host = $host`,
+			params: map[string]interface{}{"host": map[string]interface{}{"duration": "abc"}},
+			err:    `unable to bind parameter host: invalid duration: abc at line 1, char 36`,
+		},
+		{
+			s:      `SELECT value FROM cpu WHERE host =~ $host`,
+			params: map[string]interface{}{"host": 10.0},
+			err:    `parameter host is not a regex at line 1, char 37`,
+		},
+	}
+
+	for i, tt := range tests {
+		p := influxql.NewParser(strings.NewReader(tt.s))
+		p.SetParams(tt.params)
+		stmt, err := p.ParseStatement()
+		if !reflect.DeepEqual(tt.err, errstring(err)) {
+			t.Errorf("%d. %q: error mismatch:\n  exp=%s\n  got=%s\n\n", i, tt.s, tt.err, err)
+		} else if tt.err == "" && stmt.String() != tt.stmt {
+			t.Errorf("%d. %q: statement mismatch:\n  exp=%s\n  got=%s\n\n", i, tt.s, tt.stmt, stmt)
+		}
+	}
+}
+
 // Ensure the parser can parse expressions into an AST.
 func TestParser_ParseExpr(t *testing.T) {
 	var tests = []struct {
@@ -1934,6 +2324,9 @@ func TestParser_ParseExpr(t *testing.T) {
 		{s: `true`, expr: &influxql.BooleanLiteral{Val: true}},
 		{s: `false`, expr: &influxql.BooleanLiteral{Val: false}},
 		{s: `my_ident`, expr: &influxql.VarRef{Val: "my_ident"}},
+		{s: `cpu.idle`, expr: &influxql.VarRef{Val: "cpu.idle", Qualifier: "cpu"}},
+		{s: `"cpu.idle"`, expr: &influxql.VarRef{Val: "cpu.idle"}},
+		{s: `"cpu.load".value`, expr: &influxql.VarRef{Val: "cpu.load.value", Qualifier: "cpu.load"}},
 		{s: `'2000-01-01 00:00:00'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")}},
 		{s: `'2000-01-01 00:00:00.232'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00.232Z")}},
 		{s: `'2000-01-32 00:00:00'`, err: `unable to parse datetime at line 1, char 1`},
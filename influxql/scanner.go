@@ -96,7 +96,13 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 	case ';':
 		return SEMICOLON, pos, ""
 	case ':':
+		if ch1, _ := s.r.read(); ch1 == ':' {
+			return DOUBLECOLON, pos, ""
+		}
+		s.r.unread()
 		return COLON, pos, ""
+	case '$':
+		return s.scanBoundParam()
 	}
 
 	return ILLEGAL, pos, string(ch0)
@@ -159,6 +165,28 @@ func (s *Scanner) scanIdent() (tok Token, pos Pos, lit string) {
 	return IDENT, pos, lit
 }
 
+// scanBoundParam consumes a bound parameter name such as $host or $"my host".
+// The literal includes the leading dollar sign.
+func (s *Scanner) scanBoundParam() (tok Token, pos Pos, lit string) {
+	_, pos = s.r.curr()
+
+	ch, _ := s.r.read()
+	switch {
+	case ch == '"':
+		tok0, pos0, lit0 := s.scanString()
+		if tok0 == BADSTRING || tok0 == BADESCAPE {
+			return tok0, pos0, lit0
+		}
+		return BOUNDPARAM, pos, "$" + lit0
+	case isIdentFirstChar(ch):
+		s.r.unread()
+		return BOUNDPARAM, pos, "$" + ScanBareIdent(s.r)
+	default:
+		s.r.unread()
+		return ILLEGAL, pos, "$"
+	}
+}
+
 // scanString consumes a contiguous string of non-quote characters.
 // Quote characters can be consumed if they're first escaped with a backslash.
 func (s *Scanner) scanString() (tok Token, pos Pos, lit string) {
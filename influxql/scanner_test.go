@@ -53,6 +53,8 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `(`, tok: influxql.LPAREN},
 		{s: `)`, tok: influxql.RPAREN},
 		{s: `,`, tok: influxql.COMMA},
+		{s: `:`, tok: influxql.COLON},
+		{s: `::`, tok: influxql.DOUBLECOLON},
 		{s: `;`, tok: influxql.SEMICOLON},
 		{s: `.`, tok: influxql.DOT},
 		{s: `=~`, tok: influxql.EQREGEX},
@@ -105,6 +107,12 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `10w`, tok: influxql.DURATION_VAL, lit: `10w`},
 		{s: `10x`, tok: influxql.NUMBER, lit: `10`}, // non-duration unit
 
+		// Bound parameters
+		{s: `$host`, tok: influxql.BOUNDPARAM, lit: `$host`},
+		{s: `$"my host"`, tok: influxql.BOUNDPARAM, lit: `$my host`},
+		{s: `$select`, tok: influxql.BOUNDPARAM, lit: `$select`},
+		{s: `$ host`, tok: influxql.ILLEGAL, lit: `$`},
+
 		// Keywords
 		{s: `ALL`, tok: influxql.ALL},
 		{s: `ALTER`, tok: influxql.ALTER},
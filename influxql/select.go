@@ -14,6 +14,9 @@ type SelectOptions struct {
 
 	// The upper bound for a select call.
 	MaxTime time.Time
+
+	// Closed when the query is interrupted. Passed to every iterator.
+	InterruptCh <-chan struct{}
 }
 
 // Select executes stmt against ic and returns a list of iterators to stream from.
@@ -21,28 +24,73 @@ type SelectOptions struct {
 // Statements should have all rewriting performed before calling select(). This
 // includes wildcard and source expansion.
 func Select(stmt *SelectStatement, ic IteratorCreator, sopt *SelectOptions) ([]Iterator, error) {
-	// Determine base options for iterators.
-	opt, err := newIteratorOptionsStmt(stmt, sopt)
+	fields, opt, aux, err := selectFields(stmt, sopt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Results sorted by a field are limited after they are sorted instead of per series.
+	sortField := stmt.ValueSortField()
+	sortIndex, sortOpt := -1, opt
+	if sortField != nil {
+		if sortIndex = fields.index(sortField.Name); sortIndex == -1 {
+			return nil, fmt.Errorf("unknown field in ORDER BY: %s", sortField.Name)
+		}
+		opt.Limit, opt.Offset = 0, 0
+	}
+
+	var itrs []Iterator
+	if join := newJoinInfo(stmt); join != nil {
+		// Fields qualified by measurement are joined on time and tag set.
+		itrs, err = buildJoinIterators(fields, ic, opt, join)
+	} else if aux {
+		// If there are multiple auxilary fields and no calls then construct an aux iterator.
+		itrs, err = buildAuxIterators(fields, ic, opt)
+	} else {
+		itrs, err = buildFieldIterators(fields, ic, opt)
+	}
+	if err != nil || sortField == nil {
+		return itrs, err
+	}
+
+	sorted, err := newRowSortIterators(itrs, sortIndex, sortField.Ascending, sortOpt)
 	if err != nil {
+		Iterators(itrs).Close()
 		return nil, err
 	}
+	return sorted, nil
+}
+
+// selectFields determines the fields and base iterator options for stmt.
+// Returns aux as true if all fields can be read from a single auxiliary iterator.
+func selectFields(stmt *SelectStatement, sopt *SelectOptions) (fields Fields, opt IteratorOptions, aux bool, err error) {
+	// Determine base options for iterators.
+	opt, err = newIteratorOptionsStmt(stmt, sopt)
+	if err != nil {
+		return nil, opt, false, err
+	}
 
 	// Retrieve refs for each call and var ref.
 	info := newSelectInfo(stmt)
 	if len(info.calls) > 1 && len(info.refs) > 0 {
-		return nil, errors.New("cannot select fields when selecting multiple aggregates")
+		return nil, opt, false, errors.New("cannot select fields when selecting multiple aggregates")
 	}
 
 	// Determine auxiliary fields to be selected.
-	opt.Aux = make([]string, 0, len(info.refs))
+	// References to the same field are only read once.
+	opt.Aux = make([]VarRef, 0, len(info.refs))
+	seen := make(map[VarRef]struct{}, len(info.refs))
 	for ref := range info.refs {
-		opt.Aux = append(opt.Aux, ref.Val)
+		if _, ok := seen[*ref]; ok {
+			continue
+		}
+		seen[*ref] = struct{}{}
+		opt.Aux = append(opt.Aux, *ref)
 	}
-	sort.Strings(opt.Aux)
+	sort.Sort(VarRefs(opt.Aux))
 
-	// If there are multiple auxilary fields and no calls then construct an aux iterator.
 	if len(info.calls) == 0 && len(info.refs) > 0 {
-		return buildAuxIterators(stmt.Fields, ic, opt)
+		return stmt.Fields, opt, true, nil
 	}
 
 	// Include auxiliary fields from top() and bottom()
@@ -51,13 +99,13 @@ func Select(stmt *SelectStatement, ic IteratorCreator, sopt *SelectOptions) ([]I
 		if call.Name == "top" || call.Name == "bottom" {
 			for i := 1; i < len(call.Args)-1; i++ {
 				ref := call.Args[i].(*VarRef)
-				opt.Aux = append(opt.Aux, ref.Val)
+				opt.Aux = append(opt.Aux, *ref)
 				extraFields++
 			}
 		}
 	}
 
-	fields := stmt.Fields
+	fields = stmt.Fields
 	if extraFields > 0 {
 		// Rebuild the list of fields if any extra fields are being implicitly added
 		fields = make([]*Field, 0, len(stmt.Fields)+extraFields)
@@ -73,8 +121,7 @@ func Select(stmt *SelectStatement, ic IteratorCreator, sopt *SelectOptions) ([]I
 			}
 		}
 	}
-
-	return buildFieldIterators(fields, ic, opt)
+	return fields, opt, false, nil
 }
 
 // buildAuxIterators creates a set of iterators from a single combined auxilary iterator.
@@ -110,8 +157,8 @@ func buildAuxIterators(fields Fields, ic IteratorCreator, opt IteratorOptions) (
 		expr := Reduce(f.Expr, nil)
 		switch expr := expr.(type) {
 		case *VarRef:
-			itrs[i] = aitr.Iterator(expr.Val)
-		case *BinaryExpr:
+			itrs[i] = aitr.Iterator(expr.Val, expr.Type)
+		case *BinaryExpr, *Call:
 			itr, err := buildExprIterator(expr, aitr, opt)
 			if err != nil {
 				return nil, fmt.Errorf("error constructing iterator for field '%s': %s", f.String(), err)
@@ -207,6 +254,11 @@ func buildExprIterator(expr Expr, ic IteratorCreator, opt IteratorOptions) (Iter
 	case *VarRef:
 		return ic.CreateIterator(opt)
 	case *Call:
+		// String functions are applied to each point of their inputs.
+		if IsStringFunction(expr.Name) {
+			return buildStringFunctionIterator(expr, ic, opt)
+		}
+
 		// FIXME(benbjohnson): Validate that only calls with 1 arg are passed to IC.
 
 		var err error
@@ -228,7 +280,7 @@ func buildExprIterator(expr Expr, ic IteratorCreator, opt IteratorOptions) (Iter
 		case "min", "max", "sum", "first", "last":
 			itr, err = ic.CreateIterator(opt)
 		case "distinct":
-			input, err := buildExprIterator(expr.Args[0].(*VarRef), ic, opt)
+			input, err := buildExprIterator(expr.Args[0], ic, opt)
 			if err != nil {
 				return nil, err
 			}
@@ -268,8 +320,8 @@ func buildExprIterator(expr Expr, ic IteratorCreator, opt IteratorOptions) (Iter
 				// This section is O(n^2), but for what should be a low value.
 				for i := 1; i < len(expr.Args)-1; i++ {
 					ref := expr.Args[i].(*VarRef)
-					for index, name := range opt.Aux {
-						if name == ref.Val {
+					for index, aux := range opt.Aux {
+						if aux == *ref {
 							tags = append(tags, index)
 							break
 						}
@@ -292,8 +344,8 @@ func buildExprIterator(expr Expr, ic IteratorCreator, opt IteratorOptions) (Iter
 				// This section is O(n^2), but for what should be a low value.
 				for i := 1; i < len(expr.Args)-1; i++ {
 					ref := expr.Args[i].(*VarRef)
-					for index, name := range opt.Aux {
-						if name == ref.Val {
+					for index, aux := range opt.Aux {
+						if aux == *ref {
 							tags = append(tags, index)
 							break
 						}
@@ -586,6 +638,10 @@ func buildTransformIterator(lhs Iterator, rhs Iterator, op Token, ic IteratorCre
 				if p2 == nil {
 					return nil
 				}
+				if p.Nil || p2.Nil {
+					p.Nil = true
+					return p
+				}
 				p.Value = fn(p.Value, p2.Value)
 				return p
 			},
@@ -609,6 +665,10 @@ func buildTransformIterator(lhs Iterator, rhs Iterator, op Token, ic IteratorCre
 				if p2 == nil {
 					return nil
 				}
+				if p.Nil || p2.Nil {
+					p.Nil = true
+					return p
+				}
 				p.Value = fn(p.Value, p2.Value)
 				return p
 			},
@@ -632,6 +692,9 @@ func buildTransformIterator(lhs Iterator, rhs Iterator, op Token, ic IteratorCre
 				if p2 == nil {
 					return nil
 				}
+				if p.Nil || p2.Nil {
+					return &BooleanPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Nil: true, Aux: p.Aux}
+				}
 				return &BooleanPoint{
 					Name:  p.Name,
 					Tags:  p.Tags,
@@ -660,6 +723,9 @@ func buildTransformIterator(lhs Iterator, rhs Iterator, op Token, ic IteratorCre
 				if p2 == nil {
 					return nil
 				}
+				if p.Nil || p2.Nil {
+					return &BooleanPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Nil: true, Aux: p.Aux}
+				}
 				return &BooleanPoint{
 					Name:  p.Name,
 					Tags:  p.Tags,
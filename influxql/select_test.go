@@ -45,6 +45,39 @@ func TestSelect_Min(t *testing.T) {
 	}
 }
 
+// Ensure a SELECT query stops reducing points once it has been interrupted.
+func TestSelect_Interrupt(t *testing.T) {
+	closing := make(chan struct{})
+	close(closing)
+
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if opt.InterruptCh == nil {
+			t.Fatal("expected interrupt channel")
+		}
+		input := &FloatIterator{Points: []influxql.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 20},
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 11 * Second, Value: 3},
+		}}
+		if _, ok := opt.Expr.(*influxql.Call); ok {
+			return influxql.NewCallIterator(input, opt), nil
+		}
+		return input, nil
+	}
+
+	for _, s := range []string{
+		`SELECT min(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z' GROUP BY time(10s) fill(none)`,
+		`SELECT median(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z' GROUP BY time(10s) fill(none)`,
+	} {
+		itrs, err := influxql.Select(MustParseSelectStatement(s), &ic, &influxql.SelectOptions{InterruptCh: closing})
+		if err != nil {
+			t.Fatal(err)
+		} else if a := Iterators(itrs).ReadAll(); len(a) != 0 {
+			t.Fatalf("%s: unexpected points: %s", s, spew.Sdump(a))
+		}
+	}
+}
+
 // Ensure a SELECT distinct() query can be executed.
 func TestSelect_Distinct_Float(t *testing.T) {
 	var ic IteratorCreator
@@ -793,6 +826,32 @@ func TestSelect_Fill_Null_Float(t *testing.T) {
 	}
 }
 
+// Ensure a SELECT query with a fill(null) statement can be executed in descending order.
+func TestSelect_Fill_Null_Float_Descending(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		return &FloatIterator{Points: []influxql.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 32 * Second, Value: 3},
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 12 * Second, Value: 2},
+		}}, nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT mean(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:00Z' GROUP BY host, time(10s) fill(null) ORDER BY time DESC`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 50 * Second, Nil: true}},
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 40 * Second, Nil: true}},
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 30 * Second, Value: 3}},
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 20 * Second, Nil: true}},
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 10 * Second, Value: 2}},
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Nil: true}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
 // Ensure a SELECT query with a fill(<number>) statement can be executed.
 func TestSelect_Fill_Number_Float(t *testing.T) {
 	var ic IteratorCreator
@@ -1103,7 +1162,7 @@ func TestSelect_Raw(t *testing.T) {
 	// Mock two iterators -- one for each value in the query.
 	var ic IteratorCreator
 	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
-		if !reflect.DeepEqual(opt.Aux, []string{"v1", "v2"}) {
+		if !reflect.DeepEqual(opt.Aux, []influxql.VarRef{{Val: "v1"}, {Val: "v2"}}) {
 			t.Fatalf("unexpected options: %s", spew.Sdump(opt.Expr))
 
 		}
@@ -1136,6 +1195,196 @@ func TestSelect_Raw(t *testing.T) {
 	}
 }
 
+// Ensure a raw SELECT statement converts the values of fields with a type cast.
+func TestSelect_Raw_Cast(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if !reflect.DeepEqual(opt.Aux, []influxql.VarRef{
+			{Val: "v1", Type: influxql.Integer},
+			{Val: "v2", Type: influxql.Float},
+			{Val: "v3", Type: influxql.String},
+		}) {
+			t.Fatalf("unexpected options: %s", spew.Sdump(opt.Aux))
+		}
+		return &FloatIterator{Points: []influxql.FloatPoint{
+			{Time: 0, Aux: []interface{}{float64(1.5), int64(2), "a"}},
+			{Time: 1, Aux: []interface{}{float64(3), int64(4), float64(5)}},
+		}}, nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT v1::integer, v2::float, v3::string FROM cpu`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{
+			&influxql.IntegerPoint{Time: 0, Value: 1},
+			&influxql.FloatPoint{Time: 0, Value: 2},
+			&influxql.StringPoint{Time: 0, Value: "a"},
+		},
+		{
+			&influxql.IntegerPoint{Time: 1, Value: 3},
+			&influxql.FloatPoint{Time: 1, Value: 4},
+			&influxql.StringPoint{Time: 1, Nil: true},
+		},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure a raw SELECT statement can apply string functions to fields.
+func TestSelect_Raw_StringFunctions(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		return &StringIterator{Points: []influxql.StringPoint{
+			{Time: 0, Aux: []interface{}{"code=200", "GET"}},
+			{Time: 1, Aux: []interface{}{"timeout", "Post"}},
+		}}, nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT upper(method), strlen(message), regex_extract(message, /code=(\d+)/, 1), concat(lower(method), ' ', message) FROM http`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{
+			&influxql.StringPoint{Time: 0, Value: "GET"},
+			&influxql.IntegerPoint{Time: 0, Value: 8},
+			&influxql.StringPoint{Time: 0, Value: "200"},
+			&influxql.StringPoint{Time: 0, Value: "get code=200"},
+		},
+		{
+			&influxql.StringPoint{Time: 1, Value: "POST"},
+			&influxql.IntegerPoint{Time: 1, Value: 7},
+			&influxql.StringPoint{Time: 1, Nil: true},
+			&influxql.StringPoint{Time: 1, Value: "post timeout"},
+		},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the distinct results of a string function can be counted.
+func TestSelect_CountDistinct_StringFunction(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if ref, ok := opt.Expr.(*influxql.VarRef); !ok || ref.Val != "level" {
+			t.Fatalf("unexpected expr: %s", spew.Sdump(opt.Expr))
+		}
+		return &StringIterator{Points: []influxql.StringPoint{
+			{Name: "logs", Time: 0 * Second, Value: "ERROR"},
+			{Name: "logs", Time: 1 * Second, Value: "error"},
+			{Name: "logs", Time: 2 * Second, Value: "Warn"},
+			{Name: "logs", Time: 3 * Second, Nil: true},
+			{Name: "logs", Time: 11 * Second, Value: "info"},
+		}}, nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT count(distinct(lower(level))) FROM logs WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z' GROUP BY time(10s)`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.IntegerPoint{Name: "logs", Time: 0 * Second, Value: 2}},
+		{&influxql.IntegerPoint{Name: "logs", Time: 10 * Second, Value: 1}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the fields of a string function in an aggregate are read from the same point.
+func TestSelect_CountDistinct_StringFunction_MultipleFields(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if opt.Expr != nil {
+			t.Fatalf("unexpected expr: %s", spew.Sdump(opt.Expr))
+		} else if !reflect.DeepEqual(opt.Aux, []influxql.VarRef{{Val: "method"}, {Val: "path"}}) {
+			t.Fatalf("unexpected aux: %s", spew.Sdump(opt.Aux))
+		}
+		return &StringIterator{Points: []influxql.StringPoint{
+			{Name: "http", Tags: ParseTags("host=A"), Time: 0 * Second, Aux: []interface{}{"GET", "/a"}},
+			{Name: "http", Tags: ParseTags("host=A"), Time: 1 * Second, Aux: []interface{}{"POST", "/a"}},
+			{Name: "http", Tags: ParseTags("host=B"), Time: 0 * Second, Aux: []interface{}{"GET", "/a"}},
+			{Name: "http", Tags: ParseTags("host=B"), Time: 2 * Second, Aux: []interface{}{"GET", "/b"}},
+		}}, nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT count(distinct(concat(method, path))) FROM http WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:10Z' GROUP BY time(10s)`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.IntegerPoint{Name: "http", Time: 0 * Second, Value: 3}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure a SELECT query can be sorted by the value of an aggregate across series.
+func TestSelect_OrderBy_Aggregate(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		if opt.Limit != 0 {
+			t.Fatalf("unexpected limit: %d", opt.Limit)
+		}
+		return influxql.NewCallIterator(&FloatIterator{Points: []influxql.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 20},
+			{Name: "cpu", Tags: ParseTags("host=B"), Time: 0 * Second, Value: 50},
+			{Name: "cpu", Tags: ParseTags("host=C"), Time: 0 * Second, Value: 10},
+			{Name: "cpu", Tags: ParseTags("host=D"), Time: 0 * Second, Value: 40},
+		}}, opt), nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT max(value) FROM cpu GROUP BY host ORDER BY max DESC LIMIT 2 OFFSET 1`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=D"), Time: 0 * Second, Value: 40}},
+		{&influxql.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 20}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure rows are sorted by one field and rows without a value are returned last.
+func TestSelect_OrderBy_Raw(t *testing.T) {
+	var ic IteratorCreator
+	ic.CreateIteratorFn = func(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+		return &FloatIterator{Points: []influxql.FloatPoint{
+			{Time: 0 * Second, Aux: []interface{}{float64(1), float64(2)}},
+			{Time: 5 * Second, Aux: []interface{}{nil, float64(4)}},
+			{Time: 9 * Second, Aux: []interface{}{float64(3), float64(6)}},
+			{Time: 12 * Second, Aux: []interface{}{float64(3), float64(8)}},
+		}}, nil
+	}
+
+	// Execute selection.
+	itrs, err := influxql.Select(MustParseSelectStatement(`SELECT v1, v2 FROM cpu ORDER BY v1 DESC`), &ic, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if a := Iterators(itrs).ReadAll(); !deep.Equal(a, [][]influxql.Point{
+		{
+			&influxql.FloatPoint{Time: 9 * Second, Value: 3},
+			&influxql.FloatPoint{Time: 9 * Second, Value: 6},
+		},
+		{
+			&influxql.FloatPoint{Time: 12 * Second, Value: 3},
+			&influxql.FloatPoint{Time: 12 * Second, Value: 8},
+		},
+		{
+			&influxql.FloatPoint{Time: 0 * Second, Value: 1},
+			&influxql.FloatPoint{Time: 0 * Second, Value: 2},
+		},
+		{
+			&influxql.FloatPoint{Time: 5 * Second, Nil: true},
+			&influxql.FloatPoint{Time: 5 * Second, Value: 4},
+		},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
 // Ensure a SELECT binary expr queries can be executed as floats.
 func TestSelect_BinaryExpr_Float(t *testing.T) {
 	var ic IteratorCreator
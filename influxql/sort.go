@@ -0,0 +1,281 @@
+package influxql
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// sortRow represents the points read from each input at a single name, tag set and time.
+type sortRow struct {
+	name   string
+	tags   Tags
+	time   int64
+	points []Point
+
+	// Position of the row in the input. Used to keep the input order of
+	// rows with equal values.
+	seq int
+}
+
+// value returns the value of the point read from input i.
+// Returns nil if the input had no point or the point is nil.
+func (r *sortRow) value(i int) interface{} {
+	if r.points[i] == nil {
+		return nil
+	}
+	return r.points[i].value()
+}
+
+// rowSorter reads rows from a set of iterators and orders them by the value
+// of a single input. If a limit is set then only the rows that will be returned
+// are kept in memory.
+type rowSorter struct {
+	inputs    []Iterator
+	bufs      []Point
+	index     int
+	ascending bool
+	opt       IteratorOptions
+
+	rows   []*sortRow
+	loaded bool
+	closed bool
+}
+
+// newRowSortIterators returns an iterator for each input which returns the
+// rows of all inputs sorted by the values of inputs[index]. The limit and
+// offset of opt are applied to the sorted rows.
+func newRowSortIterators(inputs []Iterator, index int, ascending bool, opt IteratorOptions) ([]Iterator, error) {
+	s := &rowSorter{
+		inputs:    inputs,
+		bufs:      make([]Point, len(inputs)),
+		index:     index,
+		ascending: ascending,
+		opt:       opt,
+	}
+
+	itrs := make([]Iterator, len(inputs))
+	for i, input := range inputs {
+		switch input.(type) {
+		case FloatIterator:
+			itrs[i] = &floatRowSortIterator{sort: s, i: i}
+		case IntegerIterator:
+			itrs[i] = &integerRowSortIterator{sort: s, i: i}
+		case StringIterator:
+			itrs[i] = &stringRowSortIterator{sort: s, i: i}
+		case BooleanIterator:
+			itrs[i] = &booleanRowSortIterator{sort: s, i: i}
+		default:
+			return nil, fmt.Errorf("unsupported sort iterator type: %T", input)
+		}
+	}
+	return itrs, nil
+}
+
+// Close closes all inputs.
+func (s *rowSorter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.rows = nil
+	return Iterators(s.inputs).Close()
+}
+
+// row returns the n-th sorted row. Returns nil once all rows have been read.
+func (s *rowSorter) row(n int) *sortRow {
+	if !s.loaded {
+		s.load()
+		s.loaded = true
+	}
+
+	if n >= len(s.rows) {
+		return nil
+	}
+	return s.rows[n]
+}
+
+// load reads all rows from the inputs and sorts them.
+func (s *rowSorter) load() {
+	// Keep the rows in a heap with the last row at the top so the heap
+	// can be bounded by the limit.
+	h := &sortRowHeap{sorter: s}
+	max := 0
+	if s.opt.Limit > 0 {
+		max = s.opt.Limit + s.opt.Offset
+	}
+
+	for seq := 0; ; seq++ {
+		row := s.readRow()
+		if row == nil {
+			break
+		}
+		row.seq = seq
+
+		if max == 0 || h.Len() < max {
+			heap.Push(h, row)
+		} else if s.less(row, h.rows[0]) {
+			h.rows[0] = row
+			heap.Fix(h, 0)
+		}
+	}
+
+	rows := h.rows
+	sort.Sort(sortRows{rows: rows, sorter: s})
+
+	// Apply the offset to the sorted rows.
+	if s.opt.Offset >= len(rows) {
+		rows = nil
+	} else {
+		rows = rows[s.opt.Offset:]
+	}
+	s.rows = rows
+}
+
+// readRow reads the next set of points with the same name, tag set and time.
+// Returns nil once all inputs are exhausted.
+func (s *rowSorter) readRow() *sortRow {
+	var key Point
+	for i, input := range s.inputs {
+		if s.bufs[i] == nil {
+			s.bufs[i] = readPoint(input)
+		}
+		if p := s.bufs[i]; p != nil && (key == nil || s.before(p, key)) {
+			key = p
+		}
+	}
+	if key == nil {
+		return nil
+	}
+
+	row := &sortRow{
+		name:   key.name(),
+		tags:   key.tags(),
+		time:   key.time(),
+		points: make([]Point, len(s.inputs)),
+	}
+	for i, p := range s.bufs {
+		if p == nil {
+			continue
+		}
+		tags := p.tags()
+		if p.name() == row.name && tags.Equals(&row.tags) && p.time() == row.time {
+			row.points[i] = p
+			s.bufs[i] = nil
+		}
+	}
+	return row
+}
+
+// before returns true if p is read before other from an input iterator.
+// Iterators return each series in turn, ordered by time.
+func (s *rowSorter) before(p, other Point) bool {
+	if p.name() != other.name() {
+		return p.name() < other.name()
+	} else if id, otherID := p.tags().ID(), other.tags().ID(); id != otherID {
+		return id < otherID
+	} else if s.opt.Ascending {
+		return p.time() < other.time()
+	}
+	return p.time() > other.time()
+}
+
+// less returns true if row a is sorted before row b. Rows without a value
+// are always sorted last and rows with equal values keep their input order.
+func (s *rowSorter) less(a, b *sortRow) bool {
+	av, bv := a.value(s.index), b.value(s.index)
+	if av == nil || bv == nil {
+		if (av == nil) != (bv == nil) {
+			return bv == nil
+		}
+		return a.seq < b.seq
+	}
+
+	if cmp := compareValues(av, bv); cmp != 0 {
+		if s.ascending {
+			return cmp < 0
+		}
+		return cmp > 0
+	}
+	return a.seq < b.seq
+}
+
+// compareValues returns -1, 0 or 1 if a is less than, equal to or greater than b.
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		switch b := b.(type) {
+		case float64:
+			return compareFloats(a, b)
+		case int64:
+			return compareFloats(a, float64(b))
+		}
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return compareFloats(float64(a), float64(b))
+		case float64:
+			return compareFloats(float64(a), b)
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			if a < b {
+				return -1
+			} else if a > b {
+				return 1
+			}
+			return 0
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0
+			} else if !a {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// sortRows sorts rows in the order they are returned by a rowSorter.
+type sortRows struct {
+	rows   []*sortRow
+	sorter *rowSorter
+}
+
+func (a sortRows) Len() int           { return len(a.rows) }
+func (a sortRows) Less(i, j int) bool { return a.sorter.less(a.rows[i], a.rows[j]) }
+func (a sortRows) Swap(i, j int)      { a.rows[i], a.rows[j] = a.rows[j], a.rows[i] }
+
+// sortRowHeap is a heap of rows with the last sorted row at the top.
+type sortRowHeap struct {
+	rows   []*sortRow
+	sorter *rowSorter
+}
+
+func (h sortRowHeap) Len() int           { return len(h.rows) }
+func (h sortRowHeap) Less(i, j int) bool { return h.sorter.less(h.rows[j], h.rows[i]) }
+func (h sortRowHeap) Swap(i, j int)      { h.rows[i], h.rows[j] = h.rows[j], h.rows[i] }
+
+func (h *sortRowHeap) Push(x interface{}) {
+	h.rows = append(h.rows, x.(*sortRow))
+}
+
+func (h *sortRowHeap) Pop() interface{} {
+	old := h.rows
+	n := len(old)
+	row := old[n-1]
+	h.rows = old[0 : n-1]
+	return row
+}
@@ -25,6 +25,7 @@ const (
 	FALSE        // false
 	REGEX        // Regular expressions
 	BADREGEX     // `.*
+	BOUNDPARAM   // $param
 	literal_end
 
 	operator_beg
@@ -47,12 +48,13 @@ const (
 	GTE      // >=
 	operator_end
 
-	LPAREN    // (
-	RPAREN    // )
-	COMMA     // ,
-	COLON     // :
-	SEMICOLON // ;
-	DOT       // .
+	LPAREN      // (
+	RPAREN      // )
+	COMMA       // ,
+	COLON       // :
+	DOUBLECOLON // ::
+	SEMICOLON   // ;
+	DOT         // .
 
 	keyword_beg
 	// Keywords
@@ -154,6 +156,7 @@ var tokens = [...]string{
 	TRUE:         "TRUE",
 	FALSE:        "FALSE",
 	REGEX:        "REGEX",
+	BOUNDPARAM:   "BOUNDPARAM",
 
 	ADD: "+",
 	SUB: "-",
@@ -172,12 +175,13 @@ var tokens = [...]string{
 	GT:       ">",
 	GTE:      ">=",
 
-	LPAREN:    "(",
-	RPAREN:    ")",
-	COMMA:     ",",
-	COLON:     ":",
-	SEMICOLON: ";",
-	DOT:       ".",
+	LPAREN:      "(",
+	RPAREN:      ")",
+	COMMA:       ",",
+	COLON:       ":",
+	DOUBLECOLON: "::",
+	SEMICOLON:   ";",
+	DOT:         ".",
 
 	ALL:           "ALL",
 	ALTER:         "ALTER",
@@ -0,0 +1,201 @@
+// Package hll implements the HyperLogLog cardinality estimator.
+//
+// A sketch starts in a sparse representation which only stores the registers
+// that have been set, so sketches of small sets use little memory. Once the
+// sparse representation would use more memory than the registers themselves
+// it is converted to a dense representation.
+//
+// Two sketches with the same precision can be merged. The estimate of a merged
+// sketch is the estimate of the union of both sets, so values added to both
+// sketches are only counted once.
+package hll
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	// MinPrecision and MaxPrecision are the bounds of the sketch precision.
+	MinPrecision = 4
+	MaxPrecision = 18
+
+	// DefaultPrecision uses 16KB per dense sketch with a standard error of ~0.8%.
+	DefaultPrecision = 14
+)
+
+// ErrPrecisionMismatch is returned when merging sketches with different precisions.
+var ErrPrecisionMismatch = errors.New("hll: cannot merge sketches with different precisions")
+
+// Plus is a HyperLogLog sketch. It is not safe for concurrent use.
+type Plus struct {
+	p uint8  // precision
+	m uint32 // number of registers
+
+	sparse    map[uint32]uint8
+	registers []uint8
+}
+
+// NewPlus returns a new sketch with the given precision.
+func NewPlus(p uint8) (*Plus, error) {
+	if p < MinPrecision || p > MaxPrecision {
+		return nil, errors.New("hll: precision must be between 4 and 18")
+	}
+	return &Plus{
+		p:      p,
+		m:      1 << p,
+		sparse: make(map[uint32]uint8),
+	}, nil
+}
+
+// NewDefaultPlus returns a new sketch with the default precision.
+func NewDefaultPlus() *Plus {
+	h, _ := NewPlus(DefaultPrecision)
+	return h
+}
+
+// Add adds a value to the sketch.
+func (h *Plus) Add(v []byte) {
+	x := hash(v)
+
+	// The first p bits select the register and the position of the first set
+	// bit in the remaining bits determines its rank.
+	i := uint32(x >> (64 - h.p))
+	w := x<<h.p | 1<<(h.p-1)
+	h.set(i, clz(w)+1)
+}
+
+// set sets register i to rank if it is higher than the current value.
+func (h *Plus) set(i uint32, rank uint8) {
+	if h.registers != nil {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+		return
+	}
+
+	if rank > h.sparse[i] {
+		h.sparse[i] = rank
+	}
+
+	// Switch to the dense representation once the map would be larger.
+	if uint32(len(h.sparse)) > h.m/8 {
+		h.toDense()
+	}
+}
+
+// toDense converts the sketch to the dense representation.
+func (h *Plus) toDense() {
+	h.registers = make([]uint8, h.m)
+	for i, rank := range h.sparse {
+		h.registers[i] = rank
+	}
+	h.sparse = nil
+}
+
+// Merge adds the values of other into the sketch.
+func (h *Plus) Merge(other *Plus) error {
+	if h.p != other.p {
+		return ErrPrecisionMismatch
+	}
+
+	if other.registers == nil {
+		for i, rank := range other.sparse {
+			h.set(i, rank)
+		}
+		return nil
+	}
+
+	if h.registers == nil {
+		h.toDense()
+	}
+	for i, rank := range other.registers {
+		if rank > h.registers[i] {
+			h.registers[i] = rank
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the sketch.
+func (h *Plus) Clone() *Plus {
+	other := &Plus{p: h.p, m: h.m}
+	if h.registers != nil {
+		other.registers = make([]uint8, len(h.registers))
+		copy(other.registers, h.registers)
+		return other
+	}
+
+	other.sparse = make(map[uint32]uint8, len(h.sparse))
+	for i, rank := range h.sparse {
+		other.sparse[i] = rank
+	}
+	return other
+}
+
+// Count returns the estimated number of distinct values added to the sketch.
+func (h *Plus) Count() uint64 {
+	m := float64(h.m)
+
+	var sum float64
+	var zeros uint32
+	if h.registers != nil {
+		for _, rank := range h.registers {
+			if rank == 0 {
+				zeros++
+			}
+			sum += 1 / float64(uint64(1)<<rank)
+		}
+	} else {
+		zeros = h.m - uint32(len(h.sparse))
+		sum = float64(zeros)
+		for _, rank := range h.sparse {
+			sum += 1 / float64(uint64(1)<<rank)
+		}
+	}
+
+	// Use linear counting for small cardinalities where the raw estimate is biased.
+	estimate := alpha(h.m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// alpha returns the bias correction constant for m registers.
+func alpha(m uint32) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(m))
+}
+
+// hash returns a 64-bit hash of v. The FNV hash is finalized with the
+// MurmurHash3 mixer so that all bits are well distributed.
+func hash(v []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(v)
+	x := h.Sum64()
+
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// clz returns the number of leading zero bits in x.
+func clz(x uint64) uint8 {
+	var n uint8
+	for i := 63; i >= 0 && x&(1<<uint(i)) == 0; i-- {
+		n++
+	}
+	return n
+}
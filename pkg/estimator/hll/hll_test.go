@@ -0,0 +1,103 @@
+package hll_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/influxdata/influxdb/pkg/estimator/hll"
+)
+
+// Ensure the sketch estimates the cardinality within the expected error.
+func TestPlus_Count(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 100, 1000, 10000, 100000, 500000} {
+		h := hll.NewDefaultPlus()
+		for i := 0; i < n; i++ {
+			h.Add([]byte(fmt.Sprintf("cpu,host=server%d", i)))
+		}
+
+		// Add every value again to ensure duplicates are not counted.
+		for i := 0; i < n; i++ {
+			h.Add([]byte(fmt.Sprintf("cpu,host=server%d", i)))
+		}
+
+		if err := relativeError(h.Count(), n); err > 0.03 {
+			t.Errorf("n=%d: unexpected count: %d (error %.3f)", n, h.Count(), err)
+		}
+	}
+}
+
+// Ensure merged sketches only count values added to both once.
+func TestPlus_Merge(t *testing.T) {
+	a, b := hll.NewDefaultPlus(), hll.NewDefaultPlus()
+	for i := 0; i < 20000; i++ {
+		a.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+	for i := 10000; i < 30000; i++ {
+		b.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+
+	merged := a.Clone()
+	if err := merged.Merge(b); err != nil {
+		t.Fatal(err)
+	} else if err := relativeError(merged.Count(), 30000); err > 0.03 {
+		t.Fatalf("unexpected count: %d", merged.Count())
+	}
+
+	// Ensure the original sketch was not modified.
+	if err := relativeError(a.Count(), 20000); err > 0.03 {
+		t.Fatalf("unexpected count: %d", a.Count())
+	}
+}
+
+// Ensure a sparse sketch can be merged into a dense sketch and back.
+func TestPlus_Merge_Sparse(t *testing.T) {
+	sparse, dense := hll.NewDefaultPlus(), hll.NewDefaultPlus()
+	for i := 0; i < 10; i++ {
+		sparse.Add([]byte(fmt.Sprintf("sparse%d", i)))
+	}
+	for i := 0; i < 50000; i++ {
+		dense.Add([]byte(fmt.Sprintf("dense%d", i)))
+	}
+
+	a := sparse.Clone()
+	if err := a.Merge(dense); err != nil {
+		t.Fatal(err)
+	}
+	b := dense.Clone()
+	if err := b.Merge(sparse); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Count() != b.Count() {
+		t.Fatalf("counts differ: %d != %d", a.Count(), b.Count())
+	} else if err := relativeError(a.Count(), 50010); err > 0.03 {
+		t.Fatalf("unexpected count: %d", a.Count())
+	}
+}
+
+// Ensure sketches with different precisions cannot be merged.
+func TestPlus_Merge_ErrPrecisionMismatch(t *testing.T) {
+	a, _ := hll.NewPlus(10)
+	b, _ := hll.NewPlus(12)
+	if err := a.Merge(b); err != hll.ErrPrecisionMismatch {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure an invalid precision returns an error.
+func TestNewPlus_ErrInvalidPrecision(t *testing.T) {
+	if _, err := hll.NewPlus(3); err == nil {
+		t.Fatal("expected error")
+	} else if _, err := hll.NewPlus(19); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// relativeError returns the error of an estimate relative to the actual value.
+func relativeError(estimate uint64, actual int) float64 {
+	if actual == 0 {
+		return float64(estimate)
+	}
+	return math.Abs(float64(estimate)-float64(actual)) / float64(actual)
+}
@@ -0,0 +1,71 @@
+package antientropy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/tcp"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// Client provides an API for the anti-entropy service.
+type Client struct {
+	host string
+
+	// Dialer secures the connection to the server. Plain if nil.
+	Dialer *tcp.Dialer
+}
+
+// NewClient returns a new instance of Client.
+func NewClient(host string) *Client {
+	return &Client{host: host}
+}
+
+// Digest returns the digest of a shard on the remote server limited by f.
+// The digest of the whole shard is returned if f is nil.
+func (c *Client) Digest(shardID uint64, f *Filter) (tsdb.Digest, error) {
+	resp, err := c.do(&Request{Type: RequestDigest, ShardID: shardID, Filter: f})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Digest, nil
+}
+
+// Points returns the values of the digest keys of a shard on the remote
+// server in the time range of f. Every value is returned if f is nil.
+func (c *Client) Points(shardID uint64, keys []string, f *Filter) ([]models.Point, error) {
+	resp, err := c.do(&Request{Type: RequestPoints, ShardID: shardID, Keys: keys, Filter: f})
+	if err != nil {
+		return nil, err
+	} else if len(resp.Points) == 0 {
+		return nil, nil
+	}
+	return models.ParsePointsString(strings.Join(resp.Points, "\n"))
+}
+
+// do sends a request to the remote server and returns its response.
+func (c *Client) do(req *Request) (*Response, error) {
+	// Connect to remote server.
+	conn, err := c.Dialer.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Send request to server.
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("write request: %s", err)
+	}
+
+	// Read response from the server.
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("read response: %s", err)
+	} else if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
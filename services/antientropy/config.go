@@ -0,0 +1,30 @@
+package antientropy
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the time between repairs if none is specified.
+	DefaultCheckInterval = 30 * time.Minute
+)
+
+// Config represents the configuration for anti-entropy repair.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	CheckInterval toml.Duration `toml:"check-interval"`
+
+	// ReadRepair writes the values a replica is missing back to it when a
+	// query reading multiple owners of a shard finds them.
+	ReadRepair bool `toml:"read-repair"`
+}
+
+// NewConfig returns a new Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:       false,
+		CheckInterval: toml.Duration(DefaultCheckInterval),
+	}
+}
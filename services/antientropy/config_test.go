@@ -0,0 +1,30 @@
+package antientropy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/services/antientropy"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c antientropy.Config
+	if _, err := toml.Decode(`
+enabled = true
+check-interval = "2m"
+read-repair = true
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if time.Duration(c.CheckInterval) != 2*time.Minute {
+		t.Fatalf("unexpected check interval: %s", c.CheckInterval)
+	} else if !c.ReadRepair {
+		t.Fatalf("unexpected read repair: %v", c.ReadRepair)
+	}
+}
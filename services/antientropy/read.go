@@ -0,0 +1,381 @@
+package antientropy
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/pkg/escape"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// ErrReadConsistencyNotMet is returned when fewer owners of a shard than the
+// read consistency level requires could be read.
+var ErrReadConsistencyNotMet = errors.New("read consistency not met")
+
+// keyFieldSeparator separates the series key from the field in digest keys.
+const keyFieldSeparator = "#!~#"
+
+// Filter limits a digest to the keys that may be read by a query and the
+// points of a shard to the query's time range.
+type Filter struct {
+	// Names are the measurements of the keys. Every measurement if empty.
+	Names []string
+
+	// Condition excludes the series it can't match. Only tag references
+	// are evaluated. Every series if empty.
+	Condition string
+
+	// MinTime and MaxTime are the inclusive time range of the values.
+	MinTime int64
+	MaxTime int64
+}
+
+// timeRange returns the time range of the filter. A nil filter has no limit.
+func (f *Filter) timeRange() (min, max int64) {
+	if f == nil {
+		return math.MinInt64, math.MaxInt64
+	}
+	return f.MinTime, f.MaxTime
+}
+
+// Digest returns the entries of d for the keys of the filter's measurements
+// that may match its condition and have values in its time range. A nil
+// filter returns d.
+func (f *Filter) Digest(d tsdb.Digest) (tsdb.Digest, error) {
+	if f == nil {
+		return d, nil
+	}
+
+	var cond influxql.Expr
+	if f.Condition != "" {
+		expr, err := influxql.ParseExpr(f.Condition)
+		if err != nil {
+			return nil, err
+		}
+		cond = expr
+	}
+
+	names := make(map[string]struct{}, len(f.Names))
+	for _, name := range f.Names {
+		names[name] = struct{}{}
+	}
+
+	other := make(tsdb.Digest)
+	for key, e := range d {
+		if e.MaxTime < f.MinTime || e.MinTime > f.MaxTime {
+			continue
+		} else if _, ok := names[measurementFromKey(key)]; !ok && len(names) > 0 {
+			continue
+		} else if cond != nil && !matchTags(cond, key) {
+			continue
+		}
+		other[key] = e
+	}
+	return other, nil
+}
+
+// matchTags returns false if cond is false for the tags of the series of a
+// digest key. References to fields and to tags the series doesn't have are
+// left unresolved so a series is only excluded if cond can't be true.
+func matchTags(cond influxql.Expr, key string) bool {
+	if i := strings.Index(key, keyFieldSeparator); i != -1 {
+		key = key[:i]
+	}
+	// ParseKey expects fields after the series key so only its tags are used.
+	_, tags, _ := models.ParseKey(key)
+
+	expr := influxql.Reduce(cond, tagValuer(tags))
+	if lit, ok := expr.(*influxql.BooleanLiteral); ok {
+		return lit.Val
+	}
+	return true
+}
+
+// tagValuer returns the values of the tags of a series.
+type tagValuer models.Tags
+
+// Value returns the value of the tag key.
+func (v tagValuer) Value(key string) (interface{}, bool) {
+	value, ok := v[key]
+	return value, ok
+}
+
+// replica is an owner of a shard read by a query.
+type replica struct {
+	nodeID uint64
+	digest tsdb.Digest
+
+	// shard is set for the local replica, client for the others.
+	shard  *tsdb.Shard
+	client *Client
+}
+
+// points returns the values of keys of shard id in the filter's time range
+// on the replica.
+func (r *replica) points(id uint64, keys []string, f *Filter) ([]models.Point, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	} else if r.shard != nil {
+		min, max := f.timeRange()
+		return r.shard.Points(keys, min, max)
+	}
+	return r.client.Points(id, keys, f)
+}
+
+// ReadShards reads the series of the sources that may match the condition
+// from as many owners of each shard as level requires and merges the
+// values in the time range by series, field and timestamp. A value is taken
+// from the first replica that has it, starting with the local replica, so
+// values that differ at the same timestamp are not merged. The returned set
+// holds the merged values that the local replicas don't have.
+//
+// Keys are read MaxKeysPerRequest at a time and only the values missing
+// locally are kept, so the values the replicas agree on aren't buffered.
+// Values a replica is missing in the time range are written back to it if
+// read repair is enabled.
+func (s *Service) ReadShards(shardIDs []uint64, opt tsdb.ShardReadOptions, level tsdb.ReadConsistency) (*tsdb.PointSet, error) {
+	f := &Filter{
+		Names:   opt.Sources.Names(),
+		MinTime: opt.MinTime.UnixNano(),
+		MaxTime: opt.MaxTime.UnixNano(),
+	}
+	if opt.Condition != nil {
+		f.Condition = opt.Condition.String()
+	}
+
+	set := tsdb.NewPointSet()
+	for _, id := range shardIDs {
+		if err := s.readShard(id, f, level, set); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// readShard adds the merged values of a shard the local replica doesn't have to set.
+func (s *Service) readShard(id uint64, f *Filter, level tsdb.ReadConsistency, set *tsdb.PointSet) error {
+	_, _, sgi := s.MetaClient.ShardOwner(id)
+	si := shardInfo(sgi, id)
+	if si == nil {
+		return nil
+	}
+
+	replicas, err := s.readDigests(id, si.Owners, f, level.Required(len(si.Owners)))
+	if err != nil {
+		return err
+	}
+
+	// Keys every replica agrees on are only read from the first replica,
+	// or not at all if it's the local one. Other keys are read from every
+	// replica that has them.
+	var agreed, mismatched []string
+	for _, key := range digestKeys(replicas) {
+		if !agree(replicas, key) {
+			mismatched = append(mismatched, key)
+		} else if replicas[0].shard == nil {
+			agreed = append(agreed, key)
+		}
+	}
+	s.statMap.Add(statReadMismatch, int64(len(mismatched)))
+
+	for i := 0; i < len(agreed); i += MaxKeysPerRequest {
+		points, err := replicas[0].points(id, batch(agreed, i), f)
+		if err != nil {
+			return err
+		}
+		set.Add(points)
+	}
+
+	for i := 0; i < len(mismatched); i += MaxKeysPerRequest {
+		if err := s.readMismatched(id, replicas, batch(mismatched, i), f, set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMismatched merges the values of keys the replicas disagree on and
+// adds the ones the local replica doesn't have to set.
+func (s *Service) readMismatched(id uint64, replicas []*replica, keys []string, f *Filter, set *tsdb.PointSet) error {
+	merged := make(map[pointKey]models.Point)
+	values := make([]map[pointKey]struct{}, len(replicas))
+	for i, r := range replicas {
+		var a []string
+		for _, key := range keys {
+			if _, ok := r.digest[key]; ok {
+				a = append(a, key)
+			}
+		}
+
+		points, err := r.points(id, a, f)
+		if err != nil {
+			return err
+		}
+
+		values[i] = make(map[pointKey]struct{}, len(points))
+		for _, p := range points {
+			for field := range p.Fields() {
+				k := pointKey{string(p.Key()), field, p.UnixNano()}
+				values[i][k] = struct{}{}
+				if _, ok := merged[k]; !ok {
+					merged[k] = p
+				}
+			}
+		}
+	}
+
+	if s.config.ReadRepair {
+		s.readRepair(id, replicas, values, merged)
+	}
+
+	var a []models.Point
+	for k, p := range merged {
+		if _, ok := values[0][k]; ok && replicas[0].shard != nil {
+			continue
+		}
+		a = append(a, p)
+	}
+	set.Add(a)
+	return nil
+}
+
+// batch returns the keys of a request starting at keys[i].
+func batch(keys []string, i int) []string {
+	keys = keys[i:]
+	if len(keys) > MaxKeysPerRequest {
+		keys = keys[:MaxKeysPerRequest]
+	}
+	return keys
+}
+
+// readDigests returns the replicas of a shard read for a query with their
+// digests limited by f. The local replica is read first. Returns
+// ErrReadConsistencyNotMet if fewer than required replicas could be read.
+func (s *Service) readDigests(id uint64, owners []meta.ShardOwner, f *Filter, required int) ([]*replica, error) {
+	var replicas []*replica
+	for _, owner := range owners {
+		if owner.NodeID != s.Node.ID {
+			continue
+		}
+
+		sh := s.TSDBStore.Shard(id)
+		if sh == nil {
+			break
+		}
+		d, err := sh.Digest()
+		if err != nil {
+			return nil, err
+		}
+		if d, err = f.Digest(d); err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, &replica{nodeID: owner.NodeID, digest: d, shard: sh})
+	}
+
+	for _, owner := range owners {
+		if len(replicas) >= required {
+			break
+		} else if owner.NodeID == s.Node.ID {
+			continue
+		}
+
+		c, err := s.client(owner.NodeID)
+		if err != nil {
+			s.Logger.Printf("failed to read shard %d from node %d: %s", id, owner.NodeID, err)
+			continue
+		}
+		d, err := c.Digest(id, f)
+		if err != nil {
+			s.Logger.Printf("failed to read shard %d from node %d: %s", id, owner.NodeID, err)
+			continue
+		}
+		replicas = append(replicas, &replica{nodeID: owner.NodeID, digest: d, client: c})
+	}
+
+	if len(replicas) == 0 || len(replicas) < required {
+		return nil, ErrReadConsistencyNotMet
+	}
+	return replicas, nil
+}
+
+// readRepair writes the merged values of mismatched keys to the replicas
+// that don't have them.
+func (s *Service) readRepair(id uint64, replicas []*replica, values []map[pointKey]struct{}, merged map[pointKey]models.Point) {
+	for i, r := range replicas {
+		var missing []models.Point
+		for k, p := range merged {
+			if _, ok := values[i][k]; !ok {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		var err error
+		if r.shard != nil {
+			err = s.TSDBStore.WriteToShard(id, missing)
+		} else {
+			err = s.ShardWriter.WriteShard(id, r.nodeID, missing)
+		}
+		if err != nil {
+			s.statMap.Add(statReadRepairFail, 1)
+			s.Logger.Printf("failed to read repair shard %d on node %d: %s", id, r.nodeID, err)
+			continue
+		}
+		s.statMap.Add(statReadRepairOK, 1)
+		s.statMap.Add(statPointsReadRepaired, int64(len(missing)))
+	}
+}
+
+// digestKeys returns the sorted keys of the digests of every replica.
+func digestKeys(replicas []*replica) []string {
+	m := make(map[string]struct{})
+	for _, r := range replicas {
+		for key := range r.digest {
+			m[key] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// agree returns true if every replica has the same digest entry for key.
+func agree(replicas []*replica, key string) bool {
+	e, ok := replicas[0].digest[key]
+	if !ok {
+		return false
+	}
+	for _, r := range replicas[1:] {
+		if other, ok := r.digest[key]; !ok || other != e {
+			return false
+		}
+	}
+	return true
+}
+
+// measurementFromKey returns the unescaped measurement name of a digest key.
+func measurementFromKey(key string) string {
+	if i := strings.Index(key, keyFieldSeparator); i != -1 {
+		key = key[:i]
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\\' {
+			i++
+		} else if key[i] == ',' {
+			key = key[:i]
+			break
+		}
+	}
+	return escape.UnescapeString(key)
+}
@@ -0,0 +1,472 @@
+package antientropy // import "github.com/influxdata/influxdb/services/antientropy"
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tcp"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// MuxHeader is the header byte used for the TCP muxer.
+const MuxHeader = 7
+
+// MaxKeysPerRequest is the number of keys requested from another node at a time.
+const MaxKeysPerRequest = 100
+
+// Statistics for the anti-entropy service.
+const (
+	statRepairOK           = "repairOk"
+	statRepairFail         = "repairFail"
+	statKeysRepaired       = "keysRepaired"
+	statPointsWritten      = "pointsWritten"
+	statReadMismatch       = "readMismatch"
+	statReadRepairOK       = "readRepairOk"
+	statReadRepairFail     = "readRepairFail"
+	statPointsReadRepaired = "pointsReadRepaired"
+)
+
+// Difference describes the keys of a shard whose values differ between the
+// local node and another owner of the shard.
+type Difference struct {
+	ShardID         uint64
+	Database        string
+	RetentionPolicy string
+	Peer            uint64
+	Keys            []string
+
+	// LocalN and PeerN are the number of values of the differing keys on
+	// the local node and the peer.
+	LocalN int
+	PeerN  int
+
+	// Err is set if the digests couldn't be compared.
+	Err error
+}
+
+// Service compares the replicas of the local shards with the other owners
+// and repairs the local replicas by writing the values they are missing.
+// Values that differ at the same timestamp are reported but not repaired.
+type Service struct {
+	config Config
+
+	wg   sync.WaitGroup
+	err  chan error
+	done chan struct{}
+
+	MetaClient interface {
+		ShardOwner(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
+		DataNode(id uint64) (*meta.NodeInfo, error)
+	}
+
+	TSDBStore interface {
+		Shard(id uint64) *tsdb.Shard
+		ShardIDs() []uint64
+		WriteToShard(shardID uint64, points []models.Point) error
+	}
+
+	// ShardWriter writes values back to other owners during read repair.
+	ShardWriter interface {
+		WriteShard(shardID, ownerID uint64, points []models.Point) error
+	}
+
+	// Dialer secures connections to other nodes. Plain if nil.
+	Dialer *tcp.Dialer
+
+	Node *influxdb.Node
+
+	Listener net.Listener
+	Logger   *log.Logger
+	statMap  *expvar.Map
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		config:  c,
+		err:     make(chan error),
+		Logger:  log.New(os.Stderr, "[anti-entropy] ", log.LstdFlags),
+		statMap: influxdb.NewStatistics("anti_entropy", "anti_entropy", nil),
+	}
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	if s.done != nil {
+		return nil
+	}
+
+	s.Logger.Println("Starting anti-entropy service")
+
+	s.done = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.serve()
+
+	if s.config.Enabled {
+		s.Logger.Printf("Repairing shards with check interval of %s", s.config.CheckInterval)
+		s.wg.Add(1)
+		go s.runRepair()
+	}
+	return nil
+}
+
+// Close implements the Service interface.
+func (s *Service) Close() error {
+	if s.done == nil {
+		return nil
+	}
+
+	close(s.done)
+	if s.Listener != nil {
+		s.Listener.Close()
+	}
+	s.wg.Wait()
+	s.done = nil
+
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.Logger = l
+}
+
+// Err returns a channel for fatal out-of-band errors.
+func (s *Service) Err() <-chan error { return s.err }
+
+// serve serves digest and point requests from the listener.
+func (s *Service) serve() {
+	defer s.wg.Done()
+
+	for {
+		// Wait for next connection.
+		conn, err := s.Listener.Accept()
+		if err != nil && strings.Contains(err.Error(), "connection closed") {
+			s.Logger.Println("anti-entropy listener closed")
+			return
+		} else if err != nil {
+			s.Logger.Println("error accepting anti-entropy request: ", err.Error())
+			continue
+		}
+
+		// Handle connection in separate goroutine.
+		s.wg.Add(1)
+		go func(conn net.Conn) {
+			defer s.wg.Done()
+			defer conn.Close()
+			if err := s.handleConn(conn); err != nil {
+				s.Logger.Println(err)
+			}
+		}(conn)
+	}
+}
+
+// handleConn processes conn. This is run in a separate goroutine.
+func (s *Service) handleConn(conn net.Conn) error {
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return fmt.Errorf("read request: %s", err)
+	}
+
+	var resp Response
+	if err := s.processRequest(&req, &resp); err != nil {
+		resp.Error = err.Error()
+	}
+
+	if err := json.NewEncoder(conn).Encode(&resp); err != nil {
+		return fmt.Errorf("encode response: %s", err)
+	}
+	return nil
+}
+
+// processRequest fills in resp for a request of a local shard.
+func (s *Service) processRequest(req *Request, resp *Response) error {
+	sh := s.TSDBStore.Shard(req.ShardID)
+	if sh == nil {
+		return tsdb.ErrShardNotFound
+	}
+
+	switch req.Type {
+	case RequestDigest:
+		d, err := sh.Digest()
+		if err != nil {
+			return err
+		}
+		if resp.Digest, err = req.Filter.Digest(d); err != nil {
+			return err
+		}
+	case RequestPoints:
+		min, max := req.Filter.timeRange()
+		points, err := sh.Points(req.Keys, min, max)
+		if err != nil {
+			return err
+		}
+		resp.Points = make([]string, len(points))
+		for i, p := range points {
+			resp.Points[i] = p.String()
+		}
+	default:
+		return fmt.Errorf("request type unknown: %v", req.Type)
+	}
+	return nil
+}
+
+// runRepair periodically repairs the local shards.
+func (s *Service) runRepair() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.repairShards(time.Now().UTC())
+		case <-s.done:
+			s.Logger.Println("Anti-entropy repair terminating")
+			return
+		}
+	}
+}
+
+// repairShards repairs every local shard of a shard group that has ended.
+// Shards still receiving writes are skipped since their replicas differ
+// until in-flight writes and hinted handoff have been delivered.
+func (s *Service) repairShards(now time.Time) {
+	for _, id := range s.TSDBStore.ShardIDs() {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		_, _, sgi := s.MetaClient.ShardOwner(id)
+		if sgi == nil || sgi.EndTime.After(now) {
+			continue
+		}
+
+		diffs, err := s.ShardDifferences(id)
+		if err != nil {
+			s.Logger.Printf("failed to compare shard %d: %s", id, err)
+			continue
+		}
+
+		for _, diff := range diffs {
+			if diff.Err != nil {
+				s.Logger.Printf("failed to compare shard %d with node %d: %s", id, diff.Peer, diff.Err)
+				continue
+			} else if len(diff.Keys) == 0 {
+				continue
+			}
+
+			n, err := s.Repair(diff)
+			if err != nil {
+				s.statMap.Add(statRepairFail, 1)
+				s.Logger.Printf("failed to repair shard %d from node %d: %s", id, diff.Peer, err)
+				continue
+			}
+			s.statMap.Add(statRepairOK, 1)
+			s.statMap.Add(statKeysRepaired, int64(len(diff.Keys)))
+			s.statMap.Add(statPointsWritten, int64(n))
+
+			if n > 0 {
+				s.Logger.Printf("repaired shard %d with %d points from node %d", id, n, diff.Peer)
+			}
+		}
+	}
+}
+
+// Differences returns the differences of every local shard with the other
+// owners of the shard.
+func (s *Service) Differences() ([]Difference, error) {
+	var a []Difference
+	for _, id := range s.TSDBStore.ShardIDs() {
+		diffs, err := s.ShardDifferences(id)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, diffs...)
+	}
+	return a, nil
+}
+
+// ShardDifferences compares the local digest of a shard with the digests of
+// the other owners. A difference is returned for every other owner.
+func (s *Service) ShardDifferences(id uint64) ([]Difference, error) {
+	database, policy, sgi := s.MetaClient.ShardOwner(id)
+	si := shardInfo(sgi, id)
+	if si == nil || len(si.Owners) < 2 {
+		return nil, nil
+	}
+
+	sh := s.TSDBStore.Shard(id)
+	if sh == nil {
+		return nil, nil
+	}
+	local, err := sh.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	var a []Difference
+	for _, owner := range si.Owners {
+		if owner.NodeID == s.Node.ID {
+			continue
+		}
+
+		diff := Difference{ShardID: id, Database: database, RetentionPolicy: policy, Peer: owner.NodeID}
+		peer, err := s.peerDigest(owner.NodeID, id)
+		if err != nil {
+			diff.Err = err
+		} else {
+			diff.Keys = local.Diff(peer)
+			for _, key := range diff.Keys {
+				diff.LocalN += local[key].N
+				diff.PeerN += peer[key].N
+			}
+		}
+		a = append(a, diff)
+	}
+	return a, nil
+}
+
+// peerDigest returns the digest of a shard on another node.
+func (s *Service) peerDigest(nodeID, shardID uint64) (tsdb.Digest, error) {
+	c, err := s.client(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return c.Digest(shardID, nil)
+}
+
+// client returns a client for the anti-entropy service of another node.
+func (s *Service) client(nodeID uint64) (*Client, error) {
+	ni, err := s.MetaClient.DataNode(nodeID)
+	if err != nil {
+		return nil, err
+	} else if ni == nil {
+		return nil, meta.ErrNodeNotFound
+	}
+	c := NewClient(ni.TCPHost)
+	c.Dialer = s.Dialer
+	return c, nil
+}
+
+// shardInfo returns the shard with id from a shard group or nil if the
+// group doesn't have it.
+func shardInfo(sgi *meta.ShardGroupInfo, id uint64) *meta.ShardInfo {
+	if sgi == nil {
+		return nil
+	}
+	for i := range sgi.Shards {
+		if sgi.Shards[i].ID == id {
+			return &sgi.Shards[i]
+		}
+	}
+	return nil
+}
+
+// Repair writes the values of the differing keys that exist on the peer but
+// not on the local node. Returns the number of points written.
+func (s *Service) Repair(diff Difference) (int, error) {
+	sh := s.TSDBStore.Shard(diff.ShardID)
+	if sh == nil {
+		return 0, tsdb.ErrShardNotFound
+	}
+
+	c, err := s.client(diff.Peer)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for i := 0; i < len(diff.Keys); i += MaxKeysPerRequest {
+		keys := diff.Keys[i:]
+		if len(keys) > MaxKeysPerRequest {
+			keys = keys[:MaxKeysPerRequest]
+		}
+
+		// Find the values the local node already has.
+		local, err := sh.Points(keys, math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return n, err
+		}
+		m := make(map[pointKey]struct{}, len(local))
+		for _, p := range local {
+			for field := range p.Fields() {
+				m[pointKey{string(p.Key()), field, p.UnixNano()}] = struct{}{}
+			}
+		}
+
+		peer, err := c.Points(diff.ShardID, keys, nil)
+		if err != nil {
+			return n, err
+		}
+
+		var missing []models.Point
+		for _, p := range peer {
+			for field := range p.Fields() {
+				if _, ok := m[pointKey{string(p.Key()), field, p.UnixNano()}]; !ok {
+					missing = append(missing, p)
+					break
+				}
+			}
+		}
+
+		if len(missing) == 0 {
+			continue
+		}
+		if err := s.TSDBStore.WriteToShard(diff.ShardID, missing); err != nil {
+			return n, err
+		}
+		n += len(missing)
+	}
+	return n, nil
+}
+
+// pointKey identifies a single value in a shard.
+type pointKey struct {
+	seriesKey string
+	field     string
+	timestamp int64
+}
+
+// RequestType is the type of an anti-entropy request.
+type RequestType uint8
+
+const (
+	// RequestDigest requests the digest of a shard.
+	RequestDigest RequestType = iota
+
+	// RequestPoints requests the values of keys of a shard as points.
+	RequestPoints
+)
+
+// Request represents a request for the digest or points of a shard.
+type Request struct {
+	Type    RequestType
+	ShardID uint64
+	Keys    []string
+
+	// Filter limits the digest and points. The whole shard if nil.
+	Filter *Filter
+}
+
+// Response contains the digest or points of a shard. Points are encoded in
+// line protocol.
+type Response struct {
+	Error  string
+	Digest tsdb.Digest
+	Points []string
+}
@@ -0,0 +1,364 @@
+package antientropy_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/services/antientropy"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tcp"
+	"github.com/influxdata/influxdb/tsdb"
+	_ "github.com/influxdata/influxdb/tsdb/engine"
+)
+
+// Ensure a replica that missed writes is reported and repaired from its peer.
+func TestService_Repair(t *testing.T) {
+	s1, s2 := MustOpenService(1), MustOpenService(2)
+	defer s1.Close()
+	defer s2.Close()
+
+	for _, s := range []*Service{s1, s2} {
+		s.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) {
+			switch id {
+			case 1:
+				return &meta.NodeInfo{ID: 1, TCPHost: s1.Addr().String()}, nil
+			case 2:
+				return &meta.NodeInfo{ID: 2, TCPHost: s2.Addr().String()}, nil
+			}
+			return nil, nil
+		}
+	}
+
+	s1.MustCreateShardWithData(
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverA value=2 10`,
+		`cpu,host=serverB value=3 10`,
+	)
+	s2.MustCreateShardWithData(
+		`cpu,host=serverA value=1 0`,
+	)
+
+	// Ensure the differing keys are reported from node 2.
+	diffs, err := s2.Differences()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(diffs) != 1 {
+		t.Fatalf("unexpected differences: %+v", diffs)
+	} else if d := diffs[0]; d.ShardID != 1 || d.Peer != 1 || d.LocalN != 1 || d.PeerN != 3 ||
+		!reflect.DeepEqual(d.Keys, []string{"cpu,host=serverA#!~#value", "cpu,host=serverB#!~#value"}) {
+		t.Fatalf("unexpected difference: %+v", d)
+	}
+
+	// Ensure SHOW SHARD DIFFERENCES lists the replica.
+	e := &antientropy.StatementExecutor{Service: s2.Service, Node: s2.Node}
+	if res := e.ExecuteStatement(&influxql.ShowShardDifferencesStatement{}); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if values := res.Series[0].Values; !reflect.DeepEqual(values, [][]interface{}{{uint64(1), "db0", "rp0", uint64(2), uint64(1), 2, 1, 3, ""}}) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	// Repair node 2 and ensure both replicas are equal.
+	if n, err := s2.Repair(diffs[0]); err != nil {
+		t.Fatal(err)
+	} else if n != 2 {
+		t.Fatalf("unexpected points written: %d", n)
+	}
+
+	if diffs, err := s2.Differences(); err != nil {
+		t.Fatal(err)
+	} else if len(diffs) != 1 || len(diffs[0].Keys) != 0 {
+		t.Fatalf("unexpected differences: %+v", diffs)
+	}
+}
+
+// Ensure an unreachable peer is reported with the difference.
+func TestService_Differences_PeerError(t *testing.T) {
+	s := MustOpenService(1)
+	defer s.Close()
+	s.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) { return nil, nil }
+	s.MustCreateShardWithData(`cpu value=1 0`)
+
+	if diffs, err := s.Differences(); err != nil {
+		t.Fatal(err)
+	} else if len(diffs) != 1 || diffs[0].Err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected differences: %+v", diffs)
+	}
+}
+
+// Ensure a query read merges the values of both replicas and repairs them.
+func TestService_ReadShards(t *testing.T) {
+	c := antientropy.NewConfig()
+	c.ReadRepair = true
+	s1, s2 := MustOpenServiceWithConfig(1, c), MustOpenService(2)
+	defer s1.Close()
+	defer s2.Close()
+
+	s1.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) {
+		return &meta.NodeInfo{ID: 2, TCPHost: s2.Addr().String()}, nil
+	}
+
+	var written []models.Point
+	s1.ShardWriter = &ServiceShardWriter{
+		WriteShardFn: func(shardID, ownerID uint64, points []models.Point) error {
+			if shardID != 1 || ownerID != 2 {
+				t.Fatalf("unexpected shard/owner: %d/%d", shardID, ownerID)
+			}
+			written = append(written, points...)
+			return nil
+		},
+	}
+
+	s1.MustCreateShardWithData(
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverA value=2 10`,
+		`mem,host=serverA value=5 0`,
+	)
+	s2.MustCreateShardWithData(
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverB value=3 10`,
+	)
+
+	// Ensure only the value missing locally is returned.
+	set, err := s1.ReadShards([]uint64{1}, NewShardReadOptions("cpu", nil), tsdb.ReadConsistencyAll)
+	if err != nil {
+		t.Fatal(err)
+	} else if set.Len() != 1 {
+		t.Fatalf("unexpected values: %d", set.Len())
+	}
+
+	// Ensure the value missing on node 2 was written to it.
+	if len(written) != 1 || written[0].String() != `cpu,host=serverA value=2 10` {
+		t.Fatalf("unexpected points written: %v", written)
+	}
+
+	// Ensure the value missing on node 1 was written to the local shard.
+	if points, err := s1.Store.Shard(1).Points([]string{"cpu,host=serverB#!~#value"}, 0, 10); err != nil {
+		t.Fatal(err)
+	} else if len(points) != 1 {
+		t.Fatalf("unexpected points: %v", points)
+	}
+}
+
+// Ensure a query read only reads and repairs the values in the time range
+// of the series that may match the condition.
+func TestService_ReadShards_Filter(t *testing.T) {
+	c := antientropy.NewConfig()
+	c.ReadRepair = true
+	s1, s2 := MustOpenServiceWithConfig(1, c), MustOpenService(2)
+	defer s1.Close()
+	defer s2.Close()
+
+	s1.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) {
+		return &meta.NodeInfo{ID: 2, TCPHost: s2.Addr().String()}, nil
+	}
+
+	var written []models.Point
+	s1.ShardWriter = &ServiceShardWriter{
+		WriteShardFn: func(shardID, ownerID uint64, points []models.Point) error {
+			written = append(written, points...)
+			return nil
+		},
+	}
+
+	s1.MustCreateShardWithData(
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverA value=2 10`,
+	)
+	s2.MustCreateShardWithData(
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverA value=3 20`,
+		`cpu,host=serverB value=4 10`,
+	)
+
+	// Ensure only the value in the time range of the matching series is returned.
+	opt := NewShardReadOptions("cpu", MustParseExpr(`host = 'serverA' AND value > 0`))
+	opt.MinTime, opt.MaxTime = time.Unix(0, 0), time.Unix(0, 20)
+	if set, err := s1.ReadShards([]uint64{1}, opt, tsdb.ReadConsistencyAll); err != nil {
+		t.Fatal(err)
+	} else if set.Len() != 1 {
+		t.Fatalf("unexpected values: %d", set.Len())
+	}
+
+	// Ensure the values outside of the time range aren't read.
+	written = nil
+	opt.MinTime, opt.MaxTime = time.Unix(0, 5), time.Unix(0, 15)
+	if set, err := s1.ReadShards([]uint64{1}, opt, tsdb.ReadConsistencyAll); err != nil {
+		t.Fatal(err)
+	} else if set.Len() != 0 {
+		t.Fatalf("unexpected values: %d", set.Len())
+	} else if len(written) != 1 || written[0].String() != `cpu,host=serverA value=2 10` {
+		t.Fatalf("unexpected points written: %v", written)
+	}
+
+	// Ensure the series that can't match the condition weren't repaired locally.
+	if points, err := s1.Store.Shard(1).Points([]string{"cpu,host=serverB#!~#value"}, 0, 20); err != nil {
+		t.Fatal(err)
+	} else if len(points) != 0 {
+		t.Fatalf("unexpected points: %v", points)
+	}
+}
+
+// Ensure a query read fails if too few replicas can be read.
+func TestService_ReadShards_ErrReadConsistencyNotMet(t *testing.T) {
+	s := MustOpenService(1)
+	defer s.Close()
+	s.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) { return nil, nil }
+	s.MustCreateShardWithData(`cpu value=1 0`)
+
+	opt := NewShardReadOptions("cpu", nil)
+	if _, err := s.ReadShards([]uint64{1}, opt, tsdb.ReadConsistencyQuorum); err != antientropy.ErrReadConsistencyNotMet {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if set, err := s.ReadShards([]uint64{1}, opt, tsdb.ReadConsistencyOne); err != nil {
+		t.Fatal(err)
+	} else if set.Len() != 0 {
+		t.Fatalf("unexpected values: %d", set.Len())
+	}
+}
+
+// NewShardReadOptions returns options reading a measurement over every time.
+func NewShardReadOptions(name string, cond influxql.Expr) tsdb.ShardReadOptions {
+	return tsdb.ShardReadOptions{
+		Sources:   influxql.Sources{&influxql.Measurement{Name: name}},
+		Condition: cond,
+		MinTime:   time.Unix(0, 0),
+		MaxTime:   time.Unix(0, 100),
+	}
+}
+
+// MustParseExpr parses an expression. Panic on error.
+func MustParseExpr(s string) influxql.Expr {
+	expr, err := influxql.ParseExpr(s)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// Service represents a test wrapper for antientropy.Service.
+type Service struct {
+	*antientropy.Service
+
+	ln         net.Listener
+	MetaClient ServiceMetaClient
+	Store      *tsdb.Store
+}
+
+// MustOpenService returns a new, opened service for node id with its own
+// store. Shard 1 of db0.rp0 is owned by nodes 1 and 2. Panic on error.
+func MustOpenService(id uint64) *Service {
+	return MustOpenServiceWithConfig(id, antientropy.NewConfig())
+}
+
+// MustOpenServiceWithConfig returns a new, opened service with a config.
+// Panic on error.
+func MustOpenServiceWithConfig(id uint64, c antientropy.Config) *Service {
+	path, err := ioutil.TempDir("", "antientropy-")
+	if err != nil {
+		panic(err)
+	}
+	store := tsdb.NewStore(path)
+	store.EngineOptions.Config.WALDir = filepath.Join(path, "wal")
+	if err := store.Open(); err != nil {
+		panic(err)
+	}
+
+	// Open randomly assigned port.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	// Start muxer.
+	mux := tcp.NewMux()
+
+	s := &Service{
+		Service: antientropy.NewService(c),
+		ln:      ln,
+		Store:   store,
+	}
+	s.Service.MetaClient = &s.MetaClient
+	s.Service.TSDBStore = store
+	s.Service.Node = &influxdb.Node{ID: id}
+	s.Listener = mux.Listen(antientropy.MuxHeader)
+	go mux.Serve(ln)
+
+	s.MetaClient.ShardOwnerFn = func(shardID uint64) (string, string, *meta.ShardGroupInfo) {
+		if shardID != 1 {
+			return "", "", nil
+		}
+		return "db0", "rp0", &meta.ShardGroupInfo{
+			EndTime: time.Unix(0, 0),
+			Shards: []meta.ShardInfo{
+				{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}, {NodeID: 2}}},
+			},
+		}
+	}
+
+	if !testing.Verbose() {
+		s.SetLogger(log.New(ioutil.Discard, "", 0))
+	}
+	if err := s.Open(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Close shuts down the service, the attached listener and the store.
+func (s *Service) Close() error {
+	defer os.RemoveAll(s.Store.Path())
+	s.ln.Close()
+	s.Service.Close()
+	return s.Store.Close()
+}
+
+// Addr returns the address of the service.
+func (s *Service) Addr() net.Addr { return s.ln.Addr() }
+
+// MustCreateShardWithData creates shard 1 and writes line protocol data to it.
+func (s *Service) MustCreateShardWithData(data ...string) {
+	if err := s.Store.CreateShard("db0", "rp0", 1); err != nil {
+		panic(err)
+	}
+
+	points, err := models.ParsePointsString(strings.Join(data, "\n"))
+	if err != nil {
+		panic(err)
+	}
+	if err := s.Store.WriteToShard(1, points); err != nil {
+		panic(err)
+	}
+}
+
+// ServiceMetaClient is a mock that implements antientropy.Service.MetaClient.
+type ServiceMetaClient struct {
+	ShardOwnerFn func(shardID uint64) (string, string, *meta.ShardGroupInfo)
+	DataNodeFn   func(id uint64) (*meta.NodeInfo, error)
+}
+
+func (c *ServiceMetaClient) ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo) {
+	return c.ShardOwnerFn(shardID)
+}
+
+func (c *ServiceMetaClient) DataNode(id uint64) (*meta.NodeInfo, error) {
+	return c.DataNodeFn(id)
+}
+
+// ServiceShardWriter is a mock that implements antientropy.Service.ShardWriter.
+type ServiceShardWriter struct {
+	WriteShardFn func(shardID, ownerID uint64, points []models.Point) error
+}
+
+func (w *ServiceShardWriter) WriteShard(shardID, ownerID uint64, points []models.Point) error {
+	return w.WriteShardFn(shardID, ownerID, points)
+}
@@ -0,0 +1,63 @@
+package antientropy
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+)
+
+// StatementExecutor translates SHOW SHARD DIFFERENCES into comparisons of
+// the local shards with their other owners.
+type StatementExecutor struct {
+	Service interface {
+		Differences() ([]Difference, error)
+	}
+
+	// Node is the data node the statements are executed on.
+	Node *influxdb.Node
+}
+
+// ExecuteStatement executes anti-entropy-related query statements.
+func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.Result {
+	switch stmt := stmt.(type) {
+	case *influxql.ShowShardDifferencesStatement:
+		return e.executeShowShardDifferencesStatement(stmt)
+	default:
+		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
+	}
+}
+
+func (e *StatementExecutor) executeShowShardDifferencesStatement(stmt *influxql.ShowShardDifferencesStatement) *influxql.Result {
+	diffs, err := e.Service.Differences()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &models.Row{Name: "shard differences", Columns: []string{"shard_id", "database", "retention_policy", "node", "peer", "keys", "local_values", "peer_values", "error"}}
+	for _, diff := range diffs {
+		// Only list replicas that differ or couldn't be compared.
+		if diff.Err == nil && len(diff.Keys) == 0 {
+			continue
+		}
+
+		var errString string
+		if diff.Err != nil {
+			errString = diff.Err.Error()
+		}
+
+		row.Values = append(row.Values, []interface{}{
+			diff.ShardID,
+			diff.Database,
+			diff.RetentionPolicy,
+			e.Node.ID,
+			diff.Peer,
+			len(diff.Keys),
+			diff.LocalN,
+			diff.PeerN,
+			errString,
+		})
+	}
+	return &influxql.Result{Series: []*models.Row{row}}
+}
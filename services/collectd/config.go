@@ -1,6 +1,8 @@
 package collectd
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
@@ -28,6 +30,13 @@ const (
 	// DefaultTypesDB is the default location of the collectd types db file.
 	DefaultTypesDB = "/usr/share/collectd/types.db"
 
+	// DefaultSecurityLevel is the default security level of received packets.
+	DefaultSecurityLevel = SecurityLevelNone
+
+	// DefaultParseMultiValuePlugin is the default way values with several
+	// data sources are written.
+	DefaultParseMultiValuePlugin = ParseMultiValueSplit
+
 	// DefaultReadBuffer is the default buffer size for the UDP listener.
 	// Sets the size of the operating system's receive buffer associated with
 	// the UDP traffic. Keep in mind that the OS must be able
@@ -42,6 +51,17 @@ const (
 	DefaultReadBuffer = 0
 )
 
+// Ways of writing collectd values with several data sources.
+const (
+	// ParseMultiValueSplit writes a point per data source to a measurement
+	// named <plugin>_<data source> with a "value" field.
+	ParseMultiValueSplit = "split"
+
+	// ParseMultiValueJoin writes a point per value list to a measurement
+	// named <plugin> with a field per data source.
+	ParseMultiValueJoin = "join"
+)
+
 // Config represents a configuration for the collectd service.
 type Config struct {
 	Enabled         bool          `toml:"enabled"`
@@ -53,6 +73,14 @@ type Config struct {
 	BatchDuration   toml.Duration `toml:"batch-timeout"`
 	ReadBuffer      int           `toml:"read-buffer"`
 	TypesDB         string        `toml:"typesdb"`
+	SecurityLevel   string        `toml:"security-level"`
+	AuthFile        string        `toml:"auth-file"`
+
+	// CustomTypesDB lists additional types db files or directories. They
+	// are loaded after TypesDB so their types take precedence.
+	CustomTypesDB []string `toml:"custom-typesdb"`
+
+	ParseMultiValuePlugin string `toml:"parse-multivalue-plugin"`
 }
 
 // NewConfig returns a new instance of Config with defaults.
@@ -66,5 +94,28 @@ func NewConfig() Config {
 		BatchPending:    DefaultBatchPending,
 		BatchDuration:   DefaultBatchDuration,
 		TypesDB:         DefaultTypesDB,
+		SecurityLevel:   DefaultSecurityLevel,
+
+		ParseMultiValuePlugin: DefaultParseMultiValuePlugin,
+	}
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	switch strings.ToLower(c.SecurityLevel) {
+	case "", SecurityLevelNone:
+	case SecurityLevelSign, SecurityLevelEncrypt:
+		if c.AuthFile == "" {
+			return fmt.Errorf("auth-file is required for security level %q", c.SecurityLevel)
+		}
+	default:
+		return fmt.Errorf("invalid security level: %q", c.SecurityLevel)
+	}
+
+	switch strings.ToLower(c.ParseMultiValuePlugin) {
+	case "", ParseMultiValueSplit, ParseMultiValueJoin:
+	default:
+		return fmt.Errorf("invalid parse-multivalue-plugin: %q", c.ParseMultiValuePlugin)
 	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package collectd_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/BurntSushi/toml"
@@ -15,6 +16,10 @@ enabled = true
 bind-address = ":9000"
 database = "xxx"
 typesdb = "yyy"
+security-level = "encrypt"
+auth-file = "/etc/collectd/auth_file"
+custom-typesdb = ["/etc/collectd/types.d", "/etc/collectd/custom.db"]
+parse-multivalue-plugin = "join"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -28,5 +33,41 @@ typesdb = "yyy"
 		t.Fatalf("unexpected database: %s", c.Database)
 	} else if c.TypesDB != "yyy" {
 		t.Fatalf("unexpected types db: %s", c.TypesDB)
+	} else if c.SecurityLevel != "encrypt" {
+		t.Fatalf("unexpected security level: %s", c.SecurityLevel)
+	} else if c.AuthFile != "/etc/collectd/auth_file" {
+		t.Fatalf("unexpected auth file: %s", c.AuthFile)
+	} else if !reflect.DeepEqual(c.CustomTypesDB, []string{"/etc/collectd/types.d", "/etc/collectd/custom.db"}) {
+		t.Fatalf("unexpected custom types db: %v", c.CustomTypesDB)
+	} else if c.ParseMultiValuePlugin != "join" {
+		t.Fatalf("unexpected parse multivalue plugin: %s", c.ParseMultiValuePlugin)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := collectd.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c.SecurityLevel = "sign"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for missing auth file")
+	}
+
+	c.AuthFile = "/etc/collectd/auth_file"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	c.SecurityLevel = "secret"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for invalid security level")
+	}
+
+	c.SecurityLevel = "none"
+	c.ParseMultiValuePlugin = "merge"
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error for invalid parse-multivalue-plugin")
 	}
 }
@@ -0,0 +1,200 @@
+package collectd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Security levels of the collectd network protocol.
+const (
+	// SecurityLevelNone accepts all data. Signed data is verified and
+	// encrypted data is decrypted if an auth file is set.
+	SecurityLevelNone = "none"
+
+	// SecurityLevelSign only accepts signed or encrypted data.
+	SecurityLevelSign = "sign"
+
+	// SecurityLevelEncrypt only accepts encrypted data.
+	SecurityLevelEncrypt = "encrypt"
+)
+
+// Part types of the collectd network protocol used for security.
+const (
+	partTypeSignature  = 0x0200
+	partTypeEncryption = 0x0210
+)
+
+const (
+	// partHeaderSize is the size of the type and length of each part.
+	partHeaderSize = 4
+
+	// signatureSize is the size of the HMAC-SHA256 of a signature part.
+	signatureSize = sha256.Size
+
+	// checksumSize is the size of the SHA1 checksum of the encrypted data.
+	checksumSize = sha1.Size
+)
+
+// ErrInsecurePacket is returned when a packet isn't signed or encrypted as
+// required by the security level.
+var ErrInsecurePacket = errors.New("packet does not meet security level")
+
+// AuthError is returned when signed data can't be verified or encrypted
+// data can't be decrypted.
+type AuthError struct {
+	Username string
+	Reason   string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed for user %q: %s", e.Username, e.Reason)
+}
+
+// ReadAuthFile reads a collectd auth file. Each line has the form
+// "username: password".
+func ReadAuthFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid auth file line %d", i)
+		}
+		users[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// openPacket returns the parts of a packet that can be parsed. Signatures are
+// verified and encrypted parts are replaced by their decrypted contents using
+// the passwords in users. ErrInsecurePacket is returned if the packet has data
+// which isn't signed or encrypted as required by level.
+func openPacket(b []byte, level string, users map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	for len(b) > 0 {
+		if len(b) < partHeaderSize {
+			return nil, errors.New("packet part is truncated")
+		}
+
+		typ := binary.BigEndian.Uint16(b[0:2])
+		length := int(binary.BigEndian.Uint16(b[2:4]))
+		if length < partHeaderSize || length > len(b) {
+			return nil, fmt.Errorf("packet part has invalid length: %d", length)
+		}
+		part, rest := b[:length], b[length:]
+
+		switch typ {
+		case partTypeSignature:
+			// Without an auth file, signatures are ignored if they aren't required.
+			if level != SecurityLevelNone || users != nil {
+				if err := verifySignature(part, rest, users); err != nil {
+					return nil, err
+				}
+			}
+
+			// The signature covers the rest of the packet, so only encryption
+			// may still be required.
+			if level == SecurityLevelSign {
+				level = SecurityLevelNone
+			}
+		case partTypeEncryption:
+			data, err := decryptPart(part, users)
+			if err != nil {
+				return nil, err
+			}
+
+			// Everything within the encrypted part is trusted.
+			data, err = openPacket(data, SecurityLevelNone, users)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(data)
+		default:
+			if level != SecurityLevelNone {
+				return nil, ErrInsecurePacket
+			}
+			buf.Write(part)
+		}
+		b = rest
+	}
+	return buf.Bytes(), nil
+}
+
+// verifySignature verifies the HMAC-SHA256 of a signature part. The signature
+// covers the username and the data following the part.
+func verifySignature(part, data []byte, users map[string]string) error {
+	if len(part) <= partHeaderSize+signatureSize {
+		return errors.New("signature part is truncated")
+	}
+	sig, username := part[partHeaderSize:partHeaderSize+signatureSize], part[partHeaderSize+signatureSize:]
+
+	password, ok := users[string(username)]
+	if !ok {
+		return &AuthError{Username: string(username), Reason: "unknown user"}
+	}
+
+	h := hmac.New(sha256.New, []byte(password))
+	h.Write(username)
+	h.Write(data)
+	if !hmac.Equal(h.Sum(nil), sig) {
+		return &AuthError{Username: string(username), Reason: "invalid signature"}
+	}
+	return nil
+}
+
+// decryptPart decrypts an AES-256-OFB encrypted part. The key is the SHA256
+// of the user's password and the decrypted data starts with its SHA1 checksum.
+func decryptPart(part []byte, users map[string]string) ([]byte, error) {
+	if len(part) < partHeaderSize+2 {
+		return nil, errors.New("encryption part is truncated")
+	}
+	n := int(binary.BigEndian.Uint16(part[partHeaderSize:]))
+	part = part[partHeaderSize+2:]
+	if len(part) < n+aes.BlockSize+checksumSize {
+		return nil, errors.New("encryption part is truncated")
+	}
+	username, iv, data := string(part[:n]), part[n:n+aes.BlockSize], part[n+aes.BlockSize:]
+
+	password, ok := users[username]
+	if !ok {
+		return nil, &AuthError{Username: username, Reason: "unknown user"}
+	}
+
+	key := sha256.Sum256([]byte(password))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewOFB(block, iv).XORKeyStream(plain, data)
+
+	if sum := sha1.Sum(plain[checksumSize:]); !bytes.Equal(sum[:], plain[:checksumSize]) {
+		return nil, &AuthError{Username: username, Reason: "invalid checksum"}
+	}
+	return plain[checksumSize:], nil
+}
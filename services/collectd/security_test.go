@@ -0,0 +1,129 @@
+package collectd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// Ensure packets are accepted or rejected based on the security level.
+func TestOpenPacket(t *testing.T) {
+	users := map[string]string{"alice": "secret"}
+
+	for i, tt := range []struct {
+		level  string
+		users  map[string]string
+		packet []byte
+		err    error
+	}{
+		{level: SecurityLevelNone, users: nil, packet: testData},
+		{level: SecurityLevelNone, users: nil, packet: signPacket(testData, "alice", "secret")},
+		{level: SecurityLevelNone, users: users, packet: signPacket(testData, "alice", "secret")},
+		{level: SecurityLevelNone, users: users, packet: encryptPacket(testData, "alice", "secret")},
+		{level: SecurityLevelNone, users: users, packet: signPacket(testData, "alice", "wrong"), err: &AuthError{Username: "alice", Reason: "invalid signature"}},
+		{level: SecurityLevelSign, users: users, packet: testData, err: ErrInsecurePacket},
+		{level: SecurityLevelSign, users: users, packet: signPacket(testData, "alice", "secret")},
+		{level: SecurityLevelSign, users: users, packet: encryptPacket(testData, "alice", "secret")},
+		{level: SecurityLevelSign, users: users, packet: signPacket(testData, "bob", "secret"), err: &AuthError{Username: "bob", Reason: "unknown user"}},
+		{level: SecurityLevelSign, users: users, packet: append(signPacket(testData, "alice", "secret"), 0), err: &AuthError{Username: "alice", Reason: "invalid signature"}},
+		{level: SecurityLevelEncrypt, users: users, packet: testData, err: ErrInsecurePacket},
+		{level: SecurityLevelEncrypt, users: users, packet: signPacket(testData, "alice", "secret"), err: ErrInsecurePacket},
+		{level: SecurityLevelEncrypt, users: users, packet: encryptPacket(testData, "alice", "secret")},
+		{level: SecurityLevelEncrypt, users: users, packet: encryptPacket(signPacket(testData, "alice", "secret"), "alice", "secret")},
+		{level: SecurityLevelEncrypt, users: users, packet: encryptPacket(testData, "alice", "wrong"), err: &AuthError{Username: "alice", Reason: "invalid checksum"}},
+		{level: SecurityLevelEncrypt, users: nil, packet: encryptPacket(testData, "alice", "secret"), err: &AuthError{Username: "alice", Reason: "unknown user"}},
+	} {
+		data, err := openPacket(tt.packet, tt.level, tt.users)
+		if !reflect.DeepEqual(err, tt.err) {
+			t.Errorf("%d. unexpected error: exp=%v got=%v", i, tt.err, err)
+		} else if err == nil && !bytes.Equal(data, testData) {
+			t.Errorf("%d. unexpected data: %x", i, data)
+		}
+	}
+}
+
+// Ensure truncated security parts are rejected.
+func TestOpenPacket_Truncated(t *testing.T) {
+	users := map[string]string{"alice": "secret"}
+	for _, packet := range [][]byte{
+		{0x02},
+		{0x02, 0x00, 0x00, 0x24},
+		{0x02, 0x00, 0x00, 0x04},
+		{0x02, 0x10, 0x00, 0x04},
+		{0x02, 0x10, 0x00, 0x0b, 0x00, 0x05, 'a', 'l', 'i', 'c', 'e'},
+	} {
+		if _, err := openPacket(packet, SecurityLevelSign, users); err == nil {
+			t.Errorf("%x: expected error", packet)
+		}
+	}
+}
+
+// Ensure users and passwords are read from an auth file.
+func TestReadAuthFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "collectd-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString("# collectd users\nalice: secret\n\nbob:pass:word\n")
+	f.Close()
+
+	if users, err := ReadAuthFile(f.Name()); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(users, map[string]string{"alice": "secret", "bob": "pass:word"}) {
+		t.Fatalf("unexpected users: %v", users)
+	}
+
+	ioutil.WriteFile(f.Name(), []byte("alice\n"), 0600)
+	if _, err := ReadAuthFile(f.Name()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// signPacket returns data prefixed with a signature part, as sent by collectd
+// with "SecurityLevel Sign".
+func signPacket(data []byte, username, password string) []byte {
+	h := hmac.New(sha256.New, []byte(password))
+	h.Write([]byte(username))
+	h.Write(data)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint16(partTypeSignature))
+	binary.Write(&buf, binary.BigEndian, uint16(partHeaderSize+signatureSize+len(username)))
+	buf.Write(h.Sum(nil))
+	buf.WriteString(username)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// encryptPacket returns data within an encryption part, as sent by collectd
+// with "SecurityLevel Encrypt".
+func encryptPacket(data []byte, username, password string) []byte {
+	iv := []byte("0123456789abcdef")
+	sum := sha1.Sum(data)
+	plain := append(sum[:], data...)
+
+	key := sha256.Sum256([]byte(password))
+	block, err := aes.NewCipher(key[:])
+	check(err)
+	encrypted := make([]byte, len(plain))
+	cipher.NewOFB(block, iv).XORKeyStream(encrypted, plain)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint16(partTypeEncryption))
+	binary.Write(&buf, binary.BigEndian, uint16(partHeaderSize+2+len(username)+len(iv)+len(encrypted)))
+	binary.Write(&buf, binary.BigEndian, uint16(len(username)))
+	buf.WriteString(username)
+	buf.Write(iv)
+	buf.Write(encrypted)
+	return buf.Bytes()
+}
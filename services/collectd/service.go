@@ -3,9 +3,12 @@ package collectd // import "github.com/influxdata/influxdb/services/collectd"
 import (
 	"expvar"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +33,8 @@ const (
 	statPointsTransmitted    = "pointsTx"
 	statBatchesTransmitFail  = "batchesTxFail"
 	statDroppedPointsInvalid = "droppedPointsInvalid"
+	statPacketsInsecure      = "packetsInsecure"
+	statPacketsAuthFail      = "packetsAuthFail"
 )
 
 // pointsWriter is an internal interface to make testing easier.
@@ -58,6 +63,10 @@ type Service struct {
 	typesdb gollectd.Types
 	addr    net.Addr
 
+	// securityLevel and users are used to check signed and encrypted packets.
+	securityLevel string
+	users         map[string]string
+
 	// expvar-based stats.
 	statMap *expvar.Map
 }
@@ -96,9 +105,29 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	if err := s.Config.Validate(); err != nil {
+		return err
+	}
+
+	s.securityLevel = strings.ToLower(s.Config.SecurityLevel)
+	if s.securityLevel == "" {
+		s.securityLevel = SecurityLevelNone
+	}
+	if s.Config.AuthFile != "" {
+		users, err := ReadAuthFile(s.Config.AuthFile)
+		if err != nil {
+			return fmt.Errorf("unable to read auth file: %s", err)
+		}
+		s.users = users
+	}
+
 	if s.typesdb == nil {
 		// Open collectd types.
-		typesdb, err := gollectd.TypesDBFile(s.Config.TypesDB)
+		var paths []string
+		if s.Config.TypesDB != "" {
+			paths = append(paths, s.Config.TypesDB)
+		}
+		typesdb, err := LoadTypesDB(append(paths, s.Config.CustomTypesDB...)...)
 		if err != nil {
 			return fmt.Errorf("Open(): %s", err)
 		}
@@ -171,6 +200,49 @@ func (s *Service) SetLogger(l *log.Logger) {
 	s.Logger = l
 }
 
+// LoadTypesDB reads collectd types from each path, which may be a types db
+// file or a directory of them. Types in later files replace earlier ones.
+func LoadTypesDB(paths ...string) (gollectd.Types, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no types db set")
+	}
+
+	types := make(gollectd.Types)
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Read every regular file in a directory, sorted by name.
+		files := []string{path}
+		if fi.IsDir() {
+			fis, err := ioutil.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+
+			files = files[:0]
+			for _, fi := range fis {
+				if fi.Mode().IsRegular() && !strings.HasPrefix(fi.Name(), ".") {
+					files = append(files, filepath.Join(path, fi.Name()))
+				}
+			}
+		}
+
+		for _, file := range files {
+			t, err := gollectd.TypesDBFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", file, err)
+			}
+			for name, ds := range t {
+				types[name] = ds
+			}
+		}
+	}
+	return types, nil
+}
+
 // SetTypes sets collectd types db.
 func (s *Service) SetTypes(types string) (err error) {
 	s.typesdb, err = gollectd.TypesDB([]byte(types))
@@ -221,6 +293,22 @@ func (s *Service) serve() {
 }
 
 func (s *Service) handleMessage(buffer []byte) {
+	// Verify and decrypt the packet.
+	buffer, err := openPacket(buffer, s.securityLevel, s.users)
+	if err == ErrInsecurePacket {
+		s.statMap.Add(statPacketsInsecure, 1)
+		s.Logger.Printf("Collectd packet rejected: %s", err)
+		return
+	} else if _, ok := err.(*AuthError); ok {
+		s.statMap.Add(statPacketsAuthFail, 1)
+		s.Logger.Printf("Collectd packet rejected: %s", err)
+		return
+	} else if err != nil {
+		s.statMap.Add(statPointsParseFail, 1)
+		s.Logger.Printf("Collectd parse error: %s", err)
+		return
+	}
+
 	packets, err := gollectd.Packets(buffer, s.typesdb)
 	if err != nil {
 		s.statMap.Add(statPointsParseFail, 1)
@@ -276,26 +364,31 @@ func (s *Service) UnmarshalCollectd(packet *gollectd.Packet) []models.Point {
 		timestamp = time.Unix(int64(packet.Time), 0).UTC()
 	}
 
+	tags := make(map[string]string)
+	if packet.Hostname != "" {
+		tags["host"] = packet.Hostname
+	}
+	if packet.PluginInstance != "" {
+		tags["instance"] = packet.PluginInstance
+	}
+	if packet.Type != "" {
+		tags["type"] = packet.Type
+	}
+	if packet.TypeInstance != "" {
+		tags["type_instance"] = packet.TypeInstance
+	}
+
+	if strings.ToLower(s.Config.ParseMultiValuePlugin) == ParseMultiValueJoin {
+		return s.unmarshalJoined(packet, tags, timestamp)
+	}
+
 	var points []models.Point
 	for i := range packet.Values {
 		name := fmt.Sprintf("%s_%s", packet.Plugin, packet.Values[i].Name)
-		tags := make(map[string]string)
 		fields := make(map[string]interface{})
 
 		fields["value"] = packet.Values[i].Value
 
-		if packet.Hostname != "" {
-			tags["host"] = packet.Hostname
-		}
-		if packet.PluginInstance != "" {
-			tags["instance"] = packet.PluginInstance
-		}
-		if packet.Type != "" {
-			tags["type"] = packet.Type
-		}
-		if packet.TypeInstance != "" {
-			tags["type_instance"] = packet.TypeInstance
-		}
 		p, err := models.NewPoint(name, tags, fields, timestamp)
 		// Drop invalid points
 		if err != nil {
@@ -309,6 +402,27 @@ func (s *Service) UnmarshalCollectd(packet *gollectd.Packet) []models.Point {
 	return points
 }
 
+// unmarshalJoined returns a single point for the packet's values, with a
+// field per data source. Values which aren't numbers are skipped.
+func (s *Service) unmarshalJoined(packet *gollectd.Packet, tags map[string]string, timestamp time.Time) []models.Point {
+	fields := make(map[string]interface{}, len(packet.Values))
+	for _, v := range packet.Values {
+		if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+			continue
+		}
+		fields[v.Name] = v.Value
+	}
+
+	p, err := models.NewPoint(packet.Plugin, tags, fields, timestamp)
+	// Drop invalid points
+	if err != nil {
+		s.Logger.Printf("Dropping point %v: %v", packet.Plugin, err)
+		s.statMap.Add(statDroppedPointsInvalid, 1)
+		return nil
+	}
+	return []models.Point{p}
+}
+
 // assert will panic with a given formatted message if the given condition is false.
 func assert(condition bool, msg string, v ...interface{}) {
 	if !condition {
@@ -5,7 +5,11 @@ import (
 	"errors"
 	"io/ioutil"
 	"log"
+	"math"
 	"net"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -13,6 +17,7 @@ import (
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/toml"
+	"github.com/kimor79/gollectd"
 )
 
 // Test that the service checks / creates the target database on startup.
@@ -178,6 +183,148 @@ Loop:
 	}
 }
 
+// Test that the collectd service rejects unencrypted packets and accepts
+// encrypted ones when the security level is "encrypt".
+func TestService_SecurityLevel(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "collectd-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("alice: secret\n")
+	f.Close()
+
+	s := newTestService(len(expPoints), time.Second)
+	s.Config.SecurityLevel = SecurityLevelEncrypt
+	s.Config.AuthFile = f.Name()
+
+	pointCh := make(chan models.Point, 1000)
+	s.MetaClient.CreateDatabaseIfNotExistsFn = func(name string) (*meta.DatabaseInfo, error) { return nil, nil }
+	s.PointsWriter.WritePointsFn = func(req *cluster.WritePointsRequest) error {
+		for _, p := range req.Points {
+			pointCh <- p
+		}
+		return nil
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Unencrypted and incorrectly encrypted packets are rejected.
+	for _, b := range [][]byte{testData, encryptPacket(testData, "alice", "wrong")} {
+		if _, err := conn.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeout := time.After(time.Second)
+	for s.statMap.Get(statPacketsInsecure) == nil || s.statMap.Get(statPacketsAuthFail) == nil {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for packets to be rejected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if v := s.statMap.Get(statPacketsInsecure).String(); v != "1" {
+		t.Fatalf("unexpected insecure packets: %s", v)
+	} else if v := s.statMap.Get(statPacketsAuthFail).String(); v != "1" {
+		t.Fatalf("unexpected failed packets: %s", v)
+	}
+
+	// Encrypted packets are accepted.
+	if _, err := conn.Write(encryptPacket(testData, "alice", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	for i := range expPoints {
+		select {
+		case p := <-pointCh:
+			if got := p.String(); got != expPoints[i] {
+				t.Fatalf("\n\texp = %s\n\tgot = %s\n", expPoints[i], got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for points from collectd service")
+		}
+	}
+}
+
+// Test that types are loaded from files and directories, with later types
+// replacing earlier ones.
+func TestLoadTypesDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collectd-types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	check(os.Mkdir(filepath.Join(dir, "types.d"), 0755))
+	check(ioutil.WriteFile(filepath.Join(dir, "types.d", "a.db"), []byte("foo value:GAUGE:0:U\n"), 0644))
+	check(ioutil.WriteFile(filepath.Join(dir, "types.d", "b.db"), []byte("bar rx:DERIVE:0:U, tx:DERIVE:0:U\n"), 0644))
+	check(ioutil.WriteFile(filepath.Join(dir, "types.d", ".hidden"), []byte("baz x:BOGUS\n"), 0644))
+	check(os.Mkdir(filepath.Join(dir, "types.d", "subdir"), 0755))
+	check(ioutil.WriteFile(filepath.Join(dir, "custom.db"), []byte("foo min:GAUGE:0:U, max:GAUGE:0:U\n"), 0644))
+
+	types, err := LoadTypesDB(filepath.Join(dir, "types.d"), filepath.Join(dir, "custom.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(name string) []string {
+		var a []string
+		for _, ds := range types[name] {
+			a = append(a, ds.Name)
+		}
+		return a
+	}
+	if len(types) != 2 {
+		t.Fatalf("unexpected types: %v", types)
+	} else if got := names("foo"); !reflect.DeepEqual(got, []string{"min", "max"}) {
+		t.Fatalf("unexpected foo data sources: %v", got)
+	} else if got := names("bar"); !reflect.DeepEqual(got, []string{"rx", "tx"}) {
+		t.Fatalf("unexpected bar data sources: %v", got)
+	}
+
+	if _, err := LoadTypesDB(filepath.Join(dir, "missing.db")); err == nil {
+		t.Fatal("expected error for missing types db")
+	} else if _, err := LoadTypesDB(); err == nil {
+		t.Fatal("expected error without types db")
+	}
+}
+
+// Test that values are joined into a single point when parse-multivalue-plugin is "join".
+func TestService_UnmarshalCollectd_Join(t *testing.T) {
+	s := newTestService(1, time.Second)
+	s.Config.ParseMultiValuePlugin = ParseMultiValueJoin
+
+	points := s.UnmarshalCollectd(&gollectd.Packet{
+		Hostname:       "server01",
+		Plugin:         "interface",
+		PluginInstance: "eth0",
+		Type:           "if_octets",
+		Time:           1414080767,
+		Values: []gollectd.Value{
+			{Name: "rx", Type: gollectd.TypeDerive, Value: 10},
+			{Name: "tx", Type: gollectd.TypeDerive, Value: 20},
+			{Name: "errors", Type: gollectd.TypeGauge, Value: math.NaN()},
+		},
+	})
+
+	exp := "interface,host=server01,instance=eth0,type=if_octets rx=10,tx=20 1414080767000000000"
+	if len(points) != 1 {
+		t.Fatalf("unexpected points: %v", points)
+	} else if got := points[0].String(); got != exp {
+		t.Fatalf("\n\texp = %s\n\tgot = %s\n", exp, got)
+	}
+}
+
 type testService struct {
 	*Service
 	MetaClient   testMetaClient
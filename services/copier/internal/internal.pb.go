@@ -25,6 +25,8 @@ var _ = math.Inf
 
 type Request struct {
 	ShardID          *uint64 `protobuf:"varint,1,req,name=ShardID" json:"ShardID,omitempty"`
+	DiskSize         *bool   `protobuf:"varint,2,opt,name=DiskSize" json:"DiskSize,omitempty"`
+	Since            *int64  `protobuf:"varint,3,opt,name=Since" json:"Since,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -39,8 +41,23 @@ func (m *Request) GetShardID() uint64 {
 	return 0
 }
 
+func (m *Request) GetDiskSize() bool {
+	if m != nil && m.DiskSize != nil {
+		return *m.DiskSize
+	}
+	return false
+}
+
+func (m *Request) GetSince() int64 {
+	if m != nil && m.Since != nil {
+		return *m.Since
+	}
+	return 0
+}
+
 type Response struct {
 	Error            *string `protobuf:"bytes,1,opt,name=Error" json:"Error,omitempty"`
+	DiskSize         *int64  `protobuf:"varint,2,opt,name=DiskSize" json:"DiskSize,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -54,3 +71,10 @@ func (m *Response) GetError() string {
 	}
 	return ""
 }
+
+func (m *Response) GetDiskSize() int64 {
+	if m != nil && m.DiskSize != nil {
+		return *m.DiskSize
+	}
+	return 0
+}
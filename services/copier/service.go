@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/influxdata/influxdb/services/copier/internal"
@@ -29,6 +30,8 @@ type Service struct {
 
 	TSDBStore interface {
 		Shard(id uint64) *tsdb.Shard
+		BackupShard(id uint64, since time.Time, w io.Writer) error
+		DiskSize() (int64, error)
 	}
 
 	Listener net.Listener
@@ -104,6 +107,21 @@ func (s *Service) handleConn(conn net.Conn) error {
 		return fmt.Errorf("read request: %s", err)
 	}
 
+	// Return the size of the store if requested instead of a shard.
+	if req.GetDiskSize() {
+		resp := &internal.Response{}
+		if n, err := s.TSDBStore.DiskSize(); err != nil {
+			resp.Error = proto.String(err.Error())
+		} else {
+			resp.DiskSize = proto.Int64(n)
+		}
+
+		if err := s.writeResponse(conn, resp); err != nil {
+			return fmt.Errorf("write disk size response: %s", err)
+		}
+		return nil
+	}
+
 	// Retrieve shard.
 	sh := s.TSDBStore.Shard(req.GetShardID())
 
@@ -122,8 +140,13 @@ func (s *Service) handleConn(conn net.Conn) error {
 		return fmt.Errorf("write response: %s", err)
 	}
 
-	// Write shard to response.
-	if _, err := sh.WriteTo(conn); err != nil {
+	// Write the shard's files modified since the requested time to the
+	// response as a tar archive. All files are written if no time is set.
+	var since time.Time
+	if req.Since != nil {
+		since = time.Unix(0, req.GetSince())
+	}
+	if err := s.TSDBStore.BackupShard(req.GetShardID(), since, conn); err != nil {
 		return fmt.Errorf("write shard: %s", err)
 	}
 
@@ -177,6 +200,9 @@ func (s *Service) writeResponse(w io.Writer, resp *internal.Response) error {
 // Client represents a client for connecting remotely to a copier service.
 type Client struct {
 	host string
+
+	// Dialer secures the connection to the server. Plain if nil.
+	Dialer *tcp.Dialer
 }
 
 // NewClient return a new instance of Client.
@@ -186,17 +212,22 @@ func NewClient(host string) *Client {
 	}
 }
 
-// ShardReader returns a reader for streaming shard data.
-// Returned ReadCloser must be closed by the caller.
-func (c *Client) ShardReader(id uint64) (io.ReadCloser, error) {
+// ShardReader returns a reader for streaming shard data as a tar archive
+// of the shard's files modified after since. All files are streamed if since
+// is zero. Returned ReadCloser must be closed by the caller.
+func (c *Client) ShardReader(id uint64, since time.Time) (io.ReadCloser, error) {
 	// Connect to remote server.
-	conn, err := tcp.Dial("tcp", c.host, MuxHeader)
+	conn, err := c.Dialer.Dial("tcp", c.host, MuxHeader)
 	if err != nil {
 		return nil, err
 	}
 
 	// Send request to server.
-	if err := c.writeRequest(conn, &internal.Request{ShardID: proto.Uint64(id)}); err != nil {
+	req := &internal.Request{ShardID: proto.Uint64(id)}
+	if !since.IsZero() {
+		req.Since = proto.Int64(since.UnixNano())
+	}
+	if err := c.writeRequest(conn, req); err != nil {
 		return nil, fmt.Errorf("write request: %s", err)
 	}
 
@@ -216,6 +247,31 @@ func (c *Client) ShardReader(id uint64) (io.ReadCloser, error) {
 	return conn, nil
 }
 
+// DiskSize returns the size of all shards on the remote server in bytes.
+func (c *Client) DiskSize() (int64, error) {
+	// Connect to remote server.
+	conn, err := c.Dialer.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// Send request to server.
+	if err := c.writeRequest(conn, &internal.Request{ShardID: proto.Uint64(0), DiskSize: proto.Bool(true)}); err != nil {
+		return 0, fmt.Errorf("write request: %s", err)
+	}
+
+	// Read response from the server.
+	resp, err := c.readResponse(conn)
+	if err != nil {
+		return 0, fmt.Errorf("read response: %s", err)
+	} else if resp.GetError() != "" {
+		return 0, errors.New(resp.GetError())
+	}
+
+	return resp.GetDiskSize(), nil
+}
+
 // writeRequest marshals and writes req to w.
 func (c *Client) writeRequest(w io.Writer, req *internal.Request) error {
 	// Marshal request.
@@ -1,8 +1,8 @@
 package copier_test
 
 import (
-	"bytes"
-	"encoding/binary"
+	"archive/tar"
+	"errors"
 	"io"
 	"io/ioutil"
 	"log"
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/services/copier"
 	"github.com/influxdata/influxdb/tcp"
@@ -19,7 +20,6 @@ import (
 
 // Ensure the service can return shard data.
 func TestService_handleConn(t *testing.T) {
-	t.Skip("not implemented for tsm1 engine")
 	s := MustOpenService()
 	defer s.Close()
 
@@ -32,10 +32,25 @@ func TestService_handleConn(t *testing.T) {
 		}
 		return sh.Shard
 	}
+	s.TSDBStore.BackupShardFn = func(id uint64, since time.Time, w io.Writer) error {
+		if id != 123 {
+			t.Fatalf("unexpected id: %d", id)
+		} else if !since.IsZero() {
+			t.Fatalf("unexpected since: %s", since)
+		}
+
+		tw := tar.NewWriter(w)
+		if err := tw.WriteHeader(&tar.Header{Name: "db0/rp0/123/000000001-000000001.tsm", Size: 4}); err != nil {
+			return err
+		} else if _, err := tw.Write([]byte("data")); err != nil {
+			return err
+		}
+		return tw.Close()
+	}
 
 	// Create client and request shard from service.
 	c := copier.NewClient(s.Addr().String())
-	r, err := c.ShardReader(123)
+	r, err := c.ShardReader(123, time.Time{})
 	if err != nil {
 		t.Fatal(err)
 	} else if r == nil {
@@ -43,28 +58,44 @@ func TestService_handleConn(t *testing.T) {
 	}
 	defer r.Close()
 
-	// Slurp from reader.
-	var n uint64
-	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+	// Read the archive from the reader.
+	tr := tar.NewReader(r)
+	if hdr, err := tr.Next(); err != nil {
 		t.Fatal(err)
-	}
-	buf := make([]byte, n)
-	if _, err := io.ReadFull(r, buf); err != nil {
+	} else if hdr.Name != "db0/rp0/123/000000001-000000001.tsm" {
+		t.Fatalf("unexpected file: %s", hdr.Name)
+	} else if buf, err := ioutil.ReadAll(tr); err != nil {
 		t.Fatal(err)
+	} else if string(buf) != "data" {
+		t.Fatalf("unexpected data: %q", buf)
+	} else if _, err := tr.Next(); err != io.EOF {
+		t.Fatalf("expected EOF, got: %v", err)
+	}
+}
+
+// Ensure the service only returns the files modified since the requested time.
+func TestService_handleConn_Since(t *testing.T) {
+	s := MustOpenService()
+	defer s.Close()
+
+	sh := MustOpenShard(123)
+	defer sh.Close()
+	s.TSDBStore.ShardFn = func(id uint64) *tsdb.Shard { return sh.Shard }
+	s.TSDBStore.BackupShardFn = func(id uint64, since time.Time, w io.Writer) error {
+		if !since.Equal(time.Unix(0, 100)) {
+			t.Fatalf("unexpected since: %s", since)
+		}
+		return tar.NewWriter(w).Close()
 	}
 
-	// Read database from disk.
-	exp, err := ioutil.ReadFile(sh.Path())
+	r, err := copier.NewClient(s.Addr().String()).ShardReader(123, time.Unix(0, 100))
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer r.Close()
 
-	// Trim expected bytes since bolt won't read beyond the HWM.
-	exp = exp[0:len(buf)]
-
-	// Compare disk and reader contents.
-	if !bytes.Equal(exp, buf) {
-		t.Fatalf("data mismatch: exp=len(%d), got=len(%d)", len(exp), len(buf))
+	if _, err := tar.NewReader(r).Next(); err != io.EOF {
+		t.Fatalf("expected EOF, got: %v", err)
 	}
 }
 
@@ -78,7 +109,7 @@ func TestService_handleConn_Error(t *testing.T) {
 
 	// Create client and request shard from service.
 	c := copier.NewClient(s.Addr().String())
-	r, err := c.ShardReader(123)
+	r, err := c.ShardReader(123, time.Time{})
 	if err == nil || err.Error() != `shard not found: id=123` {
 		t.Fatalf("unexpected error: %s", err)
 	} else if r != nil {
@@ -86,6 +117,25 @@ func TestService_handleConn_Error(t *testing.T) {
 	}
 }
 
+// Ensure the service can return the size of the store.
+func TestService_handleConn_DiskSize(t *testing.T) {
+	s := MustOpenService()
+	defer s.Close()
+	s.TSDBStore.DiskSizeFn = func() (int64, error) { return 1000, nil }
+
+	if n, err := copier.NewClient(s.Addr().String()).DiskSize(); err != nil {
+		t.Fatal(err)
+	} else if n != 1000 {
+		t.Fatalf("unexpected disk size: %d", n)
+	}
+
+	// Ensure store errors are returned to the client.
+	s.TSDBStore.DiskSizeFn = func() (int64, error) { return 0, errors.New("marker") }
+	if _, err := copier.NewClient(s.Addr().String()).DiskSize(); err == nil || err.Error() != "marker" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // Service represents a test wrapper for copier.Service.
 type Service struct {
 	*copier.Service
@@ -143,11 +193,19 @@ func (s *Service) Addr() net.Addr { return s.ln.Addr() }
 
 // ServiceTSDBStore is a mock that implements copier.Service.TSDBStore.
 type ServiceTSDBStore struct {
-	ShardFn func(id uint64) *tsdb.Shard
+	ShardFn       func(id uint64) *tsdb.Shard
+	BackupShardFn func(id uint64, since time.Time, w io.Writer) error
+	DiskSizeFn    func() (int64, error)
 }
 
 func (ss *ServiceTSDBStore) Shard(id uint64) *tsdb.Shard { return ss.ShardFn(id) }
 
+func (ss *ServiceTSDBStore) BackupShard(id uint64, since time.Time, w io.Writer) error {
+	return ss.BackupShardFn(id, since, w)
+}
+
+func (ss *ServiceTSDBStore) DiskSize() (int64, error) { return ss.DiskSizeFn() }
+
 // Shard is a test wrapper for tsdb.Shard.
 type Shard struct {
 	*tsdb.Shard
@@ -0,0 +1,189 @@
+package copier
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tcp"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// StatementExecutor translates COPY SHARD and REMOVE SHARD statements into
+// shard transfers between data nodes.
+type StatementExecutor struct {
+	MetaClient interface {
+		DataNode(id uint64) (*meta.NodeInfo, error)
+		ShardOwner(shardID uint64) (database, policy string, sgi *meta.ShardGroupInfo)
+		AddShardOwner(id, nodeID uint64) error
+		RemoveShardOwner(id, nodeID uint64) error
+	}
+
+	TSDBStore interface {
+		Shard(id uint64) *tsdb.Shard
+		CreateShard(database, policy string, shardID uint64) error
+		RestoreShard(id uint64, r io.Reader) error
+		DeleteShard(id uint64) error
+	}
+
+	// Node is the data node the statements are executed on.
+	Node *influxdb.Node
+
+	// Dialer secures connections to other nodes. Plain if nil.
+	Dialer *tcp.Dialer
+}
+
+// ExecuteStatement executes shard-related query statements.
+func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.Result {
+	switch stmt := stmt.(type) {
+	case *influxql.CopyShardStatement:
+		return &influxql.Result{Err: e.executeCopyShardStatement(stmt)}
+	case *influxql.RemoveShardStatement:
+		return &influxql.Result{Err: e.executeRemoveShardStatement(stmt)}
+	default:
+		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
+	}
+}
+
+func (e *StatementExecutor) executeCopyShardStatement(stmt *influxql.CopyShardStatement) error {
+	// The destination creates the shard in its local store so the statement
+	// must be run there.
+	if stmt.Destination != e.Node.ID {
+		return fmt.Errorf("copy shard must be run on the destination node %d", stmt.Destination)
+	}
+	return e.CopyShard(stmt.ShardID, stmt.Source, nil)
+}
+
+// CopyShard copies a shard from the source node to the local node and adds
+// the local node as an owner. If wrap is not nil, it is applied to the
+// stream read from the source node.
+func (e *StatementExecutor) CopyShard(id, source uint64, wrap func(io.Reader) io.Reader) error {
+	if source == e.Node.ID {
+		return fmt.Errorf("shard %d cannot be copied to the node it is copied from", id)
+	}
+
+	database, policy, si, err := e.shard(id)
+	if err != nil {
+		return err
+	} else if !si.OwnedBy(source) {
+		return fmt.Errorf("shard %d is not owned by node %d", id, source)
+	} else if si.OwnedBy(e.Node.ID) || e.TSDBStore.Shard(id) != nil {
+		return fmt.Errorf("shard %d already exists on node %d", id, e.Node.ID)
+	}
+
+	// Look up the address of the node to copy from.
+	ni, err := e.MetaClient.DataNode(source)
+	if err != nil {
+		return err
+	} else if ni == nil {
+		return meta.ErrNodeNotFound
+	}
+
+	// Copy the shard and only add the destination as an owner once the
+	// restored shard has been reopened. A failed copy is removed again so
+	// a partial shard is never left behind. File modification times may
+	// only have second precision, so the catch up starts a second early.
+	start := time.Now().Add(-time.Second)
+	err = e.TSDBStore.CreateShard(database, policy, id)
+	if err == nil {
+		err = e.copyShard(ni.TCPHost, id, time.Time{}, wrap)
+	}
+	if err != nil {
+		e.TSDBStore.DeleteShard(id)
+		return fmt.Errorf("copy shard %d: %s", id, err)
+	}
+
+	if err := e.MetaClient.AddShardOwner(id, e.Node.ID); err != nil {
+		e.TSDBStore.DeleteShard(id)
+		return err
+	}
+
+	// Writes made during the copy were only sent to the other owners, so
+	// copy the files the source has written since the copy started.
+	if err := e.copyShard(ni.TCPHost, id, start, wrap); err != nil {
+		if rerr := e.MetaClient.RemoveShardOwner(id, e.Node.ID); rerr != nil {
+			return fmt.Errorf("catch up shard %d: %s (remove owner: %s)", id, err, rerr)
+		}
+		e.TSDBStore.DeleteShard(id)
+		return fmt.Errorf("catch up shard %d: %s", id, err)
+	}
+	return nil
+}
+
+// copyShard streams the files of a shard modified on host since the given
+// time into the local shard. All files are copied if since is zero.
+func (e *StatementExecutor) copyShard(host string, id uint64, since time.Time, wrap func(io.Reader) io.Reader) error {
+	c := NewClient(host)
+	c.Dialer = e.Dialer
+	r, err := c.ShardReader(id, since)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var rd io.Reader = r
+	if wrap != nil {
+		rd = wrap(r)
+	}
+
+	if err := e.TSDBStore.RestoreShard(id, rd); err != nil {
+		return err
+	}
+
+	// Verify the restored shard is open and readable.
+	sh := e.TSDBStore.Shard(id)
+	if sh == nil {
+		return tsdb.ErrShardNotFound
+	}
+	if _, err := sh.SeriesCount(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *StatementExecutor) executeRemoveShardStatement(stmt *influxql.RemoveShardStatement) error {
+	// The shard's files are deleted from the local store so the statement
+	// must be run on the node the shard is removed from.
+	if stmt.Source != e.Node.ID {
+		return fmt.Errorf("remove shard must be run on node %d", stmt.Source)
+	}
+	return e.RemoveShard(stmt.ShardID)
+}
+
+// RemoveShard removes the local node as an owner of a shard and deletes the
+// shard from the local store. The last owner of a shard cannot be removed and
+// the meta store rejects removals that leave a shard under-replicated.
+func (e *StatementExecutor) RemoveShard(id uint64) error {
+	_, _, si, err := e.shard(id)
+	if err != nil {
+		return err
+	} else if !si.OwnedBy(e.Node.ID) {
+		return fmt.Errorf("shard %d is not owned by node %d", id, e.Node.ID)
+	} else if len(si.Owners) == 1 {
+		return meta.ErrShardNotReplicated
+	}
+
+	// Remove ownership first so no more writes or queries are sent here.
+	if err := e.MetaClient.RemoveShardOwner(id, e.Node.ID); err != nil {
+		return err
+	}
+	return e.TSDBStore.DeleteShard(id)
+}
+
+// shard returns the database, retention policy and shard info of a shard.
+func (e *StatementExecutor) shard(id uint64) (database, policy string, si *meta.ShardInfo, err error) {
+	database, policy, sgi := e.MetaClient.ShardOwner(id)
+	if sgi == nil {
+		return "", "", nil, meta.ErrShardNotFound
+	}
+
+	for i := range sgi.Shards {
+		if sgi.Shards[i].ID == id {
+			return database, policy, &sgi.Shards[i], nil
+		}
+	}
+	return "", "", nil, meta.ErrShardNotFound
+}
@@ -0,0 +1,283 @@
+package copier_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/services/copier"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// Ensure a shard can be copied from another node and the destination is
+// added as an owner.
+func TestStatementExecutor_CopyShard(t *testing.T) {
+	// Serve a shard with data from the source node.
+	src := MustOpenStore()
+	defer src.Close()
+	src.MustCreateShardWithData("db0", "rp0", 1,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverB value=2 10`,
+	)
+
+	s := MustOpenService()
+	defer s.Close()
+	s.Service.TSDBStore = src.Store
+
+	dst := MustOpenStore()
+	defer dst.Close()
+
+	var owner uint64
+	e := NewStatementExecutor(2, dst)
+	e.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) {
+		if id != 1 {
+			t.Fatalf("unexpected node id: %d", id)
+		}
+		return &meta.NodeInfo{ID: 1, TCPHost: s.Addr().String()}, nil
+	}
+	e.MetaClient.AddShardOwnerFn = func(id, nodeID uint64) error {
+		if id != 1 {
+			t.Fatalf("unexpected shard id: %d", id)
+		}
+		owner = nodeID
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`COPY SHARD 1 FROM 1 TO 2`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if owner != 2 {
+		t.Fatalf("unexpected owner: %d", owner)
+	} else if sh := dst.Shard(1); sh == nil {
+		t.Fatal("expected shard")
+	} else if n := dst.DatabaseIndex("db0").SeriesN(); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+}
+
+// Ensure writes to the source made while the shard was copied are copied
+// once the destination is an owner.
+func TestStatementExecutor_CopyShard_CatchUp(t *testing.T) {
+	src := MustOpenStore()
+	defer src.Close()
+	src.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=serverA value=1 0`)
+
+	s := MustOpenService()
+	defer s.Close()
+	s.Service.TSDBStore = src.Store
+
+	dst := MustOpenStore()
+	defer dst.Close()
+
+	e := NewStatementExecutor(2, dst)
+	e.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) {
+		return &meta.NodeInfo{ID: 1, TCPHost: s.Addr().String()}, nil
+	}
+	e.MetaClient.AddShardOwnerFn = func(id, nodeID uint64) error {
+		// Simulate a write that was only sent to the source.
+		return src.WriteToShard(1, []models.Point{models.MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 10))})
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`COPY SHARD 1 FROM 1 TO 2`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if n := dst.DatabaseIndex("db0").SeriesN(); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	}
+}
+
+// Ensure a failed copy doesn't leave a shard behind or change its owners.
+func TestStatementExecutor_CopyShard_Error(t *testing.T) {
+	s := MustOpenService()
+	defer s.Close()
+	s.TSDBStore.ShardFn = func(id uint64) *tsdb.Shard { return nil }
+
+	dst := MustOpenStore()
+	defer dst.Close()
+
+	e := NewStatementExecutor(2, dst)
+	e.MetaClient.DataNodeFn = func(id uint64) (*meta.NodeInfo, error) {
+		return &meta.NodeInfo{ID: 1, TCPHost: s.Addr().String()}, nil
+	}
+	e.MetaClient.AddShardOwnerFn = func(id, nodeID uint64) error {
+		t.Fatal("unexpected owner change")
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`COPY SHARD 1 FROM 1 TO 2`)); res.Err == nil || res.Err.Error() != `copy shard 1: shard not found: id=1` {
+		t.Fatalf("unexpected error: %v", res.Err)
+	} else if sh := dst.Shard(1); sh != nil {
+		t.Fatal("unexpected shard")
+	}
+}
+
+// Ensure invalid copies are rejected before any data is transferred.
+func TestStatementExecutor_CopyShard_Invalid(t *testing.T) {
+	dst := MustOpenStore()
+	defer dst.Close()
+
+	e := NewStatementExecutor(2, dst)
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `COPY SHARD 1 FROM 1 TO 3`, err: `copy shard must be run on the destination node 3`},
+		{s: `COPY SHARD 1 FROM 2 TO 2`, err: `shard 1 cannot be copied to the node it is copied from`},
+		{s: `COPY SHARD 2 FROM 1 TO 2`, err: `shard not found`},
+		{s: `COPY SHARD 1 FROM 3 TO 2`, err: `shard 1 is not owned by node 3`},
+	} {
+		if res := e.ExecuteStatement(influxql.MustParseStatement(tt.s)); res.Err == nil || res.Err.Error() != tt.err {
+			t.Errorf("%s: unexpected error: %v", tt.s, res.Err)
+		}
+	}
+}
+
+// Ensure a shard can be removed from the local node.
+func TestStatementExecutor_RemoveShard(t *testing.T) {
+	store := MustOpenStore()
+	defer store.Close()
+	store.MustCreateShardWithData("db0", "rp0", 1, `cpu value=1 0`)
+
+	var removed uint64
+	e := NewStatementExecutor(1, store)
+	e.MetaClient.ShardOwnerFn = func(id uint64) (string, string, *meta.ShardGroupInfo) {
+		return "db0", "rp0", &meta.ShardGroupInfo{Shards: []meta.ShardInfo{
+			{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}, {NodeID: 2}}},
+		}}
+	}
+	e.MetaClient.RemoveShardOwnerFn = func(id, nodeID uint64) error {
+		removed = nodeID
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`REMOVE SHARD 1 FROM 1`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if removed != 1 {
+		t.Fatalf("unexpected owner removed: %d", removed)
+	} else if sh := store.Shard(1); sh != nil {
+		t.Fatal("expected shard to be deleted")
+	}
+}
+
+// Ensure the last copy of a shard cannot be removed.
+func TestStatementExecutor_RemoveShard_Invalid(t *testing.T) {
+	store := MustOpenStore()
+	defer store.Close()
+
+	e := NewStatementExecutor(1, store)
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `REMOVE SHARD 1 FROM 2`, err: `remove shard must be run on node 2`},
+		{s: `REMOVE SHARD 2 FROM 1`, err: `shard not found`},
+		{s: `REMOVE SHARD 1 FROM 1`, err: `shard not replicated`},
+	} {
+		if res := e.ExecuteStatement(influxql.MustParseStatement(tt.s)); res.Err == nil || res.Err.Error() != tt.err {
+			t.Errorf("%s: unexpected error: %v", tt.s, res.Err)
+		}
+	}
+}
+
+// StatementExecutor is a test wrapper for copier.StatementExecutor.
+type StatementExecutor struct {
+	*copier.StatementExecutor
+	MetaClient StatementExecutorMetaClient
+}
+
+// NewStatementExecutor returns a new executor for node id. By default the
+// meta client reports shard 1 of db0.rp0 as owned only by node 1.
+func NewStatementExecutor(id uint64, store *Store) *StatementExecutor {
+	e := &StatementExecutor{
+		StatementExecutor: &copier.StatementExecutor{
+			TSDBStore: store.Store,
+			Node:      &influxdb.Node{ID: id},
+		},
+	}
+	e.StatementExecutor.MetaClient = &e.MetaClient
+
+	e.MetaClient.ShardOwnerFn = func(id uint64) (string, string, *meta.ShardGroupInfo) {
+		if id != 1 {
+			return "", "", nil
+		}
+		return "db0", "rp0", &meta.ShardGroupInfo{Shards: []meta.ShardInfo{
+			{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}}},
+		}}
+	}
+	return e
+}
+
+// StatementExecutorMetaClient is a mock that implements copier.StatementExecutor.MetaClient.
+type StatementExecutorMetaClient struct {
+	DataNodeFn         func(id uint64) (*meta.NodeInfo, error)
+	ShardOwnerFn       func(shardID uint64) (string, string, *meta.ShardGroupInfo)
+	AddShardOwnerFn    func(id, nodeID uint64) error
+	RemoveShardOwnerFn func(id, nodeID uint64) error
+}
+
+func (c *StatementExecutorMetaClient) DataNode(id uint64) (*meta.NodeInfo, error) {
+	return c.DataNodeFn(id)
+}
+
+func (c *StatementExecutorMetaClient) ShardOwner(shardID uint64) (string, string, *meta.ShardGroupInfo) {
+	return c.ShardOwnerFn(shardID)
+}
+
+func (c *StatementExecutorMetaClient) AddShardOwner(id, nodeID uint64) error {
+	return c.AddShardOwnerFn(id, nodeID)
+}
+
+func (c *StatementExecutorMetaClient) RemoveShardOwner(id, nodeID uint64) error {
+	return c.RemoveShardOwnerFn(id, nodeID)
+}
+
+// Store is a test wrapper for tsdb.Store.
+type Store struct {
+	*tsdb.Store
+}
+
+// MustOpenStore returns a new, open Store at a temporary path.
+func MustOpenStore() *Store {
+	path, err := ioutil.TempDir("", "copier-store-")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Store{Store: tsdb.NewStore(path)}
+	s.EngineOptions.Config.WALDir = filepath.Join(path, "wal")
+	if err := s.Open(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Close closes the store and removes the underlying data.
+func (s *Store) Close() error {
+	defer os.RemoveAll(s.Path())
+	return s.Store.Close()
+}
+
+// MustCreateShardWithData creates a shard and writes line protocol data to it.
+func (s *Store) MustCreateShardWithData(db, rp string, shardID uint64, data ...string) {
+	if err := s.CreateShard(db, rp, shardID); err != nil {
+		panic(err)
+	}
+
+	var points []models.Point
+	for i := range data {
+		a, err := models.ParsePointsWithPrecision([]byte(strings.TrimSpace(data[i])), time.Time{}, "s")
+		if err != nil {
+			panic(err)
+		}
+		points = append(points, a...)
+	}
+
+	if err := s.WriteToShard(shardID, points); err != nil {
+		panic(err)
+	}
+}
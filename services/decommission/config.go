@@ -0,0 +1,27 @@
+package decommission
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the time between checks for leaving data nodes
+	// if none is specified.
+	DefaultCheckInterval = time.Minute
+)
+
+// Config represents the configuration for data node decommissioning.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	CheckInterval toml.Duration `toml:"check-interval"`
+}
+
+// NewConfig returns a new Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:       true,
+		CheckInterval: toml.Duration(DefaultCheckInterval),
+	}
+}
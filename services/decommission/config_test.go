@@ -0,0 +1,27 @@
+package decommission_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/services/decommission"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c decommission.Config
+	if _, err := toml.Decode(`
+enabled = false
+check-interval = "30s"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if c.Enabled {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if time.Duration(c.CheckInterval) != 30*time.Second {
+		t.Fatalf("unexpected check interval: %s", c.CheckInterval)
+	}
+}
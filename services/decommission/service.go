@@ -0,0 +1,386 @@
+package decommission // import "github.com/influxdata/influxdb/services/decommission"
+
+import (
+	"expvar"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// Statistics for the decommission service.
+const (
+	statCopyOK       = "copyOk"
+	statCopyFail     = "copyFail"
+	statHandOffOK    = "handOffOk"
+	statHandOffFail  = "handOffFail"
+	statRemoveOK     = "removeOk"
+	statRemoveFail   = "removeFail"
+	statNodesRemoved = "nodesRemoved"
+)
+
+// Move represents a shard copied from a leaving data node to a remaining
+// node, or removed from a leaving node once enough remaining nodes own it.
+type Move struct {
+	ShardID uint64
+
+	// Source is the leaving node. If Destination is zero the shard is
+	// removed from Source instead.
+	Source      uint64
+	Destination uint64
+}
+
+// IsCopy returns true if the move copies a shard to another node.
+func (m Move) IsCopy() bool { return m.Destination != 0 }
+
+// Service moves the shards of data nodes marked as leaving by DECOMMISSION
+// SERVER to the remaining data nodes and removes a leaving node from the
+// cluster once it no longer owns any shards.
+//
+// Every node plans the same moves from the meta data but only executes the
+// copies to itself and the removals from itself. Since the progress of a
+// decommission is kept in the meta data, an interrupted decommission resumes
+// with the next check.
+type Service struct {
+	config Config
+
+	MetaClient interface {
+		Databases() ([]meta.DatabaseInfo, error)
+		DataNodes() ([]meta.NodeInfo, error)
+		AddShardOwner(id, nodeID uint64) error
+		DeleteDataNode(id uint64) error
+	}
+
+	TSDBStore interface {
+		Shard(id uint64) *tsdb.Shard
+	}
+
+	// ShardMover copies shards to and removes shards from the local node.
+	ShardMover interface {
+		CopyShard(id, source uint64, wrap func(io.Reader) io.Reader) error
+		RemoveShard(id uint64) error
+	}
+
+	Node *influxdb.Node
+
+	Logger  *log.Logger
+	statMap *expvar.Map
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		config:  c,
+		Logger:  log.New(os.Stderr, "[decommission] ", log.LstdFlags),
+		statMap: influxdb.NewStatistics("decommission", "decommission", nil),
+	}
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.Logger = l
+}
+
+// Open starts the service.
+func (s *Service) Open() error {
+	if s.done != nil {
+		return nil
+	}
+
+	s.Logger.Printf("Starting decommission service with check interval of %s", s.config.CheckInterval)
+
+	s.done = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the service.
+func (s *Service) Close() error {
+	if s.done == nil {
+		return nil
+	}
+
+	close(s.done)
+	s.wg.Wait()
+	s.done = nil
+
+	return nil
+}
+
+// run periodically moves the shards of leaving data nodes.
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.decommission(); err != nil {
+				s.Logger.Printf("failed to decommission data nodes: %s", err)
+			}
+		case <-s.done:
+			s.Logger.Println("Decommission service terminating")
+			return
+		}
+	}
+}
+
+// decommission executes the local node's moves for the leaving data nodes
+// and then removes the leaving nodes that no longer own any shards.
+func (s *Service) decommission() error {
+	nodes, err := s.MetaClient.DataNodes()
+	if err != nil {
+		return err
+	} else if !hasLeaving(nodes) {
+		return nil
+	}
+
+	dbs, err := s.MetaClient.Databases()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range plan(dbs, nodes) {
+		select {
+		case <-s.done:
+			return nil
+		default:
+		}
+
+		if m.IsCopy() && m.Destination == s.Node.ID {
+			s.copyShard(m)
+		} else if m.IsCopy() && m.Source == s.Node.ID {
+			s.handOffShard(m)
+		} else if !m.IsCopy() && m.Source == s.Node.ID {
+			s.removeShard(m)
+		}
+	}
+
+	return s.removeNodes()
+}
+
+// copyShard copies a shard from the leaving node to the local node.
+func (s *Service) copyShard(m Move) {
+	s.Logger.Printf("copying shard %d from leaving node %d", m.ShardID, m.Source)
+	if err := s.ShardMover.CopyShard(m.ShardID, m.Source, nil); err != nil {
+		s.statMap.Add(statCopyFail, 1)
+		s.Logger.Printf("failed to copy shard %d from node %d: %s", m.ShardID, m.Source, err)
+		return
+	}
+	s.statMap.Add(statCopyOK, 1)
+}
+
+// handOffShard adds the destination as an owner of a shard that was never
+// written to on the local node. There is no data to copy so the destination
+// takes over the shard without a copy.
+func (s *Service) handOffShard(m Move) {
+	if s.TSDBStore.Shard(m.ShardID) != nil {
+		return
+	}
+
+	s.Logger.Printf("handing off empty shard %d to node %d", m.ShardID, m.Destination)
+	if err := s.MetaClient.AddShardOwner(m.ShardID, m.Destination); err != nil {
+		s.statMap.Add(statHandOffFail, 1)
+		s.Logger.Printf("failed to hand off shard %d to node %d: %s", m.ShardID, m.Destination, err)
+		return
+	}
+	s.statMap.Add(statHandOffOK, 1)
+}
+
+// removeShard removes a shard from the local node.
+func (s *Service) removeShard(m Move) {
+	s.Logger.Printf("removing shard %d", m.ShardID)
+	if err := s.ShardMover.RemoveShard(m.ShardID); err != nil {
+		s.statMap.Add(statRemoveFail, 1)
+		s.Logger.Printf("failed to remove shard %d: %s", m.ShardID, err)
+		return
+	}
+	s.statMap.Add(statRemoveOK, 1)
+}
+
+// removeNodes deletes the leaving data nodes that no longer own any shards.
+// Only the remaining node with the lowest id deletes nodes so the command is
+// sent once.
+func (s *Service) removeNodes() error {
+	nodes, err := s.MetaClient.DataNodes()
+	if err != nil {
+		return err
+	}
+
+	var first uint64
+	for _, n := range nodes {
+		if !n.Leaving && (first == 0 || n.ID < first) {
+			first = n.ID
+		}
+	}
+	if first != s.Node.ID {
+		return nil
+	}
+
+	dbs, err := s.MetaClient.Databases()
+	if err != nil {
+		return err
+	}
+	owned := shardCounts(dbs)
+
+	for _, n := range nodes {
+		if !n.Leaving || owned[n.ID] > 0 {
+			continue
+		}
+
+		if err := s.MetaClient.DeleteDataNode(n.ID); err != nil {
+			return err
+		}
+		s.statMap.Add(statNodesRemoved, 1)
+		s.Logger.Printf("removed decommissioned data node %d", n.ID)
+	}
+	return nil
+}
+
+// plan returns the moves that take the shards of the leaving data nodes off
+// them. While a shard has fewer remaining owners than its replication
+// factor, it's copied from a leaving owner to the remaining nodes owning the
+// fewest shards, preferring nodes in zones without a remaining owner. Once
+// it has enough remaining owners, it's removed from the leaving owners. The
+// replication factor is capped at the number of remaining nodes. Shards of
+// deleted shard groups are ignored.
+func plan(dbs []meta.DatabaseInfo, nodes []meta.NodeInfo) []Move {
+	leaving := make(map[uint64]bool)
+	zone := make(map[uint64]string)
+	var active []uint64
+	for _, n := range nodes {
+		zone[n.ID] = n.Zone
+		if n.Leaving {
+			leaving[n.ID] = true
+		} else {
+			active = append(active, n.ID)
+		}
+	}
+	if len(leaving) == 0 || len(active) == 0 {
+		return nil
+	}
+	sort.Sort(uint64Slice(active))
+
+	count := shardCounts(dbs)
+
+	var moves []Move
+	for _, di := range dbs {
+		for _, rpi := range di.RetentionPolicies {
+			replicaN := rpi.ReplicaN
+			if replicaN < 1 {
+				replicaN = 1
+			} else if replicaN > len(active) {
+				replicaN = len(active)
+			}
+
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+
+				for _, si := range sgi.Shards {
+					var sources []uint64
+					owners := make(map[uint64]bool)
+					zones := make(map[string]bool)
+					for _, o := range si.Owners {
+						owners[o.NodeID] = true
+						if leaving[o.NodeID] {
+							sources = append(sources, o.NodeID)
+						} else {
+							zones[zone[o.NodeID]] = true
+						}
+					}
+					if len(sources) == 0 {
+						continue
+					}
+
+					n := len(si.Owners) - len(sources)
+					if n >= replicaN {
+						for _, id := range sources {
+							moves = append(moves, Move{ShardID: si.ID, Source: id})
+						}
+						continue
+					}
+
+					for ; n < replicaN; n++ {
+						dest := leastLoaded(active, count, func(id uint64) bool { return !owners[id] && !zones[zone[id]] })
+						if dest == 0 {
+							dest = leastLoaded(active, count, func(id uint64) bool { return !owners[id] })
+						}
+						if dest == 0 {
+							break
+						}
+						moves = append(moves, Move{ShardID: si.ID, Source: sources[0], Destination: dest})
+						owners[dest] = true
+						zones[zone[dest]] = true
+						count[dest]++
+					}
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// leastLoaded returns the node in ids owning the fewest shards that passes
+// filter. Ties go to the lowest id. Returns zero if no node passes.
+func leastLoaded(ids []uint64, count map[uint64]int, filter func(uint64) bool) uint64 {
+	var id uint64
+	for _, other := range ids {
+		if !filter(other) {
+			continue
+		} else if id == 0 || count[other] < count[id] {
+			id = other
+		}
+	}
+	return id
+}
+
+// shardCounts returns the number of shards of shard groups that haven't
+// been deleted owned by each node.
+func shardCounts(dbs []meta.DatabaseInfo) map[uint64]int {
+	m := make(map[uint64]int)
+	for _, di := range dbs {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+				for _, si := range sgi.Shards {
+					for _, o := range si.Owners {
+						m[o.NodeID]++
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
+// hasLeaving returns true if any of the nodes is leaving the cluster.
+func hasLeaving(nodes []meta.NodeInfo) bool {
+	for _, n := range nodes {
+		if n.Leaving {
+			return true
+		}
+	}
+	return false
+}
+
+type uint64Slice []uint64
+
+func (a uint64Slice) Len() int           { return len(a) }
+func (a uint64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a uint64Slice) Less(i, j int) bool { return a[i] < a[j] }
@@ -0,0 +1,246 @@
+package decommission
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/toml"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// Ensure shards of a leaving node are copied to the remaining nodes owning
+// the fewest shards and removed once they are replicated.
+func TestPlan(t *testing.T) {
+	dbs := newDatabases(2,
+		[]uint64{1, 2},
+		[]uint64{1, 3},
+		[]uint64{2, 3},
+		[]uint64{1, 4},
+	)
+	nodes := []meta.NodeInfo{{ID: 1, Leaving: true}, {ID: 2}, {ID: 3}, {ID: 4}}
+
+	exp := []Move{
+		{ShardID: 1, Source: 1, Destination: 4},
+		{ShardID: 2, Source: 1, Destination: 2},
+		{ShardID: 4, Source: 1, Destination: 3},
+	}
+	if moves := plan(dbs, nodes); !reflect.DeepEqual(moves, exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, moves)
+	}
+
+	// Once copied, the shards are removed from the leaving node.
+	dbs = newDatabases(2,
+		[]uint64{1, 2, 4},
+		[]uint64{1, 3, 2},
+		[]uint64{2, 3},
+		[]uint64{1, 4, 3},
+	)
+	exp = []Move{
+		{ShardID: 1, Source: 1},
+		{ShardID: 2, Source: 1},
+		{ShardID: 4, Source: 1},
+	}
+	if moves := plan(dbs, nodes); !reflect.DeepEqual(moves, exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, moves)
+	}
+}
+
+// Ensure shards are copied to nodes in zones without a remaining owner before
+// nodes owning fewer shards.
+func TestPlan_Zones(t *testing.T) {
+	dbs := newDatabases(2,
+		[]uint64{1, 2},
+		[]uint64{3, 4},
+		[]uint64{4},
+	)
+	nodes := []meta.NodeInfo{
+		{ID: 1, Zone: "a", Leaving: true},
+		{ID: 2, Zone: "b"},
+		{ID: 3, Zone: "b"},
+		{ID: 4, Zone: "a"},
+	}
+
+	exp := []Move{{ShardID: 1, Source: 1, Destination: 4}}
+	if moves := plan(dbs, nodes); !reflect.DeepEqual(moves, exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, moves)
+	}
+
+	// Fall back to a node in a used zone if no other zone is left.
+	nodes[3].Zone = "b"
+	exp = []Move{{ShardID: 1, Source: 1, Destination: 3}}
+	if moves := plan(dbs, nodes); !reflect.DeepEqual(moves, exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, moves)
+	}
+}
+
+// Ensure the replication factor is capped at the number of remaining nodes.
+func TestPlan_ReplicationCapped(t *testing.T) {
+	dbs := newDatabases(3,
+		[]uint64{1, 2, 3},
+		[]uint64{1},
+	)
+	nodes := []meta.NodeInfo{{ID: 1, Leaving: true}, {ID: 2}, {ID: 3}}
+
+	exp := []Move{
+		{ShardID: 1, Source: 1},
+		{ShardID: 2, Source: 1, Destination: 2},
+		{ShardID: 2, Source: 1, Destination: 3},
+	}
+	if moves := plan(dbs, nodes); !reflect.DeepEqual(moves, exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, moves)
+	}
+}
+
+// Ensure nothing is planned without a leaving node.
+func TestPlan_NoLeaving(t *testing.T) {
+	dbs := newDatabases(1, []uint64{1})
+	if moves := plan(dbs, []meta.NodeInfo{{ID: 1}, {ID: 2}}); len(moves) != 0 {
+		t.Fatalf("unexpected moves: %+v", moves)
+	}
+}
+
+// Ensure a remaining node copies the shards planned for it and deletes the
+// leaving node once it no longer owns shards.
+func TestService_Decommission(t *testing.T) {
+	s := NewTestService(2)
+	c := &metaClient{
+		dbs:   newDatabases(1, []uint64{1}, []uint64{2}),
+		nodes: []meta.NodeInfo{{ID: 1, Leaving: true}, {ID: 2}},
+	}
+	m := &shardMover{}
+	s.MetaClient = c
+	s.ShardMover = m
+	s.Open()
+	defer s.Close()
+
+	// Shard 1 is copied but node 1 still owns it.
+	if err := s.decommission(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(m.copied, []Move{{ShardID: 1, Source: 1}}) {
+		t.Fatalf("unexpected copied shards: %+v", m.copied)
+	} else if len(m.removed) != 0 {
+		t.Fatalf("unexpected removed shards: %v", m.removed)
+	} else if len(c.deleted) != 0 {
+		t.Fatalf("unexpected deleted nodes: %v", c.deleted)
+	}
+
+	// Node 1 removed the shard so it's deleted with the next check.
+	c.dbs[0].RetentionPolicies[0].ShardGroups[0].Shards[0].Owners = []meta.ShardOwner{{NodeID: 2}}
+	if err := s.decommission(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(c.deleted, []uint64{1}) {
+		t.Fatalf("unexpected deleted nodes: %v", c.deleted)
+	}
+}
+
+// Ensure a leaving node hands off shards it has no data for and removes
+// shards that are replicated on the remaining nodes.
+func TestService_Decommission_Leaving(t *testing.T) {
+	s := NewTestService(1)
+	c := &metaClient{
+		dbs:   newDatabases(1, []uint64{1}, []uint64{1, 2}),
+		nodes: []meta.NodeInfo{{ID: 1, Leaving: true}, {ID: 2}},
+	}
+	m := &shardMover{}
+	s.MetaClient = c
+	s.ShardMover = m
+	s.Open()
+	defer s.Close()
+
+	if err := s.decommission(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(c.dbs[0].RetentionPolicies[0].ShardGroups[0].Shards[0].Owners, []meta.ShardOwner{{NodeID: 1}, {NodeID: 2}}) {
+		t.Fatalf("unexpected owners: %+v", c.dbs[0].RetentionPolicies[0].ShardGroups[0].Shards[0].Owners)
+	} else if len(m.copied) != 0 {
+		t.Fatalf("unexpected copied shards: %+v", m.copied)
+	} else if !reflect.DeepEqual(m.removed, []uint64{2}) {
+		t.Fatalf("unexpected removed shards: %v", m.removed)
+	} else if len(c.deleted) != 0 {
+		t.Fatalf("unexpected deleted nodes: %v", c.deleted)
+	}
+}
+
+// NewTestService returns a service for node id with a discarded log.
+func NewTestService(id uint64) *Service {
+	c := NewConfig()
+	c.CheckInterval = toml.Duration(time.Hour)
+
+	s := NewService(c)
+	s.Node = &influxdb.Node{ID: id}
+	s.TSDBStore = &tsdbStore{}
+	if !testing.Verbose() {
+		s.SetLogger(log.New(ioutil.Discard, "", 0))
+	}
+	return s
+}
+
+// newDatabases returns a database with a shard for each list of owners.
+// Shards are numbered in order from 1.
+func newDatabases(replicaN int, owners ...[]uint64) []meta.DatabaseInfo {
+	var sgi meta.ShardGroupInfo
+	for i, a := range owners {
+		si := meta.ShardInfo{ID: uint64(i + 1)}
+		for _, id := range a {
+			si.Owners = append(si.Owners, meta.ShardOwner{NodeID: id})
+		}
+		sgi.Shards = append(sgi.Shards, si)
+	}
+
+	return []meta.DatabaseInfo{{
+		RetentionPolicies: []meta.RetentionPolicyInfo{{
+			ReplicaN:    replicaN,
+			ShardGroups: []meta.ShardGroupInfo{sgi},
+		}},
+	}}
+}
+
+type metaClient struct {
+	dbs     []meta.DatabaseInfo
+	nodes   []meta.NodeInfo
+	deleted []uint64
+}
+
+func (c *metaClient) Databases() ([]meta.DatabaseInfo, error) { return c.dbs, nil }
+func (c *metaClient) DataNodes() ([]meta.NodeInfo, error)     { return c.nodes, nil }
+
+func (c *metaClient) AddShardOwner(id, nodeID uint64) error {
+	shards := c.dbs[0].RetentionPolicies[0].ShardGroups[0].Shards
+	for i := range shards {
+		if shards[i].ID == id {
+			shards[i].Owners = append(shards[i].Owners, meta.ShardOwner{NodeID: nodeID})
+		}
+	}
+	return nil
+}
+
+func (c *metaClient) DeleteDataNode(id uint64) error {
+	c.deleted = append(c.deleted, id)
+	return nil
+}
+
+// tsdbStore is a store without any local shards.
+type tsdbStore struct{}
+
+func (s *tsdbStore) Shard(id uint64) *tsdb.Shard { return nil }
+
+// shardMover records the shards it's asked to copy and remove.
+type shardMover struct {
+	copied  []Move
+	removed []uint64
+}
+
+func (m *shardMover) CopyShard(id, source uint64, wrap func(io.Reader) io.Reader) error {
+	m.copied = append(m.copied, Move{ShardID: id, Source: source})
+	return nil
+}
+
+func (m *shardMover) RemoveShard(id uint64) error {
+	m.removed = append(m.removed, id)
+	return nil
+}
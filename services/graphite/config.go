@@ -136,11 +136,18 @@ func (c *Config) validateTemplates() error {
 			return fmt.Errorf("missing template at position: %d", i)
 		}
 
+		// Strip the optional @database[:retention-policy] target.
+		var target string
+		if last := parts[len(parts)-1]; len(parts) > 1 && strings.HasPrefix(last, "@") {
+			target = last
+			parts = parts[:len(parts)-1]
+		}
+
 		if len(parts) > 3 {
 			return fmt.Errorf("invalid template format: '%s'", t)
 		}
 
-		template := t
+		template := parts[0]
 		filter := ""
 		tags := ""
 		if len(parts) >= 2 {
@@ -159,11 +166,21 @@ func (c *Config) validateTemplates() error {
 			tags = parts[2]
 		}
 
-		// Validate the template has one and only one measurement
-		if err := c.validateTemplate(template); err != nil {
+		if template == "drop" {
+			if tags != "" || target != "" {
+				return fmt.Errorf("drop template cannot have tags or a target: '%s'", t)
+			}
+		} else if err := c.validateTemplate(template); err != nil {
+			// Validate the template has one and only one measurement
 			return err
 		}
 
+		if target != "" {
+			if database, retentionPolicy := parseTarget(target); database == "" && retentionPolicy == "" {
+				return fmt.Errorf("invalid template target: '%s'", target)
+			}
+		}
+
 		// Prevent duplicate filters in the config
 		if _, ok := filters[filter]; ok {
 			return fmt.Errorf("duplicate filter '%s' found at position: %d", filter, i)
@@ -165,3 +165,31 @@ func TestConfigValidateFilterDuplicates(t *testing.T) {
 	}
 
 }
+
+func TestConfigValidateTemplateTargets(t *testing.T) {
+	c := &graphite.Config{}
+	c.Templates = []string{
+		"drop",
+		"stats.* measurement.field @stats",
+		"tmp.* .measurement env=tmp @:short",
+		"servers.* .host.measurement* @servers:week",
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("config validate expected no error, got %v", err)
+	}
+
+	c.Templates = []string{"stats.* measurement.field @"}
+	if err := c.Validate(); err == nil {
+		t.Errorf("config validate expected error. got nil")
+	}
+
+	c.Templates = []string{"stats.* drop env=tmp"}
+	if err := c.Validate(); err == nil {
+		t.Errorf("config validate expected error. got nil")
+	}
+
+	c.Templates = []string{"stats.* drop @stats"}
+	if err := c.Validate(); err == nil {
+		t.Errorf("config validate expected error. got nil")
+	}
+}
@@ -1,6 +1,12 @@
 package graphite
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMetricDropped is returned when a metric matches a template that drops it.
+var ErrMetricDropped = errors.New("metric dropped by template")
 
 // An UnsupportedValueError is returned when a parsed value is not
 // supported.
@@ -50,11 +50,20 @@ func NewParserWithOptions(options Options) (*Parser, error) {
 
 		template := pattern
 		filter := ""
-		// Format is [filter] <template> [tag1=value1,tag2=value2]
+		// Format is [filter] <template> [tag1=value1,tag2=value2] [@database[:retention-policy]]
 		parts := strings.Fields(pattern)
 		if len(parts) < 1 {
 			continue
-		} else if len(parts) >= 2 {
+		}
+
+		var database, retentionPolicy string
+		if last := parts[len(parts)-1]; len(parts) > 1 && strings.HasPrefix(last, "@") {
+			database, retentionPolicy = parseTarget(last)
+			parts = parts[:len(parts)-1]
+			template = parts[0]
+		}
+
+		if len(parts) >= 2 {
 			if strings.Contains(parts[1], "=") {
 				template = parts[0]
 			} else {
@@ -73,10 +82,17 @@ func NewParserWithOptions(options Options) (*Parser, error) {
 			}
 		}
 
+		// A template of "drop" discards the metrics matching its filter.
+		if template == "drop" {
+			matcher.Add(filter, newDropTemplate())
+			continue
+		}
+
 		tmpl, err := NewTemplate(template, tags, options.Separator)
 		if err != nil {
 			return nil, err
 		}
+		tmpl.database, tmpl.retentionPolicy = database, retentionPolicy
 		matcher.Add(filter, tmpl)
 	}
 	return &Parser{matcher: matcher, tags: options.DefaultTags}, nil
@@ -94,32 +110,57 @@ func NewParser(templates []string, defaultTags models.Tags) (*Parser, error) {
 
 // Parse performs Graphite parsing of a single line.
 func (p *Parser) Parse(line string) (models.Point, error) {
+	pt, _, err := p.parse(line)
+	return pt, err
+}
+
+// parse parses a single line and also returns the template that matched it.
+func (p *Parser) parse(line string) (models.Point, *template, error) {
 	// Break into 3 fields (name, value, timestamp).
 	fields := strings.Fields(line)
 	if len(fields) != 2 && len(fields) != 3 {
-		return nil, fmt.Errorf("received %q which doesn't have required fields", line)
+		return nil, nil, fmt.Errorf("received %q which doesn't have required fields", line)
 	}
 
-	// decode the name and tags
-	template := p.matcher.Match(fields[0])
-	measurement, tags, field, err := template.Apply(fields[0])
+	// Parse value.
+	v, err := strconv.ParseFloat(fields[1], 64)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf(`field "%s" value: %s`, fields[0], err)
 	}
 
-	// Could not extract measurement, use the raw value
-	if measurement == "" {
-		measurement = fields[0]
+	// If no 3rd field, use now as timestamp
+	unixTime := float64(-1)
+	if len(fields) == 3 {
+		// Parse timestamp.
+		unixTime, err = strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf(`field "%s" time: %s`, fields[0], err)
+		}
 	}
 
-	// Parse value.
-	v, err := strconv.ParseFloat(fields[1], 64)
+	return p.parseMetric(fields[0], v, unixTime)
+}
+
+// parseMetric returns the point for a metric name, value and unix time, and
+// the template that matched the name.
+func (p *Parser) parseMetric(name string, v float64, unixTime float64) (models.Point, *template, error) {
+	// decode the name and tags
+	template := p.matcher.Match(name)
+	if template.drop {
+		return nil, template, ErrMetricDropped
+	}
+	measurement, tags, field, err := template.Apply(name)
 	if err != nil {
-		return nil, fmt.Errorf(`field "%s" value: %s`, fields[0], err)
+		return nil, template, err
+	}
+
+	// Could not extract measurement, use the raw value
+	if measurement == "" {
+		measurement = name
 	}
 
 	if math.IsNaN(v) || math.IsInf(v, 0) {
-		return nil, &UnsupportedValueError{Field: fields[0], Value: v}
+		return nil, template, &UnsupportedValueError{Field: name, Value: v}
 	}
 
 	fieldValues := map[string]interface{}{}
@@ -129,24 +170,14 @@ func (p *Parser) Parse(line string) (models.Point, error) {
 		fieldValues["value"] = v
 	}
 
-	// If no 3rd field, use now as timestamp
+	// -1 is a special value that gets converted to current UTC time
+	// See https://github.com/graphite-project/carbon/issues/54
 	timestamp := time.Now().UTC()
-
-	if len(fields) == 3 {
-		// Parse timestamp.
-		unixTime, err := strconv.ParseFloat(fields[2], 64)
-		if err != nil {
-			return nil, fmt.Errorf(`field "%s" time: %s`, fields[0], err)
-		}
-
-		// -1 is a special value that gets converted to current UTC time
-		// See https://github.com/graphite-project/carbon/issues/54
-		if unixTime != float64(-1) {
-			// Check if we have fractional seconds
-			timestamp = time.Unix(int64(unixTime), int64((unixTime-math.Floor(unixTime))*float64(time.Second)))
-			if timestamp.Before(MinDate) || timestamp.After(MaxDate) {
-				return nil, fmt.Errorf("timestamp out of range")
-			}
+	if unixTime != float64(-1) {
+		// Check if we have fractional seconds
+		timestamp = time.Unix(int64(unixTime), int64((unixTime-math.Floor(unixTime))*float64(time.Second)))
+		if timestamp.Before(MinDate) || timestamp.After(MaxDate) {
+			return nil, template, fmt.Errorf("timestamp out of range")
 		}
 	}
 
@@ -156,7 +187,25 @@ func (p *Parser) Parse(line string) (models.Point, error) {
 			tags[k] = v
 		}
 	}
-	return models.NewPoint(measurement, tags, fieldValues, timestamp)
+	pt, err := models.NewPoint(measurement, tags, fieldValues, timestamp)
+	return pt, template, err
+}
+
+// targets returns the databases and retention policies set by templates.
+func (p *Parser) targets() []target {
+	var a []target
+	seen := make(map[target]bool)
+	p.matcher.Walk(func(t *template) {
+		if t.database == "" && t.retentionPolicy == "" {
+			return
+		}
+		tt := target{database: t.database, retentionPolicy: t.retentionPolicy}
+		if !seen[tt] {
+			seen[tt] = true
+			a = append(a, tt)
+		}
+	})
+	return a
 }
 
 // ApplyTemplate extracts the template fields from the given line and
@@ -169,6 +218,9 @@ func (p *Parser) ApplyTemplate(line string) (string, map[string]string, string,
 	}
 	// decode the name and tags
 	template := p.matcher.Match(fields[0])
+	if template.drop {
+		return "", make(map[string]string), "", ErrMetricDropped
+	}
 	name, tags, field, err := template.Apply(fields[0])
 	// Set the default tags on the point if they are not already set
 	for k, v := range p.tags {
@@ -185,6 +237,34 @@ type template struct {
 	defaultTags       models.Tags
 	greedyMeasurement bool
 	separator         string
+
+	// drop discards matching metrics.
+	drop bool
+
+	// database and retentionPolicy override where matching metrics are
+	// written. Empty values use the input's settings.
+	database        string
+	retentionPolicy string
+}
+
+// newDropTemplate returns a template that drops matching metrics.
+func newDropTemplate() *template {
+	return &template{drop: true}
+}
+
+// target is a database and retention policy points are written to.
+type target struct {
+	database        string
+	retentionPolicy string
+}
+
+// parseTarget parses a template target of the form @database[:retention-policy].
+func parseTarget(s string) (database, retentionPolicy string) {
+	s = strings.TrimPrefix(s, "@")
+	if i := strings.Index(s, ":"); i != -1 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
 }
 
 // NewTemplate returns a new template ensuring it has a measurement
@@ -280,6 +360,14 @@ func (m *matcher) AddDefaultTemplate(template *template) {
 	m.defaultTemplate = template
 }
 
+// Walk calls fn for every template in the matcher.
+func (m *matcher) Walk(fn func(*template)) {
+	if m.defaultTemplate != nil {
+		fn(m.defaultTemplate)
+	}
+	m.root.walk(fn)
+}
+
 // Match returns the template that matches the given graphite line
 func (m *matcher) Match(line string) *template {
 	tmpl := m.root.Search(line)
@@ -328,6 +416,17 @@ func (n *node) insert(values []string, template *template) {
 	newNode.insert(values[1:], template)
 }
 
+// walk calls fn for the template of n and of every child of n. Templates
+// inherited by wildcard children may be visited more than once.
+func (n *node) walk(fn func(*template)) {
+	if n.template != nil {
+		fn(n.template)
+	}
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
 // Insert inserts the given string template into the tree.  The filter string is separated
 // on "." and each part is used as the path in the tree.
 func (n *node) Insert(filter string, template *template) {
@@ -694,3 +694,46 @@ func TestApplyTemplateFieldError(t *testing.T) {
 			"'field' can only be used once in each template: current.users.logged_in")
 	}
 }
+
+// Test that metrics matching a drop template are dropped.
+func TestParseDropTemplate(t *testing.T) {
+	p, err := graphite.NewParserWithOptions(graphite.Options{
+		Templates: []string{
+			"servers.*.tmp.* drop",
+			"servers.* .host.measurement*",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating parser, got %v", err)
+	}
+
+	if _, err := p.Parse("servers.localhost.tmp.cpu 50 1435077219"); err != graphite.ErrMetricDropped {
+		t.Fatalf("expected metric to be dropped, got %v", err)
+	}
+
+	pt, err := p.Parse("servers.localhost.cpu_load 11 1435077219")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if pt.Name() != "cpu_load" {
+		t.Fatalf("unexpected measurement: %s", pt.Name())
+	}
+}
+
+// Test that a template's database and retention policy don't change the point.
+func TestParseTemplateTarget(t *testing.T) {
+	p, err := graphite.NewParserWithOptions(graphite.Options{
+		Templates: []string{"servers.* .host.measurement* region=us-west @servers:week"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating parser, got %v", err)
+	}
+
+	pt, err := p.Parse("servers.localhost.cpu_load 11 1435077219")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if pt.Name() != "cpu_load" {
+		t.Fatalf("unexpected measurement: %s", pt.Name())
+	} else if tags := pt.Tags(); !reflect.DeepEqual(tags, models.Tags{"host": "localhost", "region": "us-west"}) {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+}
@@ -0,0 +1,475 @@
+package graphite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxPickleSize is the largest pickle accepted from a carbon relay.
+const MaxPickleSize = 4 * 1024 * 1024
+
+// pickleMetric is a single metric sent in a pickle.
+type pickleMetric struct {
+	name      string
+	value     float64
+	timestamp float64
+}
+
+// pickleList is a list on the unpickler stack. It's a pointer so that
+// appends are seen through the memo.
+type pickleList struct {
+	items []interface{}
+}
+
+// decodePickle decodes a pickled list of (path, (timestamp, value)) tuples as
+// sent by carbon relays. Only the opcodes needed to build lists, tuples,
+// strings and numbers are supported; any opcode that could import or call
+// objects is rejected.
+func decodePickle(b []byte) ([]pickleMetric, error) {
+	u := &unpickler{buf: b, memo: make(map[int]interface{})}
+	v, err := u.load()
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := v.(*pickleList)
+	if !ok {
+		return nil, errors.New("pickle is not a list")
+	}
+
+	metrics := make([]pickleMetric, 0, len(list.items))
+	for _, item := range list.items {
+		t, ok := item.([]interface{})
+		if !ok || len(t) != 2 {
+			return nil, errors.New("pickled metric is not a (path, (timestamp, value)) tuple")
+		}
+		name, ok := t[0].(string)
+		if !ok {
+			return nil, errors.New("pickled metric path is not a string")
+		}
+		dp, ok := t[1].([]interface{})
+		if !ok || len(dp) != 2 {
+			return nil, fmt.Errorf("pickled metric %q has no (timestamp, value) tuple", name)
+		}
+
+		timestamp, ok := pickleFloat(dp[0])
+		if !ok {
+			return nil, fmt.Errorf("pickled metric %q has invalid timestamp", name)
+		}
+		value, ok := pickleFloat(dp[1])
+		if !ok {
+			return nil, fmt.Errorf("pickled metric %q has invalid value", name)
+		}
+
+		metrics = append(metrics, pickleMetric{name: name, value: value, timestamp: timestamp})
+	}
+	return metrics, nil
+}
+
+// pickleFloat returns a pickled number as a float.
+func pickleFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		// Protocol 0 pickles may send numbers as strings.
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// unpickler is a minimal pickle virtual machine.
+type unpickler struct {
+	buf   []byte
+	pos   int
+	stack []interface{}
+	marks []int
+	memo  map[int]interface{}
+}
+
+// load runs the pickle until the STOP opcode and returns the result.
+func (u *unpickler) load() (interface{}, error) {
+	for {
+		op, err := u.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case 0x80: // PROTO
+			if _, err := u.read(1); err != nil {
+				return nil, err
+			}
+		case 0x95: // FRAME
+			if _, err := u.read(8); err != nil {
+				return nil, err
+			}
+		case '.': // STOP
+			return u.pop()
+
+		case '(': // MARK
+			u.marks = append(u.marks, len(u.stack))
+		case ']': // EMPTY_LIST
+			u.push(&pickleList{})
+		case 'l': // LIST
+			items, err := u.popMark()
+			if err != nil {
+				return nil, err
+			}
+			u.push(&pickleList{items: items})
+		case ')': // EMPTY_TUPLE
+			u.push([]interface{}{})
+		case 't': // TUPLE
+			items, err := u.popMark()
+			if err != nil {
+				return nil, err
+			}
+			u.push(items)
+		case 0x85, 0x86, 0x87: // TUPLE1, TUPLE2, TUPLE3
+			n := int(op-0x85) + 1
+			if len(u.stack)-n < u.bottom() {
+				return nil, errors.New("pickle stack underflow")
+			}
+			items := make([]interface{}, n)
+			copy(items, u.stack[len(u.stack)-n:])
+			u.stack = u.stack[:len(u.stack)-n]
+			u.push(items)
+		case 'a': // APPEND
+			v, err := u.pop()
+			if err != nil {
+				return nil, err
+			}
+			if err := u.append(v); err != nil {
+				return nil, err
+			}
+		case 'e': // APPENDS
+			items, err := u.popMark()
+			if err != nil {
+				return nil, err
+			}
+			if err := u.append(items...); err != nil {
+				return nil, err
+			}
+
+		case 'N': // NONE
+			u.push(nil)
+		case 0x88: // NEWTRUE
+			u.push(int64(1))
+		case 0x89: // NEWFALSE
+			u.push(int64(0))
+		case 'J': // BININT
+			b, err := u.read(4)
+			if err != nil {
+				return nil, err
+			}
+			u.push(int64(int32(binary.LittleEndian.Uint32(b))))
+		case 'K': // BININT1
+			b, err := u.read(1)
+			if err != nil {
+				return nil, err
+			}
+			u.push(int64(b[0]))
+		case 'M': // BININT2
+			b, err := u.read(2)
+			if err != nil {
+				return nil, err
+			}
+			u.push(int64(binary.LittleEndian.Uint16(b)))
+		case 'I', 'L': // INT, LONG
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			v, err := parsePickleInt(strings.TrimSuffix(line, "L"))
+			if err != nil {
+				return nil, err
+			}
+			u.push(v)
+		case 0x8a: // LONG1
+			n, err := u.readByte()
+			if err != nil {
+				return nil, err
+			}
+			b, err := u.read(int(n))
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeLong(b)
+			if err != nil {
+				return nil, err
+			}
+			u.push(v)
+		case 'G': // BINFLOAT
+			b, err := u.read(8)
+			if err != nil {
+				return nil, err
+			}
+			u.push(math.Float64frombits(binary.BigEndian.Uint64(b)))
+		case 'F': // FLOAT
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			f, err := strconv.ParseFloat(line, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pickle float: %s", err)
+			}
+			u.push(f)
+
+		case 'S': // STRING
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			s, err := unquotePickleString(line)
+			if err != nil {
+				return nil, err
+			}
+			u.push(s)
+		case 'V': // UNICODE
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			u.push(line)
+		case 'T', 'X', 'B': // BINSTRING, BINUNICODE, BINBYTES
+			b, err := u.read(4)
+			if err != nil {
+				return nil, err
+			}
+			s, err := u.read(int(binary.LittleEndian.Uint32(b)))
+			if err != nil {
+				return nil, err
+			}
+			u.push(string(s))
+		case 'U', 'C', 0x8c: // SHORT_BINSTRING, SHORT_BINBYTES, SHORT_BINUNICODE
+			n, err := u.readByte()
+			if err != nil {
+				return nil, err
+			}
+			s, err := u.read(int(n))
+			if err != nil {
+				return nil, err
+			}
+			u.push(string(s))
+
+		case 'p': // PUT
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			i, err := strconv.Atoi(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pickle memo index: %s", err)
+			}
+			if err := u.put(i); err != nil {
+				return nil, err
+			}
+		case 'q': // BINPUT
+			b, err := u.read(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.put(int(b[0])); err != nil {
+				return nil, err
+			}
+		case 'r': // LONG_BINPUT
+			b, err := u.read(4)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.put(int(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+		case 0x94: // MEMOIZE
+			if err := u.put(len(u.memo)); err != nil {
+				return nil, err
+			}
+		case 'g': // GET
+			line, err := u.readLine()
+			if err != nil {
+				return nil, err
+			}
+			i, err := strconv.Atoi(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pickle memo index: %s", err)
+			}
+			if err := u.get(i); err != nil {
+				return nil, err
+			}
+		case 'h': // BINGET
+			b, err := u.read(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.get(int(b[0])); err != nil {
+				return nil, err
+			}
+		case 'j': // LONG_BINGET
+			b, err := u.read(4)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.get(int(binary.LittleEndian.Uint32(b))); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported pickle opcode: 0x%02x", op)
+		}
+	}
+}
+
+func (u *unpickler) read(n int) ([]byte, error) {
+	if n < 0 || len(u.buf)-u.pos < n {
+		return nil, errors.New("unexpected end of pickle")
+	}
+	b := u.buf[u.pos : u.pos+n]
+	u.pos += n
+	return b, nil
+}
+
+func (u *unpickler) readByte() (byte, error) {
+	b, err := u.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readLine reads up to the next newline, which is discarded.
+func (u *unpickler) readLine() (string, error) {
+	i := bytes.IndexByte(u.buf[u.pos:], '\n')
+	if i == -1 {
+		return "", errors.New("unexpected end of pickle")
+	}
+	line := string(u.buf[u.pos : u.pos+i])
+	u.pos += i + 1
+	return line, nil
+}
+
+func (u *unpickler) push(v interface{}) {
+	u.stack = append(u.stack, v)
+}
+
+// bottom returns the index of the lowest value on the stack which may be
+// popped without popping the last mark.
+func (u *unpickler) bottom() int {
+	if len(u.marks) == 0 {
+		return 0
+	}
+	return u.marks[len(u.marks)-1]
+}
+
+func (u *unpickler) pop() (interface{}, error) {
+	if len(u.stack) <= u.bottom() {
+		return nil, errors.New("pickle stack underflow")
+	}
+	v := u.stack[len(u.stack)-1]
+	u.stack = u.stack[:len(u.stack)-1]
+	return v, nil
+}
+
+// popMark pops all values above the last mark.
+func (u *unpickler) popMark() ([]interface{}, error) {
+	if len(u.marks) == 0 {
+		return nil, errors.New("pickle mark not found")
+	}
+	i := u.marks[len(u.marks)-1]
+	u.marks = u.marks[:len(u.marks)-1]
+	if i > len(u.stack) {
+		return nil, errors.New("pickle stack underflow")
+	}
+
+	items := make([]interface{}, len(u.stack)-i)
+	copy(items, u.stack[i:])
+	u.stack = u.stack[:i]
+	return items, nil
+}
+
+// append appends values to the list on top of the stack.
+func (u *unpickler) append(values ...interface{}) error {
+	if len(u.stack) <= u.bottom() {
+		return errors.New("pickle stack underflow")
+	}
+	list, ok := u.stack[len(u.stack)-1].(*pickleList)
+	if !ok {
+		return errors.New("pickle append to a non-list")
+	}
+	list.items = append(list.items, values...)
+	return nil
+}
+
+func (u *unpickler) put(i int) error {
+	if len(u.stack) <= u.bottom() {
+		return errors.New("pickle stack underflow")
+	}
+	u.memo[i] = u.stack[len(u.stack)-1]
+	return nil
+}
+
+func (u *unpickler) get(i int) error {
+	v, ok := u.memo[i]
+	if !ok {
+		return fmt.Errorf("pickle memo index not found: %d", i)
+	}
+	u.push(v)
+	return nil
+}
+
+// parsePickleInt parses a protocol 0 integer. Integers too large for an
+// int64 are returned as floats.
+func parsePickleInt(s string) (interface{}, error) {
+	switch s {
+	case "00":
+		return int64(0), nil
+	case "01":
+		return int64(1), nil
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pickle integer: %s", s)
+	}
+	return f, nil
+}
+
+// decodeLong decodes a little-endian two's complement integer of up to
+// 8 bytes.
+func decodeLong(b []byte) (int64, error) {
+	if len(b) > 8 {
+		return 0, errors.New("pickle integer too large")
+	} else if len(b) == 0 {
+		return 0, nil
+	}
+
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+
+	// Sign extend negative values.
+	if b[len(b)-1]&0x80 != 0 && len(b) < 8 {
+		v |= ^uint64(0) << (8 * uint(len(b)))
+	}
+	return int64(v), nil
+}
+
+// unquotePickleString removes the quotes around a protocol 0 string.
+func unquotePickleString(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || (s[0] != '\'' && s[0] != '"') {
+		return "", fmt.Errorf("invalid pickle string: %s", s)
+	}
+	return s[1 : len(s)-1], nil
+}
@@ -0,0 +1,47 @@
+package graphite
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Ensure pickles written by carbon with protocol 0 and 2 are decoded.
+func TestDecodePickle(t *testing.T) {
+	exp := []pickleMetric{
+		{name: "servers.a.cpu", value: 0.5, timestamp: 1420070400},
+		{name: "servers.b.cpu", value: 2, timestamp: 1420070400},
+	}
+
+	for _, b := range []string{
+		"(lp0\n(Vservers.a.cpu\np1\n(I1420070400\nF0.5\ntp2\ntp3\na(Vservers.b.cpu\np4\n(F1420070400.0\nI2\ntp5\ntp6\na.",
+		"\x80\x02]q\x00(X\r\x00\x00\x00servers.a.cpuq\x01J\x00\x8e\xa4TG?\xe0\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03X\r\x00\x00\x00servers.b.cpuq\x04GA\xd5)#\x80\x00\x00\x00K\x02\x86q\x05\x86q\x06e.",
+	} {
+		metrics, err := decodePickle([]byte(b))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", b, err)
+		} else if !reflect.DeepEqual(metrics, exp) {
+			t.Errorf("%q: unexpected metrics:\n\nexp=%#v\n\ngot=%#v", b, exp, metrics)
+		}
+	}
+}
+
+// Ensure pickles that aren't a list of metrics or that call into Python are rejected.
+func TestDecodePickle_Invalid(t *testing.T) {
+	for _, b := range []string{
+		"",
+		"cos\nsystem\n(S'ls'\ntR.",
+		"\x80\x02cos\nsystem\nq\x00X\x02\x00\x00\x00lsq\x01\x85q\x02Rq\x03.",
+		"(lp0\nI1\na.",
+		"(lp0\n(S'cpu'\nI1\ntp1\na.",
+		"(lp0\n(S'cpu'\n(S'x'\nI1\ntp1\ntp2\na.",
+		"(lp0\n",
+		"\x80\x02]q\x00h\x05.",
+		"K\x01K\x02(\x86t.",
+		"](K\x01a.",
+		"K\x01(.",
+	} {
+		if _, err := decodePickle([]byte(b)); err == nil {
+			t.Errorf("%q: expected error", b)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package graphite // import "github.com/influxdata/influxdb/services/graphite"
 
 import (
 	"bufio"
+	"encoding/binary"
 	"expvar"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"net"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/influxdata/influxdb"
 	"github.com/influxdata/influxdb/cluster"
+	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/monitor"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/tsdb"
@@ -31,6 +34,7 @@ const (
 	statPointsParseFail     = "pointsParseFail"
 	statPointsNaNFail       = "pointsNaNFail"
 	statPointsUnsupported   = "pointsUnsupportedFail"
+	statPointsDropped       = "pointsDropped"
 	statBatchesTransmitted  = "batchesTx"
 	statPointsTransmitted   = "pointsTx"
 	statBatchesTransmitFail = "batchesTxFail"
@@ -60,8 +64,10 @@ type Service struct {
 	consistencyLevel cluster.ConsistencyLevel
 	udpReadBuffer    int
 
-	batcher *tsdb.PointBatcher
-	parser  *Parser
+	// batchers holds a batcher for the input's database and for each
+	// database and retention policy set by a template.
+	batchers map[target]*tsdb.PointBatcher
+	parser   *Parser
 
 	logger           *log.Logger
 	statMap          *expvar.Map
@@ -142,21 +148,33 @@ func (s *Service) Open() error {
 		s.Monitor.RegisterDiagnosticsClient(key, s)
 	}
 
-	if _, err := s.MetaClient.CreateDatabase(s.database); err != nil {
-		s.logger.Printf("Failed to ensure target database %s exists: %s", s.database, err.Error())
-		return err
-	}
+	// Start a batcher for each target.
+	s.batchers = make(map[target]*tsdb.PointBatcher)
+	for _, t := range append([]target{{}}, s.parser.targets()...) {
+		t = s.resolveTarget(t)
+		if _, ok := s.batchers[t]; ok {
+			continue
+		}
 
-	s.batcher = tsdb.NewPointBatcher(s.batchSize, s.batchPending, s.batchTimeout)
-	s.batcher.Start()
+		if _, err := s.MetaClient.CreateDatabase(t.database); err != nil {
+			s.logger.Printf("Failed to ensure target database %s exists: %s", t.database, err.Error())
+			return err
+		}
 
-	// Start processing batches.
-	s.wg.Add(1)
-	go s.processBatches(s.batcher)
+		batcher := tsdb.NewPointBatcher(s.batchSize, s.batchPending, s.batchTimeout)
+		batcher.Start()
+		s.batchers[t] = batcher
+
+		// Start processing batches.
+		s.wg.Add(1)
+		go s.processBatches(batcher, t)
+	}
 
 	var err error
 	if strings.ToLower(s.protocol) == "tcp" {
-		s.addr, err = s.openTCPServer()
+		s.addr, err = s.openTCPServer(s.handleTCPConnection)
+	} else if strings.ToLower(s.protocol) == "pickle" {
+		s.addr, err = s.openTCPServer(s.handlePickleConnection)
 	} else if strings.ToLower(s.protocol) == "udp" {
 		s.addr, err = s.openUDPServer()
 	} else {
@@ -191,8 +209,8 @@ func (s *Service) Close() error {
 		s.udpConn.Close()
 	}
 
-	if s.batcher != nil {
-		s.batcher.Stop()
+	for _, batcher := range s.batchers {
+		batcher.Stop()
 	}
 	close(s.done)
 	s.wg.Wait()
@@ -211,8 +229,9 @@ func (s *Service) Addr() net.Addr {
 	return s.addr
 }
 
-// openTCPServer opens the Graphite input in TCP mode and starts processing data.
-func (s *Service) openTCPServer() (net.Addr, error) {
+// openTCPServer opens the Graphite input in TCP mode and starts processing
+// data. Each connection is served by handle.
+func (s *Service) openTCPServer(handle func(net.Conn)) (net.Addr, error) {
 	ln, err := net.Listen("tcp", s.bindAddress)
 	if err != nil {
 		return nil, err
@@ -234,7 +253,7 @@ func (s *Service) openTCPServer() (net.Addr, error) {
 			}
 
 			s.wg.Add(1)
-			go s.handleTCPConnection(conn)
+			go handle(conn)
 		}
 	}()
 	return ln.Addr(), nil
@@ -268,6 +287,60 @@ func (s *Service) handleTCPConnection(conn net.Conn) {
 	}
 }
 
+// handlePickleConnection services a connection from a carbon relay using the
+// pickle protocol. Each message is a 4-byte big-endian length followed by a
+// pickled list of metrics.
+func (s *Service) handlePickleConnection(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+	defer s.statMap.Add(statConnectionsActive, -1)
+	defer s.untrackConnection(conn)
+	s.statMap.Add(statConnectionsActive, 1)
+	s.statMap.Add(statConnectionsHandled, 1)
+	s.trackConnection(conn)
+
+	// Guard against malformed pickles crashing the server.
+	defer func() {
+		if err := recover(); err != nil {
+			s.logger.Printf("unable to decode pickle from %s: %v", conn.RemoteAddr(), err)
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+
+	var hdr [4]byte
+	for {
+		if _, err := io.ReadFull(reader, hdr[:]); err != nil {
+			return
+		}
+
+		n := binary.BigEndian.Uint32(hdr[:])
+		if n > MaxPickleSize {
+			s.logger.Printf("pickle from %s too large: %d bytes", conn.RemoteAddr(), n)
+			return
+		}
+
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return
+		}
+		s.statMap.Add(statBytesReceived, int64(len(hdr)+len(buf)))
+
+		metrics, err := decodePickle(buf)
+		if err != nil {
+			s.logger.Printf("unable to decode pickle from %s: %s", conn.RemoteAddr(), err)
+			s.statMap.Add(statPointsParseFail, 1)
+			continue
+		}
+
+		s.statMap.Add(statPointsReceived, int64(len(metrics)))
+		for _, m := range metrics {
+			pt, tmpl, err := s.parser.parseMetric(m.name, m.value, m.timestamp)
+			s.handlePoint(m.name, pt, tmpl, err)
+		}
+	}
+}
+
 func (s *Service) trackConnection(c net.Conn) {
 	s.tcpConnectionsMu.Lock()
 	defer s.tcpConnectionsMu.Unlock()
@@ -330,8 +403,17 @@ func (s *Service) handleLine(line string) {
 	}
 
 	// Parse it.
-	point, err := s.parser.Parse(line)
-	if err != nil {
+	point, tmpl, err := s.parser.parse(line)
+	s.handlePoint(line, point, tmpl, err)
+}
+
+// handlePoint sends a parsed point to the batcher of the template's target.
+// If parsing failed, the error is recorded instead.
+func (s *Service) handlePoint(line string, point models.Point, tmpl *template, err error) {
+	if err == ErrMetricDropped {
+		s.statMap.Add(statPointsDropped, 1)
+		return
+	} else if err != nil {
 		switch err := err.(type) {
 		case *UnsupportedValueError:
 			// Graphite ignores NaN values with no error.
@@ -345,25 +427,37 @@ func (s *Service) handleLine(line string) {
 		return
 	}
 
-	s.batcher.In() <- point
+	var t target
+	if tmpl != nil {
+		t = target{database: tmpl.database, retentionPolicy: tmpl.retentionPolicy}
+	}
+	s.batchers[s.resolveTarget(t)].In() <- point
+}
+
+// resolveTarget returns t with the input's database if none is set.
+func (s *Service) resolveTarget(t target) target {
+	if t.database == "" {
+		t.database = s.database
+	}
+	return t
 }
 
-// processBatches continually drains the given batcher and writes the batches to the database.
-func (s *Service) processBatches(batcher *tsdb.PointBatcher) {
+// processBatches continually drains the given batcher and writes the batches to the target.
+func (s *Service) processBatches(batcher *tsdb.PointBatcher, t target) {
 	defer s.wg.Done()
 	for {
 		select {
 		case batch := <-batcher.Out():
 			if err := s.PointsWriter.WritePoints(&cluster.WritePointsRequest{
-				Database:         s.database,
-				RetentionPolicy:  "",
+				Database:         t.database,
+				RetentionPolicy:  t.retentionPolicy,
 				ConsistencyLevel: s.consistencyLevel,
 				Points:           batch,
 			}); err == nil {
 				s.statMap.Add(statBatchesTransmitted, 1)
 				s.statMap.Add(statPointsTransmitted, int64(len(batch)))
 			} else {
-				s.logger.Printf("failed to write point batch to database %q: %s", s.database, err)
+				s.logger.Printf("failed to write point batch to database %q: %s", t.database, err)
 				s.statMap.Add(statBatchesTransmitFail, 1)
 			}
 
@@ -1,6 +1,7 @@
 package graphite_test
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net"
 	"sync"
@@ -89,6 +90,78 @@ func Test_ServerGraphiteTCP(t *testing.T) {
 	wg.Wait()
 }
 
+// Ensure the pickle receiver writes metrics to the database and retention
+// policy of the matching template and drops metrics matching a drop template.
+func Test_ServerGraphitePickle(t *testing.T) {
+	t.Parallel()
+
+	config := graphite.Config{}
+	config.Database = "graphitedb"
+	config.Protocol = "pickle"
+	config.BatchSize = 0 // No batching.
+	config.BatchTimeout = toml.Duration(time.Second)
+	config.BindAddress = ":0"
+	config.Templates = []string{
+		"servers.*.tmp.* drop",
+		"servers.* .host.measurement* @servers:week",
+	}
+
+	service, err := graphite.NewService(config)
+	if err != nil {
+		t.Fatalf("failed to create Graphite service: %s", err.Error())
+	}
+
+	requests := make(chan *cluster.WritePointsRequest, 10)
+	service.PointsWriter = &PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			requests <- req
+			return nil
+		},
+	}
+	service.MetaClient = &DatabaseCreator{}
+
+	if err := service.Open(); err != nil {
+		t.Fatalf("failed to open Graphite service: %s", err.Error())
+	}
+	defer service.Close()
+
+	_, port, _ := net.SplitHostPort(service.Addr().String())
+	conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A pickle of [("servers.a.tmp.cpu", (1420070400, 1)), ("servers.a.cpu", (1420070400, 0.5))].
+	payload := "(lp0\n(S'servers.a.tmp.cpu'\np1\n(I1420070400\nI1\ntp2\ntp3\na(S'servers.a.cpu'\np4\n(I1420070400\nF0.5\ntp5\ntp6\na."
+	hdr := make([]byte, 4)
+	binary.BigEndian.PutUint32(hdr, uint32(len(payload)))
+	if _, err := conn.Write(append(hdr, payload...)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case req := <-requests:
+		pt, _ := models.NewPoint(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 0.5},
+			time.Unix(1420070400, 0))
+
+		if req.Database != "servers" {
+			t.Fatalf("unexpected database: %s", req.Database)
+		} else if req.RetentionPolicy != "week" {
+			t.Fatalf("unexpected retention policy: %s", req.RetentionPolicy)
+		} else if len(req.Points) != 1 {
+			t.Fatalf("expected 1 point, got %d", len(req.Points))
+		} else if req.Points[0].String() != pt.String() {
+			t.Fatalf("expected point %v, got %v", pt.String(), req.Points[0].String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for points")
+	}
+}
+
 func Test_ServerGraphiteUDP(t *testing.T) {
 	t.Parallel()
 
@@ -35,6 +35,12 @@ type NodeProcessor struct {
 	meta   metaClient
 	writer shardWriter
 
+	// smu protects paused and replayRate. It's separate from mu since
+	// Close holds mu while waiting for the replay to return.
+	smu        sync.RWMutex
+	paused     bool
+	replayRate float64 // bytes per second sent by the last replay
+
 	statMap *expvar.Map
 	Logger  *log.Logger
 }
@@ -172,8 +178,17 @@ func (n *NodeProcessor) run() {
 			}
 
 		case <-time.After(currInterval):
+			if n.Paused() {
+				continue
+			}
+
 			limiter := NewRateLimiter(n.RetryRateLimit)
+			start, sent := time.Now(), 0
 			for {
+				if n.Paused() {
+					break
+				}
+
 				c, err := n.SendWrite()
 				if err != nil {
 					if err == io.EOF {
@@ -193,14 +208,101 @@ func (n *NodeProcessor) run() {
 
 				// Update how many bytes we've sent
 				limiter.Update(c)
+				sent += c
 
 				// Block to maintain the throughput rate
 				time.Sleep(limiter.Delay())
 			}
+			n.setReplayRate(sent, time.Since(start))
 		}
 	}
 }
 
+// setReplayRate records the rate of a replay that sent n bytes in d.
+func (n *NodeProcessor) setReplayRate(sent int, d time.Duration) {
+	n.smu.Lock()
+	defer n.smu.Unlock()
+
+	n.replayRate = 0
+	if sent > 0 && d > 0 {
+		n.replayRate = float64(sent) / d.Seconds()
+	}
+}
+
+// Pause stops sending hinted-handoff data to the node. Data is still queued.
+func (n *NodeProcessor) Pause() {
+	n.smu.Lock()
+	defer n.smu.Unlock()
+	n.paused = true
+}
+
+// Resume resumes sending hinted-handoff data to the node.
+func (n *NodeProcessor) Resume() {
+	n.smu.Lock()
+	defer n.smu.Unlock()
+	n.paused = false
+}
+
+// Paused returns true if sending data to the node is paused.
+func (n *NodeProcessor) Paused() bool {
+	n.smu.RLock()
+	defer n.smu.RUnlock()
+	return n.paused
+}
+
+// NodeStatus describes the hinted-handoff queue of a node.
+type NodeStatus struct {
+	NodeID uint64
+	Active bool
+	Paused bool
+
+	// Bytes and Segments are the size of the queued data and the number of
+	// segment files holding it.
+	Bytes    int64
+	Segments int
+
+	// OldestAge is the age of the oldest segment holding queued data.
+	OldestAge time.Duration
+
+	// ReplayRate is the bytes per second sent by the last replay.
+	ReplayRate float64
+}
+
+// Status returns the status of the NodeProcessor's queue.
+func (n *NodeProcessor) Status() (NodeStatus, error) {
+	active, err := n.Active()
+	if err != nil {
+		return NodeStatus{}, err
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.done == nil {
+		return NodeStatus{}, fmt.Errorf("node processor is closed")
+	}
+
+	qs, err := n.queue.Stats()
+	if err != nil {
+		return NodeStatus{}, err
+	}
+
+	n.smu.RLock()
+	st := NodeStatus{
+		NodeID:     n.nodeID,
+		Active:     active,
+		Paused:     n.paused,
+		Bytes:      qs.bytes,
+		Segments:   qs.segments,
+		ReplayRate: n.replayRate,
+	}
+	n.smu.RUnlock()
+	if !qs.oldest.IsZero() {
+		st.OldestAge = time.Since(qs.oldest)
+	}
+	return st, nil
+}
+
 // SendWrite attempts to sent the current block of hinted data to the target node. If successful,
 // it returns the number of bytes it sent and advances to the next block. Otherwise returns EOF
 // when there is no more data or the node is inactive.
@@ -141,6 +141,20 @@ func TestNodeProcessorSendBlock(t *testing.T) {
 		t.Fatalf("SendWrite() write count mismatch: got %v, exp %v", count, exp)
 	}
 
+	// Check the status of the queue.
+	n.Pause()
+	st, err := n.Status()
+	if err != nil {
+		t.Fatalf("Failed to get node processor status: %v", err)
+	}
+	if st.NodeID != expNodeID || st.Active || !st.Paused || st.Bytes == 0 || st.Segments != 1 {
+		t.Fatalf("Node processor status is unexpected value of: %+v", st)
+	}
+	n.Resume()
+	if n.Paused() {
+		t.Fatalf("Node processor still paused after resume")
+	}
+
 	if err := n.Close(); err != nil {
 		t.Fatalf("Failed to close node processor: %v", err)
 	}
@@ -78,6 +78,13 @@ type queuePos struct {
 	tail string
 }
 
+// queueStats describes the entries of a queue that haven't been advanced past.
+type queueStats struct {
+	bytes    int64     // size of the entries including their length prefixes
+	segments int       // number of segment files
+	oldest   time.Time // modification time of the oldest segment with entries
+}
+
 type segments []*segment
 
 // newQueue create a queue that will store segments in dir and that will
@@ -233,6 +240,28 @@ func (l *queue) Position() (*queuePos, error) {
 	return qp, nil
 }
 
+// Stats returns the size of the entries not yet advanced past, the number of
+// segments and the modification time of the oldest segment with entries. Like
+// PurgeOlderThan, the age of entries is taken from their segment.
+func (l *queue) Stats() (*queueStats, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	qs := &queueStats{segments: len(l.segments)}
+	for _, s := range l.segments {
+		n := s.pending()
+		if n > 0 && qs.oldest.IsZero() {
+			mod, err := s.lastModified()
+			if err != nil {
+				return nil, err
+			}
+			qs.oldest = mod
+		}
+		qs.bytes += n
+	}
+	return qs, nil
+}
+
 // diskUsage returns the total size on disk used by the queue
 func (l *queue) diskUsage() int64 {
 	var size int64
@@ -634,6 +663,13 @@ func (l *segment) diskUsage() int64 {
 	return l.size
 }
 
+// pending returns the size of the entries not yet advanced past.
+func (l *segment) pending() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.size - footerSize - l.pos
+}
+
 func (l *segment) SetMaxSegmentSize(size int64) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -325,3 +325,55 @@ func TestPurgeQueue(t *testing.T) {
 	}
 
 }
+
+func TestQueueStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hh_queue")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := newQueue(dir, 1024)
+	if err != nil {
+		t.Fatalf("failed to create queue: %v", err)
+	}
+
+	if err := q.Open(); err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+
+	// An empty queue has no oldest entry.
+	qs, err := q.Stats()
+	if err != nil {
+		t.Fatalf("Queue.Stats failed: %v", err)
+	} else if qs.bytes != 0 || qs.segments != 1 || !qs.oldest.IsZero() {
+		t.Fatalf("Queue.Stats mismatch: got %+v", qs)
+	}
+
+	for _, s := range []string{"one", "two"} {
+		if err := q.Append([]byte(s)); err != nil {
+			t.Fatalf("Queue.Append failed: %v", err)
+		}
+	}
+
+	// Each entry is prefixed with its 8 byte length.
+	qs, err = q.Stats()
+	if err != nil {
+		t.Fatalf("Queue.Stats failed: %v", err)
+	} else if exp := int64(2 * (8 + 3)); qs.bytes != exp {
+		t.Errorf("Queue.Stats bytes mismatch: got %v, exp %v", qs.bytes, exp)
+	} else if qs.oldest.IsZero() {
+		t.Errorf("Queue.Stats oldest mismatch: got zero time")
+	}
+
+	if err := q.Advance(); err != nil {
+		t.Fatalf("Queue.Advance failed: %v", err)
+	}
+
+	qs, err = q.Stats()
+	if err != nil {
+		t.Fatalf("Queue.Stats failed: %v", err)
+	} else if exp := int64(8 + 3); qs.bytes != exp {
+		t.Errorf("Queue.Stats bytes mismatch: got %v, exp %v", qs.bytes, exp)
+	}
+}
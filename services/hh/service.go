@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +23,10 @@ import (
 // disabled hinted handoff service.
 var ErrHintedHandoffDisabled = fmt.Errorf("hinted handoff disabled")
 
+// ErrNodeQueueNotFound is returned when controlling the queue of a node
+// without any hinted handoff data.
+var ErrNodeQueueNotFound = fmt.Errorf("hinted handoff queue not found")
+
 const (
 	writeShardReq       = "writeShardReq"
 	writeShardReqPoints = "writeShardReqPoints"
@@ -38,6 +43,9 @@ type Service struct {
 
 	processors map[uint64]*NodeProcessor
 
+	// paused is set while sending data to all nodes is paused.
+	paused bool
+
 	statMap *expvar.Map
 	Logger  *log.Logger
 	cfg     Config
@@ -169,6 +177,9 @@ func (s *Service) WriteShard(shardID, ownerID uint64, points []models.Point) err
 			processor, ok = s.processors[ownerID]
 			if !ok {
 				processor = NewNodeProcessor(ownerID, s.pathforNode(ownerID), s.shardWriter, s.MetaClient)
+				if s.paused {
+					processor.Pause()
+				}
 				if err := processor.Open(); err != nil {
 					return err
 				}
@@ -217,6 +228,95 @@ func (s *Service) Diagnostics() (*monitor.Diagnostic, error) {
 	return d, nil
 }
 
+// Status returns the status of the queue of every node, ordered by node id.
+func (s *Service) Status() ([]NodeStatus, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrHintedHandoffDisabled
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := make([]NodeStatus, 0, len(s.processors))
+	for _, p := range s.processors {
+		st, err := p.Status()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, st)
+	}
+	sort.Sort(nodeStatuses(a))
+	return a, nil
+}
+
+// Purge deletes all queued data for a node without sending it.
+func (s *Service) Purge(nodeID uint64) error {
+	if !s.cfg.Enabled {
+		return ErrHintedHandoffDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.processors[nodeID]
+	if !ok {
+		return ErrNodeQueueNotFound
+	}
+
+	if err := p.Close(); err != nil {
+		return err
+	}
+	if err := p.Purge(); err != nil {
+		return err
+	}
+	delete(s.processors, nodeID)
+
+	s.Logger.Printf("purged hinted handoff queue for node %d", nodeID)
+	return nil
+}
+
+// Pause stops sending queued data to a node. If nodeID is zero, sending
+// to all nodes is paused, including nodes that get a queue later.
+func (s *Service) Pause(nodeID uint64) error {
+	return s.setPaused(nodeID, true)
+}
+
+// Resume resumes sending queued data to a node. If nodeID is zero, sending
+// to all nodes is resumed.
+func (s *Service) Resume(nodeID uint64) error {
+	return s.setPaused(nodeID, false)
+}
+
+func (s *Service) setPaused(nodeID uint64, paused bool) error {
+	if !s.cfg.Enabled {
+		return ErrHintedHandoffDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var processors []*NodeProcessor
+	if nodeID == 0 {
+		s.paused = paused
+		for _, p := range s.processors {
+			processors = append(processors, p)
+		}
+	} else if p, ok := s.processors[nodeID]; ok {
+		processors = append(processors, p)
+	} else {
+		return ErrNodeQueueNotFound
+	}
+
+	for _, p := range processors {
+		if paused {
+			p.Pause()
+		} else {
+			p.Resume()
+		}
+	}
+	return nil
+}
+
 // purgeInactiveProcessors will cause the service to remove processors for inactive nodes.
 func (s *Service) purgeInactiveProcessors() {
 	defer s.wg.Done()
@@ -269,6 +369,13 @@ func (s *Service) purgeInactiveProcessors() {
 	}
 }
 
+// nodeStatuses is a slice of NodeStatus used for sorting by node id.
+type nodeStatuses []NodeStatus
+
+func (a nodeStatuses) Len() int           { return len(a) }
+func (a nodeStatuses) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a nodeStatuses) Less(i, j int) bool { return a[i].NodeID < a[j].NodeID }
+
 // pathforNode returns the directory for HH data, for the given node.
 func (s *Service) pathforNode(nodeID uint64) string {
 	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%d", nodeID))
@@ -0,0 +1,57 @@
+package hh
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+)
+
+// StatementExecutor translates hinted handoff statements into calls on the
+// local hinted handoff service.
+type StatementExecutor struct {
+	Service interface {
+		Status() ([]NodeStatus, error)
+		Purge(nodeID uint64) error
+		Pause(nodeID uint64) error
+		Resume(nodeID uint64) error
+	}
+}
+
+// ExecuteStatement executes hinted-handoff-related query statements.
+func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.Result {
+	switch stmt := stmt.(type) {
+	case *influxql.ShowHintedHandoffStatement:
+		return e.executeShowHintedHandoffStatement(stmt)
+	case *influxql.PurgeHintedHandoffStatement:
+		return &influxql.Result{Err: e.Service.Purge(stmt.NodeID)}
+	case *influxql.PauseHintedHandoffStatement:
+		return &influxql.Result{Err: e.Service.Pause(stmt.NodeID)}
+	case *influxql.ResumeHintedHandoffStatement:
+		return &influxql.Result{Err: e.Service.Resume(stmt.NodeID)}
+	default:
+		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
+	}
+}
+
+func (e *StatementExecutor) executeShowHintedHandoffStatement(stmt *influxql.ShowHintedHandoffStatement) *influxql.Result {
+	a, err := e.Service.Status()
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	row := &models.Row{Name: "hinted handoff", Columns: []string{"node", "active", "paused", "bytes", "segments", "oldest_age", "replay_rate"}}
+	for _, st := range a {
+		row.Values = append(row.Values, []interface{}{
+			st.NodeID,
+			st.Active,
+			st.Paused,
+			st.Bytes,
+			st.Segments,
+			(st.OldestAge / time.Second * time.Second).String(),
+			st.ReplayRate,
+		})
+	}
+	return &influxql.Result{Series: []*models.Row{row}}
+}
@@ -1,15 +1,20 @@
 package httpd
 
+import (
+	"github.com/influxdata/influxdb/toml"
+)
+
 // Config represents a configuration for a HTTP service.
 type Config struct {
-	Enabled          bool   `toml:"enabled"`
-	BindAddress      string `toml:"bind-address"`
-	AuthEnabled      bool   `toml:"auth-enabled"`
-	LogEnabled       bool   `toml:"log-enabled"`
-	WriteTracing     bool   `toml:"write-tracing"`
-	PprofEnabled     bool   `toml:"pprof-enabled"`
-	HTTPSEnabled     bool   `toml:"https-enabled"`
-	HTTPSCertificate string `toml:"https-certificate"`
+	Enabled          bool          `toml:"enabled"`
+	BindAddress      string        `toml:"bind-address"`
+	AuthEnabled      bool          `toml:"auth-enabled"`
+	LogEnabled       bool          `toml:"log-enabled"`
+	WriteTracing     bool          `toml:"write-tracing"`
+	PprofEnabled     bool          `toml:"pprof-enabled"`
+	HTTPSEnabled     bool          `toml:"https-enabled"`
+	HTTPSCertificate string        `toml:"https-certificate"`
+	QueryTimeout     toml.Duration `toml:"query-timeout"`
 }
 
 // NewConfig returns a new Config with default settings.
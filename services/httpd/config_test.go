@@ -2,9 +2,11 @@ package httpd_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/influxdata/influxdb/services/httpd"
+	itoml "github.com/influxdata/influxdb/toml"
 )
 
 func TestConfig_Parse(t *testing.T) {
@@ -19,6 +21,7 @@ write-tracing = true
 pprof-enabled = true
 https-enabled = true
 https-certificate = "/dev/null"
+query-timeout = "30s"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -40,6 +43,8 @@ https-certificate = "/dev/null"
 		t.Fatalf("unexpected https enabled: %v", c.HTTPSEnabled)
 	} else if c.HTTPSCertificate != "/dev/null" {
 		t.Fatalf("unexpected https certificate: %v", c.HTTPSCertificate)
+	} else if time.Duration(c.QueryTimeout) != 30*time.Second {
+		t.Fatalf("unexpected query timeout: %v", c.QueryTimeout)
 	}
 }
 
@@ -50,3 +55,11 @@ func TestConfig_WriteTracing(t *testing.T) {
 		t.Fatalf("write tracing was not set")
 	}
 }
+
+func TestConfig_QueryTimeout(t *testing.T) {
+	c := httpd.Config{QueryTimeout: itoml.Duration(time.Minute)}
+	s := httpd.NewService(c)
+	if s.Handler.QueryTimeout != time.Minute {
+		t.Fatalf("unexpected query timeout: %v", s.Handler.QueryTimeout)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/services/continuous_querier"
 	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tsdb"
 	"github.com/influxdata/influxdb/uuid"
 )
 
@@ -66,7 +67,7 @@ type Handler struct {
 
 	QueryExecutor interface {
 		Authorize(u *meta.UserInfo, q *influxql.Query, db string) error
-		ExecuteQuery(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+		ExecuteQueryWithConsistency(q *influxql.Query, db string, chunkSize int, level tsdb.ReadConsistency, closing chan struct{}) (<-chan *influxql.Result, error)
 	}
 
 	PointsWriter interface {
@@ -79,6 +80,10 @@ type Handler struct {
 	loggingEnabled bool // Log every HTTP access.
 	WriteTrace     bool // Detailed logging of write path
 	statMap        *expvar.Map
+
+	// Default time a query may run before it is interrupted.
+	// Zero means queries never time out.
+	QueryTimeout time.Duration
 }
 
 // NewHandler returns a new instance of handler with routes.
@@ -245,6 +250,18 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 	p := influxql.NewParser(strings.NewReader(qp))
 	db := q.Get("db")
 
+	// Bind parameters, if provided, are substituted for $name placeholders.
+	if rawParams := q.Get("params"); rawParams != "" {
+		params := make(map[string]interface{})
+		decoder := json.NewDecoder(strings.NewReader(rawParams))
+		decoder.UseNumber()
+		if err := decoder.Decode(&params); err != nil {
+			httpError(w, "error parsing query parameters: "+err.Error(), pretty, http.StatusBadRequest)
+			return
+		}
+		p.SetParams(params)
+	}
+
 	// Parse query from query string.
 	query, err := p.ParseQuery()
 	if err != nil {
@@ -280,19 +297,62 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 		}
 	}
 
-	// Make sure if the client disconnects we signal the query to abort
+	// Parse the query timeout. Use the default if not provided. A requested
+	// timeout can only shorten the configured timeout.
+	timeout := h.QueryTimeout
+	if s := q.Get("timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			httpError(w, "invalid timeout: "+s, pretty, http.StatusBadRequest)
+			return
+		}
+		if timeout == 0 || d < timeout {
+			timeout = d
+		}
+	}
+
+	// Parse the read consistency level. Only the local shards are read if
+	// not provided.
+	level, err := tsdb.ParseReadConsistency(q.Get("consistency"))
+	if err != nil {
+		httpError(w, "invalid consistency: "+q.Get("consistency"), pretty, http.StatusBadRequest)
+		return
+	}
+
+	// Make sure if the client disconnects or the timeout is reached
+	// we signal the query to abort.
 	closing := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+
+	var notify <-chan bool
 	if notifier, ok := w.(http.CloseNotifier); ok {
-		notify := notifier.CloseNotify()
-		go func() {
-			<-notify
-			close(closing)
-		}()
+		notify = notifier.CloseNotify()
+	}
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
 	}
 
+	// The interrupt error is set before closing is closed so it can be
+	// read once the results channel is drained.
+	interruptErr := influxql.ErrQueryInterrupted
+	go func() {
+		select {
+		case <-notify:
+		case <-timer:
+			interruptErr = influxql.ErrQueryTimeout
+		case <-done:
+			return
+		}
+		close(closing)
+	}()
+
 	// Execute query.
 	w.Header().Add("content-type", "application/json")
-	results, err := h.QueryExecutor.ExecuteQuery(query, db, chunkSize, closing)
+	results, err := h.QueryExecutor.ExecuteQueryWithConsistency(query, db, chunkSize, level, closing)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -312,6 +372,11 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 			continue
 		}
 
+		// Report why the query was interrupted.
+		if r.Err == influxql.ErrQueryInterrupted {
+			r.Err = interruptErr
+		}
+
 		// if requested, convert result timestamps to epoch
 		if epoch != "" {
 			convertToEpoch(r, epoch)
@@ -354,6 +419,11 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 			// Append remaining rows as new rows.
 			r.Series = r.Series[rowsMerged:]
 			cr.Series = append(cr.Series, r.Series...)
+
+			// Keep the error if the statement failed after returning rows.
+			if r.Err != nil {
+				cr.Err = r.Err
+			}
 		} else {
 			resp.Results = append(resp.Results, r)
 		}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"regexp"
 	"testing"
@@ -19,6 +20,7 @@ import (
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/services/httpd"
 	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tsdb"
 )
 
 func TestBatchWrite_UnmarshalEpoch(t *testing.T) {
@@ -175,6 +177,83 @@ func TestHandler_QueryRegex(t *testing.T) {
 	h.ServeHTTP(w, MustNewRequest("GET", "/query?db=test&q=SELECT%20%2A%20FROM%20test%20WHERE%20url%20%3D~%20%2Fhttp%5C%3A%5C%2F%5C%2Fwww.akamai%5C.com%2F", nil))
 }
 
+// Ensure the handler substitutes bound parameters into the query.
+func TestHandler_Query_Params(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		if q.String() != `SELECT * FROM bar WHERE host = 'server\'01' AND value > 10.000` {
+			t.Fatalf("unexpected query: %s", q.String())
+		}
+		return NewResultChan(nil), nil
+	}
+
+	params := url.QueryEscape(`{"host":"server'01","value":10}`)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar+WHERE+host+%3D+$host+AND+value+>+$value&params="+params, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// Ensure the handler returns a status 400 if the bound parameters cannot be parsed.
+func TestHandler_Query_ErrInvalidParams(t *testing.T) {
+	h := NewHandler(false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?q=SELECT+*+FROM+bar&params=%7B", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"error parsing query parameters: unexpected EOF"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler interrupts a query and returns an error once its timeout is reached.
+func TestHandler_Query_Timeout(t *testing.T) {
+	for _, tt := range []struct {
+		path    string
+		timeout time.Duration
+	}{
+		{path: "/query?db=foo&q=SELECT+*+FROM+bar&timeout=10ms"},
+		{path: "/query?db=foo&q=SELECT+*+FROM+bar", timeout: 10 * time.Millisecond},
+		{path: "/query?db=foo&q=SELECT+*+FROM+bar&timeout=1h", timeout: 10 * time.Millisecond},
+	} {
+		h := NewHandler(false)
+		h.QueryTimeout = tt.timeout
+		h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+			ch := make(chan *influxql.Result)
+			go func() {
+				defer close(ch)
+				ch <- &influxql.Result{Series: models.Rows([]*models.Row{{Name: "series0"}})}
+				<-closing
+				ch <- &influxql.Result{Err: influxql.ErrQueryInterrupted}
+			}()
+			return ch, nil
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewJSONRequest("GET", tt.path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status: %d", w.Code)
+		} else if w.Body.String() != `{"results":[{"series":[{"name":"series0"}],"error":"query timeout reached"}]}` {
+			t.Fatalf("unexpected body: %s", w.Body.String())
+		}
+	}
+}
+
+// Ensure the handler returns a status 400 if the timeout cannot be parsed or isn't positive.
+func TestHandler_Query_ErrInvalidTimeout(t *testing.T) {
+	for _, s := range []string{"10", "0s", "-1s"} {
+		h := NewHandler(false)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?q=SELECT+*+FROM+bar&timeout="+s, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: unexpected status: %d", s, w.Code)
+		} else if w.Body.String() != `{"error":"invalid timeout: `+s+`"}` {
+			t.Fatalf("%s: unexpected body: %s", s, w.Body.String())
+		}
+	}
+}
+
 // Ensure the handler merges results from the same statement.
 func TestHandler_Query_MergeResults(t *testing.T) {
 	h := NewHandler(false)
@@ -235,6 +314,34 @@ func TestHandler_Query_Chunked(t *testing.T) {
 	}
 }
 
+// Ensure the handler passes the read consistency level to the query executor.
+func TestHandler_Query_Consistency(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(&influxql.Result{StatementID: 1, Series: models.Rows([]*models.Row{{Name: "series0"}})}), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&consistency=quorum", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if h.QueryExecutor.ReadConsistency != tsdb.ReadConsistencyQuorum {
+		t.Fatalf("unexpected consistency: %s", h.QueryExecutor.ReadConsistency)
+	}
+}
+
+// Ensure the handler returns a status 400 if the read consistency level is invalid.
+func TestHandler_Query_ErrInvalidConsistency(t *testing.T) {
+	h := NewHandler(false)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&consistency=most", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"invalid consistency: most"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
 // Ensure the handler returns a status 400 if the query is not passed in.
 func TestHandler_Query_ErrQueryRequired(t *testing.T) {
 	h := NewHandler(false)
@@ -485,13 +592,17 @@ func (s *HandlerMetaStore) Users() []meta.UserInfo {
 type HandlerQueryExecutor struct {
 	AuthorizeFn    func(u *meta.UserInfo, q *influxql.Query, db string) error
 	ExecuteQueryFn func(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+
+	// ReadConsistency is the level of the last executed query.
+	ReadConsistency tsdb.ReadConsistency
 }
 
 func (e *HandlerQueryExecutor) Authorize(u *meta.UserInfo, q *influxql.Query, db string) error {
 	return e.AuthorizeFn(u, q, db)
 }
 
-func (e *HandlerQueryExecutor) ExecuteQuery(q *influxql.Query, db string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+func (e *HandlerQueryExecutor) ExecuteQueryWithConsistency(q *influxql.Query, db string, chunkSize int, level tsdb.ReadConsistency, closing chan struct{}) (<-chan *influxql.Result, error) {
+	e.ReadConsistency = level
 	return e.ExecuteQueryFn(q, db, chunkSize, closing)
 }
 
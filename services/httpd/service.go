@@ -65,6 +65,7 @@ func NewService(c Config) *Service {
 		Logger: log.New(os.Stderr, "[httpd] ", log.LstdFlags),
 	}
 	s.Handler.Logger = s.Logger
+	s.Handler.QueryTimeout = time.Duration(c.QueryTimeout)
 	return s
 }
 
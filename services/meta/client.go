@@ -282,6 +282,39 @@ func (c *Client) DeleteDataNode(id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteDataNodeCommand, internal.E_DeleteDataNodeCommand_Command, cmd)
 }
 
+// SetDataNodeZone sets the zone of a data node.
+func (c *Client) SetDataNodeZone(id uint64, zone string) error {
+	if ni := c.data().DataNode(id); ni == nil {
+		return ErrNodeNotFound
+	} else if ni.Zone == zone {
+		return nil
+	}
+
+	cmd := &internal.SetDataNodeZoneCommand{
+		ID:   proto.Uint64(id),
+		Zone: proto.String(zone),
+	}
+
+	return c.retryUntilExec(internal.Command_SetDataNodeZoneCommand, internal.E_SetDataNodeZoneCommand_Command, cmd)
+}
+
+// DecommissionDataNode marks a data node as leaving the cluster.
+func (c *Client) DecommissionDataNode(id uint64) error {
+	// Validate against the local copy first so a bad request isn't retried.
+	data := c.data()
+	if ni := data.DataNode(id); ni != nil && ni.Leaving {
+		return nil
+	} else if err := data.Clone().DecommissionDataNode(id); err != nil {
+		return err
+	}
+
+	cmd := &internal.DecommissionDataNodeCommand{
+		ID: proto.Uint64(id),
+	}
+
+	return c.retryUntilExec(internal.Command_DecommissionDataNodeCommand, internal.E_DecommissionDataNodeCommand_Command, cmd)
+}
+
 // MetaNodes returns the meta nodes' info.
 func (c *Client) MetaNodes() ([]NodeInfo, error) {
 	return c.data().MetaNodes, nil
@@ -735,6 +768,26 @@ func (c *Client) DeleteShardGroup(database, policy string, id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteShardGroupCommand, internal.E_DeleteShardGroupCommand_Command, cmd)
 }
 
+// AddShardOwner adds a data node to the owners of a shard.
+func (c *Client) AddShardOwner(id, nodeID uint64) error {
+	cmd := &internal.AddShardOwnerCommand{
+		ID:     proto.Uint64(id),
+		NodeID: proto.Uint64(nodeID),
+	}
+
+	return c.retryUntilExec(internal.Command_AddShardOwnerCommand, internal.E_AddShardOwnerCommand_Command, cmd)
+}
+
+// RemoveShardOwner removes a data node from the owners of a shard.
+func (c *Client) RemoveShardOwner(id, nodeID uint64) error {
+	cmd := &internal.RemoveShardOwnerCommand{
+		ID:     proto.Uint64(id),
+		NodeID: proto.Uint64(nodeID),
+	}
+
+	return c.retryUntilExec(internal.Command_RemoveShardOwnerCommand, internal.E_RemoveShardOwnerCommand_Command, cmd)
+}
+
 // PrecreateShardGroups creates shard groups whose endtime is before the 'to' time passed in, but
 // is yet to expire before 'from'. This is to avoid the need for these shards to be created when data
 // for the corresponding time range arrives. Shard creation involves Raft consensus, and precreation
@@ -89,6 +89,56 @@ func (data *Data) setDataNode(nodeID uint64, host, tcpHost string) error {
 	return nil
 }
 
+// DecommissionDataNode marks a data node as leaving the cluster. A leaving
+// node isn't assigned new shard groups and is removed from the metadata once
+// its shards have been copied to the remaining nodes.
+func (data *Data) DecommissionDataNode(id uint64) error {
+	if id == 0 {
+		return ErrNodeIDRequired
+	}
+
+	ni := data.DataNode(id)
+	if ni == nil {
+		return ErrNodeNotFound
+	} else if ni.Leaving {
+		return nil
+	}
+
+	// Ensure another node remains to take over the shards.
+	if len(data.ActiveDataNodes()) < 2 {
+		return ErrNodeUnableToDecommissionFinalNode
+	}
+
+	ni.Leaving = true
+	return nil
+}
+
+// SetDataNodeZone sets the zone of a data node. Shard group owners are
+// spread across zones.
+func (data *Data) SetDataNodeZone(id uint64, zone string) error {
+	if id == 0 {
+		return ErrNodeIDRequired
+	}
+
+	ni := data.DataNode(id)
+	if ni == nil {
+		return ErrNodeNotFound
+	}
+	ni.Zone = zone
+	return nil
+}
+
+// ActiveDataNodes returns the data nodes that aren't leaving the cluster.
+func (data *Data) ActiveDataNodes() []NodeInfo {
+	var nodes []NodeInfo
+	for _, n := range data.DataNodes {
+		if !n.Leaving {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
 // DeleteDataNode removes a node from the metadata.
 func (data *Data) DeleteDataNode(id uint64) error {
 	// Node has to be larger than 0 to be real
@@ -443,8 +493,10 @@ func (data *Data) ShardGroupByTimestamp(database, policy string, timestamp time.
 
 // CreateShardGroup creates a shard group on a database and policy for a given timestamp.
 func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time) error {
-	// Ensure there are nodes in the metadata.
-	if len(data.DataNodes) == 0 {
+	// Ensure there are nodes in the metadata. Nodes leaving the cluster
+	// aren't assigned new shards.
+	nodes := data.ActiveDataNodes()
+	if len(nodes) == 0 {
 		return nil
 	}
 
@@ -465,14 +517,14 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 	replicaN := rpi.ReplicaN
 	if replicaN == 0 {
 		replicaN = 1
-	} else if replicaN > len(data.DataNodes) {
-		replicaN = len(data.DataNodes)
+	} else if replicaN > len(nodes) {
+		replicaN = len(nodes)
 	}
 
 	// Determine shard count by node count divided by replication factor.
 	// This will ensure nodes will get distributed across nodes evenly and
 	// replicated the correct number of times.
-	shardN := len(data.DataNodes) / replicaN
+	shardN := len(nodes) / replicaN
 
 	// Create the shard group.
 	data.MaxShardGroupID++
@@ -488,17 +540,10 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 		sgi.Shards[i] = ShardInfo{ID: data.MaxShardID}
 	}
 
-	// Assign data nodes to shards via round robin.
-	// Start from a repeatably "random" place in the node list.
-	nodeIndex := int(data.Index % uint64(len(data.DataNodes)))
-	for i := range sgi.Shards {
-		si := &sgi.Shards[i]
-		for j := 0; j < replicaN; j++ {
-			nodeID := data.DataNodes[nodeIndex%len(data.DataNodes)].ID
-			si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
-			nodeIndex++
-		}
-	}
+	// Assign data nodes to shards via round robin, spreading the owners of
+	// each shard across zones. Start from a repeatably "random" place in the
+	// node list.
+	assignShardOwners(sgi.Shards, nodes, replicaN, int(data.Index%uint64(len(nodes))))
 
 	// Retention policy has a new shard group, so update the policy. Shard
 	// Groups must be stored in sorted order, as other parts of the system
@@ -509,6 +554,55 @@ func (data *Data) CreateShardGroup(database, policy string, timestamp time.Time)
 	return nil
 }
 
+// assignShardOwners assigns replicaN owners to each shard. Nodes are taken
+// in order starting at nodeIndex. Each owner is chosen from the first of these
+// that isn't already an owner:
+//
+//  1. a node without a shard in the group, in a zone the shard isn't in yet
+//  2. a node without a shard in the group
+//  3. a node in a zone the shard isn't in yet
+//  4. any node
+//
+// If no zones are set this is a plain round robin over the nodes.
+func assignShardOwners(shards []ShardInfo, nodes []NodeInfo, replicaN, nodeIndex int) {
+	assigned := make(map[uint64]bool)
+	for i := range shards {
+		si := &shards[i]
+		zones := make(map[string]bool)
+		for len(si.Owners) < replicaN {
+			j := nextShardOwner(si, nodes, nodeIndex, func(n NodeInfo) bool { return !assigned[n.ID] && !zones[n.Zone] })
+			if j == -1 {
+				j = nextShardOwner(si, nodes, nodeIndex, func(n NodeInfo) bool { return !assigned[n.ID] })
+			}
+			if j == -1 {
+				j = nextShardOwner(si, nodes, nodeIndex, func(n NodeInfo) bool { return !zones[n.Zone] })
+			}
+			if j == -1 {
+				j = nextShardOwner(si, nodes, nodeIndex, func(n NodeInfo) bool { return true })
+			}
+
+			n := nodes[j]
+			si.Owners = append(si.Owners, ShardOwner{NodeID: n.ID})
+			assigned[n.ID] = true
+			zones[n.Zone] = true
+			nodeIndex = j + 1
+		}
+	}
+}
+
+// nextShardOwner returns the index of the first node from nodeIndex onwards,
+// wrapping around, that isn't an owner of si and matches fn. Returns -1 if
+// there is no such node.
+func nextShardOwner(si *ShardInfo, nodes []NodeInfo, nodeIndex int, fn func(NodeInfo) bool) int {
+	for i := 0; i < len(nodes); i++ {
+		j := (nodeIndex + i) % len(nodes)
+		if !si.OwnedBy(nodes[j].ID) && fn(nodes[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
 // DeleteShardGroup removes a shard group from a database and retention policy by id.
 func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	// Find retention policy.
@@ -530,6 +624,77 @@ func (data *Data) DeleteShardGroup(database, policy string, id uint64) error {
 	return ErrShardGroupNotFound
 }
 
+// shard returns a pointer to the shard with the given id in an active shard
+// group and to its retention policy.
+func (data *Data) shard(id uint64) (*RetentionPolicyInfo, *ShardInfo) {
+	for di := range data.Databases {
+		for ri := range data.Databases[di].RetentionPolicies {
+			rp := &data.Databases[di].RetentionPolicies[ri]
+			for gi := range rp.ShardGroups {
+				if rp.ShardGroups[gi].Deleted() {
+					continue
+				}
+				for si := range rp.ShardGroups[gi].Shards {
+					if rp.ShardGroups[gi].Shards[si].ID == id {
+						return rp, &rp.ShardGroups[gi].Shards[si]
+					}
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// AddShardOwner adds a data node to the owners of a shard.
+// Adding a node that already owns the shard is a no-op.
+func (data *Data) AddShardOwner(id, nodeID uint64) error {
+	if data.DataNode(nodeID) == nil {
+		return ErrNodeNotFound
+	}
+
+	_, si := data.shard(id)
+	if si == nil {
+		return ErrShardNotFound
+	} else if si.OwnedBy(nodeID) {
+		return nil
+	}
+
+	si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
+	return nil
+}
+
+// RemoveShardOwner removes a data node from the owners of a shard.
+// The last owner of a shard cannot be removed, nor can an owner be removed if
+// the shard would be left with fewer owners than its retention policy's
+// replication factor, limited to the number of active data nodes.
+func (data *Data) RemoveShardOwner(id, nodeID uint64) error {
+	rp, si := data.shard(id)
+	if si == nil {
+		return ErrShardNotFound
+	} else if !si.OwnedBy(nodeID) {
+		return ErrNodeNotFound
+	} else if len(si.Owners) == 1 {
+		return ErrShardNotReplicated
+	}
+
+	replicaN := rp.ReplicaN
+	if n := len(data.ActiveDataNodes()); replicaN > n {
+		replicaN = n
+	}
+	if len(si.Owners)-1 < replicaN {
+		return ErrShardUnderReplicated
+	}
+
+	var owners []ShardOwner
+	for _, o := range si.Owners {
+		if o.NodeID != nodeID {
+			owners = append(owners, o)
+		}
+	}
+	si.Owners = owners
+	return nil
+}
+
 // CreateContinuousQuery adds a named continuous query to a database.
 func (data *Data) CreateContinuousQuery(database, name, query string) error {
 	di := data.Database(database)
@@ -849,6 +1014,12 @@ type NodeInfo struct {
 	ID      uint64
 	Host    string
 	TCPHost string
+
+	// Leaving is set while a data node is being decommissioned.
+	Leaving bool
+
+	// Zone is the rack or zone of a data node.
+	Zone string
 }
 
 // clone returns a deep copy of ni.
@@ -860,6 +1031,8 @@ func (ni NodeInfo) marshal() *internal.NodeInfo {
 	pb.ID = proto.Uint64(ni.ID)
 	pb.Host = proto.String(ni.Host)
 	pb.TCPHost = proto.String(ni.TCPHost)
+	pb.Leaving = proto.Bool(ni.Leaving)
+	pb.Zone = proto.String(ni.Zone)
 	return pb
 }
 
@@ -868,6 +1041,8 @@ func (ni *NodeInfo) unmarshal(pb *internal.NodeInfo) {
 	ni.ID = pb.GetID()
 	ni.Host = pb.GetHost()
 	ni.TCPHost = pb.GetTCPHost()
+	ni.Leaving = pb.GetLeaving()
+	ni.Zone = pb.GetZone()
 }
 
 // NodeInfos is a slice of NodeInfo used for sorting
@@ -33,6 +33,10 @@ var (
 	// ErrNodeUnableToDropFinalNode is returned if the node being dropped is the last
 	// node in the cluster
 	ErrNodeUnableToDropFinalNode = errors.New("unable to drop the final node in a cluster")
+
+	// ErrNodeUnableToDecommissionFinalNode is returned if the node being
+	// decommissioned is the last data node not leaving the cluster.
+	ErrNodeUnableToDecommissionFinalNode = errors.New("unable to decommission the final data node in a cluster")
 )
 
 var (
@@ -81,6 +85,13 @@ var (
 	// ErrShardNotReplicated is returned if the node requested to be dropped has
 	// the last copy of a shard present and the force keyword was not used
 	ErrShardNotReplicated = errors.New("shard not replicated")
+
+	// ErrShardUnderReplicated is returned when removing an owner of a shard
+	// would leave it with fewer owners than its replication factor.
+	ErrShardUnderReplicated = errors.New("shard would be under-replicated")
+
+	// ErrShardNotFound is returned when mutating a shard that doesn't exist.
+	ErrShardNotFound = errors.New("shard not found")
 )
 
 var (
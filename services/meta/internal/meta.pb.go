@@ -50,6 +50,10 @@ It has these top-level messages:
 	DeleteDataNodeCommand
 	Response
 	SetMetaNodeCommand
+	AddShardOwnerCommand
+	RemoveShardOwnerCommand
+	DecommissionDataNodeCommand
+	SetDataNodeZoneCommand
 */
 package internal
 
@@ -93,6 +97,10 @@ const (
 	Command_DeleteMetaNodeCommand            Command_Type = 27
 	Command_DeleteDataNodeCommand            Command_Type = 28
 	Command_SetMetaNodeCommand               Command_Type = 29
+	Command_AddShardOwnerCommand             Command_Type = 30
+	Command_RemoveShardOwnerCommand          Command_Type = 31
+	Command_DecommissionDataNodeCommand      Command_Type = 32
+	Command_SetDataNodeZoneCommand           Command_Type = 33
 )
 
 var Command_Type_name = map[int32]string{
@@ -124,6 +132,10 @@ var Command_Type_name = map[int32]string{
 	27: "DeleteMetaNodeCommand",
 	28: "DeleteDataNodeCommand",
 	29: "SetMetaNodeCommand",
+	30: "AddShardOwnerCommand",
+	31: "RemoveShardOwnerCommand",
+	32: "DecommissionDataNodeCommand",
+	33: "SetDataNodeZoneCommand",
 }
 var Command_Type_value = map[string]int32{
 	"CreateNodeCommand":                1,
@@ -154,6 +166,10 @@ var Command_Type_value = map[string]int32{
 	"DeleteMetaNodeCommand":            27,
 	"DeleteDataNodeCommand":            28,
 	"SetMetaNodeCommand":               29,
+	"AddShardOwnerCommand":             30,
+	"RemoveShardOwnerCommand":          31,
+	"DecommissionDataNodeCommand":      32,
+	"SetDataNodeZoneCommand":           33,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -273,6 +289,8 @@ type NodeInfo struct {
 	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	Host             *string `protobuf:"bytes,2,req,name=Host" json:"Host,omitempty"`
 	TCPHost          *string `protobuf:"bytes,3,opt,name=TCPHost" json:"TCPHost,omitempty"`
+	Leaving          *bool   `protobuf:"varint,4,opt,name=Leaving" json:"Leaving,omitempty"`
+	Zone             *string `protobuf:"bytes,5,opt,name=Zone" json:"Zone,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -301,6 +319,20 @@ func (m *NodeInfo) GetTCPHost() string {
 	return ""
 }
 
+func (m *NodeInfo) GetLeaving() bool {
+	if m != nil && m.Leaving != nil {
+		return *m.Leaving
+	}
+	return false
+}
+
+func (m *NodeInfo) GetZone() string {
+	if m != nil && m.Zone != nil {
+		return *m.Zone
+	}
+	return ""
+}
+
 type DatabaseInfo struct {
 	Name                   *string                `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	DefaultRetentionPolicy *string                `protobuf:"bytes,2,req,name=DefaultRetentionPolicy" json:"DefaultRetentionPolicy,omitempty"`
@@ -1644,6 +1676,126 @@ var E_SetMetaNodeCommand_Command = &proto.ExtensionDesc{
 	Tag:           "bytes,129,opt,name=command",
 }
 
+type AddShardOwnerCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	NodeID           *uint64 `protobuf:"varint,2,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *AddShardOwnerCommand) Reset()         { *m = AddShardOwnerCommand{} }
+func (m *AddShardOwnerCommand) String() string { return proto.CompactTextString(m) }
+func (*AddShardOwnerCommand) ProtoMessage()    {}
+
+func (m *AddShardOwnerCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *AddShardOwnerCommand) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+var E_AddShardOwnerCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*AddShardOwnerCommand)(nil),
+	Field:         130,
+	Name:          "internal.AddShardOwnerCommand.command",
+	Tag:           "bytes,130,opt,name=command",
+}
+
+type RemoveShardOwnerCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	NodeID           *uint64 `protobuf:"varint,2,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *RemoveShardOwnerCommand) Reset()         { *m = RemoveShardOwnerCommand{} }
+func (m *RemoveShardOwnerCommand) String() string { return proto.CompactTextString(m) }
+func (*RemoveShardOwnerCommand) ProtoMessage()    {}
+
+func (m *RemoveShardOwnerCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *RemoveShardOwnerCommand) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+var E_RemoveShardOwnerCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*RemoveShardOwnerCommand)(nil),
+	Field:         131,
+	Name:          "internal.RemoveShardOwnerCommand.command",
+	Tag:           "bytes,131,opt,name=command",
+}
+
+type DecommissionDataNodeCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *DecommissionDataNodeCommand) Reset()         { *m = DecommissionDataNodeCommand{} }
+func (m *DecommissionDataNodeCommand) String() string { return proto.CompactTextString(m) }
+func (*DecommissionDataNodeCommand) ProtoMessage()    {}
+
+func (m *DecommissionDataNodeCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+var E_DecommissionDataNodeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*DecommissionDataNodeCommand)(nil),
+	Field:         132,
+	Name:          "internal.DecommissionDataNodeCommand.command",
+	Tag:           "bytes,132,opt,name=command",
+}
+
+type SetDataNodeZoneCommand struct {
+	ID               *uint64 `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Zone             *string `protobuf:"bytes,2,req,name=Zone" json:"Zone,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetDataNodeZoneCommand) Reset()         { *m = SetDataNodeZoneCommand{} }
+func (m *SetDataNodeZoneCommand) String() string { return proto.CompactTextString(m) }
+func (*SetDataNodeZoneCommand) ProtoMessage()    {}
+
+func (m *SetDataNodeZoneCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetDataNodeZoneCommand) GetZone() string {
+	if m != nil && m.Zone != nil {
+		return *m.Zone
+	}
+	return ""
+}
+
+var E_SetDataNodeZoneCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDataNodeZoneCommand)(nil),
+	Field:         133,
+	Name:          "internal.SetDataNodeZoneCommand.command",
+	Tag:           "bytes,133,opt,name=command",
+}
+
 func init() {
 	proto.RegisterType((*Data)(nil), "internal.Data")
 	proto.RegisterType((*NodeInfo)(nil), "internal.NodeInfo")
@@ -1686,6 +1838,10 @@ func init() {
 	proto.RegisterType((*DeleteDataNodeCommand)(nil), "internal.DeleteDataNodeCommand")
 	proto.RegisterType((*Response)(nil), "internal.Response")
 	proto.RegisterType((*SetMetaNodeCommand)(nil), "internal.SetMetaNodeCommand")
+	proto.RegisterType((*AddShardOwnerCommand)(nil), "internal.AddShardOwnerCommand")
+	proto.RegisterType((*RemoveShardOwnerCommand)(nil), "internal.RemoveShardOwnerCommand")
+	proto.RegisterType((*DecommissionDataNodeCommand)(nil), "internal.DecommissionDataNodeCommand")
+	proto.RegisterType((*SetDataNodeZoneCommand)(nil), "internal.SetDataNodeZoneCommand")
 	proto.RegisterEnum("internal.Command_Type", Command_Type_name, Command_Type_value)
 	proto.RegisterExtension(E_CreateNodeCommand_Command)
 	proto.RegisterExtension(E_DeleteNodeCommand_Command)
@@ -1715,4 +1871,8 @@ func init() {
 	proto.RegisterExtension(E_DeleteMetaNodeCommand_Command)
 	proto.RegisterExtension(E_DeleteDataNodeCommand_Command)
 	proto.RegisterExtension(E_SetMetaNodeCommand_Command)
+	proto.RegisterExtension(E_AddShardOwnerCommand_Command)
+	proto.RegisterExtension(E_RemoveShardOwnerCommand_Command)
+	proto.RegisterExtension(E_DecommissionDataNodeCommand_Command)
+	proto.RegisterExtension(E_SetDataNodeZoneCommand_Command)
 }
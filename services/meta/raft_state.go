@@ -12,6 +12,7 @@ import (
 
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/raft-boltdb"
+	"github.com/influxdata/influxdb/tcp"
 )
 
 // Raft configuration.
@@ -34,6 +35,7 @@ type raftState struct {
 	raftStore *raftboltdb.BoltStore
 	raftLayer *raftLayer
 	ln        net.Listener
+	dialer    *tcp.Dialer
 	addr      string
 	logger    *log.Logger
 	path      string
@@ -66,7 +68,7 @@ func (r *raftState) open(s *store, ln net.Listener, initializePeers []string) er
 	config.ShutdownOnRemove = false
 
 	// Build raft layer to multiplex listener.
-	r.raftLayer = newRaftLayer(r.addr, r.ln)
+	r.raftLayer = newRaftLayer(r.addr, r.ln, r.dialer)
 
 	// Create a transport layer
 	r.transport = raft.NewNetworkTransport(r.raftLayer, 3, 10*time.Second, config.LogOutput)
@@ -271,6 +273,7 @@ func (r *raftState) isLeader() bool {
 type raftLayer struct {
 	addr   *raftLayerAddr
 	ln     net.Listener
+	dialer *tcp.Dialer
 	conn   chan net.Conn
 	closed chan struct{}
 }
@@ -288,10 +291,11 @@ func (r *raftLayerAddr) String() string {
 }
 
 // newRaftLayer returns a new instance of raftLayer.
-func newRaftLayer(addr string, ln net.Listener) *raftLayer {
+func newRaftLayer(addr string, ln net.Listener, dialer *tcp.Dialer) *raftLayer {
 	return &raftLayer{
 		addr:   &raftLayerAddr{addr},
 		ln:     ln,
+		dialer: dialer,
 		conn:   make(chan net.Conn),
 		closed: make(chan struct{}),
 	}
@@ -304,17 +308,7 @@ func (l *raftLayer) Addr() net.Addr {
 
 // Dial creates a new network connection.
 func (l *raftLayer) Dial(addr string, timeout time.Duration) (net.Conn, error) {
-	conn, err := net.DialTimeout("tcp", addr, timeout)
-	if err != nil {
-		return nil, err
-	}
-	// Write a marker byte for raft messages.
-	_, err = conn.Write([]byte{MuxHeader})
-	if err != nil {
-		conn.Close()
-		return nil, err
-	}
-	return conn, err
+	return l.dialer.DialTimeout("tcp", addr, MuxHeader, timeout)
 }
 
 // Accept waits for the next connection.
@@ -10,6 +10,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/influxdata/influxdb/tcp"
 )
 
 const (
@@ -19,6 +21,10 @@ const (
 type Service struct {
 	RaftListener net.Listener
 
+	// RaftDialer secures connections to other raft peers. Connections are
+	// plain if nil.
+	RaftDialer *tcp.Dialer
+
 	config   *Config
 	handler  *handler
 	ln       net.Listener
@@ -110,6 +116,7 @@ func (s *Service) Open() error {
 
 	// Open the store
 	s.store = newStore(s.config, s.httpAddr, s.raftAddr)
+	s.store.raftDialer = s.RaftDialer
 
 	handler := newHandler(s.config, s)
 	handler.logger = s.Logger
@@ -770,6 +770,60 @@ func TestMetaService_Shards(t *testing.T) {
 	}
 }
 
+func TestMetaService_ShardOwners(t *testing.T) {
+	t.Parallel()
+
+	d, s, c := newServiceAndClient()
+	defer os.RemoveAll(d)
+	defer s.Close()
+	defer c.Close()
+
+	if _, err := c.CreateDataNode("foo:8180", "foo:8281"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.CreateDataNode("bar:8180", "bar:8281"); err != nil {
+		t.Fatal(err)
+	} else if _, err := c.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	}
+
+	sg, err := c.CreateShardGroup("db0", "default", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := sg.Shards[0].ID
+	owner := sg.Shards[0].Owners[0].NodeID
+	other := uint64(1)
+	if owner == other {
+		other = 2
+	}
+
+	// Add the other node as an owner of the shard.
+	if err := c.AddShardOwner(id, other); err != nil {
+		t.Fatal(err)
+	}
+	_, _, g := c.ShardOwner(id)
+	if !reflect.DeepEqual(g.Shards[0].Owners, []meta.ShardOwner{{owner}, {other}}) {
+		t.Fatalf("unexpected owners: %v", g.Shards[0].Owners)
+	}
+
+	// An owner can't be removed if the shard would be under-replicated.
+	if err := c.RemoveShardOwner(id, owner); err == nil || err.Error() != meta.ErrShardUnderReplicated.Error() {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Remove the original owner of the shard once it isn't replicated.
+	replicaN := 1
+	if err := c.UpdateRetentionPolicy("db0", "default", &meta.RetentionPolicyUpdate{ReplicaN: &replicaN}); err != nil {
+		t.Fatal(err)
+	} else if err := c.RemoveShardOwner(id, owner); err != nil {
+		t.Fatal(err)
+	}
+	_, _, g = c.ShardOwner(id)
+	if !reflect.DeepEqual(g.Shards[0].Owners, []meta.ShardOwner{{other}}) {
+		t.Fatalf("unexpected owners: %v", g.Shards[0].Owners)
+	}
+}
+
 func TestMetaService_CreateRemoveMetaNode(t *testing.T) {
 	t.Parallel()
 
@@ -1187,6 +1241,134 @@ func TestMetaService_DropDataNode(t *testing.T) {
 	}
 }
 
+func TestMetaService_DecommissionDataNode(t *testing.T) {
+	t.Parallel()
+
+	d, s, c := newServiceAndClient()
+	defer os.RemoveAll(d)
+	defer s.Close()
+	defer c.Close()
+
+	if _, err := c.CreateDataNode("foo:8180", "bar:8281"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The only data node cannot be decommissioned.
+	if res := c.ExecuteStatement(mustParseStatement("DECOMMISSION SERVER 1")); res.Err != meta.ErrNodeUnableToDecommissionFinalNode {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+
+	if _, err := c.CreateDataNode("foo:8190", "bar:8291"); err != nil {
+		t.Fatal(err)
+	}
+	if res := c.ExecuteStatement(mustParseStatement("DECOMMISSION SERVER 1")); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+
+	if n, err := c.DataNode(1); err != nil {
+		t.Fatal(err)
+	} else if !n.Leaving {
+		t.Fatalf("expected node to be leaving: %v", n)
+	}
+
+	// New shard groups are only assigned to the remaining node.
+	if _, err := c.CreateDatabaseWithRetentionPolicy("foo", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2}); err != nil {
+		t.Fatal(err)
+	}
+	sg, err := c.CreateShardGroup("foo", "rp0", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sg.Shards) != 1 || !reflect.DeepEqual(sg.Shards[0].Owners, []meta.ShardOwner{{2}}) {
+		t.Fatalf("unexpected shards: %v", sg.Shards)
+	}
+
+	// The remaining node cannot be decommissioned as well.
+	if err := c.DecommissionDataNode(2); err != meta.ErrNodeUnableToDecommissionFinalNode {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMetaService_SetDataNodeZone(t *testing.T) {
+	t.Parallel()
+
+	d, s, c := newServiceAndClient()
+	defer os.RemoveAll(d)
+	defer s.Close()
+	defer c.Close()
+
+	for i, zone := range []string{"a", "a", "b", "b"} {
+		n, err := c.CreateDataNode(fmt.Sprintf("foo:%d", 8180+i), fmt.Sprintf("bar:%d", 8280+i))
+		if err != nil {
+			t.Fatal(err)
+		} else if err := c.SetDataNodeZone(n.ID, zone); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := c.DataNode(3); err != nil {
+		t.Fatal(err)
+	} else if n.Zone != "b" {
+		t.Fatalf("unexpected zone: %s", n.Zone)
+	}
+	if err := c.SetDataNodeZone(10, "c"); err != meta.ErrNodeNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The replicas of each shard are in different zones.
+	if _, err := c.CreateDatabaseWithRetentionPolicy("foo", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 2}); err != nil {
+		t.Fatal(err)
+	}
+	sg, err := c.CreateShardGroup("foo", "rp0", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sh := range sg.Shards {
+		if len(sh.Owners) != 2 {
+			t.Fatalf("unexpected owners: %v", sh.Owners)
+		}
+		z0, _ := c.DataNode(sh.Owners[0].NodeID)
+		z1, _ := c.DataNode(sh.Owners[1].NodeID)
+		if z0.Zone == z1.Zone {
+			t.Fatalf("replicas in the same zone: %v", sh.Owners)
+		}
+	}
+
+	// With more replicas than zones, every replica is still on its own node.
+	if _, err := c.CreateRetentionPolicy("foo", &meta.RetentionPolicyInfo{Name: "rp1", ReplicaN: 3}); err != nil {
+		t.Fatal(err)
+	}
+	sg, err = c.CreateShardGroup("foo", "rp1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sh := range sg.Shards {
+		if len(sh.Owners) != 3 {
+			t.Fatalf("unexpected owners: %v", sh.Owners)
+		}
+		zones := make(map[string]bool)
+		for i, o := range sh.Owners {
+			n, _ := c.DataNode(o.NodeID)
+			zones[n.Zone] = true
+			for _, other := range sh.Owners[:i] {
+				if other.NodeID == o.NodeID {
+					t.Fatalf("duplicate owner: %v", sh.Owners)
+				}
+			}
+		}
+		if len(zones) != 2 {
+			t.Fatalf("replicas not spread across zones: %v", sh.Owners)
+		}
+	}
+
+	res := c.ExecuteStatement(mustParseStatement("SHOW SERVERS"))
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	} else if got := res.Series[0].Values[2][3]; got != "b" {
+		t.Fatalf("unexpected zone: %v", got)
+	}
+}
+
 func TestMetaService_PersistClusterIDAfterRestart(t *testing.T) {
 	t.Parallel()
 
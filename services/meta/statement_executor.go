@@ -19,6 +19,7 @@ type StatementExecutor struct {
 		MetaNodes() ([]NodeInfo, error)
 		DeleteDataNode(nodeID uint64) error
 		DeleteMetaNode(nodeID uint64) error
+		DecommissionDataNode(nodeID uint64) error
 
 		Database(name string) (*DatabaseInfo, error)
 		Databases() ([]DatabaseInfo, error)
@@ -99,6 +100,8 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.
 		return e.executeShowStatsStatement(stmt)
 	case *influxql.DropServerStatement:
 		return e.executeDropServerStatement(stmt)
+	case *influxql.DecommissionServerStatement:
+		return e.executeDecommissionServerStatement(stmt)
 	case *influxql.CreateSubscriptionStatement:
 		return e.executeCreateSubscriptionStatement(stmt)
 	case *influxql.DropSubscriptionStatement:
@@ -165,10 +168,14 @@ func (e *StatementExecutor) executeShowServersStatement(q *influxql.ShowServersS
 		return &influxql.Result{Err: err}
 	}
 
-	dataNodes := &models.Row{Columns: []string{"id", "http_addr", "tcp_addr"}}
+	dataNodes := &models.Row{Columns: []string{"id", "http_addr", "tcp_addr", "zone", "status"}}
 	dataNodes.Name = "data_nodes"
 	for _, ni := range nis {
-		dataNodes.Values = append(dataNodes.Values, []interface{}{ni.ID, ni.Host, ni.TCPHost})
+		status := "active"
+		if ni.Leaving {
+			status = "leaving"
+		}
+		dataNodes.Values = append(dataNodes.Values, []interface{}{ni.ID, ni.Host, ni.TCPHost, ni.Zone, status})
 	}
 
 	nis, err = e.Store.MetaNodes()
@@ -196,6 +203,10 @@ func (e *StatementExecutor) executeDropServerStatement(q *influxql.DropServerSta
 	return &influxql.Result{Err: err}
 }
 
+func (e *StatementExecutor) executeDecommissionServerStatement(q *influxql.DecommissionServerStatement) *influxql.Result {
+	return &influxql.Result{Err: e.Store.DecommissionDataNode(q.NodeID)}
+}
+
 func (e *StatementExecutor) executeCreateUserStatement(q *influxql.CreateUserStatement) *influxql.Result {
 	_, err := e.Store.CreateUser(q.Name, q.Password, q.Admin)
 	return &influxql.Result{Err: err}
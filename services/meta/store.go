@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/influxdata/influxdb/services/meta/internal"
+	"github.com/influxdata/influxdb/tcp"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/hashicorp/raft"
@@ -44,8 +45,9 @@ type store struct {
 	opened      bool
 	logger      *log.Logger
 
-	raftAddr string
-	httpAddr string
+	raftAddr   string
+	raftDialer *tcp.Dialer
+	httpAddr   string
 }
 
 // newStore will create a new metastore with the passed in config
@@ -218,6 +220,7 @@ func (s *store) openRaft(initializePeers []string, raftln net.Listener) error {
 	rs := newRaftState(s.config, s.raftAddr)
 	rs.logger = s.logger
 	rs.path = s.path
+	rs.dialer = s.raftDialer
 
 	if err := rs.open(s, raftln, initializePeers); err != nil {
 		return err
@@ -88,6 +88,14 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applyCreateDataNodeCommand(&cmd)
 		case internal.Command_DeleteDataNodeCommand:
 			return fsm.applyDeleteDataNodeCommand(&cmd)
+		case internal.Command_AddShardOwnerCommand:
+			return fsm.applyAddShardOwnerCommand(&cmd)
+		case internal.Command_RemoveShardOwnerCommand:
+			return fsm.applyRemoveShardOwnerCommand(&cmd)
+		case internal.Command_DecommissionDataNodeCommand:
+			return fsm.applyDecommissionDataNodeCommand(&cmd)
+		case internal.Command_SetDataNodeZoneCommand:
+			return fsm.applySetDataNodeZoneCommand(&cmd)
 		default:
 			panic(fmt.Errorf("cannot apply command: %x", l.Data))
 		}
@@ -576,6 +584,54 @@ func (fsm *storeFSM) applyDeleteDataNodeCommand(cmd *internal.Command) interface
 	return nil
 }
 
+func (fsm *storeFSM) applyDecommissionDataNodeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DecommissionDataNodeCommand_Command)
+	v := ext.(*internal.DecommissionDataNodeCommand)
+
+	other := fsm.data.Clone()
+	if err := other.DecommissionDataNode(v.GetID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetDataNodeZoneCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDataNodeZoneCommand_Command)
+	v := ext.(*internal.SetDataNodeZoneCommand)
+
+	other := fsm.data.Clone()
+	if err := other.SetDataNodeZone(v.GetID(), v.GetZone()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyAddShardOwnerCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_AddShardOwnerCommand_Command)
+	v := ext.(*internal.AddShardOwnerCommand)
+
+	other := fsm.data.Clone()
+	if err := other.AddShardOwner(v.GetID(), v.GetNodeID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyRemoveShardOwnerCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_RemoveShardOwnerCommand_Command)
+	v := ext.(*internal.RemoveShardOwnerCommand)
+
+	other := fsm.data.Clone()
+	if err := other.RemoveShardOwner(v.GetID(), v.GetNodeID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
 func (fsm *storeFSM) Snapshot() (raft.FSMSnapshot, error) {
 	s := (*store)(fsm)
 	s.mu.Lock()
@@ -42,6 +42,7 @@ type Config struct {
 	BatchPending     int           `toml:"batch-pending"`
 	BatchTimeout     toml.Duration `toml:"batch-timeout"`
 	LogPointErrors   bool          `toml:"log-point-errors"`
+	QueryEnabled     bool          `toml:"query-enabled"`
 }
 
 // NewConfig returns a new config for the service.
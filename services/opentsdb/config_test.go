@@ -18,6 +18,7 @@ consistency-level ="all"
 tls-enabled = true
 certificate = "/etc/ssl/cert.pem"
 log-point-errors = true
+query-enabled = true
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -37,5 +38,7 @@ log-point-errors = true
 		t.Fatalf("unexpected certificate: %s", c.Certificate)
 	} else if !c.LogPointErrors {
 		t.Fatalf("unexpected log-point-errors: %v", c.LogPointErrors)
+	} else if !c.QueryEnabled {
+		t.Fatalf("unexpected query-enabled: %v", c.QueryEnabled)
 	}
 }
@@ -10,13 +10,22 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/influxdb"
 	"github.com/influxdata/influxdb/cluster"
+	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/influxdb/models"
 )
 
+// DefaultSuggestMax is the default number of results returned by /api/suggest.
+const DefaultSuggestMax = 25
+
 // Handler is an http.Handler for the service.
 type Handler struct {
 	Database         string
@@ -27,6 +36,13 @@ type Handler struct {
 		WritePoints(p *cluster.WritePointsRequest) error
 	}
 
+	QueryExecutor interface {
+		ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	}
+
+	// The query endpoints aren't authenticated so they must be enabled.
+	QueryEnabled bool
+
 	Logger *log.Logger
 
 	statMap *expvar.Map
@@ -38,6 +54,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	case "/api/put":
 		h.servePut(w, r)
+	case "/api/query":
+		if !h.QueryEnabled {
+			http.NotFound(w, r)
+			return
+		}
+		h.serveQuery(w, r)
+	case "/api/suggest":
+		if !h.QueryEnabled {
+			http.NotFound(w, r)
+			return
+		}
+		h.serveSuggest(w, r)
 	default:
 		http.NotFound(w, r)
 	}
@@ -141,6 +169,263 @@ func (h *Handler) servePut(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// serveQuery implements OpenTSDB's HTTP /api/query endpoint. Each query is
+// translated into an InfluxQL SELECT statement which downsamples every
+// series. The series are then combined with the query's aggregator.
+func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	h.statMap.Add(statQueryRequests, 1)
+
+	var req queryRequest
+	switch r.Method {
+	case "GET":
+		var err error
+		if req, err = parseQueryParams(r.URL.Query()); err != nil {
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case "POST":
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(&req); err != nil {
+			h.httpError(w, "json object decode error", http.StatusBadRequest)
+			return
+		}
+	default:
+		h.httpError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Determine the time range.
+	now := time.Now()
+	if req.Start == nil {
+		h.httpError(w, "missing start time", http.StatusBadRequest)
+		return
+	}
+	start, err := parseQueryTime(req.Start, now)
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end := now
+	if req.End != nil {
+		if end, err = parseQueryTime(req.End, now); err != nil {
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Translate every query before executing any of them.
+	if len(req.Queries) == 0 {
+		h.httpError(w, "missing queries", http.StatusBadRequest)
+		return
+	}
+	stmts := make([]*influxql.SelectStatement, len(req.Queries))
+	for i := range req.Queries {
+		stmt, err := req.Queries[i].selectStatement(h.Database, h.RetentionPolicy, start, end)
+		if err != nil {
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		stmts[i] = stmt
+	}
+
+	closing := make(chan struct{})
+	defer close(closing)
+
+	results := make([]*queryResult, 0)
+	for i, stmt := range stmts {
+		q := &req.Queries[i]
+
+		rows, err := h.execute(stmt, closing)
+		if err != nil {
+			h.httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		a, err := newSeries(rows)
+		if err != nil {
+			h.httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if q.Rate {
+			for _, s := range a {
+				s.rate()
+			}
+		}
+
+		qr, err := aggregate(q.Metric, q.Aggregator, q.groupBy(), a, req.MsResolution)
+		if err != nil {
+			h.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		results = append(results, qr...)
+	}
+
+	h.writeJSON(w, results)
+}
+
+// parseQueryParams parses the parameters of a GET /api/query request.
+func parseQueryParams(values url.Values) (queryRequest, error) {
+	// The ms flag requests millisecond timestamps.
+	_, ms := values["ms"]
+	req := queryRequest{MsResolution: ms}
+	if s := values.Get("start"); s != "" {
+		req.Start = s
+	}
+	if s := values.Get("end"); s != "" {
+		req.End = s
+	}
+
+	for _, m := range values["m"] {
+		q, err := parseMetricQuery(m)
+		if err != nil {
+			return req, err
+		}
+		req.Queries = append(req.Queries, *q)
+	}
+	return req, nil
+}
+
+// suggestRequest represents an /api/suggest request.
+type suggestRequest struct {
+	Type string `json:"type"`
+	Q    string `json:"q"`
+	Max  int    `json:"max"`
+}
+
+// serveSuggest implements OpenTSDB's HTTP /api/suggest endpoint. It returns
+// the sorted metrics, tag keys or tag values starting with a prefix.
+func (h *Handler) serveSuggest(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	h.statMap.Add(statSuggestRequests, 1)
+
+	var req suggestRequest
+	switch r.Method {
+	case "GET":
+		req.Type, req.Q = r.URL.Query().Get("type"), r.URL.Query().Get("q")
+		if s := r.URL.Query().Get("max"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				h.httpError(w, "invalid max: "+s, http.StatusBadRequest)
+				return
+			}
+			req.Max = n
+		}
+	case "POST":
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.httpError(w, "json object decode error", http.StatusBadRequest)
+			return
+		}
+	default:
+		h.httpError(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Max <= 0 {
+		req.Max = DefaultSuggestMax
+	}
+
+	closing := make(chan struct{})
+	defer close(closing)
+
+	var rows models.Rows
+	var err error
+	switch req.Type {
+	case "metrics":
+		rows, err = h.execute(&influxql.ShowMeasurementsStatement{
+			Source: &influxql.Measurement{Regex: &influxql.RegexLiteral{
+				Val: regexp.MustCompile("^" + regexp.QuoteMeta(req.Q)),
+			}},
+		}, closing)
+	case "tagk":
+		rows, err = h.execute(&influxql.ShowTagKeysStatement{}, closing)
+	case "tagv":
+		// Tag values can only be shown for specific keys so find them first.
+		if rows, err = h.execute(&influxql.ShowTagKeysStatement{}, closing); err == nil {
+			if keys := rowStrings(rows, ""); len(keys) > 0 {
+				rows, err = h.execute(&influxql.ShowTagValuesStatement{TagKeys: keys}, closing)
+			} else {
+				rows = nil
+			}
+		}
+	default:
+		h.httpError(w, "invalid suggest type: "+req.Type, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	values := rowStrings(rows, req.Q)
+	if len(values) > req.Max {
+		values = values[:req.Max]
+	}
+	h.writeJSON(w, values)
+}
+
+// rowStrings returns the sorted, unique string values in the last column
+// of rows that start with prefix.
+func rowStrings(rows models.Rows, prefix string) []string {
+	seen := make(map[string]bool)
+	a := make([]string, 0)
+	for _, row := range rows {
+		for _, values := range row.Values {
+			if len(values) == 0 {
+				continue
+			}
+			if s, ok := values[len(values)-1].(string); ok && strings.HasPrefix(s, prefix) && !seen[s] {
+				seen[s] = true
+				a = append(a, s)
+			}
+		}
+	}
+	sort.Strings(a)
+	return a
+}
+
+// execute runs a single statement against the handler's database and
+// returns the rows of its results.
+func (h *Handler) execute(stmt influxql.Statement, closing chan struct{}) (models.Rows, error) {
+	ch, err := h.QueryExecutor.ExecuteQuery(&influxql.Query{Statements: influxql.Statements{stmt}}, h.Database, 0, closing)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drain the results even after an error so the executor isn't blocked.
+	var rows models.Rows
+	for res := range ch {
+		if res.Err != nil && err == nil {
+			err = res.Err
+		}
+		rows = append(rows, res.Series...)
+	}
+	return rows, err
+}
+
+// writeJSON writes v as the JSON body of a successful response.
+func (h *Handler) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.Logger.Println("json encode error: ", err)
+	}
+}
+
+// httpError writes an error in the format used by the OpenTSDB HTTP API.
+func (h *Handler) httpError(w http.ResponseWriter, message string, code int) {
+	var body struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	body.Error.Code, body.Error.Message = code, message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
 // chanListener represents a listener that receives connections through a channel.
 type chanListener struct {
 	addr net.Addr
@@ -0,0 +1,811 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+)
+
+// queryRequest represents the body of an /api/query request.
+type queryRequest struct {
+	Start        interface{} `json:"start"`
+	End          interface{} `json:"end"`
+	Queries      []subQuery  `json:"queries"`
+	MsResolution bool        `json:"msResolution"`
+}
+
+// subQuery represents a single metric query within an /api/query request.
+type subQuery struct {
+	Aggregator string            `json:"aggregator"`
+	Metric     string            `json:"metric"`
+	Downsample string            `json:"downsample"`
+	Rate       bool              `json:"rate"`
+	Tags       map[string]string `json:"tags"`
+	Filters    []tagFilter       `json:"filters"`
+}
+
+// tagFilter represents an OpenTSDB tag filter.
+type tagFilter struct {
+	Type    string `json:"type"`
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+// queryResult represents a single series returned by /api/query.
+type queryResult struct {
+	Metric        string             `json:"metric"`
+	Tags          map[string]string  `json:"tags"`
+	AggregateTags []string           `json:"aggregateTags"`
+	DPS           map[string]float64 `json:"dps"`
+}
+
+// aggregateFunc combines the values of several series at a single timestamp.
+type aggregateFunc func(a []float64) float64
+
+// aggregator combines series with fn. If interpolate is set, a series without
+// a value at a timestamp contributes a value linearly interpolated from its
+// surrounding points. Otherwise only series with a value are combined, which
+// is the same as filling missing values with zero for zimsum.
+type aggregator struct {
+	fn          aggregateFunc
+	interpolate bool
+}
+
+// aggregators maps OpenTSDB aggregators to the aggregators used to combine series.
+// The "none" aggregator is handled separately since it doesn't combine series.
+var aggregators = map[string]aggregator{
+	"sum":    {sumValues, true},
+	"zimsum": {sumValues, false},
+	"min":    {minValues, true},
+	"mimmin": {minValues, false},
+	"max":    {maxValues, true},
+	"mimmax": {maxValues, false},
+	"avg":    {meanValues, true},
+	"dev":    {stddevValues, true},
+	"count":  {func(a []float64) float64 { return float64(len(a)) }, false},
+	"first":  {func(a []float64) float64 { return a[0] }, false},
+	"last":   {func(a []float64) float64 { return a[len(a)-1] }, false},
+	"p50":    {percentileValues(50), true},
+	"p75":    {percentileValues(75), true},
+	"p90":    {percentileValues(90), true},
+	"p95":    {percentileValues(95), true},
+	"p99":    {percentileValues(99), true},
+	"p999":   {percentileValues(99.9), true},
+}
+
+// downsamplers maps OpenTSDB downsampling functions to InfluxQL calls.
+var downsamplers = map[string]string{
+	"sum":    "sum",
+	"zimsum": "sum",
+	"min":    "min",
+	"mimmin": "min",
+	"max":    "max",
+	"mimmax": "max",
+	"avg":    "mean",
+	"dev":    "stddev",
+	"count":  "count",
+	"first":  "first",
+	"last":   "last",
+}
+
+// tagFilterFuncRegex matches a filter using the function syntax, such as wildcard(web*).
+var tagFilterFuncRegex = regexp.MustCompile(`^([a-z_]+)\((.*)\)$`)
+
+// durationRegex matches an OpenTSDB duration, such as 5m or 1d.
+var durationRegex = regexp.MustCompile(`^(\d+)(ms|s|m|h|d|w|n|y)$`)
+
+// timeLayouts are the absolute time formats accepted by OpenTSDB.
+var timeLayouts = []string{
+	"2006/01/02-15:04:05",
+	"2006/01/02 15:04:05",
+	"2006/01/02-15:04",
+	"2006/01/02 15:04",
+	"2006/01/02",
+}
+
+// parseQueryTime parses an OpenTSDB start or end time. Times can be a unix
+// timestamp in seconds or milliseconds, a relative time such as "1h-ago" or
+// an absolute time such as "2016/01/02-15:04:05".
+func parseQueryTime(v interface{}, now time.Time) (time.Time, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return time.Time{}, fmt.Errorf("invalid time: %v", v)
+	}
+
+	if s == "now" {
+		return now, nil
+	} else if strings.HasSuffix(s, "-ago") {
+		d, err := parseDuration(strings.TrimSuffix(s, "-ago"))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	} else if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// If time value is over a billion then it's milliseconds.
+		if n < 10000000000 {
+			return time.Unix(n, 0), nil
+		}
+		return time.Unix(n/1000, (n%1000)*int64(time.Millisecond)), nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", s)
+}
+
+// parseDuration parses an OpenTSDB duration. A month is 30 days and a year is 365 days.
+func parseDuration(s string) (time.Duration, error) {
+	m := durationRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+
+	var unit time.Duration
+	switch m[2] {
+	case "ms":
+		unit = time.Millisecond
+	case "s":
+		unit = time.Second
+	case "m":
+		unit = time.Minute
+	case "h":
+		unit = time.Hour
+	case "d":
+		unit = 24 * time.Hour
+	case "w":
+		unit = 7 * 24 * time.Hour
+	case "n":
+		unit = 30 * 24 * time.Hour
+	case "y":
+		unit = 365 * 24 * time.Hour
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// downsample represents a parsed downsample specification such as "1m-avg-zero".
+type downsample struct {
+	interval time.Duration
+	call     *influxql.Call
+	fill     influxql.FillOption
+}
+
+// parseDownsample parses an OpenTSDB downsample specification of the form
+// <interval>-<function>[-<fill policy>].
+func parseDownsample(s string) (*downsample, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid downsample: %s", s)
+	}
+
+	interval, err := parseDuration(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid downsample: %s", s)
+	}
+
+	ds := &downsample{interval: interval, fill: influxql.NoFill}
+	value := &influxql.VarRef{Val: "value"}
+	if name, ok := downsamplers[parts[1]]; ok {
+		ds.call = &influxql.Call{Name: name, Args: []influxql.Expr{value}}
+	} else if p, ok := parsePercentile(parts[1]); ok {
+		ds.call = &influxql.Call{Name: "percentile", Args: []influxql.Expr{value, &influxql.NumberLiteral{Val: p}}}
+	} else {
+		return nil, fmt.Errorf("unsupported downsample function: %s", parts[1])
+	}
+
+	if len(parts) == 3 {
+		switch parts[2] {
+		case "none":
+		case "zero":
+			ds.fill = influxql.NumberFill
+		default:
+			return nil, fmt.Errorf("unsupported fill policy: %s", parts[2])
+		}
+	}
+	return ds, nil
+}
+
+// parsePercentile parses a percentile function name such as p95 or p999.
+func parsePercentile(s string) (float64, bool) {
+	switch s {
+	case "p50":
+		return 50, true
+	case "p75":
+		return 75, true
+	case "p90":
+		return 90, true
+	case "p95":
+		return 95, true
+	case "p99":
+		return 99, true
+	case "p999":
+		return 99.9, true
+	}
+	return 0, false
+}
+
+// parseMetricQuery parses a query from the m parameter of a GET request:
+//
+//	<aggregator>:[<downsample>:][rate:]<metric>[{<tag>=<filter>,...}][{<tag>=<filter>,...}]
+//
+// Filters in the first braces are grouped by and filters in the second aren't.
+func parseMetricQuery(s string) (*subQuery, error) {
+	head, rest := s, ""
+	if i := strings.Index(s, "{"); i != -1 {
+		head, rest = s[:i], s[i:]
+	}
+
+	parts := strings.Split(head, ":")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid metric query: %s", s)
+	}
+
+	q := &subQuery{Aggregator: parts[0], Metric: parts[len(parts)-1]}
+	for _, p := range parts[1 : len(parts)-1] {
+		if p == "rate" {
+			q.Rate = true
+		} else if q.Downsample == "" {
+			q.Downsample = p
+		} else {
+			return nil, fmt.Errorf("invalid metric query: %s", s)
+		}
+	}
+
+	for i := 0; rest != ""; i++ {
+		end := indexOutsideParens(rest, '}')
+		if i > 1 || rest[0] != '{' || end == -1 {
+			return nil, fmt.Errorf("invalid metric query: %s", s)
+		}
+
+		for _, kv := range splitOutsideParens(rest[1:end], ',') {
+			if kv == "" {
+				continue
+			}
+
+			pair := strings.SplitN(kv, "=", 2)
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("invalid tag filter: %s", kv)
+			}
+
+			f, err := parseTagFilter(pair[0], pair[1], i == 0)
+			if err != nil {
+				return nil, err
+			}
+			q.Filters = append(q.Filters, f)
+		}
+		rest = rest[end+1:]
+	}
+	return q, nil
+}
+
+// indexOutsideParens returns the index of the first c in s that isn't
+// within parentheses, or -1 if there is none.
+func indexOutsideParens(s string, c byte) int {
+	var depth int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case c:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitOutsideParens splits s on each sep that isn't within parentheses.
+func splitOutsideParens(s string, sep byte) []string {
+	var a []string
+	for {
+		i := indexOutsideParens(s, sep)
+		if i == -1 {
+			return append(a, s)
+		}
+		a = append(a, s[:i])
+		s = s[i+1:]
+	}
+}
+
+// filters returns the tag filters of the query. Entries in the tags map are
+// grouped by and use the legacy syntax where "*" is a wildcard and "|"
+// separates literal values.
+func (q *subQuery) filters() ([]tagFilter, error) {
+	keys := make([]string, 0, len(q.Tags))
+	for k := range q.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var filters []tagFilter
+	for _, k := range keys {
+		f, err := parseTagFilter(k, q.Tags[k], true)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return append(filters, q.Filters...), nil
+}
+
+// parseTagFilter parses a tag filter from its string form, such as
+// "web01|web02", "web*" or "regexp(web.*)".
+func parseTagFilter(tagk, s string, groupBy bool) (tagFilter, error) {
+	f := tagFilter{Tagk: tagk, GroupBy: groupBy}
+	if m := tagFilterFuncRegex.FindStringSubmatch(s); m != nil {
+		f.Type, f.Filter = m[1], m[2]
+	} else if strings.Contains(s, "*") {
+		f.Type, f.Filter = "wildcard", s
+	} else {
+		f.Type, f.Filter = "literal_or", s
+	}
+
+	if f.Tagk == "" {
+		return f, errors.New("missing tag key in filter")
+	}
+	return f, nil
+}
+
+// expr returns the InfluxQL condition for the filter. A nil expression
+// matches every series.
+func (f *tagFilter) expr() (influxql.Expr, error) {
+	if f.Tagk == "" {
+		return nil, errors.New("missing tag key in filter")
+	}
+	ref := &influxql.VarRef{Val: f.Tagk}
+
+	switch f.Type {
+	case "literal_or", "not_literal_or":
+		op, join := influxql.EQ, influxql.OR
+		if f.Type == "not_literal_or" {
+			op, join = influxql.NEQ, influxql.AND
+		}
+
+		var expr influxql.Expr
+		for _, v := range strings.Split(f.Filter, "|") {
+			e := &influxql.BinaryExpr{Op: op, LHS: ref, RHS: &influxql.StringLiteral{Val: v}}
+			if expr == nil {
+				expr = e
+			} else {
+				expr = &influxql.BinaryExpr{Op: join, LHS: expr, RHS: e}
+			}
+		}
+		return &influxql.ParenExpr{Expr: expr}, nil
+	case "wildcard", "iwildcard":
+		if f.Filter == "*" {
+			return nil, nil
+		}
+
+		parts := strings.Split(f.Filter, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		pattern := "^" + strings.Join(parts, ".*") + "$"
+		if f.Type == "iwildcard" {
+			pattern = "(?i)" + pattern
+		}
+		return newRegexExpr(ref, pattern)
+	case "regexp":
+		return newRegexExpr(ref, f.Filter)
+	default:
+		return nil, fmt.Errorf("unsupported filter type: %s", f.Type)
+	}
+}
+
+// newRegexExpr returns a condition matching ref against pattern.
+func newRegexExpr(ref *influxql.VarRef, pattern string) (influxql.Expr, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regexp filter: %s", err)
+	}
+	return &influxql.BinaryExpr{Op: influxql.EQREGEX, LHS: ref, RHS: &influxql.RegexLiteral{Val: re}}, nil
+}
+
+// selectStatement translates the query into an InfluxQL statement for the
+// time range. Every series is selected separately so the aggregator can
+// be applied across series afterwards.
+func (q *subQuery) selectStatement(database, retentionPolicy string, start, end time.Time) (*influxql.SelectStatement, error) {
+	if q.Metric == "" {
+		return nil, errors.New("missing metric")
+	}
+	if _, ok := aggregators[q.Aggregator]; !ok && q.Aggregator != "none" {
+		return nil, fmt.Errorf("unsupported aggregator: %s", q.Aggregator)
+	}
+
+	stmt := &influxql.SelectStatement{
+		Sources: influxql.Sources{&influxql.Measurement{
+			Database:        database,
+			RetentionPolicy: retentionPolicy,
+			Name:            q.Metric,
+		}},
+		Dimensions: influxql.Dimensions{{Expr: &influxql.Wildcard{}}},
+	}
+
+	if q.Downsample != "" {
+		ds, err := parseDownsample(q.Downsample)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Fields = influxql.Fields{{Expr: ds.call}}
+		stmt.Dimensions = append(influxql.Dimensions{{Expr: &influxql.Call{
+			Name: "time",
+			Args: []influxql.Expr{&influxql.DurationLiteral{Val: ds.interval}},
+		}}}, stmt.Dimensions...)
+		stmt.Fill = ds.fill
+		if ds.fill == influxql.NumberFill {
+			stmt.FillValue = 0
+		}
+	} else {
+		stmt.Fields = influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}}
+		stmt.IsRawQuery = true
+	}
+
+	// Restrict the time range and apply the tag filters.
+	timeRef := &influxql.VarRef{Val: "time"}
+	var cond influxql.Expr = &influxql.BinaryExpr{
+		Op:  influxql.AND,
+		LHS: &influxql.BinaryExpr{Op: influxql.GTE, LHS: timeRef, RHS: &influxql.TimeLiteral{Val: start.UTC()}},
+		RHS: &influxql.BinaryExpr{Op: influxql.LTE, LHS: timeRef, RHS: &influxql.TimeLiteral{Val: end.UTC()}},
+	}
+
+	filters, err := q.filters()
+	if err != nil {
+		return nil, err
+	}
+	for i := range filters {
+		expr, err := filters[i].expr()
+		if err != nil {
+			return nil, err
+		} else if expr != nil {
+			cond = &influxql.BinaryExpr{Op: influxql.AND, LHS: cond, RHS: expr}
+		}
+	}
+	stmt.Condition = cond
+
+	return stmt, nil
+}
+
+// groupBy returns the sorted tag keys the query groups series by.
+func (q *subQuery) groupBy() []string {
+	filters, _ := q.filters()
+
+	seen := make(map[string]bool)
+	var keys []string
+	for _, f := range filters {
+		if f.GroupBy && !seen[f.Tagk] {
+			seen[f.Tagk] = true
+			keys = append(keys, f.Tagk)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dataPoint represents a single value of a series.
+type dataPoint struct {
+	time  int64
+	value float64
+}
+
+// series represents the data points of a single series read for a query.
+type series struct {
+	tags   map[string]string
+	points []dataPoint
+}
+
+// newSeries converts the rows of a query result into series. Rows for the
+// same series are merged and null values are skipped.
+func newSeries(rows models.Rows) ([]*series, error) {
+	var a []*series
+	m := make(map[string]*series)
+	for _, row := range rows {
+		key := string(models.Tags(row.Tags).HashKey())
+		s := m[key]
+		if s == nil {
+			s = &series{tags: row.Tags}
+			if s.tags == nil {
+				s.tags = make(map[string]string)
+			}
+			m[key] = s
+			a = append(a, s)
+		}
+
+		for _, values := range row.Values {
+			if len(values) != 2 || values[1] == nil {
+				continue
+			}
+
+			t, ok := values[0].(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("unexpected time: %v", values[0])
+			}
+
+			var v float64
+			switch value := values[1].(type) {
+			case float64:
+				v = value
+			case int64:
+				v = float64(value)
+			default:
+				return nil, fmt.Errorf("unexpected value: %v", value)
+			}
+			s.points = append(s.points, dataPoint{time: t.UnixNano(), value: v})
+		}
+	}
+	return a, nil
+}
+
+// downsample averages the points of the series within each interval so it has
+// at most one point per interval. Point times are truncated to the interval.
+func (s *series) downsample(interval int64) {
+	sort.Sort(dataPoints(s.points))
+
+	var points []dataPoint
+	var n int
+	for _, p := range s.points {
+		t := p.time - p.time%interval
+		if len(points) > 0 && points[len(points)-1].time == t {
+			last := &points[len(points)-1]
+			last.value += (p.value - last.value) / float64(n+1)
+			n++
+			continue
+		}
+		points = append(points, dataPoint{time: t, value: p.value})
+		n = 1
+	}
+	s.points = points
+}
+
+// valueAt returns the value of the series at t, searching its points from
+// index i. A missing value is linearly interpolated from the surrounding
+// points if interpolate is set. The index of the first point at or after t is
+// returned so later times can continue the search from there.
+func (s *series) valueAt(t int64, i int, interpolate bool) (float64, int, bool) {
+	for i < len(s.points) && s.points[i].time < t {
+		i++
+	}
+	if i < len(s.points) && s.points[i].time == t {
+		return s.points[i].value, i, true
+	} else if !interpolate || i == 0 || i == len(s.points) {
+		return 0, i, false
+	}
+
+	prev, next := s.points[i-1], s.points[i]
+	return prev.value + (next.value-prev.value)*float64(t-prev.time)/float64(next.time-prev.time), i, true
+}
+
+// dataPoints represents a list of data points sortable by time.
+type dataPoints []dataPoint
+
+func (a dataPoints) Len() int           { return len(a) }
+func (a dataPoints) Less(i, j int) bool { return a[i].time < a[j].time }
+func (a dataPoints) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// int64Slice represents a sortable list of timestamps.
+type int64Slice []int64
+
+func (a int64Slice) Len() int           { return len(a) }
+func (a int64Slice) Less(i, j int) bool { return a[i] < a[j] }
+func (a int64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// rate replaces the points of the series with the per second rate of change.
+func (s *series) rate() {
+	var points []dataPoint
+	for i := 1; i < len(s.points); i++ {
+		prev, curr := s.points[i-1], s.points[i]
+		if elapsed := float64(curr.time-prev.time) / float64(time.Second); elapsed > 0 {
+			points = append(points, dataPoint{time: curr.time, value: (curr.value - prev.value) / elapsed})
+		}
+	}
+	s.points = points
+}
+
+// aggregate combines series into query results. Series with the same values
+// for the group by tags are combined with the aggregator at each timestamp of
+// any of the series. Series have at most one point per second, or per
+// millisecond if msResolution is set. Tags that differ between the combined
+// series are reported as aggregate tags.
+func aggregate(metric, name string, groupBy []string, a []*series, msResolution bool) ([]*queryResult, error) {
+	var fn aggregateFunc
+	var interpolate bool
+	if name != "none" {
+		agg, ok := aggregators[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported aggregator: %s", name)
+		}
+		fn, interpolate = agg.fn, agg.interpolate
+	}
+
+	interval := int64(time.Second)
+	if msResolution {
+		interval = int64(time.Millisecond)
+	}
+	for _, s := range a {
+		s.downsample(interval)
+	}
+
+	// Group series by the values of the group by tags.
+	var keys []string
+	groups := make(map[string][]*series)
+	for i, s := range a {
+		var key string
+		if fn == nil {
+			key = strconv.Itoa(i)
+		} else {
+			values := make([]string, len(groupBy))
+			for j, k := range groupBy {
+				values[j] = s.tags[k]
+			}
+			key = strings.Join(values, "\x00")
+		}
+
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], s)
+	}
+
+	results := make([]*queryResult, 0, len(keys))
+	for _, key := range keys {
+		group := groups[key]
+		r := &queryResult{
+			Metric:        metric,
+			Tags:          make(map[string]string),
+			AggregateTags: []string{},
+			DPS:           make(map[string]float64),
+		}
+
+		// Keep tags shared by every series and report the others as aggregated.
+		aggregated := make(map[string]bool)
+		for k, v := range group[0].tags {
+			r.Tags[k] = v
+		}
+		for _, s := range group[1:] {
+			for k, v := range s.tags {
+				if r.Tags[k] != v {
+					aggregated[k] = true
+				}
+			}
+			for k := range r.Tags {
+				if _, ok := s.tags[k]; !ok {
+					aggregated[k] = true
+				}
+			}
+		}
+		for k := range aggregated {
+			delete(r.Tags, k)
+			r.AggregateTags = append(r.AggregateTags, k)
+		}
+		sort.Strings(r.AggregateTags)
+
+		// Find every timestamp of the series in the group.
+		var times []int64
+		seen := make(map[int64]bool)
+		for _, s := range group {
+			for _, p := range s.points {
+				if !seen[p.time] {
+					seen[p.time] = true
+					times = append(times, p.time)
+				}
+			}
+		}
+		sort.Sort(int64Slice(times))
+
+		// Combine the values of the series at each timestamp.
+		index := make([]int, len(group))
+		for _, t := range times {
+			var v []float64
+			for i, s := range group {
+				value, j, ok := s.valueAt(t, index[i], interpolate)
+				index[i] = j
+				if ok {
+					v = append(v, value)
+				}
+			}
+
+			value := v[0]
+			if fn != nil {
+				value = fn(v)
+			}
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			r.DPS[strconv.FormatInt(t/interval, 10)] = value
+		}
+
+		if len(r.DPS) > 0 {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func sumValues(a []float64) float64 {
+	var sum float64
+	for _, v := range a {
+		sum += v
+	}
+	return sum
+}
+
+func minValues(a []float64) float64 {
+	min := a[0]
+	for _, v := range a[1:] {
+		min = math.Min(min, v)
+	}
+	return min
+}
+
+func maxValues(a []float64) float64 {
+	max := a[0]
+	for _, v := range a[1:] {
+		max = math.Max(max, v)
+	}
+	return max
+}
+
+func meanValues(a []float64) float64 {
+	return sumValues(a) / float64(len(a))
+}
+
+// stddevValues returns the sample standard deviation, which is zero for a single value.
+func stddevValues(a []float64) float64 {
+	if len(a) < 2 {
+		return 0
+	}
+
+	mean := meanValues(a)
+	var variance float64
+	for _, v := range a {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(a)-1))
+}
+
+// percentileValues returns a function calculating the nearest rank
+// percentile, the same way as the InfluxQL percentile() function.
+func percentileValues(p float64) aggregateFunc {
+	return func(a []float64) float64 {
+		sorted := make([]float64, len(a))
+		copy(sorted, a)
+		sort.Float64s(sorted)
+
+		i := int(math.Floor(float64(len(sorted))*p/100.0+0.5)) - 1
+		if i < 0 {
+			i = 0
+		} else if i >= len(sorted) {
+			i = len(sorted) - 1
+		}
+		return sorted[i]
+	}
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/influxdata/influxdb"
 	"github.com/influxdata/influxdb/cluster"
+	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/tsdb"
@@ -43,6 +44,8 @@ const (
 	statConnectionsActive        = "connsActive"
 	statConnectionsHandled       = "connsHandled"
 	statDroppedPointsInvalid     = "droppedPointsInvalid"
+	statQueryRequests            = "queryReq"
+	statSuggestRequests          = "suggestReq"
 )
 
 // Service manages the listener and handler for an HTTP endpoint.
@@ -68,6 +71,9 @@ type Service struct {
 	MetaClient interface {
 		CreateDatabase(name string) (*meta.DatabaseInfo, error)
 	}
+	QueryExecutor interface {
+		ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error)
+	}
 
 	// Points received over the telnet protocol are batched.
 	batchSize    int
@@ -76,6 +82,7 @@ type Service struct {
 	batcher      *tsdb.PointBatcher
 
 	LogPointErrors bool
+	QueryEnabled   bool
 	Logger         *log.Logger
 	statMap        *expvar.Map
 }
@@ -101,6 +108,7 @@ func NewService(c Config) (*Service, error) {
 		batchTimeout:     time.Duration(c.BatchTimeout),
 		Logger:           log.New(os.Stderr, "[opentsdb] ", log.LstdFlags),
 		LogPointErrors:   c.LogPointErrors,
+		QueryEnabled:     c.QueryEnabled,
 	}
 	return s, nil
 }
@@ -361,6 +369,8 @@ func (s *Service) serveHTTP() {
 		RetentionPolicy:  s.RetentionPolicy,
 		ConsistencyLevel: s.ConsistencyLevel,
 		PointsWriter:     s.PointsWriter,
+		QueryExecutor:    s.QueryExecutor,
+		QueryEnabled:     s.QueryEnabled,
 		Logger:           s.Logger,
 		statMap:          s.statMap,
 	}}
@@ -1,10 +1,12 @@
 package opentsdb_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strings"
 	"sync/atomic"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/influxdata/influxdb/cluster"
+	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/influxdb/models"
 	"github.com/influxdata/influxdb/services/meta"
 	"github.com/influxdata/influxdb/services/opentsdb"
@@ -132,9 +135,284 @@ func TestService_HTTP(t *testing.T) {
 	}
 }
 
+// Ensure OpenTSDB queries are translated into InfluxQL and the series are
+// combined by the aggregator.
+func TestService_HTTPQuery(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryFn = func(stmt influxql.Statement, database string) (models.Rows, error) {
+		if database != "db0" {
+			t.Fatalf("unexpected database: %s", database)
+		} else if exp := `SELECT mean(value) FROM db0.."sys.cpu" WHERE time >= '2013-01-01T00:00:00Z' AND time <= '2013-01-01T01:00:00Z' AND host =~ /^web.*$/ AND (dc = 'lga' OR dc = 'ewr') GROUP BY time(1m), * fill(none)`; stmt.String() != exp {
+			t.Fatalf("unexpected statement:\n\nexp=%s\n\ngot=%s", exp, stmt.String())
+		}
+		return models.Rows{
+			{Name: "sys.cpu", Tags: map[string]string{"dc": "lga", "host": "web01"}, Columns: []string{"time", "mean"}, Values: [][]interface{}{
+				{time.Unix(1356998400, 0).UTC(), 1.0},
+				{time.Unix(1356998460, 0).UTC(), nil},
+			}},
+			{Name: "sys.cpu", Tags: map[string]string{"dc": "ewr", "host": "web01"}, Columns: []string{"time", "mean"}, Values: [][]interface{}{
+				{time.Unix(1356998400, 0).UTC(), 2.0},
+			}},
+			{Name: "sys.cpu", Tags: map[string]string{"dc": "lga", "host": "web02"}, Columns: []string{"time", "mean"}, Values: [][]interface{}{
+				{time.Unix(1356998460, 0).UTC(), 4.0},
+			}},
+		}, nil
+	}
+
+	resp, err := http.Post("http://"+s.Addr().String()+"/api/query", "application/json", strings.NewReader(`{
+		"start": 1356998400,
+		"end": "2013/01/01-01:00:00",
+		"queries": [{
+			"aggregator": "sum",
+			"metric": "sys.cpu",
+			"downsample": "1m-avg",
+			"tags": {"host": "web*"},
+			"filters": [{"type": "literal_or", "tagk": "dc", "filter": "lga|ewr", "groupBy": false}]
+		}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var results []map[string]interface{}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	} else if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	} else if exp := []map[string]interface{}{
+		{
+			"metric":        "sys.cpu",
+			"tags":          map[string]interface{}{"host": "web01"},
+			"aggregateTags": []interface{}{"dc"},
+			"dps":           map[string]interface{}{"1356998400": 3.0},
+		},
+		{
+			"metric":        "sys.cpu",
+			"tags":          map[string]interface{}{"dc": "lga", "host": "web02"},
+			"aggregateTags": []interface{}{},
+			"dps":           map[string]interface{}{"1356998460": 4.0},
+		},
+	}; !reflect.DeepEqual(results, exp) {
+		t.Fatalf("unexpected results:\n\nexp=%v\n\ngot=%v", exp, results)
+	}
+}
+
+// Ensure a query in the m parameter of a GET request is supported.
+func TestService_HTTPQuery_GET(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryFn = func(stmt influxql.Statement, database string) (models.Rows, error) {
+		if exp := `SELECT value FROM db0.."sys.cpu" WHERE time >= '2013-01-01T00:00:00Z' AND time <= '2013-01-01T00:10:00Z' AND (host = 'web01' OR host = 'web02') AND cpu =~ /^(a|b)$/ GROUP BY *`; stmt.String() != exp {
+			t.Fatalf("unexpected statement:\n\nexp=%s\n\ngot=%s", exp, stmt.String())
+		}
+		return models.Rows{
+			{Name: "sys.cpu", Tags: map[string]string{"host": "web01"}, Columns: []string{"time", "value"}, Values: [][]interface{}{
+				{time.Unix(1356998400, 0).UTC(), 10.0},
+				{time.Unix(1356998410, 500*int64(time.Millisecond)).UTC(), 31.0},
+			}},
+		}, nil
+	}
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/api/query?ms&start=1356998400&end=1356999000000&m=" +
+		url.QueryEscape("none:rate:sys.cpu{host=web01|web02}{cpu=regexp(^(a|b)$)}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d: %s", resp.StatusCode, body)
+	} else if exp := `[{"metric":"sys.cpu","tags":{"host":"web01"},"aggregateTags":[],"dps":{"1356998410500":2}}]`; strings.TrimSpace(string(body)) != exp {
+		t.Fatalf("unexpected body:\n\nexp=%s\n\ngot=%s", exp, body)
+	}
+}
+
+// Ensure aggregators interpolate missing values and points within the same
+// second are averaged without ms resolution.
+func TestService_HTTPQuery_Interpolate(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryFn = func(stmt influxql.Statement, database string) (models.Rows, error) {
+		return models.Rows{
+			{Name: "sys.cpu", Tags: map[string]string{"host": "web01"}, Columns: []string{"time", "value"}, Values: [][]interface{}{
+				{time.Unix(1356998400, 0).UTC(), 1.0},
+				{time.Unix(1356998420, 0).UTC(), 3.0},
+			}},
+			{Name: "sys.cpu", Tags: map[string]string{"host": "web02"}, Columns: []string{"time", "value"}, Values: [][]interface{}{
+				{time.Unix(1356998410, 0).UTC(), 10.0},
+				{time.Unix(1356998410, 500*int64(time.Millisecond)).UTC(), 20.0},
+			}},
+		}, nil
+	}
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/api/query?start=1356998400&m=sum:sys.cpu&m=zimsum:sys.cpu&m=mimmax:sys.cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d: %s", resp.StatusCode, body)
+	} else if exp := `[` +
+		`{"metric":"sys.cpu","tags":{},"aggregateTags":["host"],"dps":{"1356998400":1,"1356998410":17,"1356998420":3}},` +
+		`{"metric":"sys.cpu","tags":{},"aggregateTags":["host"],"dps":{"1356998400":1,"1356998410":15,"1356998420":3}},` +
+		`{"metric":"sys.cpu","tags":{},"aggregateTags":["host"],"dps":{"1356998400":1,"1356998410":15,"1356998420":3}}` +
+		`]`; strings.TrimSpace(string(body)) != exp {
+		t.Fatalf("unexpected body:\n\nexp=%s\n\ngot=%s", exp, body)
+	}
+}
+
+// Ensure invalid queries return an OpenTSDB error.
+func TestService_HTTPQuery_Invalid(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryFn = func(stmt influxql.Statement, database string) (models.Rows, error) {
+		t.Fatalf("unexpected statement: %s", stmt)
+		return nil, nil
+	}
+
+	for _, body := range []string{
+		`{"queries":[{"aggregator":"sum","metric":"sys.cpu"}]}`,
+		`{"start":"yesterday","queries":[{"aggregator":"sum","metric":"sys.cpu"}]}`,
+		`{"start":"1h-ago","queries":[]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"sum"}]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"median","metric":"sys.cpu"}]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"sum","metric":"sys.cpu","downsample":"1m"}]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"sum","metric":"sys.cpu","downsample":"0all-sum"}]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"sum","metric":"sys.cpu","downsample":"1m-avg-nan"}]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"sum","metric":"sys.cpu","tags":{"host":"regexp(web[)"}}]}`,
+		`{"start":"1h-ago","queries":[{"aggregator":"sum","metric":"sys.cpu","filters":[{"type":"not_a_filter","tagk":"host","filter":"web01"}]}]}`,
+	} {
+		resp, err := http.Post("http://"+s.Addr().String()+"/api/query", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var e struct {
+			Error struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest || e.Error.Code != http.StatusBadRequest || e.Error.Message == "" {
+			t.Errorf("%s: unexpected error: %d %v", body, resp.StatusCode, e)
+		}
+	}
+}
+
+// Ensure tag values starting with a prefix can be suggested.
+// Ensure the query endpoints aren't served unless they're enabled.
+func TestService_HTTPQuery_Disabled(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	s.QueryEnabled = false
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryFn = func(stmt influxql.Statement, database string) (models.Rows, error) {
+		t.Fatalf("unexpected statement: %s", stmt)
+		return nil, nil
+	}
+
+	for _, path := range []string{"/api/query?start=1h-ago&m=sum:sys.cpu", "/api/suggest?type=metrics"} {
+		resp, err := http.Get("http://" + s.Addr().String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s: unexpected status code: %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestService_HTTPSuggest(t *testing.T) {
+	t.Parallel()
+
+	s := NewService("db0")
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.QueryExecutor.ExecuteQueryFn = func(stmt influxql.Statement, database string) (models.Rows, error) {
+		switch stmt := stmt.(type) {
+		case *influxql.ShowTagKeysStatement:
+			return models.Rows{
+				{Name: "sys.cpu", Columns: []string{"tagKey"}, Values: [][]interface{}{{"dc"}, {"host"}}},
+				{Name: "sys.mem", Columns: []string{"tagKey"}, Values: [][]interface{}{{"host"}}},
+			}, nil
+		case *influxql.ShowTagValuesStatement:
+			if !reflect.DeepEqual(stmt.TagKeys, []string{"dc", "host"}) {
+				t.Fatalf("unexpected tag keys: %v", stmt.TagKeys)
+			}
+			return models.Rows{
+				{Name: "dcTagValues", Columns: []string{"dc"}, Values: [][]interface{}{{"lga"}}},
+				{Name: "hostTagValues", Columns: []string{"host"}, Values: [][]interface{}{{"web03"}, {"web01"}, {"db01"}, {"web02"}}},
+			}, nil
+		default:
+			t.Fatalf("unexpected statement: %s", stmt)
+			return nil, nil
+		}
+	}
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/api/suggest?type=tagv&q=web&max=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var values []string
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	} else if err := json.NewDecoder(resp.Body).Decode(&values); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(values, []string{"web01", "web02"}) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
 type Service struct {
 	*opentsdb.Service
-	PointsWriter PointsWriter
+	PointsWriter  PointsWriter
+	QueryExecutor QueryExecutor
 }
 
 // NewService returns a new instance of Service.
@@ -143,9 +421,11 @@ func NewService(database string) *Service {
 		BindAddress:      "127.0.0.1:0",
 		Database:         database,
 		ConsistencyLevel: "one",
+		QueryEnabled:     true,
 	})
 	s := &Service{Service: srv}
 	s.Service.PointsWriter = &s.PointsWriter
+	s.Service.QueryExecutor = &s.QueryExecutor
 	s.Service.MetaClient = &DatabaseCreator{}
 
 	if !testing.Verbose() {
@@ -164,6 +444,21 @@ func (w *PointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
 	return w.WritePointsFn(p)
 }
 
+// QueryExecutor represents a mock impl of QueryExecutor.
+type QueryExecutor struct {
+	ExecuteQueryFn func(stmt influxql.Statement, database string) (models.Rows, error)
+}
+
+func (e *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	ch := make(chan *influxql.Result, len(query.Statements))
+	for _, stmt := range query.Statements {
+		rows, err := e.ExecuteQueryFn(stmt, database)
+		ch <- &influxql.Result{Series: rows, Err: err}
+	}
+	close(ch)
+	return ch, nil
+}
+
 type DatabaseCreator struct {
 }
 
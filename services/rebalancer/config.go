@@ -0,0 +1,44 @@
+package rebalancer
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+)
+
+const (
+	// DefaultCheckInterval is the time between rebalancing rounds if none is specified.
+	DefaultCheckInterval = 10 * time.Minute
+
+	// DefaultNodeTimeout is how long a data node must be unreachable before
+	// its shards are copied to other nodes.
+	DefaultNodeTimeout = 10 * time.Minute
+
+	// DefaultMaxConcurrentMoves is the default number of shards a node
+	// copies or removes at the same time.
+	DefaultMaxConcurrentMoves = 1
+
+	// DefaultMaxBandwidth is the default number of bytes per second a node
+	// copies shards at. Zero means unlimited.
+	DefaultMaxBandwidth = 0
+)
+
+// Config represents the configuration for the shard rebalancer.
+type Config struct {
+	Enabled            bool          `toml:"enabled"`
+	CheckInterval      toml.Duration `toml:"check-interval"`
+	NodeTimeout        toml.Duration `toml:"node-timeout"`
+	MaxConcurrentMoves int           `toml:"max-concurrent-moves"`
+	MaxBandwidth       int64         `toml:"max-bandwidth"`
+}
+
+// NewConfig returns a new Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Enabled:            false,
+		CheckInterval:      toml.Duration(DefaultCheckInterval),
+		NodeTimeout:        toml.Duration(DefaultNodeTimeout),
+		MaxConcurrentMoves: DefaultMaxConcurrentMoves,
+		MaxBandwidth:       DefaultMaxBandwidth,
+	}
+}
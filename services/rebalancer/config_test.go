@@ -0,0 +1,36 @@
+package rebalancer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/services/rebalancer"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c rebalancer.Config
+	if _, err := toml.Decode(`
+enabled = true
+check-interval = "2m"
+node-timeout = "5m"
+max-concurrent-moves = 3
+max-bandwidth = 1048576
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled state: %v", c.Enabled)
+	} else if time.Duration(c.CheckInterval) != 2*time.Minute {
+		t.Fatalf("unexpected check interval: %s", c.CheckInterval)
+	} else if time.Duration(c.NodeTimeout) != 5*time.Minute {
+		t.Fatalf("unexpected node timeout: %s", c.NodeTimeout)
+	} else if c.MaxConcurrentMoves != 3 {
+		t.Fatalf("unexpected max concurrent moves: %d", c.MaxConcurrentMoves)
+	} else if c.MaxBandwidth != 1048576 {
+		t.Fatalf("unexpected max bandwidth: %d", c.MaxBandwidth)
+	}
+}
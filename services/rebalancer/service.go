@@ -0,0 +1,712 @@
+package rebalancer // import "github.com/influxdata/influxdb/services/rebalancer"
+
+import (
+	"errors"
+	"expvar"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/services/copier"
+	"github.com/influxdata/influxdb/services/meta"
+)
+
+// Statistics for the rebalancer service.
+const (
+	statMovesPlanned = "movesPlanned"
+	statMovesActive  = "movesActive"
+	statCopyOK       = "copyOk"
+	statCopyFail     = "copyFail"
+	statRemoveOK     = "removeOk"
+	statRemoveFail   = "removeFail"
+	statBytesCopied  = "bytesCopied"
+)
+
+// States of a move.
+const (
+	MovePending = "pending"
+	MoveRunning = "running"
+	MoveDone    = "done"
+	MoveFailed  = "failed"
+)
+
+// Reasons a move is planned.
+const (
+	ReasonReplication = "replication"
+	ReasonBalance     = "balance"
+)
+
+// errClosing is returned by copies interrupted by closing the service.
+var errClosing = errors.New("rebalancer closing")
+
+// Move represents a shard copied to or removed from a data node.
+type Move struct {
+	ShardID         uint64
+	Database        string
+	RetentionPolicy string
+
+	// Source is the node the shard is copied from. If Destination is zero
+	// the shard is removed from Source instead.
+	Source      uint64
+	Destination uint64
+
+	Reason string
+
+	State     string
+	Bytes     int64
+	StartTime time.Time
+	Err       error
+}
+
+// IsCopy returns true if the move copies a shard to another node.
+func (m *Move) IsCopy() bool { return m.Destination != 0 }
+
+// Service periodically moves shards between data nodes so every shard has
+// as many owners as its retention policy's replication factor and disk usage
+// is even across the data nodes.
+//
+// Every node plans the same moves from the meta data and the disk usage of
+// all data nodes but only executes the copies to itself and the removals
+// from itself.
+type Service struct {
+	config Config
+
+	mu    sync.RWMutex
+	moves []*Move // moves of the current or last round
+
+	// unreachable holds the time each data node was first found to be
+	// unreachable. Only accessed by the rebalancing goroutine.
+	unreachable map[uint64]time.Time
+
+	throttle *throttle
+
+	MetaClient interface {
+		Databases() ([]meta.DatabaseInfo, error)
+		DataNodes() ([]meta.NodeInfo, error)
+	}
+
+	TSDBStore interface {
+		DiskSize() (int64, error)
+	}
+
+	// ShardMover copies shards to and removes shards from the local node.
+	ShardMover interface {
+		CopyShard(id, source uint64, wrap func(io.Reader) io.Reader) error
+		RemoveShard(id uint64) error
+	}
+
+	// RemoteDiskSize returns the disk usage of the data node at host.
+	RemoteDiskSize func(host string) (int64, error)
+
+	Node *influxdb.Node
+
+	Logger  *log.Logger
+	statMap *expvar.Map
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) *Service {
+	return &Service{
+		config:      c,
+		unreachable: make(map[uint64]time.Time),
+		RemoteDiskSize: func(host string) (int64, error) {
+			return copier.NewClient(host).DiskSize()
+		},
+		Logger:  log.New(os.Stderr, "[rebalancer] ", log.LstdFlags),
+		statMap: influxdb.NewStatistics("rebalancer", "rebalancer", nil),
+	}
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.Logger = l
+}
+
+// Open starts the rebalancer.
+func (s *Service) Open() error {
+	if s.done != nil {
+		return nil
+	}
+
+	s.Logger.Printf("Starting rebalancer service with check interval of %s, node timeout of %s",
+		s.config.CheckInterval, s.config.NodeTimeout)
+
+	s.done = make(chan struct{})
+	if s.config.MaxBandwidth > 0 {
+		s.throttle = newThrottle(s.config.MaxBandwidth)
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Close stops the rebalancer and interrupts running copies.
+func (s *Service) Close() error {
+	if s.done == nil {
+		return nil
+	}
+
+	close(s.done)
+	s.wg.Wait()
+	s.done = nil
+
+	return nil
+}
+
+// Moves returns the moves of the current or last round executed by this node.
+func (s *Service) Moves() []Move {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := make([]Move, len(s.moves))
+	for i, m := range s.moves {
+		a[i] = *m
+	}
+	return a
+}
+
+// run periodically rebalances the shards.
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.CheckInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.rebalance(time.Now().UTC()); err != nil {
+				s.Logger.Printf("failed to rebalance shards: %s", err)
+			}
+		case <-s.done:
+			s.Logger.Println("Rebalancer service terminating")
+			return
+		}
+	}
+}
+
+// rebalance plans the moves for the current state of the cluster and
+// executes the moves of the local node.
+func (s *Service) rebalance(now time.Time) error {
+	nodes, err := s.nodeStates(now)
+	if err != nil {
+		return err
+	}
+
+	// Shards of a node being decommissioned are moved by the decommission
+	// service so rebalancing waits until the node has left.
+	for _, n := range nodes {
+		if n.Leaving {
+			s.Logger.Printf("skipping rebalance while data node %d is leaving", n.ID)
+			return nil
+		}
+	}
+
+	dbs, err := s.MetaClient.Databases()
+	if err != nil {
+		return err
+	}
+
+	var moves []*Move
+	for _, m := range plan(dbs, nodes, now) {
+		if (m.IsCopy() && m.Destination == s.Node.ID) || (!m.IsCopy() && m.Source == s.Node.ID) {
+			m.State = MovePending
+			moves = append(moves, m)
+		}
+	}
+
+	s.mu.Lock()
+	s.moves = moves
+	s.mu.Unlock()
+
+	if len(moves) == 0 {
+		return nil
+	}
+	s.statMap.Add(statMovesPlanned, int64(len(moves)))
+	s.Logger.Printf("executing %d shard moves", len(moves))
+
+	// Execute the moves with a bounded number of moves running at a time.
+	n := s.config.MaxConcurrentMoves
+	if n < 1 {
+		n = 1
+	}
+	sem := make(chan struct{}, n)
+
+	var wg sync.WaitGroup
+loop:
+	for _, m := range moves {
+		select {
+		case sem <- struct{}{}:
+		case <-s.done:
+			break loop
+		}
+
+		wg.Add(1)
+		go func(m *Move) {
+			defer func() { <-sem; wg.Done() }()
+			s.execute(m)
+		}(m)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// execute copies or removes a shard.
+func (s *Service) execute(m *Move) {
+	s.mu.Lock()
+	m.State, m.StartTime = MoveRunning, time.Now().UTC()
+	s.mu.Unlock()
+
+	s.statMap.Add(statMovesActive, 1)
+	defer s.statMap.Add(statMovesActive, -1)
+
+	var err error
+	if m.IsCopy() {
+		s.Logger.Printf("copying shard %d from node %d for %s", m.ShardID, m.Source, m.Reason)
+		err = s.ShardMover.CopyShard(m.ShardID, m.Source, func(r io.Reader) io.Reader {
+			return &moveReader{r: r, m: m, s: s}
+		})
+		if err != nil {
+			s.statMap.Add(statCopyFail, 1)
+		} else {
+			s.statMap.Add(statCopyOK, 1)
+		}
+	} else {
+		s.Logger.Printf("removing shard %d for %s", m.ShardID, m.Reason)
+		err = s.ShardMover.RemoveShard(m.ShardID)
+		if err != nil {
+			s.statMap.Add(statRemoveFail, 1)
+		} else {
+			s.statMap.Add(statRemoveOK, 1)
+		}
+	}
+
+	s.mu.Lock()
+	if err != nil {
+		m.State, m.Err = MoveFailed, err
+	} else {
+		m.State = MoveDone
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		s.Logger.Printf("failed to move shard %d: %s", m.ShardID, err)
+	}
+}
+
+// nodeStates returns the disk usage and reachability of every data node.
+func (s *Service) nodeStates(now time.Time) ([]nodeState, error) {
+	nis, err := s.MetaClient.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	a := make([]nodeState, 0, len(nis))
+	for _, ni := range nis {
+		var size int64
+		var err error
+		if ni.ID == s.Node.ID {
+			size, err = s.TSDBStore.DiskSize()
+		} else {
+			size, err = s.RemoteDiskSize(ni.TCPHost)
+		}
+
+		ns := nodeState{ID: ni.ID, Zone: ni.Zone, DiskSize: size, Reachable: err == nil, Leaving: ni.Leaving}
+		if err != nil {
+			since, ok := s.unreachable[ni.ID]
+			if !ok {
+				s.Logger.Printf("data node %d unreachable: %s", ni.ID, err)
+				since = now
+				s.unreachable[ni.ID] = since
+			}
+			ns.Dead = now.Sub(since) >= time.Duration(s.config.NodeTimeout)
+		} else {
+			delete(s.unreachable, ni.ID)
+		}
+		a = append(a, ns)
+	}
+	return a, nil
+}
+
+// moveReader counts the bytes read by a shard copy and limits the rate of
+// all copies to the configured bandwidth.
+type moveReader struct {
+	r io.Reader
+	m *Move
+	s *Service
+}
+
+func (r *moveReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.s.done:
+		return 0, errClosing
+	default:
+	}
+
+	n, err := r.r.Read(p)
+
+	r.s.mu.Lock()
+	r.m.Bytes += int64(n)
+	r.s.mu.Unlock()
+	r.s.statMap.Add(statBytesCopied, int64(n))
+
+	if r.s.throttle != nil {
+		if err := r.s.throttle.wait(n, r.s.done); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+// throttle limits the rate bytes are read at across readers.
+type throttle struct {
+	mu   sync.Mutex
+	rate int64     // bytes per second
+	next time.Time // time the next read may continue
+}
+
+// newThrottle returns a throttle that allows rate bytes per second.
+func newThrottle(rate int64) *throttle {
+	return &throttle{rate: rate}
+}
+
+// wait blocks until n more bytes are allowed or done is closed.
+func (t *throttle) wait(n int, done chan struct{}) error {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	at := t.next
+	t.next = t.next.Add(time.Duration(int64(n) * int64(time.Second) / t.rate))
+	t.mu.Unlock()
+
+	if !at.After(now) {
+		return nil
+	}
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-done:
+		return errClosing
+	}
+}
+
+// nodeState is the state of a data node at the start of a rebalancing round.
+type nodeState struct {
+	ID       uint64
+	Zone     string
+	DiskSize int64
+
+	// Reachable is true if the node returned its disk usage. Dead is true if
+	// the node has been unreachable for longer than the node timeout and its
+	// shards are considered lost.
+	Reachable bool
+	Dead      bool
+
+	// Leaving is true if the node is being decommissioned.
+	Leaving bool
+}
+
+// shardState is a shard and the shard group and retention policy it's in.
+type shardState struct {
+	database  string
+	policy    string
+	replicaN  int
+	startTime time.Time
+	cold      bool // shard group has ended and no longer receives new data
+	info      meta.ShardInfo
+}
+
+// planner simulates the disk usage of the data nodes while moves are planned.
+type planner struct {
+	ids   []uint64
+	nodes map[uint64]nodeState
+	size  map[uint64]int64
+	count map[uint64]int
+}
+
+// plan returns the moves that restore the replication factor of every shard
+// and then even out the disk usage of the data nodes. Shards are only copied
+// from and to reachable nodes. Copies go to nodes in zones without an owner
+// first, and surplus copies are removed from zones with another owner first.
+// Shards are only moved to even out disk usage once their shard group has
+// ended and every data node is reachable, and never if the move leaves the
+// owners in fewer zones. A copy made for balance leaves the shard
+// over-replicated and the surplus owner is removed in a later round.
+func plan(dbs []meta.DatabaseInfo, nodes []nodeState, now time.Time) []*Move {
+	p := &planner{
+		nodes: make(map[uint64]nodeState),
+		size:  make(map[uint64]int64),
+		count: make(map[uint64]int),
+	}
+
+	var live int
+	allReachable := true
+	for _, n := range nodes {
+		p.ids = append(p.ids, n.ID)
+		p.nodes[n.ID] = n
+		p.size[n.ID] = n.DiskSize
+		if !n.Dead {
+			live++
+		}
+		if !n.Reachable {
+			allReachable = false
+		}
+	}
+	sort.Sort(uint64Slice(p.ids))
+
+	// Collect all shards and the number of shards on each node.
+	var shards []*shardState
+	for _, di := range dbs {
+		for _, rpi := range di.RetentionPolicies {
+			for _, sgi := range rpi.ShardGroups {
+				if sgi.Deleted() {
+					continue
+				}
+
+				for _, si := range sgi.Shards {
+					shards = append(shards, &shardState{
+						database:  di.Name,
+						policy:    rpi.Name,
+						replicaN:  rpi.ReplicaN,
+						startTime: sgi.StartTime,
+						cold:      !sgi.EndTime.After(now),
+						info:      si,
+					})
+					for _, o := range si.Owners {
+						p.count[o.NodeID]++
+					}
+				}
+			}
+		}
+	}
+
+	var moves []*Move
+	var candidates []*shardState
+	for _, sh := range shards {
+		// Owners on dead or removed nodes don't count as replicas.
+		var owners, reachable []uint64
+		for _, o := range sh.info.Owners {
+			n, ok := p.nodes[o.NodeID]
+			if !ok || n.Dead {
+				continue
+			}
+			owners = append(owners, o.NodeID)
+			if n.Reachable {
+				reachable = append(reachable, o.NodeID)
+			}
+		}
+
+		replicaN := sh.replicaN
+		if replicaN > live {
+			replicaN = live
+		}
+		if replicaN < 1 {
+			replicaN = 1
+		}
+
+		switch {
+		case len(owners) < replicaN:
+			// Shards without a reachable owner can't be copied.
+			if len(reachable) == 0 {
+				continue
+			}
+
+			src := p.least(reachable, nil)
+			chosen := make(map[uint64]bool)
+			zones := p.zones(owners)
+			for i := len(owners); i < replicaN; i++ {
+				filter := func(id uint64) bool {
+					return p.nodes[id].Reachable && !sh.info.OwnedBy(id) && !chosen[id]
+				}
+				dst := p.least(p.ids, func(id uint64) bool { return filter(id) && !zones[p.nodes[id].Zone] })
+				if dst == 0 {
+					dst = p.least(p.ids, filter)
+				}
+				if dst == 0 {
+					break
+				}
+				chosen[dst] = true
+				zones[p.nodes[dst].Zone] = true
+
+				moves = append(moves, sh.move(src, dst, ReasonReplication))
+				p.add(dst, p.estimate(src))
+			}
+
+		case len(owners) > replicaN && len(reachable) == len(owners):
+			// Remove surplus copies from the nodes using the most disk.
+			removed := make(map[uint64]bool)
+			for i := replicaN; i < len(owners); i++ {
+				src := p.most(reachable, func(id uint64) bool {
+					if removed[id] {
+						return false
+					}
+					for _, other := range reachable {
+						if other != id && !removed[other] && p.nodes[other].Zone == p.nodes[id].Zone {
+							return true
+						}
+					}
+					return false
+				})
+				if src == 0 {
+					src = p.most(reachable, func(id uint64) bool { return !removed[id] })
+				}
+				removed[src] = true
+
+				moves = append(moves, sh.move(src, 0, ReasonReplication))
+				p.add(src, -p.estimate(src))
+			}
+
+		case sh.cold && len(reachable) == len(sh.info.Owners):
+			candidates = append(candidates, sh)
+		}
+	}
+
+	// Only balance when the usage of every node is known.
+	if !allReachable || len(p.ids) < 2 {
+		return moves
+	}
+
+	// Move the oldest shards from the node using the most disk to the node
+	// using the least until their difference is less than two shards.
+	sort.Sort(shardStates(candidates))
+	moved := make(map[uint64]bool)
+	for range candidates {
+		src, dst := p.most(p.ids, nil), p.least(p.ids, nil)
+		est := p.estimate(src)
+		if src == dst || est <= 0 || p.size[src]-p.size[dst] <= 2*est {
+			break
+		}
+
+		var sh *shardState
+		for _, c := range candidates {
+			if !moved[c.info.ID] && c.info.OwnedBy(src) && !c.info.OwnedBy(dst) && p.keepsZones(c.info, src, dst) {
+				sh = c
+				break
+			}
+		}
+		if sh == nil {
+			break
+		}
+		moved[sh.info.ID] = true
+
+		moves = append(moves, sh.move(src, dst, ReasonBalance))
+		p.add(src, -est)
+		p.add(dst, est)
+	}
+
+	return moves
+}
+
+// zones returns the zones of the nodes in ids.
+func (p *planner) zones(ids []uint64) map[string]bool {
+	m := make(map[string]bool)
+	for _, id := range ids {
+		m[p.nodes[id].Zone] = true
+	}
+	return m
+}
+
+// keepsZones returns true if moving si from src to dst doesn't leave its
+// owners in fewer zones.
+func (p *planner) keepsZones(si meta.ShardInfo, src, dst uint64) bool {
+	before, after := make(map[string]bool), make(map[string]bool)
+	after[p.nodes[dst].Zone] = true
+	for _, o := range si.Owners {
+		before[p.nodes[o.NodeID].Zone] = true
+		if o.NodeID != src {
+			after[p.nodes[o.NodeID].Zone] = true
+		}
+	}
+	return len(after) >= len(before)
+}
+
+// estimate returns the average size of a shard on a node.
+func (p *planner) estimate(id uint64) int64 {
+	if p.count[id] == 0 {
+		return 0
+	}
+	return p.size[id] / int64(p.count[id])
+}
+
+// add updates the simulated disk usage and shard count of a node.
+func (p *planner) add(id uint64, size int64) {
+	p.size[id] += size
+	if size < 0 {
+		p.count[id]--
+	} else {
+		p.count[id]++
+	}
+}
+
+// least returns the node in ids using the least disk that passes filter.
+// Ties go to the lowest id. Returns zero if no node passes.
+func (p *planner) least(ids []uint64, filter func(uint64) bool) uint64 {
+	var min uint64
+	for _, id := range ids {
+		if filter != nil && !filter(id) {
+			continue
+		}
+		if min == 0 || p.size[id] < p.size[min] || (p.size[id] == p.size[min] && id < min) {
+			min = id
+		}
+	}
+	return min
+}
+
+// most returns the node in ids using the most disk that passes filter.
+// Ties go to the lowest id. Returns zero if no node passes.
+func (p *planner) most(ids []uint64, filter func(uint64) bool) uint64 {
+	var max uint64
+	for _, id := range ids {
+		if filter != nil && !filter(id) {
+			continue
+		}
+		if max == 0 || p.size[id] > p.size[max] || (p.size[id] == p.size[max] && id < max) {
+			max = id
+		}
+	}
+	return max
+}
+
+// move returns a move of the shard from src to dst.
+func (sh *shardState) move(src, dst uint64, reason string) *Move {
+	return &Move{
+		ShardID:         sh.info.ID,
+		Database:        sh.database,
+		RetentionPolicy: sh.policy,
+		Source:          src,
+		Destination:     dst,
+		Reason:          reason,
+	}
+}
+
+// shardStates sorts shards from oldest to newest.
+type shardStates []*shardState
+
+func (a shardStates) Len() int      { return len(a) }
+func (a shardStates) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a shardStates) Less(i, j int) bool {
+	if !a[i].startTime.Equal(a[j].startTime) {
+		return a[i].startTime.Before(a[j].startTime)
+	}
+	return a[i].info.ID < a[j].info.ID
+}
+
+type uint64Slice []uint64
+
+func (a uint64Slice) Len() int           { return len(a) }
+func (a uint64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a uint64Slice) Less(i, j int) bool { return a[i] < a[j] }
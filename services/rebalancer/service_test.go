@@ -0,0 +1,367 @@
+package rebalancer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/toml"
+)
+
+var now = time.Date(2016, 1, 10, 0, 0, 0, 0, time.UTC)
+
+// Ensure shards that lost an owner on a dead node are copied to the live
+// node using the least disk, including the shards already planned for it.
+func TestPlan_Replication(t *testing.T) {
+	dbs := newDatabases(2,
+		shardGroup(now, []uint64{1, 2}, []uint64{2, 3}),
+	)
+	nodes := []nodeState{
+		{ID: 1, DiskSize: 100, Reachable: true},
+		{ID: 2, DiskSize: 300, Reachable: false, Dead: true},
+		{ID: 3, DiskSize: 200, Reachable: true},
+		{ID: 4, DiskSize: 50, Reachable: true},
+	}
+
+	exp := []Move{
+		{ShardID: 1, Database: "db0", RetentionPolicy: "rp0", Source: 1, Destination: 4, Reason: ReasonReplication},
+		{ShardID: 2, Database: "db0", RetentionPolicy: "rp0", Source: 3, Destination: 1, Reason: ReasonReplication},
+	}
+	if moves := plan(dbs, nodes, now); !reflect.DeepEqual(derefMoves(moves), exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, derefMoves(moves))
+	}
+}
+
+// Ensure shards are copied to nodes in zones without an owner first.
+func TestPlan_Replication_Zones(t *testing.T) {
+	dbs := newDatabases(2,
+		shardGroup(now, []uint64{1, 2}),
+	)
+	nodes := []nodeState{
+		{ID: 1, Zone: "a", DiskSize: 100, Reachable: true},
+		{ID: 2, Zone: "b", Reachable: false, Dead: true},
+		{ID: 3, Zone: "a", DiskSize: 0, Reachable: true},
+		{ID: 4, Zone: "b", DiskSize: 200, Reachable: true},
+	}
+
+	exp := []Move{
+		{ShardID: 1, Database: "db0", RetentionPolicy: "rp0", Source: 1, Destination: 4, Reason: ReasonReplication},
+	}
+	if moves := plan(dbs, nodes, now); !reflect.DeepEqual(derefMoves(moves), exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, derefMoves(moves))
+	}
+}
+
+// Ensure shards aren't copied while an unreachable node is within its timeout.
+func TestPlan_Replication_Unreachable(t *testing.T) {
+	dbs := newDatabases(2,
+		shardGroup(now, []uint64{1, 2}),
+	)
+	nodes := []nodeState{
+		{ID: 1, DiskSize: 100, Reachable: true},
+		{ID: 2, DiskSize: 300, Reachable: false},
+		{ID: 3, DiskSize: 0, Reachable: true},
+	}
+
+	if moves := plan(dbs, nodes, now); len(moves) != 0 {
+		t.Fatalf("unexpected moves: %+v", derefMoves(moves))
+	}
+}
+
+// Ensure surplus copies are removed from the nodes using the most disk.
+func TestPlan_OverReplicated(t *testing.T) {
+	dbs := newDatabases(1,
+		shardGroup(now, []uint64{1, 2}),
+	)
+	nodes := []nodeState{
+		{ID: 1, DiskSize: 100, Reachable: true},
+		{ID: 2, DiskSize: 300, Reachable: true},
+	}
+
+	exp := []Move{
+		{ShardID: 1, Database: "db0", RetentionPolicy: "rp0", Source: 2, Reason: ReasonReplication},
+	}
+	if moves := plan(dbs, nodes, now); !reflect.DeepEqual(derefMoves(moves), exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, derefMoves(moves))
+	}
+}
+
+// Ensure the oldest ended shards are moved from the node using the most disk
+// to the node using the least.
+func TestPlan_Balance(t *testing.T) {
+	dbs := newDatabases(1,
+		shardGroup(now.Add(-48*time.Hour), []uint64{1}, []uint64{1}),
+		shardGroup(now.Add(-72*time.Hour), []uint64{1}, []uint64{1}),
+		shardGroup(now, []uint64{1}),
+	)
+	nodes := []nodeState{
+		{ID: 1, DiskSize: 500, Reachable: true},
+		{ID: 2, DiskSize: 0, Reachable: true},
+	}
+
+	// Shards 3 and 4 are in the oldest group. Moving both leaves a difference
+	// of less than two shards so no more shards are moved.
+	exp := []Move{
+		{ShardID: 3, Database: "db0", RetentionPolicy: "rp0", Source: 1, Destination: 2, Reason: ReasonBalance},
+		{ShardID: 4, Database: "db0", RetentionPolicy: "rp0", Source: 1, Destination: 2, Reason: ReasonBalance},
+	}
+	if moves := plan(dbs, nodes, now); !reflect.DeepEqual(derefMoves(moves), exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, derefMoves(moves))
+	}
+}
+
+// Ensure surplus copies are removed from zones with another owner first.
+func TestPlan_OverReplicated_Zones(t *testing.T) {
+	dbs := newDatabases(2,
+		shardGroup(now, []uint64{1, 2, 3}),
+	)
+	nodes := []nodeState{
+		{ID: 1, Zone: "a", DiskSize: 100, Reachable: true},
+		{ID: 2, Zone: "a", DiskSize: 200, Reachable: true},
+		{ID: 3, Zone: "b", DiskSize: 300, Reachable: true},
+	}
+
+	exp := []Move{
+		{ShardID: 1, Database: "db0", RetentionPolicy: "rp0", Source: 2, Reason: ReasonReplication},
+	}
+	if moves := plan(dbs, nodes, now); !reflect.DeepEqual(derefMoves(moves), exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, derefMoves(moves))
+	}
+}
+
+// Ensure shards aren't moved for balance if their owners end up in fewer zones.
+func TestPlan_Balance_Zones(t *testing.T) {
+	dbs := newDatabases(2,
+		shardGroup(now.Add(-72*time.Hour), []uint64{1, 2}, []uint64{1, 2}),
+		shardGroup(now.Add(-48*time.Hour), []uint64{1, 3}, []uint64{1, 3}),
+	)
+	nodes := []nodeState{
+		{ID: 1, Zone: "a", DiskSize: 800, Reachable: true},
+		{ID: 2, Zone: "b", DiskSize: 300, Reachable: true},
+		{ID: 3, Zone: "a", DiskSize: 300, Reachable: true},
+		{ID: 4, Zone: "b", DiskSize: 0, Reachable: true},
+	}
+
+	// Moving shards 1 and 2 to node 4 would leave both owners in zone b so
+	// shard 3 is moved instead.
+	exp := []Move{
+		{ShardID: 3, Database: "db0", RetentionPolicy: "rp0", Source: 1, Destination: 4, Reason: ReasonBalance},
+	}
+	if moves := plan(dbs, nodes, now); !reflect.DeepEqual(derefMoves(moves), exp) {
+		t.Fatalf("unexpected moves:\n\nexp=%+v\n\ngot=%+v\n\n", exp, derefMoves(moves))
+	}
+}
+
+// Ensure shards aren't moved for balance while a node is unreachable.
+func TestPlan_Balance_Unreachable(t *testing.T) {
+	dbs := newDatabases(1,
+		shardGroup(now.Add(-48*time.Hour), []uint64{1}, []uint64{1}, []uint64{1}),
+	)
+	nodes := []nodeState{
+		{ID: 1, DiskSize: 900, Reachable: true},
+		{ID: 2, DiskSize: 0, Reachable: true},
+		{ID: 3, Reachable: false},
+	}
+
+	if moves := plan(dbs, nodes, now); len(moves) != 0 {
+		t.Fatalf("unexpected moves: %+v", derefMoves(moves))
+	}
+}
+
+// Ensure a node only executes the moves to and from itself and records them.
+func TestService_Rebalance(t *testing.T) {
+	s := NewTestService(3)
+	s.MetaClient = &metaClient{
+		dbs: newDatabases(2,
+			shardGroup(now, []uint64{1, 2}, []uint64{1, 3}),
+		),
+		nodes: []meta.NodeInfo{{ID: 1, TCPHost: "host1"}, {ID: 2, TCPHost: "host2"}, {ID: 3, TCPHost: "host3"}},
+	}
+	s.RemoteDiskSize = func(host string) (int64, error) {
+		if host == "host2" {
+			return 0, errors.New("connection refused")
+		}
+		return 100, nil
+	}
+
+	var mu sync.Mutex
+	var copied []uint64
+	s.ShardMover = &shardMover{
+		CopyShardFn: func(id, source uint64, wrap func(io.Reader) io.Reader) error {
+			mu.Lock()
+			copied = append(copied, id)
+			mu.Unlock()
+
+			if _, err := ioutil.ReadAll(wrap(bytes.NewReader(make([]byte, 1000)))); err != nil {
+				t.Fatal(err)
+			}
+			return nil
+		},
+		RemoveShardFn: func(id uint64) error {
+			t.Fatalf("unexpected remove: %d", id)
+			return nil
+		},
+	}
+	s.Open()
+	defer s.Close()
+
+	// Node 2 isn't dead until it's unreachable for longer than the timeout.
+	if err := s.rebalance(now); err != nil {
+		t.Fatal(err)
+	} else if len(s.Moves()) != 0 {
+		t.Fatalf("unexpected moves: %+v", s.Moves())
+	}
+
+	// Shard 1 is copied from node 1 to node 3.
+	if err := s.rebalance(now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(copied, []uint64{1}) {
+		t.Fatalf("unexpected copies: %v", copied)
+	}
+
+	moves := s.Moves()
+	if len(moves) != 1 {
+		t.Fatalf("unexpected moves: %+v", moves)
+	} else if m := moves[0]; m.ShardID != 1 || m.Source != 1 || m.Destination != 3 || m.State != MoveDone || m.Bytes != 1000 {
+		t.Fatalf("unexpected move: %+v", m)
+	}
+}
+
+// Ensure a failed move is recorded with its error.
+func TestService_Rebalance_Error(t *testing.T) {
+	s := NewTestService(1)
+	s.MetaClient = &metaClient{
+		dbs:   newDatabases(1, shardGroup(now, []uint64{1, 2})),
+		nodes: []meta.NodeInfo{{ID: 1, TCPHost: "host1"}, {ID: 2, TCPHost: "host2"}},
+	}
+	s.TSDBStore = &tsdbStore{size: 500}
+	s.RemoteDiskSize = func(host string) (int64, error) { return 100, nil }
+	s.ShardMover = &shardMover{
+		RemoveShardFn: func(id uint64) error { return errors.New("marker") },
+	}
+	s.Open()
+	defer s.Close()
+
+	if err := s.rebalance(now); err != nil {
+		t.Fatal(err)
+	} else if moves := s.Moves(); len(moves) != 1 {
+		t.Fatalf("unexpected moves: %+v", moves)
+	} else if m := moves[0]; m.State != MoveFailed || m.Err == nil || m.Err.Error() != "marker" {
+		t.Fatalf("unexpected move: %+v", m)
+	}
+}
+
+// Ensure the throttle limits the rate of reads.
+func TestThrottle(t *testing.T) {
+	th := newThrottle(1000)
+	done := make(chan struct{})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := th.wait(100, done); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Fatalf("throttle too fast: %s", d)
+	}
+
+	// Ensure waiting is interrupted when done is closed.
+	close(done)
+	if err := th.wait(1000000, done); err != errClosing {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// NewTestService returns a service for node id with a discarded log.
+func NewTestService(id uint64) *Service {
+	c := NewConfig()
+	c.CheckInterval = toml.Duration(time.Hour)
+	c.NodeTimeout = toml.Duration(time.Minute)
+	c.MaxConcurrentMoves = 2
+
+	s := NewService(c)
+	s.Node = &influxdb.Node{ID: id}
+	s.TSDBStore = &tsdbStore{size: 100}
+	if !testing.Verbose() {
+		s.SetLogger(log.New(ioutil.Discard, "", 0))
+	}
+	return s
+}
+
+// newDatabases returns database db0 with a retention policy rp0 holding the
+// shard groups. Shards are numbered in order from 1.
+func newDatabases(replicaN int, groups ...meta.ShardGroupInfo) []meta.DatabaseInfo {
+	var id uint64
+	for i := range groups {
+		groups[i].ID = uint64(i + 1)
+		for j := range groups[i].Shards {
+			id++
+			groups[i].Shards[j].ID = id
+		}
+	}
+
+	return []meta.DatabaseInfo{{
+		Name: "db0",
+		RetentionPolicies: []meta.RetentionPolicyInfo{{
+			Name:        "rp0",
+			ReplicaN:    replicaN,
+			ShardGroups: groups,
+		}},
+	}}
+}
+
+// shardGroup returns a day long shard group starting at start with a shard
+// for each list of owners.
+func shardGroup(start time.Time, owners ...[]uint64) meta.ShardGroupInfo {
+	sgi := meta.ShardGroupInfo{StartTime: start, EndTime: start.Add(24 * time.Hour)}
+	for _, a := range owners {
+		var si meta.ShardInfo
+		for _, id := range a {
+			si.Owners = append(si.Owners, meta.ShardOwner{NodeID: id})
+		}
+		sgi.Shards = append(sgi.Shards, si)
+	}
+	return sgi
+}
+
+func derefMoves(a []*Move) []Move {
+	other := make([]Move, len(a))
+	for i := range a {
+		other[i] = *a[i]
+	}
+	return other
+}
+
+type metaClient struct {
+	dbs   []meta.DatabaseInfo
+	nodes []meta.NodeInfo
+}
+
+func (c *metaClient) Databases() ([]meta.DatabaseInfo, error) { return c.dbs, nil }
+func (c *metaClient) DataNodes() ([]meta.NodeInfo, error)     { return c.nodes, nil }
+
+type tsdbStore struct {
+	size int64
+}
+
+func (s *tsdbStore) DiskSize() (int64, error) { return s.size, nil }
+
+type shardMover struct {
+	CopyShardFn   func(id, source uint64, wrap func(io.Reader) io.Reader) error
+	RemoveShardFn func(id uint64) error
+}
+
+func (m *shardMover) CopyShard(id, source uint64, wrap func(io.Reader) io.Reader) error {
+	return m.CopyShardFn(id, source, wrap)
+}
+
+func (m *shardMover) RemoveShard(id uint64) error { return m.RemoveShardFn(id) }
@@ -0,0 +1,61 @@
+package rebalancer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+)
+
+// StatementExecutor translates SHOW REBALANCE into the rebalancer's moves.
+type StatementExecutor struct {
+	Service interface {
+		Moves() []Move
+	}
+}
+
+// ExecuteStatement executes rebalancer-related query statements.
+func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.Result {
+	switch stmt := stmt.(type) {
+	case *influxql.ShowRebalanceStatement:
+		return e.executeShowRebalanceStatement(stmt)
+	default:
+		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
+	}
+}
+
+func (e *StatementExecutor) executeShowRebalanceStatement(stmt *influxql.ShowRebalanceStatement) *influxql.Result {
+	row := &models.Row{Name: "rebalance", Columns: []string{"shard_id", "database", "retention_policy", "action", "source", "destination", "reason", "state", "bytes", "start_time", "error"}}
+	for _, m := range e.Service.Moves() {
+		action, destination := "copy", interface{}(m.Destination)
+		if !m.IsCopy() {
+			action, destination = "remove", nil
+		}
+
+		var startTime string
+		if !m.StartTime.IsZero() {
+			startTime = m.StartTime.UTC().Format(time.RFC3339)
+		}
+
+		var errString string
+		if m.Err != nil {
+			errString = m.Err.Error()
+		}
+
+		row.Values = append(row.Values, []interface{}{
+			m.ShardID,
+			m.Database,
+			m.RetentionPolicy,
+			action,
+			m.Source,
+			destination,
+			m.Reason,
+			m.State,
+			m.Bytes,
+			startTime,
+			errString,
+		})
+	}
+	return &influxql.Result{Series: []*models.Row{row}}
+}
@@ -1,11 +1,14 @@
 package snapshotter // import "github.com/influxdata/influxdb/services/snapshotter"
 
 import (
+	"bufio"
 	"bytes"
 	"encoding"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
@@ -27,6 +30,10 @@ const (
 	BackupMagicHeader = 0x59590101
 )
 
+// ErrReplicatedRestore is returned when a shard is restored into a retention
+// policy that replicates its shards to other nodes.
+var ErrReplicatedRestore = errors.New("cannot restore a shard with a replication factor greater than 1")
+
 // Service manages the listener for the snapshot endpoint.
 type Service struct {
 	wg  sync.WaitGroup
@@ -37,6 +44,9 @@ type Service struct {
 	MetaClient interface {
 		encoding.BinaryMarshaler
 		Database(name string) (*meta.DatabaseInfo, error)
+		CreateDatabase(name string) (*meta.DatabaseInfo, error)
+		CreateRetentionPolicy(database string, rpi *meta.RetentionPolicyInfo) (*meta.RetentionPolicyInfo, error)
+		CreateShardGroup(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error)
 	}
 
 	TSDBStore *tsdb.Store
@@ -108,8 +118,9 @@ func (s *Service) serve() {
 
 // handleConn processes conn. This is run in a separate goroutine.
 func (s *Service) handleConn(conn net.Conn) error {
-	r, err := s.readRequest(conn)
-	if err != nil {
+	dec := json.NewDecoder(conn)
+	var r Request
+	if err := dec.Decode(&r); err != nil {
 		return fmt.Errorf("read request: %s", err)
 	}
 
@@ -126,6 +137,14 @@ func (s *Service) handleConn(conn net.Conn) error {
 		return s.writeDatabaseInfo(conn, r.Database)
 	case RequestRetentionPolicyInfo:
 		return s.writeRetentionPolicyInfo(conn, r.Database, r.RetentionPolicy)
+	case RequestShardRestore:
+		// The archive follows the request so include what the decoder
+		// has already buffered, skipping the newline after the request.
+		br := bufio.NewReader(io.MultiReader(dec.Buffered(), conn))
+		if b, err := br.Peek(1); err == nil && b[0] == '\n' {
+			br.ReadByte()
+		}
+		return s.restoreShard(conn, &r, br)
 	default:
 		return fmt.Errorf("request type unknown: %v", r.Type)
 	}
@@ -253,13 +272,81 @@ func (s *Service) writeRetentionPolicyInfo(conn net.Conn, database, retentionPol
 	return nil
 }
 
-// readRequest Unmarshals a request object from the conn
-func (s *Service) readRequest(conn net.Conn) (Request, error) {
-	var r Request
-	if err := json.NewDecoder(conn).Decode(&r); err != nil {
-		return r, err
+// restoreShard imports a shard archive read from r into the local shard of
+// the requested database and retention policy and writes back its path.
+func (s *Service) restoreShard(conn net.Conn, req *Request, r io.Reader) error {
+	path, err := func() (string, error) {
+		id, err := s.restoreTarget(req)
+		if err != nil {
+			return "", err
+		}
+
+		if err := s.TSDBStore.RestoreShard(id, r); err != nil {
+			return "", err
+		}
+		return s.TSDBStore.ShardRelativePath(id)
+	}()
+
+	res := Response{}
+	if err != nil {
+		res.Err = err.Error()
+	} else {
+		res.Paths = []string{path}
+	}
+
+	if err := json.NewEncoder(conn).Encode(res); err != nil {
+		return fmt.Errorf("encode resonse: %s", err.Error())
+	}
+	return err
+}
+
+// restoreTarget returns the ID of the local shard that a restored shard is
+// imported into. The database, retention policy, shard group and shard are
+// created if they don't exist. Replicated shards are rejected since only the
+// local copy would be restored.
+func (s *Service) restoreTarget(req *Request) (uint64, error) {
+	if req.Database == "" {
+		return 0, meta.ErrDatabaseNameRequired
+	} else if req.RetentionPolicy == "" {
+		return 0, meta.ErrRetentionPolicyNameRequired
+	} else if req.RetentionPolicyInfo != nil && req.RetentionPolicyInfo.ReplicaN > 1 {
+		return 0, ErrReplicatedRestore
 	}
-	return r, nil
+
+	if _, err := s.MetaClient.CreateDatabase(req.Database); err != nil {
+		return 0, err
+	}
+
+	rpi := meta.RetentionPolicyInfo{Name: req.RetentionPolicy, ReplicaN: 1}
+	if req.RetentionPolicyInfo != nil {
+		rpi.ReplicaN = req.RetentionPolicyInfo.ReplicaN
+		rpi.Duration = req.RetentionPolicyInfo.Duration
+		rpi.ShardGroupDuration = req.RetentionPolicyInfo.ShardGroupDuration
+	}
+	if _, err := s.MetaClient.CreateRetentionPolicy(req.Database, &rpi); err != nil {
+		return 0, err
+	}
+
+	sgi, err := s.MetaClient.CreateShardGroup(req.Database, req.RetentionPolicy, req.Time)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sh := range sgi.Shards {
+		if !sh.OwnedBy(s.Node.ID) {
+			continue
+		} else if len(sh.Owners) > 1 {
+			return 0, ErrReplicatedRestore
+		}
+
+		if s.TSDBStore.Shard(sh.ID) == nil {
+			if err := s.TSDBStore.CreateShard(req.Database, req.RetentionPolicy, sh.ID); err != nil {
+				return 0, err
+			}
+		}
+		return sh.ID, nil
+	}
+	return 0, fmt.Errorf("shard group %d has no shard on node %d", sgi.ID, s.Node.ID)
 }
 
 type RequestType uint8
@@ -269,6 +356,7 @@ const (
 	RequestMetastoreBackup
 	RequestDatabaseInfo
 	RequestRetentionPolicyInfo
+	RequestShardRestore
 )
 
 // Request represents a request for a specific backup or for information
@@ -279,12 +367,24 @@ type Request struct {
 	RetentionPolicy string
 	ShardID         uint64
 	Since           time.Time
+
+	// Time is a time within the shard group a restored shard belongs to.
+	// A shard restore request is followed by the shard's backup archive.
+	Time time.Time
+
+	// RetentionPolicyInfo holds the settings used to create the retention
+	// policy of a restored shard if it doesn't exist.
+	RetentionPolicyInfo *meta.RetentionPolicyInfo `json:",omitempty"`
 }
 
 // Response contains the relative paths for all the shards on this server
-// that are in the requested database or retention policy
+// that are in the requested database or retention policy, or the path of
+// a restored shard.
 type Response struct {
 	Paths []string
+
+	// Err is set if a shard restore failed.
+	Err string `json:",omitempty"`
 }
 
 // u64tob converts a uint64 into an 8-byte slice.
@@ -0,0 +1,237 @@
+package statsd
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/services/graphite"
+)
+
+// series identifies the point a metric is aggregated into.
+type series struct {
+	name  string
+	tags  models.Tags
+	field string
+}
+
+// key returns a unique key for the series.
+func (s *series) key() string {
+	return s.name + string(s.tags.HashKey()) + " " + s.field
+}
+
+type counter struct {
+	series
+	value float64
+}
+
+type gauge struct {
+	series
+	value   float64
+	updated bool
+	flushed time.Time
+}
+
+type timer struct {
+	series
+	values []float64
+	count  float64
+}
+
+type set struct {
+	series
+	values map[string]struct{}
+}
+
+// Aggregator accumulates StatsD metrics and turns them into points at each
+// flush. Counters, timers and sets are reset at each flush. Gauges keep
+// their value so relative updates apply to it, but are only written when
+// they were updated since the last flush.
+//
+// Aggregator is not safe for concurrent use.
+type Aggregator struct {
+	parser      *graphite.Parser
+	percentiles []float64
+
+	// GaugeExpiry is how long a gauge is kept without updates. A later
+	// relative update of an expired gauge starts from zero. Gauges are
+	// kept forever if zero.
+	GaugeExpiry time.Duration
+
+	counters map[string]*counter
+	gauges   map[string]*gauge
+	timers   map[string]*timer
+	sets     map[string]*set
+}
+
+// NewAggregator returns a new Aggregator that maps metric names to
+// measurements and tags with parser and calculates the percentiles of timers.
+func NewAggregator(parser *graphite.Parser, percentiles []float64) *Aggregator {
+	return &Aggregator{
+		parser:      parser,
+		percentiles: percentiles,
+		counters:    make(map[string]*counter),
+		gauges:      make(map[string]*gauge),
+		timers:      make(map[string]*timer),
+		sets:        make(map[string]*set),
+	}
+}
+
+// Add adds a metric to the current flush interval.
+func (a *Aggregator) Add(m *Metric) error {
+	name, tags, field, err := a.parser.ApplyTemplate(m.Name)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = m.Name
+	}
+
+	// Tags sent with the metric override tags from the template.
+	for k, v := range m.Tags {
+		tags[k] = v
+	}
+
+	s := series{name: name, tags: models.Tags(tags), field: field}
+	key := s.key()
+
+	switch m.Type {
+	case Counter:
+		c := a.counters[key]
+		if c == nil {
+			c = &counter{series: s}
+			a.counters[key] = c
+		}
+		c.value += m.Value / m.SampleRate
+	case Gauge:
+		g := a.gauges[key]
+		if g == nil {
+			g = &gauge{series: s}
+			a.gauges[key] = g
+		}
+		if m.Relative {
+			g.value += m.Value
+		} else {
+			g.value = m.Value
+		}
+		g.updated = true
+	case Timer:
+		t := a.timers[key]
+		if t == nil {
+			t = &timer{series: s}
+			a.timers[key] = t
+		}
+		t.values = append(t.values, m.Value)
+		t.count += 1 / m.SampleRate
+	case Set:
+		st := a.sets[key]
+		if st == nil {
+			st = &set{series: s, values: make(map[string]struct{})}
+			a.sets[key] = st
+		}
+		st.values[m.SetValue] = struct{}{}
+	}
+	return nil
+}
+
+// Flush returns the points aggregated over interval, timestamped now, and
+// starts a new interval.
+func (a *Aggregator) Flush(now time.Time, interval time.Duration) []models.Point {
+	var points []models.Point
+	add := func(s *series, fields map[string]interface{}) {
+		pt, err := models.NewPoint(s.name, s.tags, fields, now)
+		if err != nil {
+			return
+		}
+		points = append(points, pt)
+	}
+
+	for _, c := range a.counters {
+		fields := map[string]interface{}{fieldName(c.field, "value"): c.value}
+		if interval > 0 {
+			fields[fieldName(c.field, "rate")] = c.value / interval.Seconds()
+		}
+		add(&c.series, fields)
+	}
+
+	for key, g := range a.gauges {
+		if !g.updated {
+			if a.GaugeExpiry > 0 && now.Sub(g.flushed) >= a.GaugeExpiry {
+				delete(a.gauges, key)
+			}
+			continue
+		}
+		add(&g.series, map[string]interface{}{fieldName(g.field, "value"): g.value})
+		g.updated = false
+		g.flushed = now
+	}
+
+	for _, t := range a.timers {
+		add(&t.series, a.timerFields(t))
+	}
+
+	for _, st := range a.sets {
+		add(&st.series, map[string]interface{}{fieldName(st.field, "value"): int64(len(st.values))})
+	}
+
+	a.counters = make(map[string]*counter)
+	a.timers = make(map[string]*timer)
+	a.sets = make(map[string]*set)
+
+	return points
+}
+
+// timerFields returns the statistics of a timer. For each percentile the
+// upper bound and the mean of the values within the percentile are included.
+func (a *Aggregator) timerFields(t *timer) map[string]interface{} {
+	values := t.values
+	sort.Float64s(values)
+
+	var sum float64
+	cumulative := make([]float64, len(values))
+	for i, v := range values {
+		sum += v
+		cumulative[i] = sum
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+
+	fields := map[string]interface{}{
+		fieldName(t.field, "count"):  t.count,
+		fieldName(t.field, "lower"):  values[0],
+		fieldName(t.field, "upper"):  values[len(values)-1],
+		fieldName(t.field, "sum"):    sum,
+		fieldName(t.field, "mean"):   mean,
+		fieldName(t.field, "stddev"): math.Sqrt(variance / float64(len(values))),
+	}
+
+	for _, p := range a.percentiles {
+		n := int(math.Floor(p/100*float64(len(values)) + 0.5))
+		if n == 0 {
+			continue
+		}
+
+		suffix := strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", -1)
+		fields[fieldName(t.field, "upper_"+suffix)] = values[n-1]
+		fields[fieldName(t.field, "mean_"+suffix)] = cumulative[n-1] / float64(n)
+	}
+	return fields
+}
+
+// fieldName returns the name of a statistic. The field set by a template
+// replaces "value" and prefixes other statistics.
+func fieldName(field, stat string) string {
+	if field == "" {
+		return stat
+	} else if stat == "value" {
+		return field
+	}
+	return field + "_" + stat
+}
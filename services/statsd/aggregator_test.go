@@ -0,0 +1,154 @@
+package statsd_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/services/graphite"
+	"github.com/influxdata/influxdb/services/statsd"
+)
+
+// Ensure counters, gauges, timers and sets are aggregated per flush.
+func TestAggregator_Flush(t *testing.T) {
+	a := NewAggregator(nil, nil)
+	MustAdd(a,
+		"requests:1|c",
+		"requests:2|c|@0.5",
+		"temperature:20|g",
+		"temperature:+2|g",
+		"users:alice|s",
+		"users:bob|s",
+		"users:alice|s",
+	)
+	for i := 1; i <= 10; i++ {
+		MustAdd(a, "latency:"+strconv.Itoa(i)+"|ms")
+	}
+
+	now := time.Unix(100, 0)
+	points := a.Flush(now, 10*time.Second)
+	exp := map[string]map[string]interface{}{
+		"requests":    {"value": 5.0, "rate": 0.5},
+		"temperature": {"value": 22.0},
+		"users":       {"value": int64(2)},
+		"latency": {
+			"count":    10.0,
+			"lower":    1.0,
+			"upper":    10.0,
+			"sum":      55.0,
+			"mean":     5.5,
+			"stddev":   2.8722813232690143,
+			"upper_90": 9.0,
+			"mean_90":  5.0,
+		},
+	}
+	if got := PointFields(points); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points:\n\nexp=%v\n\ngot=%v", exp, got)
+	}
+	for _, pt := range points {
+		if !pt.Time().Equal(now) {
+			t.Fatalf("unexpected time: %s", pt.Time())
+		}
+	}
+
+	// Only gauges keep their value and are written again once updated.
+	if points := a.Flush(now, 10*time.Second); len(points) != 0 {
+		t.Fatalf("unexpected points: %v", points)
+	}
+	MustAdd(a, "temperature:-1|g")
+	exp = map[string]map[string]interface{}{"temperature": {"value": 21.0}}
+	if got := PointFields(a.Flush(now, 10*time.Second)); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points: %v", got)
+	}
+}
+
+// Ensure gauges that aren't updated are dropped after the expiry.
+func TestAggregator_Flush_GaugeExpiry(t *testing.T) {
+	a := NewAggregator(nil, nil)
+	a.GaugeExpiry = time.Minute
+	MustAdd(a, "temperature:20|g")
+	if points := a.Flush(time.Unix(0, 0), 10*time.Second); len(points) != 1 {
+		t.Fatalf("unexpected points: %v", points)
+	}
+
+	// Ensure the gauge keeps its value before the expiry.
+	a.Flush(time.Unix(50, 0), 10*time.Second)
+	MustAdd(a, "temperature:+2|g")
+	exp := map[string]map[string]interface{}{"temperature": {"value": 22.0}}
+	if got := PointFields(a.Flush(time.Unix(55, 0), 10*time.Second)); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points: %v", got)
+	}
+
+	// Ensure a relative update starts from zero after the expiry.
+	a.Flush(time.Unix(115, 0), 10*time.Second)
+	MustAdd(a, "temperature:+2|g")
+	exp = map[string]map[string]interface{}{"temperature": {"value": 2.0}}
+	if got := PointFields(a.Flush(time.Unix(120, 0), 10*time.Second)); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected points: %v", got)
+	}
+}
+
+// Ensure metric names are mapped with templates and tags are merged.
+func TestAggregator_Templates(t *testing.T) {
+	a := NewAggregator([]string{"api.* measurement.endpoint.field"}, nil)
+	MustAdd(a, "api.login.errors:2|c|#region:us-east")
+
+	points := a.Flush(time.Unix(0, 0), time.Second)
+	if len(points) != 1 {
+		t.Fatalf("unexpected points: %v", points)
+	} else if points[0].Name() != "api" {
+		t.Fatalf("unexpected name: %s", points[0].Name())
+	} else if tags := points[0].Tags(); !reflect.DeepEqual(tags, models.Tags{"endpoint": "login", "region": "us-east"}) {
+		t.Fatalf("unexpected tags: %v", tags)
+	} else if fields := points[0].Fields(); !reflect.DeepEqual(fields, models.Fields{"errors": 2.0, "errors_rate": 2.0}) {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
+
+// Ensure fractional percentiles are calculated and named.
+func TestAggregator_Percentiles(t *testing.T) {
+	a := NewAggregator(nil, []float64{50, 99.9})
+	MustAdd(a, "latency:1|ms", "latency:2|ms", "latency:3|ms", "latency:4|ms")
+
+	fields := a.Flush(time.Unix(0, 0), time.Second)[0].Fields()
+	if fields["upper_50"] != 2.0 || fields["mean_50"] != 1.5 {
+		t.Fatalf("unexpected 50th percentile: %v", fields)
+	} else if fields["upper_99_9"] != 4.0 || fields["mean_99_9"] != 2.5 {
+		t.Fatalf("unexpected 99.9th percentile: %v", fields)
+	}
+}
+
+// NewAggregator returns an aggregator using templates with a "_" separator.
+func NewAggregator(templates []string, percentiles []float64) *statsd.Aggregator {
+	p, err := graphite.NewParserWithOptions(graphite.Options{Templates: templates, Separator: statsd.DefaultSeparator})
+	if err != nil {
+		panic(err)
+	}
+	if percentiles == nil {
+		percentiles = []float64{statsd.DefaultPercentile}
+	}
+	return statsd.NewAggregator(p, percentiles)
+}
+
+// MustAdd parses and adds lines to an aggregator. Panic on error.
+func MustAdd(a *statsd.Aggregator, lines ...string) {
+	for _, line := range lines {
+		m, err := statsd.ParseLine(line)
+		if err != nil {
+			panic(err)
+		} else if err := a.Add(m); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// PointFields returns the fields of points by measurement name.
+func PointFields(points []models.Point) map[string]map[string]interface{} {
+	m := make(map[string]map[string]interface{})
+	for _, pt := range points {
+		m[pt.Name()] = pt.Fields()
+	}
+	return m
+}
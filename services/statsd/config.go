@@ -0,0 +1,146 @@
+package statsd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/services/graphite"
+	"github.com/influxdata/influxdb/toml"
+)
+
+const (
+	// DefaultBindAddress is the default binding interface if none is specified.
+	DefaultBindAddress = ":8125"
+
+	// DefaultDatabase is the default database if none is specified.
+	DefaultDatabase = "statsd"
+
+	// DefaultConsistencyLevel is the default write consistency for the StatsD input.
+	DefaultConsistencyLevel = "one"
+
+	// DefaultSeparator is the default join character to use when joining multiple
+	// measurement parts in a template.
+	DefaultSeparator = "_"
+
+	// DefaultFlushInterval is the default interval at which aggregated metrics
+	// are written.
+	DefaultFlushInterval = 10 * time.Second
+
+	// DefaultGaugeExpiry is the default time a gauge is kept without updates.
+	DefaultGaugeExpiry = time.Hour
+
+	// DefaultPercentile is the default percentile calculated for timers.
+	DefaultPercentile = 90
+
+	// DefaultBatchSize is the default write batch size.
+	DefaultBatchSize = 5000
+
+	// DefaultBatchPending is the default number of pending write batches.
+	DefaultBatchPending = 10
+
+	// DefaultBatchTimeout is the default StatsD batch timeout.
+	DefaultBatchTimeout = time.Second
+
+	// DefaultReadBuffer is the default buffer size for the UDP listener.
+	// DefaultReadBuffer = 0 means to use the OS default.
+	DefaultReadBuffer = 0
+)
+
+// Config represents the configuration for StatsD endpoints.
+type Config struct {
+	Enabled          bool          `toml:"enabled"`
+	BindAddress      string        `toml:"bind-address"`
+	Database         string        `toml:"database"`
+	RetentionPolicy  string        `toml:"retention-policy"`
+	ConsistencyLevel string        `toml:"consistency-level"`
+	FlushInterval    toml.Duration `toml:"flush-interval"`
+	GaugeExpiry      toml.Duration `toml:"gauge-expiry"`
+	Percentiles      []float64     `toml:"percentiles"`
+	Templates        []string      `toml:"templates"`
+	Tags             []string      `toml:"tags"`
+	Separator        string        `toml:"separator"`
+	BatchSize        int           `toml:"batch-size"`
+	BatchPending     int           `toml:"batch-pending"`
+	BatchTimeout     toml.Duration `toml:"batch-timeout"`
+	ReadBuffer       int           `toml:"read-buffer"`
+}
+
+// WithDefaults takes the given config and returns a new config with any required
+// default values set.
+func (c *Config) WithDefaults() *Config {
+	d := *c
+	if d.BindAddress == "" {
+		d.BindAddress = DefaultBindAddress
+	}
+	if d.Database == "" {
+		d.Database = DefaultDatabase
+	}
+	if d.ConsistencyLevel == "" {
+		d.ConsistencyLevel = DefaultConsistencyLevel
+	}
+	if d.FlushInterval == 0 {
+		d.FlushInterval = toml.Duration(DefaultFlushInterval)
+	}
+	if d.GaugeExpiry == 0 {
+		d.GaugeExpiry = toml.Duration(DefaultGaugeExpiry)
+	}
+	if d.Percentiles == nil {
+		d.Percentiles = []float64{DefaultPercentile}
+	}
+	if d.Separator == "" {
+		d.Separator = DefaultSeparator
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.BatchPending == 0 {
+		d.BatchPending = DefaultBatchPending
+	}
+	if d.BatchTimeout == 0 {
+		d.BatchTimeout = toml.Duration(DefaultBatchTimeout)
+	}
+	if d.ReadBuffer == 0 {
+		d.ReadBuffer = DefaultReadBuffer
+	}
+	return &d
+}
+
+// DefaultTags returns the config's tags.
+func (c *Config) DefaultTags() models.Tags {
+	tags := models.Tags{}
+	for _, t := range c.Tags {
+		parts := strings.Split(t, "=")
+		tags[parts[0]] = parts[1]
+	}
+	return tags
+}
+
+// Validate validates the config's templates, tags and percentiles.
+func (c *Config) Validate() error {
+	// Templates and tags use the graphite format. All points are written
+	// to the configured database, so templates can't have a target.
+	for _, t := range c.Templates {
+		if parts := strings.Fields(t); len(parts) > 1 && strings.HasPrefix(parts[len(parts)-1], "@") {
+			return fmt.Errorf("template targets are not supported: '%s'", t)
+		}
+	}
+	g := graphite.Config{Templates: c.Templates, Tags: c.Tags}
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	for _, p := range c.Percentiles {
+		if p <= 0 || p > 100 {
+			return fmt.Errorf("invalid percentile: %v", p)
+		}
+	}
+
+	if c.FlushInterval < 0 {
+		return fmt.Errorf("invalid flush interval: %s", c.FlushInterval)
+	} else if c.GaugeExpiry < 0 {
+		return fmt.Errorf("invalid gauge expiry: %s", c.GaugeExpiry)
+	}
+	return nil
+}
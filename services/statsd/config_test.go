@@ -0,0 +1,83 @@
+package statsd_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/services/statsd"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c statsd.Config
+	if _, err := toml.Decode(`
+enabled = true
+bind-address = ":8126"
+database = "mydb"
+retention-policy = "myrp"
+flush-interval = "5s"
+gauge-expiry = "30m"
+percentiles = [90.0, 99.9]
+templates = ["api.* measurement.endpoint"]
+tags = ["region=us-east"]
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.Enabled {
+		t.Fatalf("unexpected enabled: %v", c.Enabled)
+	} else if c.BindAddress != ":8126" {
+		t.Fatalf("unexpected bind address: %s", c.BindAddress)
+	} else if c.Database != "mydb" {
+		t.Fatalf("unexpected database: %s", c.Database)
+	} else if c.RetentionPolicy != "myrp" {
+		t.Fatalf("unexpected retention policy: %s", c.RetentionPolicy)
+	} else if time.Duration(c.FlushInterval) != 5*time.Second {
+		t.Fatalf("unexpected flush interval: %s", c.FlushInterval)
+	} else if time.Duration(c.GaugeExpiry) != 30*time.Minute {
+		t.Fatalf("unexpected gauge expiry: %s", c.GaugeExpiry)
+	} else if !reflect.DeepEqual(c.Percentiles, []float64{90, 99.9}) {
+		t.Fatalf("unexpected percentiles: %v", c.Percentiles)
+	} else if !reflect.DeepEqual(c.Templates, []string{"api.* measurement.endpoint"}) {
+		t.Fatalf("unexpected templates: %v", c.Templates)
+	} else if !reflect.DeepEqual(c.Tags, []string{"region=us-east"}) {
+		t.Fatalf("unexpected tags: %v", c.Tags)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfig_WithDefaults(t *testing.T) {
+	c := (&statsd.Config{}).WithDefaults()
+	if c.BindAddress != statsd.DefaultBindAddress {
+		t.Fatalf("unexpected bind address: %s", c.BindAddress)
+	} else if c.Database != statsd.DefaultDatabase {
+		t.Fatalf("unexpected database: %s", c.Database)
+	} else if time.Duration(c.FlushInterval) != statsd.DefaultFlushInterval {
+		t.Fatalf("unexpected flush interval: %s", c.FlushInterval)
+	} else if time.Duration(c.GaugeExpiry) != statsd.DefaultGaugeExpiry {
+		t.Fatalf("unexpected gauge expiry: %s", c.GaugeExpiry)
+	} else if !reflect.DeepEqual(c.Percentiles, []float64{statsd.DefaultPercentile}) {
+		t.Fatalf("unexpected percentiles: %v", c.Percentiles)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for i, c := range []statsd.Config{
+		{Percentiles: []float64{0}},
+		{Percentiles: []float64{100.5}},
+		{Templates: []string{"host.cpu"}},
+		{Tags: []string{"region"}},
+		{Templates: []string{"api.* measurement.field @api"}},
+		{GaugeExpiry: -1},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%d. expected error", i)
+		}
+	}
+}
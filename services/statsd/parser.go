@@ -0,0 +1,118 @@
+package statsd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metric types of the StatsD protocol.
+const (
+	Counter   = "c"
+	Gauge     = "g"
+	Timer     = "ms"
+	Histogram = "h"
+	Set       = "s"
+)
+
+// Metric represents a single value sent to StatsD.
+type Metric struct {
+	Name string
+	Type string
+
+	// Value is the value of a counter, gauge or timer.
+	Value float64
+
+	// SetValue is the value of a set.
+	SetValue string
+
+	// Relative is set for a gauge whose value has an explicit sign. The
+	// value is added to the current value of the gauge.
+	Relative bool
+
+	// SampleRate is the fraction of values sent by the client. Counters
+	// and timers are scaled by it.
+	SampleRate float64
+
+	// Tags are the DogStatsD-style tags of the metric.
+	Tags map[string]string
+}
+
+// ParseLine parses a single StatsD line of the form:
+//
+//	<name>:<value>|<type>[|@<sample rate>][|#<tag>:<value>,<tag>:<value>]
+//
+// Histograms are returned as timers. A tag without a value is set to "true".
+func ParseLine(line string) (*Metric, error) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return nil, fmt.Errorf("received %q which doesn't have a name", line)
+	}
+
+	parts := strings.Split(line[i+1:], "|")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("received %q which doesn't have required fields", line)
+	}
+
+	m := &Metric{Name: line[:i], Type: parts[1], SampleRate: 1}
+	for _, p := range parts[2:] {
+		switch {
+		case strings.HasPrefix(p, "@"):
+			rate, err := strconv.ParseFloat(p[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return nil, fmt.Errorf(`metric "%s" has invalid sample rate: %s`, m.Name, p[1:])
+			}
+			m.SampleRate = rate
+		case strings.HasPrefix(p, "#"):
+			m.Tags = parseTags(p[1:])
+		default:
+			return nil, fmt.Errorf(`metric "%s" has invalid section: %s`, m.Name, p)
+		}
+	}
+
+	value := parts[0]
+	switch m.Type {
+	case Counter, Gauge, Timer, Histogram:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf(`metric "%s" value: %s`, m.Name, err)
+		} else if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf(`metric "%s" has unsupported value: %v`, m.Name, v)
+		}
+		m.Value = v
+
+		if m.Type == Gauge {
+			m.Relative = strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-")
+		} else if m.Type == Histogram {
+			m.Type = Timer
+		}
+	case Set:
+		if value == "" {
+			return nil, fmt.Errorf(`metric "%s" has an empty set value`, m.Name)
+		}
+		m.SetValue = value
+	default:
+		return nil, fmt.Errorf(`metric "%s" has unknown type: %s`, m.Name, m.Type)
+	}
+
+	return m, nil
+}
+
+// parseTags parses a comma separated list of DogStatsD tags.
+func parseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, t := range strings.Split(s, ",") {
+		if t == "" {
+			continue
+		}
+
+		kv := strings.SplitN(t, ":", 2)
+		if len(kv) == 1 {
+			tags[kv[0]] = "true"
+		} else if kv[0] != "" && kv[1] != "" {
+			tags[kv[0]] = kv[1]
+		}
+	}
+	return tags
+}
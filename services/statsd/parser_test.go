@@ -0,0 +1,71 @@
+package statsd_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/influxdb/services/statsd"
+)
+
+func TestParseLine(t *testing.T) {
+	var tests = []struct {
+		line   string
+		metric *statsd.Metric
+		err    bool
+	}{
+		{
+			line:   "requests:1|c",
+			metric: &statsd.Metric{Name: "requests", Type: statsd.Counter, Value: 1, SampleRate: 1},
+		},
+		{
+			line:   "requests:3|c|@0.1",
+			metric: &statsd.Metric{Name: "requests", Type: statsd.Counter, Value: 3, SampleRate: 0.1},
+		},
+		{
+			line:   "temperature:21.5|g",
+			metric: &statsd.Metric{Name: "temperature", Type: statsd.Gauge, Value: 21.5, SampleRate: 1},
+		},
+		{
+			line:   "temperature:-2|g",
+			metric: &statsd.Metric{Name: "temperature", Type: statsd.Gauge, Value: -2, Relative: true, SampleRate: 1},
+		},
+		{
+			line:   "latency:320|ms|#host:a,canary",
+			metric: &statsd.Metric{Name: "latency", Type: statsd.Timer, Value: 320, SampleRate: 1, Tags: map[string]string{"host": "a", "canary": "true"}},
+		},
+		{
+			line:   "size:12|h",
+			metric: &statsd.Metric{Name: "size", Type: statsd.Timer, Value: 12, SampleRate: 1},
+		},
+		{
+			line:   "users:alice|s",
+			metric: &statsd.Metric{Name: "users", Type: statsd.Set, SetValue: "alice", SampleRate: 1},
+		},
+		{line: "requests", err: true},
+		{line: ":1|c", err: true},
+		{line: "requests:1", err: true},
+		{line: "requests:x|c", err: true},
+		{line: "requests:NaN|c", err: true},
+		{line: "requests:1|x", err: true},
+		{line: "requests:1|c|@2", err: true},
+		{line: "requests:1|c|foo", err: true},
+		{line: "users:|s", err: true},
+	}
+
+	for _, tt := range tests {
+		m, err := statsd.ParseLine(tt.line)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected error", tt.line)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.line, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(m, tt.metric) {
+			t.Errorf("%s: unexpected metric:\n\nexp=%#v\n\ngot=%#v", tt.line, tt.metric, m)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package statsd // import "github.com/influxdata/influxdb/services/statsd"
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb"
+	"github.com/influxdata/influxdb/cluster"
+	"github.com/influxdata/influxdb/services/graphite"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+const udpBufferSize = 65536
+
+// statistics gathered by the statsd package.
+const (
+	statMetricsReceived     = "metricsRx"
+	statBytesReceived       = "bytesRx"
+	statMetricsParseFail    = "metricsParseFail"
+	statMetricsDropped      = "metricsDropped"
+	statReadFail            = "readFail"
+	statPointsFlushed       = "pointsFlushed"
+	statBatchesTransmitted  = "batchesTx"
+	statPointsTransmitted   = "pointsTx"
+	statBatchesTransmitFail = "batchesTxFail"
+)
+
+// Service represents a StatsD listener. Metrics received over UDP are
+// aggregated and written once per flush interval.
+type Service struct {
+	conn *net.UDPConn
+	addr *net.UDPAddr
+	wg   sync.WaitGroup
+	done chan struct{}
+
+	mu         sync.Mutex
+	aggregator *Aggregator
+
+	batcher          *tsdb.PointBatcher
+	config           Config
+	consistencyLevel cluster.ConsistencyLevel
+
+	PointsWriter interface {
+		WritePoints(p *cluster.WritePointsRequest) error
+	}
+
+	MetaClient interface {
+		CreateDatabase(name string) (*meta.DatabaseInfo, error)
+	}
+
+	Logger  *log.Logger
+	statMap *expvar.Map
+}
+
+// NewService returns a new instance of Service.
+func NewService(c Config) (*Service, error) {
+	d := *c.WithDefaults()
+
+	consistencyLevel, err := cluster.ParseConsistencyLevel(d.ConsistencyLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := graphite.NewParserWithOptions(graphite.Options{
+		Templates:   d.Templates,
+		DefaultTags: d.DefaultTags(),
+		Separator:   d.Separator,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	aggregator := NewAggregator(parser, d.Percentiles)
+	aggregator.GaugeExpiry = time.Duration(d.GaugeExpiry)
+
+	return &Service{
+		config:           d,
+		consistencyLevel: consistencyLevel,
+		done:             make(chan struct{}),
+		aggregator:       aggregator,
+		batcher:          tsdb.NewPointBatcher(d.BatchSize, d.BatchPending, time.Duration(d.BatchTimeout)),
+		Logger:           log.New(os.Stderr, "[statsd] ", log.LstdFlags),
+	}, nil
+}
+
+// Open starts the service.
+func (s *Service) Open() (err error) {
+	// Configure expvar monitoring. It's OK to do this even if the service fails to open and
+	// should be done before any data could arrive for the service.
+	key := strings.Join([]string{"statsd", s.config.BindAddress}, ":")
+	tags := map[string]string{"bind": s.config.BindAddress}
+	s.statMap = influxdb.NewStatistics(key, "statsd", tags)
+
+	if _, err := s.MetaClient.CreateDatabase(s.config.Database); err != nil {
+		return errors.New("Failed to ensure target database exists")
+	}
+
+	s.addr, err = net.ResolveUDPAddr("udp", s.config.BindAddress)
+	if err != nil {
+		s.Logger.Printf("Failed to resolve UDP address %s: %s", s.config.BindAddress, err)
+		return err
+	}
+
+	s.conn, err = net.ListenUDP("udp", s.addr)
+	if err != nil {
+		s.Logger.Printf("Failed to set up UDP listener at address %s: %s", s.addr, err)
+		return err
+	}
+
+	if s.config.ReadBuffer != 0 {
+		if err := s.conn.SetReadBuffer(s.config.ReadBuffer); err != nil {
+			s.Logger.Printf("Failed to set UDP read buffer to %d: %s", s.config.ReadBuffer, err)
+			return err
+		}
+	}
+
+	s.Logger.Printf("Started listening on UDP: %s", s.config.BindAddress)
+
+	s.batcher.Start()
+
+	s.wg.Add(3)
+	go s.serve()
+	go s.flusher()
+	go s.writer()
+
+	return nil
+}
+
+// serve reads packets from the listener and aggregates their metrics.
+func (s *Service) serve() {
+	defer s.wg.Done()
+
+	buf := make([]byte, udpBufferSize)
+	for {
+		n, _, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			s.statMap.Add(statReadFail, 1)
+			s.Logger.Printf("Failed to read UDP message: %s", err)
+			continue
+		}
+		s.statMap.Add(statBytesReceived, int64(n))
+		s.handlePacket(buf[:n])
+	}
+}
+
+// handlePacket parses and aggregates the newline separated metrics in buf.
+func (s *Service) handlePacket(buf []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		m, err := ParseLine(string(line))
+		if err == nil {
+			err = s.aggregator.Add(m)
+		}
+		if err == graphite.ErrMetricDropped {
+			s.statMap.Add(statMetricsDropped, 1)
+			continue
+		} else if err != nil {
+			s.statMap.Add(statMetricsParseFail, 1)
+			s.Logger.Printf("unable to parse line: %s: %s", line, err)
+			continue
+		}
+		s.statMap.Add(statMetricsReceived, 1)
+	}
+}
+
+// flusher sends the aggregated points to the batcher once per interval.
+func (s *Service) flusher() {
+	defer s.wg.Done()
+
+	interval := time.Duration(s.config.FlushInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(interval)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// flush sends the points aggregated over interval to the batcher.
+func (s *Service) flush(interval time.Duration) {
+	s.mu.Lock()
+	points := s.aggregator.Flush(time.Now().UTC(), interval)
+	s.mu.Unlock()
+
+	for _, pt := range points {
+		select {
+		case s.batcher.In() <- pt:
+		case <-s.done:
+			return
+		}
+	}
+	s.statMap.Add(statPointsFlushed, int64(len(points)))
+}
+
+// writer writes the batches of points to the database.
+func (s *Service) writer() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case batch := <-s.batcher.Out():
+			if err := s.PointsWriter.WritePoints(&cluster.WritePointsRequest{
+				Database:         s.config.Database,
+				RetentionPolicy:  s.config.RetentionPolicy,
+				ConsistencyLevel: s.consistencyLevel,
+				Points:           batch,
+			}); err == nil {
+				s.statMap.Add(statBatchesTransmitted, 1)
+				s.statMap.Add(statPointsTransmitted, int64(len(batch)))
+			} else {
+				s.Logger.Printf("failed to write point batch to database %q: %s", s.config.Database, err)
+				s.statMap.Add(statBatchesTransmitFail, 1)
+			}
+
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close closes the underlying listener. Metrics aggregated since the last
+// flush are dropped.
+func (s *Service) Close() error {
+	if s.conn == nil {
+		return errors.New("Service already closed")
+	}
+
+	close(s.done)
+	s.conn.Close()
+	s.wg.Wait()
+	s.batcher.Stop()
+
+	// Release all remaining resources.
+	s.done = nil
+	s.conn = nil
+
+	s.Logger.Print("Service closed")
+
+	return nil
+}
+
+// SetLogger sets the internal logger to the logger passed in.
+func (s *Service) SetLogger(l *log.Logger) {
+	s.Logger = l
+}
+
+// Addr returns the listener's address.
+func (s *Service) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
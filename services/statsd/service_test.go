@@ -0,0 +1,98 @@
+package statsd_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/cluster"
+	"github.com/influxdata/influxdb/services/meta"
+	"github.com/influxdata/influxdb/services/statsd"
+	"github.com/influxdata/influxdb/toml"
+)
+
+// Ensure the service aggregates metrics sent over UDP and writes them at
+// each flush.
+func TestService_Flush(t *testing.T) {
+	t.Parallel()
+
+	c := statsd.Config{
+		BindAddress:   "127.0.0.1:0",
+		Database:      "statsd",
+		FlushInterval: toml.Duration(100 * time.Millisecond),
+		BatchTimeout:  toml.Duration(10 * time.Millisecond),
+	}
+	s, err := statsd.NewService(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := make(chan *cluster.WritePointsRequest, 10)
+	s.PointsWriter = &PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			requests <- req
+			return nil
+		},
+	}
+	dbCreator := DatabaseCreator{}
+	s.MetaClient = &dbCreator
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if !dbCreator.Created {
+		t.Fatalf("failed to create target database")
+	}
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("requests:1|c\nrequests:2|c\nbad line\ntemperature:20|g\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := make(map[string]map[string]interface{})
+	timeout := time.After(5 * time.Second)
+	for len(fields) < 2 {
+		select {
+		case req := <-requests:
+			if req.Database != "statsd" {
+				t.Fatalf("unexpected database: %s", req.Database)
+			}
+			for k, v := range PointFields(req.Points) {
+				fields[k] = v
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for points: %v", fields)
+		}
+	}
+
+	if v := fields["requests"]["value"]; v != 3.0 {
+		t.Fatalf("unexpected requests: %v", fields["requests"])
+	} else if v := fields["temperature"]["value"]; v != 20.0 {
+		t.Fatalf("unexpected temperature: %v", fields["temperature"])
+	}
+}
+
+// PointsWriter represents a mock impl of PointsWriter.
+type PointsWriter struct {
+	WritePointsFn func(*cluster.WritePointsRequest) error
+}
+
+func (w *PointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+// DatabaseCreator represents a mock impl of the meta client.
+type DatabaseCreator struct {
+	Created bool
+}
+
+func (d *DatabaseCreator) CreateDatabase(name string) (*meta.DatabaseInfo, error) {
+	d.Created = true
+	return nil, nil
+}
@@ -0,0 +1,71 @@
+package tcp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+// ErrAuthenticationFailed is returned when a connection doesn't prove the
+// shared secret.
+var ErrAuthenticationFailed = errors.New("mux authentication failed")
+
+const (
+	// nonceSize is the size of the challenge sent by the listener.
+	nonceSize = 32
+
+	// authOK and authFailed are the responses to a challenge.
+	authOK     = 0
+	authFailed = 1
+)
+
+// challenge sends a random nonce over rw and verifies the response is the
+// HMAC of the nonce keyed with secret. The result is written back so the
+// dialing side gets a clear error.
+func challenge(rw io.ReadWriter, secret string) error {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return err
+	} else if _, err := rw.Write(nonce[:]); err != nil {
+		return err
+	}
+
+	var mac [sha256.Size]byte
+	if _, err := io.ReadFull(rw, mac[:]); err != nil {
+		return err
+	}
+
+	if !hmac.Equal(mac[:], sign(secret, nonce[:])) {
+		rw.Write([]byte{authFailed})
+		return ErrAuthenticationFailed
+	}
+	_, err := rw.Write([]byte{authOK})
+	return err
+}
+
+// respond answers a challenge from a listener over rw.
+func respond(rw io.ReadWriter, secret string) error {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rw, nonce[:]); err != nil {
+		return err
+	} else if _, err := rw.Write(sign(secret, nonce[:])); err != nil {
+		return err
+	}
+
+	var status [1]byte
+	if _, err := io.ReadFull(rw, status[:]); err != nil {
+		return err
+	} else if status[0] != authOK {
+		return ErrAuthenticationFailed
+	}
+	return nil
+}
+
+// sign returns the HMAC-SHA256 of nonce keyed with secret.
+func sign(secret string, nonce []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(nonce)
+	return h.Sum(nil)
+}
@@ -0,0 +1,86 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// Config represents the security configuration of the mux listener and of
+// the connections dialed to other mux listeners.
+type Config struct {
+	// TLSEnabled requires mutual TLS on every connection. Both sides present
+	// a certificate signed by the configured CA.
+	TLSEnabled  bool   `toml:"tls-enabled"`
+	CA          string `toml:"ca-certificate"`
+	Certificate string `toml:"certificate"`
+	PrivateKey  string `toml:"private-key"`
+
+	// SharedSecret, if set, must be proven by every connection before the
+	// header byte is read.
+	SharedSecret string `toml:"shared-secret"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{}
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if !c.TLSEnabled {
+		return nil
+	}
+
+	if c.CA == "" {
+		return errors.New("mux ca-certificate must be specified when tls is enabled")
+	} else if c.Certificate == "" {
+		return errors.New("mux certificate must be specified when tls is enabled")
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS configuration used by both the listener and the
+// dialer, or nil if TLS is not enabled. The private key is read from the
+// certificate file if it's not set.
+func (c Config) TLSConfig() (*tls.Config, error) {
+	if !c.TLSEnabled {
+		return nil, nil
+	}
+
+	key := c.PrivateKey
+	if key == "" {
+		key = c.Certificate
+	}
+	cert, err := tls.LoadX509KeyPair(c.Certificate, key)
+	if err != nil {
+		return nil, fmt.Errorf("load certificate: %s", err)
+	}
+
+	buf, err := ioutil.ReadFile(c.CA)
+	if err != nil {
+		return nil, fmt.Errorf("read ca certificate: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no certificates found in %s", c.CA)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// Dialer returns a dialer for connecting to mux listeners using the config.
+func (c Config) Dialer() (*Dialer, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &Dialer{TLSConfig: tlsConfig, Secret: c.SharedSecret}, nil
+}
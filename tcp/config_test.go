@@ -0,0 +1,53 @@
+package tcp_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/tcp"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	// Parse configuration.
+	var c tcp.Config
+	if _, err := toml.Decode(`
+tls-enabled = true
+ca-certificate = "/etc/ssl/ca.pem"
+certificate = "/etc/ssl/node.pem"
+private-key = "/etc/ssl/node.key"
+shared-secret = "foo"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Validate configuration.
+	if !c.TLSEnabled {
+		t.Fatalf("unexpected tls enabled: %v", c.TLSEnabled)
+	} else if c.CA != "/etc/ssl/ca.pem" {
+		t.Fatalf("unexpected ca certificate: %s", c.CA)
+	} else if c.Certificate != "/etc/ssl/node.pem" {
+		t.Fatalf("unexpected certificate: %s", c.Certificate)
+	} else if c.PrivateKey != "/etc/ssl/node.key" {
+		t.Fatalf("unexpected private key: %s", c.PrivateKey)
+	} else if c.SharedSecret != "foo" {
+		t.Fatalf("unexpected shared secret: %s", c.SharedSecret)
+	}
+}
+
+// Ensure TLS requires a CA and a certificate.
+func TestConfig_Validate(t *testing.T) {
+	c := tcp.NewConfig()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.TLSEnabled = true
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected error")
+	}
+
+	c.CA, c.Certificate = "/etc/ssl/ca.pem", "/etc/ssl/node.pem"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
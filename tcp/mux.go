@@ -1,6 +1,7 @@
 package tcp // import "github.com/influxdata/influxdb/tcp"
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -27,6 +28,13 @@ type Mux struct {
 	// The amount of time to wait for the first header byte.
 	Timeout time.Duration
 
+	// TLSConfig, if set, requires TLS on every connection.
+	TLSConfig *tls.Config
+
+	// Secret, if set, must be proven by every connection before the
+	// header byte is read.
+	Secret string
+
 	// Out-of-band error logger
 	Logger *log.Logger
 }
@@ -72,13 +80,31 @@ func (mux *Mux) Serve(ln net.Listener) error {
 
 func (mux *Mux) handleConn(conn net.Conn) {
 	defer mux.wg.Done()
-	// Set a read deadline so connections with no data don't timeout.
-	if err := conn.SetReadDeadline(time.Now().Add(mux.Timeout)); err != nil {
+	// Set a deadline so connections with no data don't timeout.
+	if err := conn.SetDeadline(time.Now().Add(mux.Timeout)); err != nil {
 		conn.Close()
-		mux.Logger.Printf("tcp.Mux: cannot set read deadline: %s", err)
+		mux.Logger.Printf("tcp.Mux: cannot set deadline: %s", err)
 		return
 	}
 
+	// Secure the connection before reading the header byte.
+	if mux.TLSConfig != nil {
+		tlsConn := tls.Server(conn, mux.TLSConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			mux.Logger.Printf("tcp.Mux: tls handshake failed: %s", err)
+			return
+		}
+		conn = tlsConn
+	}
+	if mux.Secret != "" {
+		if err := challenge(conn, mux.Secret); err != nil {
+			conn.Close()
+			mux.Logger.Printf("tcp.Mux: cannot authenticate %s: %s", conn.RemoteAddr(), err)
+			return
+		}
+	}
+
 	// Read first byte from connection to determine handler.
 	var typ [1]byte
 	if _, err := io.ReadFull(conn, typ[:]); err != nil {
@@ -87,10 +113,10 @@ func (mux *Mux) handleConn(conn net.Conn) {
 		return
 	}
 
-	// Reset read deadline and let the listener handle that.
-	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+	// Reset deadline and let the listener handle that.
+	if err := conn.SetDeadline(time.Time{}); err != nil {
 		conn.Close()
-		mux.Logger.Printf("tcp.Mux: cannot reset set read deadline: %s", err)
+		mux.Logger.Printf("tcp.Mux: cannot reset set deadline: %s", err)
 		return
 	}
 
@@ -160,14 +186,87 @@ func (ln *listener) Addr() net.Addr {
 
 // Dial connects to a remote mux listener with a given header byte.
 func Dial(network, address string, header byte) (net.Conn, error) {
-	conn, err := net.Dial(network, address)
+	var d *Dialer
+	return d.Dial(network, address, header)
+}
+
+// Dialer connects to remote mux listeners that may require TLS or a shared
+// secret. A nil Dialer connects without either.
+type Dialer struct {
+	// TLSConfig, if set, is used to establish a TLS connection. The server
+	// name is taken from the dialed address.
+	TLSConfig *tls.Config
+
+	// Secret is proven to the listener if set.
+	Secret string
+}
+
+// Dial connects to a remote mux listener with a given header byte.
+func (d *Dialer) Dial(network, address string, header byte) (net.Conn, error) {
+	return d.DialTimeout(network, address, header, 0)
+}
+
+// DialTimeout connects to a remote mux listener with a given header byte.
+// The timeout applies to the connection and the handshakes.
+func (d *Dialer) DialTimeout(network, address string, header byte, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout(network, address, timeout)
 	if err != nil {
 		return nil, err
 	}
 
+	if d != nil {
+		if conn, err = d.secure(conn, address, timeout); err != nil {
+			return nil, err
+		}
+	}
+
 	if _, err := conn.Write([]byte{header}); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("write mux header: %s", err)
 	}
 
 	return conn, nil
 }
+
+// secure performs the TLS and shared secret handshakes on conn. The
+// connection is closed on error.
+func (d *Dialer) secure(conn net.Conn, address string, timeout time.Duration) (net.Conn, error) {
+	if timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if d.TLSConfig != nil {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		tlsConn := tls.Client(conn, &tls.Config{
+			Certificates: d.TLSConfig.Certificates,
+			RootCAs:      d.TLSConfig.RootCAs,
+			ServerName:   host,
+		})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("tls handshake: %s", err)
+		}
+		conn = tlsConn
+	}
+
+	if d.Secret != "" {
+		if err := respond(conn, d.Secret); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
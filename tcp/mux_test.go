@@ -2,9 +2,16 @@ package tcp_test
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"net"
 	"strings"
 	"sync"
@@ -135,3 +142,153 @@ func TestMux_Listen_ErrAlreadyRegistered(t *testing.T) {
 	mux.Listen(5)
 	mux.Listen(5)
 }
+
+// Ensure a connection proving the shared secret is accepted and one with the
+// wrong secret is rejected.
+func TestMux_Secret(t *testing.T) {
+	mux := tcp.NewMux()
+	mux.Secret = "foo"
+	ln := MustServeEcho(mux, 5)
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	if err := dialEcho(&tcp.Dialer{Secret: "foo"}, addr, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := dialEcho(&tcp.Dialer{Secret: "bar"}, addr, 5); err != tcp.ErrAuthenticationFailed {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure connections require a certificate signed by the CA when TLS is enabled.
+func TestMux_TLS(t *testing.T) {
+	serverConfig, clientConfig := MustTLSConfigs()
+
+	mux := tcp.NewMux()
+	mux.TLSConfig = serverConfig
+	mux.Secret = "foo"
+	ln := MustServeEcho(mux, 5)
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	if err := dialEcho(&tcp.Dialer{TLSConfig: clientConfig, Secret: "foo"}, addr, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// A client without a certificate is rejected.
+	if err := dialEcho(&tcp.Dialer{TLSConfig: &tls.Config{RootCAs: clientConfig.RootCAs}, Secret: "foo"}, addr, 5); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// A plain connection is rejected.
+	if err := dialEcho(nil, addr, 5); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// MustServeEcho serves mux on a random port with an echo handler under
+// header. Panic on error.
+func MustServeEcho(mux *tcp.Mux, header byte) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	if !testing.Verbose() {
+		mux.Logger = log.New(ioutil.Discard, "", 0)
+	}
+	mux.Timeout = time.Second
+
+	echo := mux.Listen(header)
+	go mux.Serve(ln)
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return ln
+}
+
+// dialEcho dials an echo handler and ensures a message is echoed back.
+func dialEcho(d *tcp.Dialer, addr string, header byte) error {
+	conn, err := d.DialTimeout("tcp", addr, header, time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("OK")); err != nil {
+		return err
+	}
+	var buf [2]byte
+	if _, err := io.ReadFull(conn, buf[:]); err != nil {
+		return err
+	} else if string(buf[:]) != "OK" {
+		return errors.New("unexpected response: " + string(buf[:]))
+	}
+	return nil
+}
+
+// MustTLSConfigs returns server and client TLS configs with certificates for
+// 127.0.0.1 signed by a new CA. Panic on error.
+func MustTLSConfigs() (server, client *tls.Config) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		panic(err)
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	ca, err = x509.ParseCertificate(caDER)
+	if err != nil {
+		panic(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		panic(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "node"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, ca, &key.PublicKey, caKey)
+	if err != nil {
+		panic(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	server = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	client = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}
+	return server, client
+}
@@ -0,0 +1,162 @@
+package tsdb
+
+import (
+	"sync"
+
+	"github.com/influxdata/influxdb/pkg/estimator/hll"
+)
+
+// CardinalitySketches estimates the number of distinct measurements, series,
+// tag keys, tag values and field keys written to a shard. The sketches of
+// multiple shards can be merged to estimate the cardinality of a database
+// without counting values in more than one shard twice.
+//
+// Sketches cannot remove values so estimates include dropped series until
+// the shard is deleted.
+type CardinalitySketches struct {
+	mu           sync.RWMutex
+	measurements *hll.Plus
+	series       *hll.Plus
+	byName       map[string]*measurementSketches
+}
+
+// measurementSketches holds the sketches of a single measurement.
+type measurementSketches struct {
+	series    *hll.Plus
+	tagKeys   *hll.Plus
+	tagValues map[string]*hll.Plus
+	fieldKeys *hll.Plus
+}
+
+// NewCardinalitySketches returns a new, empty set of sketches.
+func NewCardinalitySketches() *CardinalitySketches {
+	return &CardinalitySketches{
+		measurements: hll.NewDefaultPlus(),
+		series:       hll.NewDefaultPlus(),
+		byName:       make(map[string]*measurementSketches),
+	}
+}
+
+// measurement returns the sketches for a measurement, creating them if needed.
+// The caller must hold the write lock.
+func (c *CardinalitySketches) measurement(name string) *measurementSketches {
+	m := c.byName[name]
+	if m == nil {
+		m = &measurementSketches{
+			series:    hll.NewDefaultPlus(),
+			tagKeys:   hll.NewDefaultPlus(),
+			tagValues: make(map[string]*hll.Plus),
+			fieldKeys: hll.NewDefaultPlus(),
+		}
+		c.byName[name] = m
+		c.measurements.Add([]byte(name))
+	}
+	return m
+}
+
+// AddSeries adds a series of a measurement and its tags to the sketches.
+func (c *CardinalitySketches) AddSeries(measurement, key string, tags map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.series.Add([]byte(key))
+
+	m := c.measurement(measurement)
+	m.series.Add([]byte(key))
+	for k, v := range tags {
+		m.tagKeys.Add([]byte(k))
+
+		values := m.tagValues[k]
+		if values == nil {
+			values = hll.NewDefaultPlus()
+			m.tagValues[k] = values
+		}
+		values.Add([]byte(v))
+	}
+}
+
+// AddField adds a field key of a measurement to the sketches.
+func (c *CardinalitySketches) AddField(measurement, field string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.measurement(measurement).fieldKeys.Add([]byte(field))
+}
+
+// DeleteMeasurement removes the sketches of a measurement. The measurement and
+// its series are still included in the database estimates.
+func (c *CardinalitySketches) DeleteMeasurement(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.byName, name)
+}
+
+// Measurements returns a copy of the measurement name sketch.
+func (c *CardinalitySketches) Measurements() *hll.Plus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.measurements.Clone()
+}
+
+// Series returns a copy of the series sketch of the shard.
+func (c *CardinalitySketches) Series() *hll.Plus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.series.Clone()
+}
+
+// MeasurementNames returns the names of all measurements with sketches.
+func (c *CardinalitySketches) MeasurementNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	a := make([]string, 0, len(c.byName))
+	for name := range c.byName {
+		a = append(a, name)
+	}
+	return a
+}
+
+// MeasurementSeries returns a copy of the series sketch of a measurement.
+// Returns nil if the measurement has no sketches.
+func (c *CardinalitySketches) MeasurementSeries(name string) *hll.Plus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if m := c.byName[name]; m != nil {
+		return m.series.Clone()
+	}
+	return nil
+}
+
+// TagKeys returns a copy of the tag key sketch of a measurement.
+// Returns nil if the measurement has no sketches.
+func (c *CardinalitySketches) TagKeys(name string) *hll.Plus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if m := c.byName[name]; m != nil {
+		return m.tagKeys.Clone()
+	}
+	return nil
+}
+
+// TagValues returns a copy of the sketch of the values of a tag key in a measurement.
+// Returns nil if the measurement has no values for the key.
+func (c *CardinalitySketches) TagValues(name, key string) *hll.Plus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if m := c.byName[name]; m != nil {
+		if values := m.tagValues[key]; values != nil {
+			return values.Clone()
+		}
+	}
+	return nil
+}
+
+// FieldKeys returns a copy of the field key sketch of a measurement.
+// Returns nil if the measurement has no sketches.
+func (c *CardinalitySketches) FieldKeys(name string) *hll.Plus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if m := c.byName[name]; m != nil {
+		return m.fieldKeys.Clone()
+	}
+	return nil
+}
@@ -93,6 +93,10 @@ type Config struct {
 	// Query logging
 	QueryLogEnabled bool `toml:"query-log-enabled"`
 
+	// Check that series have data in the time range of meta queries
+	// instead of only checking the shards they were written to.
+	MetaQueryCheckData bool `toml:"meta-query-check-data"`
+
 	// Compaction options for tsm1 (descriptions above with defaults)
 	CacheMaxMemorySize             uint64        `toml:"cache-max-memory-size"`
 	CacheSnapshotMemorySize        uint64        `toml:"cache-snapshot-memory-size"`
@@ -255,7 +255,7 @@ func TestFloatCursorIterator_MultipleValues(t *testing.T) {
 	}, true)
 
 	opt := influxql.IteratorOptions{
-		Expr: &influxql.VarRef{Val: "val1"}, Aux: []string{"val1", "val2"},
+		Expr: &influxql.VarRef{Val: "val1"}, Aux: []influxql.VarRef{{Val: "val1"}, {Val: "val2"}},
 		Ascending: true,
 		StartTime: influxql.MinTime,
 		EndTime:   influxql.MaxTime,
@@ -294,7 +294,7 @@ func TestFloatCursorIterator_Aux_SingleValue(t *testing.T) {
 	}, true)
 
 	opt := influxql.IteratorOptions{
-		Aux:       []string{"val1"},
+		Aux:       []influxql.VarRef{{Val: "val1"}},
 		Ascending: true,
 		StartTime: influxql.MinTime,
 		EndTime:   influxql.MaxTime,
@@ -333,7 +333,7 @@ func TestFloatCursorIterator_Aux_MultipleValues(t *testing.T) {
 	}, true)
 
 	opt := influxql.IteratorOptions{
-		Aux:       []string{"val1", "val2"},
+		Aux:       []influxql.VarRef{{Val: "val1"}, {Val: "val2"}},
 		Ascending: true,
 		StartTime: influxql.MinTime,
 		EndTime:   influxql.MaxTime,
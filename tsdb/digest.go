@@ -0,0 +1,40 @@
+package tsdb
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrDigestNotSupported is returned when a shard's engine can't summarize its data.
+var ErrDigestNotSupported = errors.New("engine does not support digests")
+
+// Digest summarizes the values of every series key and field in a shard so
+// the replicas of a shard can be compared without transferring their data.
+// Digests are keyed by the engine's composite series and field key.
+type Digest map[string]DigestEntry
+
+// DigestEntry summarizes the values of a single series key and field.
+type DigestEntry struct {
+	MinTime int64  `json:"minTime"`
+	MaxTime int64  `json:"maxTime"`
+	N       int    `json:"n"`
+	Hash    uint64 `json:"hash"`
+}
+
+// Diff returns the sorted keys whose values differ between d and other,
+// including keys that only exist in one of them.
+func (d Digest) Diff(other Digest) []string {
+	var keys []string
+	for k, e := range d {
+		if oe, ok := other[k]; !ok || oe != e {
+			keys = append(keys, k)
+		}
+	}
+	for k := range other {
+		if _, ok := d[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -42,6 +42,23 @@ type Engine interface {
 	io.WriterTo
 
 	Backup(w io.Writer, basePath string, since time.Time) error
+	Restore(r io.Reader, basePath string) error
+}
+
+// SeriesDataChecker is implemented by engines that can check if a series has
+// data within a time range.
+type SeriesDataChecker interface {
+	SeriesHasDataInRange(key string, min, max int64) bool
+}
+
+// Digester is implemented by engines that can summarize their data so the
+// replicas of a shard can be compared and repaired.
+type Digester interface {
+	Digest() (Digest, error)
+
+	// Points returns the values of the digest keys between min and max,
+	// inclusive, as points.
+	Points(keys []string, min, max int64) ([]models.Point, error)
 }
 
 // EngineFormat represents the format for an engine.
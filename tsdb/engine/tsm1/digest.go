@@ -0,0 +1,134 @@
+package tsm1
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// Ensure Engine implements the interface.
+var _ tsdb.Digester = &Engine{}
+
+// Digest returns a summary of the values of every key in the engine. Values
+// in the cache are included so the digest reflects all acknowledged writes.
+// The values of one key are decoded at a time so digests don't depend on how
+// values are split into blocks and files.
+func (e *Engine) Digest() (tsdb.Digest, error) {
+	d := make(tsdb.Digest)
+	for _, key := range e.digestKeys() {
+		values, err := e.readRange(key, math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return nil, err
+		} else if len(values) == 0 {
+			continue
+		}
+		d[key] = digestValues(values)
+	}
+	return d, nil
+}
+
+// Points returns the values of keys between min and max, inclusive, as points.
+func (e *Engine) Points(keys []string, min, max int64) ([]models.Point, error) {
+	var points []models.Point
+	for _, key := range keys {
+		values, err := e.readRange(key, min, max)
+		if err != nil {
+			return nil, err
+		}
+
+		// ParseKey expects fields after the series key so only its tags are used.
+		seriesKey, field := seriesAndFieldFromCompositeKey(key)
+		_, tags, _ := models.ParseKey(seriesKey)
+		name := measurementFromSeriesKey(seriesKey)
+
+		for _, v := range values {
+			pt, err := models.NewPoint(name, tags, models.Fields{field: v.Value()}, v.Time())
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, pt)
+		}
+	}
+	return points, nil
+}
+
+// measurementFromSeriesKey returns the escaped measurement name of a series key.
+func measurementFromSeriesKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '\\':
+			i++
+		case ',':
+			return key[:i]
+		}
+	}
+	return key
+}
+
+// digestKeys returns the sorted keys in the file store and the cache.
+func (e *Engine) digestKeys() []string {
+	keys := e.FileStore.Keys()
+
+	m := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		m[key] = struct{}{}
+	}
+	for _, key := range e.Cache.Keys() {
+		if _, ok := m[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readRange returns the deduplicated values of key between min and max,
+// inclusive, in the file store and cache.
+func (e *Engine) readRange(key string, min, max int64) (Values, error) {
+	values, err := e.FileStore.ReadRange(key, min, max)
+	if err != nil {
+		return nil, err
+	}
+	values = append(values, e.Cache.Values(key).include(min, max)...)
+	return Values(values).Deduplicate(), nil
+}
+
+// digestValues hashes the timestamps and values of sorted values.
+func digestValues(values Values) tsdb.DigestEntry {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range values {
+		binary.BigEndian.PutUint64(buf[:], uint64(v.UnixNano()))
+		h.Write(buf[:])
+
+		switch v := v.Value().(type) {
+		case float64:
+			binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+			h.Write(buf[:])
+		case int64:
+			binary.BigEndian.PutUint64(buf[:], uint64(v))
+			h.Write(buf[:])
+		case bool:
+			if v {
+				h.Write([]byte{1})
+			} else {
+				h.Write([]byte{0})
+			}
+		case string:
+			binary.BigEndian.PutUint64(buf[:], uint64(len(v)))
+			h.Write(buf[:])
+			h.Write([]byte(v))
+		}
+	}
+
+	return tsdb.DigestEntry{
+		MinTime: values[0].UnixNano(),
+		MaxTime: values[len(values)-1].UnixNano(),
+		N:       len(values),
+		Hash:    h.Sum64(),
+	}
+}
@@ -217,6 +217,17 @@ func (a Values) Deduplicate() Values {
 	return other
 }
 
+// include returns the values with timestamps between min and max, inclusive.
+func (a Values) include(min, max int64) Values {
+	var other Values
+	for _, v := range a {
+		if t := v.UnixNano(); t >= min && t <= max {
+			other = append(other, v)
+		}
+	}
+	return other
+}
+
 // Sort methods
 func (a Values) Len() int           { return len(a) }
 func (a Values) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -183,11 +183,17 @@ func (e *Engine) Close() error {
 func (e *Engine) SetLogOutput(w io.Writer) {}
 
 // LoadMetadataIndex loads the shard metadata into memory.
-func (e *Engine) LoadMetadataIndex(_ *tsdb.Shard, index *tsdb.DatabaseIndex, measurementFields map[string]*tsdb.MeasurementFields) error {
+func (e *Engine) LoadMetadataIndex(sh *tsdb.Shard, index *tsdb.DatabaseIndex, measurementFields map[string]*tsdb.MeasurementFields) error {
 	// Save reference to index for iterator creation.
 	e.index = index
 	e.measurementFields = measurementFields
 
+	// Rebuild the cardinality sketches of the shard, if there is one.
+	var sketches *tsdb.CardinalitySketches
+	if sh != nil {
+		sketches = sh.Sketches()
+	}
+
 	keys := e.FileStore.Keys()
 
 	keysLoaded := make(map[string]bool)
@@ -202,7 +208,7 @@ func (e *Engine) LoadMetadataIndex(_ *tsdb.Shard, index *tsdb.DatabaseIndex, mea
 			return err
 		}
 
-		if err := e.addToIndexFromKey(k, fieldType, index, measurementFields); err != nil {
+		if err := e.addToIndexFromKey(sh, k, fieldType, index, measurementFields, sketches); err != nil {
 			return err
 		}
 
@@ -224,7 +230,7 @@ func (e *Engine) LoadMetadataIndex(_ *tsdb.Shard, index *tsdb.DatabaseIndex, mea
 			continue
 		}
 
-		if err := e.addToIndexFromKey(key, fieldType, index, measurementFields); err != nil {
+		if err := e.addToIndexFromKey(sh, key, fieldType, index, measurementFields, sketches); err != nil {
 			return err
 		}
 	}
@@ -295,9 +301,76 @@ func (e *Engine) writeFileToBackup(f FileStat, shardRelativePath string, tw *tar
 	return err
 }
 
+// Restore adds the TSM files of a tar archive written by Backup to the engine.
+// Each restored file is given a new generation so it never replaces an
+// existing file, including files restored from an earlier archive.
+func (e *Engine) Restore(r io.Reader, basePath string) error {
+	var newFiles []string
+	seen := make(map[string]bool)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			e.removeFiles(newFiles)
+			return fmt.Errorf("read archive: %s", err)
+		}
+
+		// Only TSM files are restored. A file is written to the archive once
+		// for itself and once for its tombstone, so skip duplicates.
+		name := filepath.Base(hdr.Name)
+		if filepath.Ext(name) != "."+TSMFileExtension || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		path := filepath.Join(e.path, fmt.Sprintf("%09d-%09d.%s.tmp", e.FileStore.NextGeneration(), 1, TSMFileExtension))
+		if err := e.readFileFromBackup(tr, hdr, path); err != nil {
+			e.removeFiles(newFiles)
+			return err
+		}
+		newFiles = append(newFiles, path)
+	}
+
+	return e.FileStore.Replace(nil, newFiles)
+}
+
+// readFileFromBackup writes a single file from a tar archive to path and checks
+// it against the size recorded in the archive.
+func (e *Engine) readFileFromBackup(tr *tar.Reader, hdr *tar.Header, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	if err := func() error {
+		defer f.Close()
+
+		if n, err := io.Copy(f, tr); err != nil {
+			return fmt.Errorf("restore %s: %s", hdr.Name, err)
+		} else if n != hdr.Size {
+			return fmt.Errorf("restore %s: wrote %d of %d bytes", hdr.Name, n, hdr.Size)
+		}
+		return f.Sync()
+	}(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// removeFiles removes partially restored files.
+func (e *Engine) removeFiles(paths []string) {
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
+
 // addToIndexFromKey will pull the measurement name, series key, and field name from a composite key and add it to the
-// database index and measurement fields
-func (e *Engine) addToIndexFromKey(key string, fieldType influxql.DataType, index *tsdb.DatabaseIndex, measurementFields map[string]*tsdb.MeasurementFields) error {
+// database index, measurement fields and cardinality sketches
+func (e *Engine) addToIndexFromKey(sh *tsdb.Shard, key string, fieldType influxql.DataType, index *tsdb.DatabaseIndex, measurementFields map[string]*tsdb.MeasurementFields, sketches *tsdb.CardinalitySketches) error {
 	seriesKey, field := seriesAndFieldFromCompositeKey(key)
 	measurement := tsdb.MeasurementFromSeriesKey(seriesKey)
 
@@ -323,7 +396,15 @@ func (e *Engine) addToIndexFromKey(key string, fieldType influxql.DataType, inde
 
 	s := tsdb.NewSeries(seriesKey, tags)
 	s.InitializeShards()
-	index.CreateSeriesIndexIfNotExists(measurement, s)
+	s = index.CreateSeriesIndexIfNotExists(measurement, s)
+	if sh != nil {
+		s.AssignShard(sh.ID())
+	}
+
+	if sketches != nil {
+		sketches.AddSeries(measurement, seriesKey, tags)
+		sketches.AddField(measurement, field)
+	}
 
 	return nil
 }
@@ -670,6 +751,97 @@ func (e *Engine) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator
 	return influxql.NewSortedMergeIterator(itrs, opt), nil
 }
 
+// IteratorCost estimates the work required to create an iterator for opt.
+// Cached values and blocks are only counted if they overlap the time range.
+func (e *Engine) IteratorCost(opt influxql.IteratorOptions) (influxql.IteratorCost, error) {
+	// Determine the fields that would be read for each series.
+	var fields []string
+	switch expr := opt.Expr.(type) {
+	case *influxql.VarRef:
+		fields = append(fields, expr.Val)
+	case *influxql.Call:
+		if ref, ok := expr.Args[0].(*influxql.VarRef); ok {
+			fields = append(fields, ref.Val)
+		}
+	}
+	for _, ref := range opt.Aux {
+		fields = append(fields, ref.Val)
+	}
+
+	minTime, maxTime := time.Unix(0, opt.StartTime), time.Unix(0, opt.EndTime)
+
+	var cost influxql.IteratorCost
+	readFiles := make(map[TSMFile]struct{})
+	mms := tsdb.Measurements(e.index.MeasurementsByName(influxql.Sources(opt.Sources).Names()))
+	for _, mm := range mms {
+		tagSets, err := mm.TagSets(opt.Dimensions, opt.Condition)
+		if err != nil {
+			return influxql.IteratorCost{}, err
+		}
+		tagSets = influxql.LimitTagSets(tagSets, opt.SLimit, opt.SOffset)
+
+		for _, t := range tagSets {
+			cost.NumSeries += int64(len(t.SeriesKeys))
+			for _, seriesKey := range t.SeriesKeys {
+				for _, field := range fields {
+					if !mm.HasField(field) {
+						continue
+					}
+					key := SeriesFieldKey(seriesKey, field)
+
+					for _, v := range e.Cache.Values(key) {
+						if ts := v.UnixNano(); ts >= opt.StartTime && ts <= opt.EndTime {
+							cost.CachedValues++
+						}
+					}
+
+					e.FileStore.walkEntries(key, func(f TSMFile, ie *IndexEntry) bool {
+						if ie.MaxTime.Before(minTime) || ie.MinTime.After(maxTime) {
+							return true
+						}
+						readFiles[f] = struct{}{}
+						cost.BlocksRead++
+						cost.BlockSize += int64(ie.Size)
+						return true
+					})
+				}
+			}
+		}
+	}
+	cost.NumFiles = int64(len(readFiles))
+	return cost, nil
+}
+
+// SeriesHasDataInRange returns true if any field of the series has a cached
+// value or a block between min and max.
+func (e *Engine) SeriesHasDataInRange(seriesKey string, min, max int64) bool {
+	mm := e.index.Measurement(tsdb.MeasurementFromSeriesKey(seriesKey))
+	if mm == nil {
+		return false
+	}
+
+	minTime, maxTime := time.Unix(0, min), time.Unix(0, max)
+	for _, field := range mm.FieldNames() {
+		key := SeriesFieldKey(seriesKey, field)
+
+		for _, v := range e.Cache.Values(key) {
+			if ts := v.UnixNano(); ts >= min && ts <= max {
+				return true
+			}
+		}
+
+		var found bool
+		e.FileStore.walkEntries(key, func(f TSMFile, ie *IndexEntry) bool {
+			found = ie.OverlapsTimeRange(minTime, maxTime)
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Engine) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList, error) {
 	seriesList := influxql.SeriesList{}
 	mms := tsdb.Measurements(e.index.MeasurementsByName(influxql.Sources(opt.Sources).Names()))
@@ -701,22 +873,26 @@ func (e *Engine) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList,
 			// Determine the aux field types.
 			for _, seriesKey := range t.SeriesKeys {
 				tags := influxql.NewTags(e.index.TagsForSeries(seriesKey))
-				for i, field := range opt.Aux {
+				for i, ref := range opt.Aux {
 					typ := func() influxql.DataType {
+						if ref.Type == influxql.Tag {
+							return influxql.Unknown
+						}
+
 						mf := e.measurementFields[mm.Name]
 						if mf == nil {
 							return influxql.Unknown
 						}
 
-						f := mf.Fields[field]
+						f := mf.Fields[ref.Val]
 						if f == nil {
 							return influxql.Unknown
 						}
 						return f.Type
 					}()
 
-					if typ == influxql.Unknown {
-						if v := tags.Value(field); v != "" {
+					if typ == influxql.Unknown && isTagRef(ref) {
+						if v := tags.Value(ref.Val); v != "" {
 							// All tags are strings.
 							typ = influxql.String
 						}
@@ -758,10 +934,14 @@ func (e *Engine) createVarRefIterator(opt influxql.IteratorOptions) ([]influxql.
 
 			// Filter the names from condition to only fields from the measurement.
 			conditionFields := make([]string, 0, len(conditionNames))
-			for _, f := range conditionNames {
-				if mm.HasField(f) {
-					conditionFields = append(conditionFields, f)
+			for _, ref := range conditionNames {
+				if ref.Type == influxql.Tag || !mm.HasField(ref.Val) {
+					continue
 				}
+				if n := len(conditionFields); n > 0 && conditionFields[n-1] == ref.Val {
+					continue
+				}
+				conditionFields = append(conditionFields, ref.Val)
 			}
 
 			for _, t := range tagSets {
@@ -773,6 +953,7 @@ func (e *Engine) createVarRefIterator(opt influxql.IteratorOptions) ([]influxql.
 						continue
 					}
 					itrs = append(itrs, itr)
+					opt.Stats.AddSeries(1)
 				}
 			}
 		}
@@ -798,17 +979,18 @@ func (e *Engine) createVarRefSeriesIterator(ref *influxql.VarRef, mm *tsdb.Measu
 	var aux []cursorAt
 	if len(opt.Aux) > 0 {
 		aux = make([]cursorAt, len(opt.Aux))
-		for i := range aux {
-			// Create cursor from field.
-			cur := e.buildCursor(mm.Name, seriesKey, opt.Aux[i], opt)
-			if cur != nil {
-				aux[i] = newBufCursor(cur)
-				continue
+		for i, ref := range opt.Aux {
+			// Create cursor from field, unless a tag was requested.
+			if ref.Type != influxql.Tag {
+				if cur := e.buildCursor(mm.Name, seriesKey, ref.Val, opt); cur != nil {
+					aux[i] = newBufCursor(cur)
+					continue
+				}
 			}
 
 			// If field doesn't exist, use the tag value.
-			// However, if the tag value is blank then return a null.
-			if v := tags.Value(opt.Aux[i]); v == "" {
+			// However, if the tag value is blank or only fields were requested then return a null.
+			if v := tags.Value(ref.Val); v == "" || !isTagRef(ref) {
 				aux[i] = &stringNilLiteralCursor{}
 			} else {
 				aux[i] = &stringLiteralCursor{value: v}
@@ -838,6 +1020,11 @@ func (e *Engine) createVarRefSeriesIterator(ref *influxql.VarRef, mm *tsdb.Measu
 		return newFloatIterator(mm.Name, tags, itrOpt, nil, aux, conds, conditionFields), nil
 	}
 
+	// Tags cannot be iterated over as the main expression.
+	if ref.Type == influxql.Tag {
+		return nil, nil
+	}
+
 	// Build main cursor.
 	cur := e.buildCursor(mm.Name, seriesKey, ref.Val, opt)
 
@@ -846,18 +1033,28 @@ func (e *Engine) createVarRefSeriesIterator(ref *influxql.VarRef, mm *tsdb.Measu
 		return nil, nil
 	}
 
+	var itr influxql.Iterator
 	switch cur := cur.(type) {
 	case floatCursor:
-		return newFloatIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields), nil
+		itr = newFloatIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields)
 	case integerCursor:
-		return newIntegerIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields), nil
+		itr = newIntegerIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields)
 	case stringCursor:
-		return newStringIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields), nil
+		itr = newStringIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields)
 	case booleanCursor:
-		return newBooleanIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields), nil
+		itr = newBooleanIterator(mm.Name, tags, itrOpt, cur, aux, conds, conditionFields)
 	default:
 		panic("unreachable")
 	}
+
+	// Convert the values if a type cast was requested.
+	return influxql.NewCastIterator(itr, ref.Type), nil
+}
+
+// isTagRef returns true if ref can be resolved to a tag value.
+// Type casts and field specifiers only refer to fields.
+func isTagRef(ref influxql.VarRef) bool {
+	return ref.Type == influxql.Unknown || ref.Type == influxql.Tag
 }
 
 // buildCursor creates an untyped cursor for a field.
@@ -893,28 +1090,28 @@ func (e *Engine) buildCursor(measurement, seriesKey, field string, opt influxql.
 func (e *Engine) buildFloatCursor(measurement, seriesKey, field string, opt influxql.IteratorOptions) floatCursor {
 	cacheValues := e.Cache.Values(SeriesFieldKey(seriesKey, field))
 	keyCursor := e.KeyCursor(SeriesFieldKey(seriesKey, field), time.Unix(0, opt.SeekTime()).UTC(), opt.Ascending)
-	return newFloatCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor)
+	return newFloatCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor, opt.Stats)
 }
 
 // buildIntegerCursor creates a cursor for an integer field.
 func (e *Engine) buildIntegerCursor(measurement, seriesKey, field string, opt influxql.IteratorOptions) integerCursor {
 	cacheValues := e.Cache.Values(SeriesFieldKey(seriesKey, field))
 	keyCursor := e.KeyCursor(SeriesFieldKey(seriesKey, field), time.Unix(0, opt.SeekTime()).UTC(), opt.Ascending)
-	return newIntegerCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor)
+	return newIntegerCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor, opt.Stats)
 }
 
 // buildStringCursor creates a cursor for a string field.
 func (e *Engine) buildStringCursor(measurement, seriesKey, field string, opt influxql.IteratorOptions) stringCursor {
 	cacheValues := e.Cache.Values(SeriesFieldKey(seriesKey, field))
 	keyCursor := e.KeyCursor(SeriesFieldKey(seriesKey, field), time.Unix(0, opt.SeekTime()).UTC(), opt.Ascending)
-	return newStringCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor)
+	return newStringCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor, opt.Stats)
 }
 
 // buildBooleanCursor creates a cursor for a boolean field.
 func (e *Engine) buildBooleanCursor(measurement, seriesKey, field string, opt influxql.IteratorOptions) booleanCursor {
 	cacheValues := e.Cache.Values(SeriesFieldKey(seriesKey, field))
 	keyCursor := e.KeyCursor(SeriesFieldKey(seriesKey, field), time.Unix(0, opt.SeekTime()).UTC(), opt.Ascending)
-	return newBooleanCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor)
+	return newBooleanCursor(opt.SeekTime(), opt.Ascending, cacheValues, keyCursor, opt.Stats)
 }
 
 // SeriesFieldKey combine a series key and field name for a unique string to be hashed to a numeric ID
@@ -4,9 +4,11 @@ import (
 	"archive/tar"
 	"bytes"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -210,6 +212,63 @@ func TestEngine_Backup(t *testing.T) {
 	}
 }
 
+// Ensure engine restores backed up files without replacing existing files.
+func TestEngine_Restore(t *testing.T) {
+	newEngine := func() (*tsm1.Engine, string) {
+		f, _ := ioutil.TempFile("", "tsm")
+		f.Close()
+		os.Remove(f.Name())
+		walPath := filepath.Join(f.Name(), "wal")
+		os.MkdirAll(walPath, 0777)
+
+		e := tsm1.NewEngine(f.Name(), walPath, tsdb.NewEngineOptions()).(*tsm1.Engine)
+		e.CompactionPlan = &mockPlanner{}
+		if err := e.Open(); err != nil {
+			t.Fatalf("failed to open tsm1 engine: %s", err.Error())
+		}
+		return e, f.Name()
+	}
+
+	// Write a point to each engine. Both engines create a file with the same name.
+	src, srcPath := newEngine()
+	defer os.RemoveAll(srcPath)
+	defer src.Close()
+	dst, dstPath := newEngine()
+	defer os.RemoveAll(dstPath)
+	defer dst.Close()
+
+	if err := src.WritePoints([]models.Point{MustParsePointString("cpu,host=A value=1.1 1000000000")}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if err := dst.WritePoints([]models.Point{MustParsePointString("cpu,host=B value=1.2 2000000000")}, nil, nil); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if err := dst.WriteSnapshot(); err != nil {
+		t.Fatalf("failed to snapshot: %s", err.Error())
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := src.Backup(b, "", time.Unix(0, 0)); err != nil {
+		t.Fatalf("failed to backup: %s", err.Error())
+	}
+
+	// Restore the same archive twice.
+	for i := 0; i < 2; i++ {
+		if err := dst.Restore(bytes.NewReader(b.Bytes()), ""); err != nil {
+			t.Fatalf("failed to restore: %s", err.Error())
+		}
+	}
+
+	if n := dst.FileStore.Count(); n != 3 {
+		t.Fatalf("file count wrong: exp: %d, got: %d", 3, n)
+	}
+	keys := dst.FileStore.Keys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"cpu,host=A#!~#value", "cpu,host=B#!~#value"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+}
+
 // Ensure engine can create an ascending iterator for cached values.
 func TestEngine_CreateIterator_Cache_Ascending(t *testing.T) {
 	t.Parallel()
@@ -255,6 +314,154 @@ func TestEngine_CreateIterator_Cache_Ascending(t *testing.T) {
 	}
 }
 
+// Ensure digests are equal for the same values in the cache and in TSM files
+// and differ once a value changes.
+func TestEngine_Digest(t *testing.T) {
+	t.Parallel()
+
+	e1, e2 := MustOpenEngine(), MustOpenEngine()
+	defer e1.Close()
+	defer e2.Close()
+
+	for _, e := range []*Engine{e1, e2} {
+		if err := e.WritePointsString(
+			`cpu,host=A value=1.1 1000000000`,
+			`cpu,host=A value=1.2 2000000000`,
+			`mem,host=A free=10i 1000000000`,
+		); err != nil {
+			t.Fatalf("failed to write points: %s", err.Error())
+		}
+	}
+	e2.MustWriteSnapshot()
+
+	d1, err := e1.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := e2.Digest()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(d1) != 2 {
+		t.Fatalf("unexpected digest length: %d", len(d1))
+	} else if keys := d1.Diff(d2); len(keys) != 0 {
+		t.Fatalf("unexpected differences: %v", keys)
+	} else if e := d1["cpu,host=A#!~#value"]; e.MinTime != 1000000000 || e.MaxTime != 2000000000 || e.N != 2 {
+		t.Fatalf("unexpected digest entry: %+v", e)
+	}
+
+	// Overwrite a value in the second engine.
+	if err := e2.WritePointsString(`cpu,host=A value=1.3 2000000000`); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+	if d2, err = e2.Digest(); err != nil {
+		t.Fatal(err)
+	} else if keys := d1.Diff(d2); !reflect.DeepEqual(keys, []string{"cpu,host=A#!~#value"}) {
+		t.Fatalf("unexpected differences: %v", keys)
+	}
+
+	// Ensure the values of the differing key are returned as points.
+	points, err := e2.Points([]string{"cpu,host=A#!~#value"}, math.MinInt64, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(points) != 2 || points[1].String() != `cpu,host=A value=1.3 2000000000` {
+		t.Fatalf("unexpected points: %v", points)
+	}
+
+	// Ensure only the values in the time range are returned.
+	if points, err := e2.Points([]string{"cpu,host=A#!~#value"}, 1500000000, math.MaxInt64); err != nil {
+		t.Fatal(err)
+	} else if len(points) != 1 || points[0].String() != `cpu,host=A value=1.3 2000000000` {
+		t.Fatalf("unexpected points: %v", points)
+	}
+}
+
+// Ensure digests are equal for the same values split into different blocks
+// and files, such as a replica that has been compacted.
+func TestEngine_Digest_Compacted(t *testing.T) {
+	t.Parallel()
+
+	e1, e2 := MustOpenEngine(), MustOpenEngine()
+	defer e1.Close()
+	defer e2.Close()
+
+	// Write the values in separate snapshots on the first engine.
+	for _, points := range [][]string{
+		{`cpu,host=A value=1.1 1000000000`, `mem,host=A free=10i 1000000000`},
+		{`cpu,host=A value=1.2 2000000000`, `mem,host=A free=20i 2000000000`},
+	} {
+		if err := e1.WritePointsString(points...); err != nil {
+			t.Fatalf("failed to write points: %s", err.Error())
+		} else if err := e2.WritePointsString(points...); err != nil {
+			t.Fatalf("failed to write points: %s", err.Error())
+		}
+		e1.MustWriteSnapshot()
+	}
+	e2.MustWriteSnapshot()
+
+	// Compact the files of the first engine into a single file.
+	var paths []string
+	for _, f := range e1.FileStore.Files() {
+		paths = append(paths, f.Path())
+	}
+	if files, err := e1.Compactor.CompactFull(paths); err != nil {
+		t.Fatal(err)
+	} else if err := e1.FileStore.Replace(paths, files); err != nil {
+		t.Fatal(err)
+	}
+
+	d1, err := e1.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := e2.Digest()
+	if err != nil {
+		t.Fatal(err)
+	} else if keys := d1.Diff(d2); len(keys) != 0 {
+		t.Fatalf("unexpected differences: %v", keys)
+	} else if e := d1["mem,host=A#!~#free"]; e.MinTime != 1000000000 || e.MaxTime != 2000000000 || e.N != 2 {
+		t.Fatalf("unexpected digest entry: %+v", e)
+	}
+}
+
+// Ensure engine iterators don't return points for an interrupted query.
+func TestEngine_CreateIterator_Interrupt(t *testing.T) {
+	t.Parallel()
+
+	e := MustOpenEngine()
+	defer e.Close()
+
+	e.Index().CreateMeasurementIndexIfNotExists("cpu")
+	e.MeasurementFields("cpu").CreateFieldIfNotExists("value", influxql.Float, false)
+	e.Index().CreateSeriesIndexIfNotExists("cpu", tsdb.NewSeries("cpu,host=A", map[string]string{"host": "A"}))
+	if err := e.WritePointsString(
+		`cpu,host=A value=1.1 1000000000`,
+		`cpu,host=A value=1.2 2000000000`,
+	); err != nil {
+		t.Fatalf("failed to write points: %s", err.Error())
+	}
+
+	closing := make(chan struct{})
+	close(closing)
+
+	itr, err := e.CreateIterator(influxql.IteratorOptions{
+		Expr:        influxql.MustParseExpr(`value`),
+		Dimensions:  []string{"host"},
+		Sources:     []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+		StartTime:   influxql.MinTime,
+		EndTime:     influxql.MaxTime,
+		Ascending:   true,
+		InterruptCh: closing,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fitr := itr.(influxql.FloatIterator)
+
+	if p := fitr.Next(); p != nil {
+		t.Fatalf("expected eof: %v", p)
+	}
+}
+
 // Ensure engine can create an descending iterator for cached values.
 func TestEngine_CreateIterator_Cache_Descending(t *testing.T) {
 	t.Parallel()
@@ -415,7 +622,7 @@ func TestEngine_CreateIterator_Aux(t *testing.T) {
 
 	itr, err := e.CreateIterator(influxql.IteratorOptions{
 		Expr:       influxql.MustParseExpr(`value`),
-		Aux:        []string{"F"},
+		Aux:        []influxql.VarRef{{Val: "F"}},
 		Dimensions: []string{"host"},
 		Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
 		StartTime:  influxql.MinTime,
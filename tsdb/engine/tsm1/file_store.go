@@ -329,6 +329,46 @@ func (f *FileStore) Read(key string, t time.Time) ([]Value, error) {
 	return nil, nil
 }
 
+// ReadRange returns the values of key between min and max, inclusive, in
+// every file, oldest file first. Blocks outside the range aren't decoded.
+// Values overwritten in newer files are not removed.
+func (f *FileStore) ReadRange(key string, min, max int64) ([]Value, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var values []Value
+	for _, f := range f.files {
+		for _, entry := range f.Entries(key) {
+			if entry.MaxTime.UnixNano() < min || entry.MinTime.UnixNano() > max {
+				continue
+			}
+
+			v, err := f.ReadAt(entry, nil)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, Values(v).include(min, max)...)
+		}
+	}
+	return values, nil
+}
+
+// walkEntries calls fn with every index entry of key and the file holding
+// it, oldest file first, until fn returns false. The files can't be replaced
+// by a compaction while fn runs.
+func (f *FileStore) walkEntries(key string, fn func(f TSMFile, entry *IndexEntry) bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, f := range f.files {
+		for _, entry := range f.Entries(key) {
+			if !fn(f, entry) {
+				return
+			}
+		}
+	}
+}
+
 func (f *FileStore) KeyCursor(key string, t time.Time, ascending bool) *KeyCursor {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
@@ -47,6 +47,34 @@ func TestFileStore_Read(t *testing.T) {
 	}
 }
 
+// Ensure values are only read from the blocks in a time range.
+func TestFileStore_ReadRange(t *testing.T) {
+	fs := tsm1.NewFileStore("")
+
+	// Setup 3 files
+	data := []keyValues{
+		keyValues{"cpu", []tsm1.Value{tsm1.NewValue(time.Unix(0, 0), 1.0), tsm1.NewValue(time.Unix(1, 0), 2.0)}},
+		keyValues{"cpu", []tsm1.Value{tsm1.NewValue(time.Unix(2, 0), 3.0)}},
+		keyValues{"mem", []tsm1.Value{tsm1.NewValue(time.Unix(1, 0), 4.0)}},
+	}
+
+	files, err := newFiles(data...)
+	if err != nil {
+		t.Fatalf("unexpected error creating files: %v", err)
+	}
+
+	fs.Add(files...)
+
+	values, err := fs.ReadRange("cpu", time.Unix(1, 0).UnixNano(), time.Unix(1, 0).UnixNano())
+	if err != nil {
+		t.Fatalf("unexpected error reading values: %v", err)
+	} else if len(values) != 1 {
+		t.Fatalf("value length mismatch: got %v, exp %v", len(values), 1)
+	} else if got := values[0].Value(); got != 2.0 {
+		t.Fatalf("read value mismatch: got %v, exp %v", got, 2.0)
+	}
+}
+
 func TestFileStore_SeekToAsc_FromStart(t *testing.T) {
 	fs := tsm1.NewFileStore("")
 
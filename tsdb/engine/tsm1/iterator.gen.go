@@ -138,6 +138,11 @@ func newFloatIterator(name string, tags influxql.Tags, opt influxql.IteratorOpti
 // Next returns the next point from the iterator.
 func (itr *floatIterator) Next() *influxql.FloatPoint {
 	for {
+		// Stop decoding blocks if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		seek := tsdb.EOF
 
 		if itr.cur != nil {
@@ -191,11 +196,11 @@ type floatCursor interface {
 	nextFloat() (t int64, v float64)
 }
 
-func newFloatCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor) floatCursor {
+func newFloatCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) floatCursor {
 	if ascending {
-		return newFloatAscendingCursor(seek, cacheValues, tsmKeyCursor)
+		return newFloatAscendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 	}
-	return newFloatDescendingCursor(seek, cacheValues, tsmKeyCursor)
+	return newFloatDescendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 }
 
 type floatAscendingCursor struct {
@@ -210,10 +215,12 @@ type floatAscendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newFloatAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *floatAscendingCursor {
-	c := &floatAscendingCursor{}
+func newFloatAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *floatAscendingCursor {
+	c := &floatAscendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -223,6 +230,9 @@ func newFloatAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCu
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]FloatValue, 10)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadFloatBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -267,17 +277,20 @@ func (c *floatAscendingCursor) nextFloat() (int64, float64) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey < tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -298,6 +311,7 @@ func (c *floatAscendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -314,10 +328,12 @@ type floatDescendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newFloatDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *floatDescendingCursor {
-	c := &floatDescendingCursor{}
+func newFloatDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *floatDescendingCursor {
+	c := &floatDescendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -330,6 +346,9 @@ func newFloatDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyC
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]FloatValue, 1000)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadFloatBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -377,17 +396,20 @@ func (c *floatDescendingCursor) nextFloat() (int64, float64) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey > tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -408,6 +430,7 @@ func (c *floatDescendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -470,6 +493,11 @@ func newIntegerIterator(name string, tags influxql.Tags, opt influxql.IteratorOp
 // Next returns the next point from the iterator.
 func (itr *integerIterator) Next() *influxql.IntegerPoint {
 	for {
+		// Stop decoding blocks if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		seek := tsdb.EOF
 
 		if itr.cur != nil {
@@ -523,11 +551,11 @@ type integerCursor interface {
 	nextInteger() (t int64, v int64)
 }
 
-func newIntegerCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor) integerCursor {
+func newIntegerCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) integerCursor {
 	if ascending {
-		return newIntegerAscendingCursor(seek, cacheValues, tsmKeyCursor)
+		return newIntegerAscendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 	}
-	return newIntegerDescendingCursor(seek, cacheValues, tsmKeyCursor)
+	return newIntegerDescendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 }
 
 type integerAscendingCursor struct {
@@ -542,10 +570,12 @@ type integerAscendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newIntegerAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *integerAscendingCursor {
-	c := &integerAscendingCursor{}
+func newIntegerAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *integerAscendingCursor {
+	c := &integerAscendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -555,6 +585,9 @@ func newIntegerAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *Key
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]IntegerValue, 10)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadIntegerBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -599,17 +632,20 @@ func (c *integerAscendingCursor) nextInteger() (int64, int64) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey < tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -630,6 +666,7 @@ func (c *integerAscendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -646,10 +683,12 @@ type integerDescendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newIntegerDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *integerDescendingCursor {
-	c := &integerDescendingCursor{}
+func newIntegerDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *integerDescendingCursor {
+	c := &integerDescendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -662,6 +701,9 @@ func newIntegerDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *Ke
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]IntegerValue, 1000)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadIntegerBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -709,17 +751,20 @@ func (c *integerDescendingCursor) nextInteger() (int64, int64) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey > tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -740,6 +785,7 @@ func (c *integerDescendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -802,6 +848,11 @@ func newStringIterator(name string, tags influxql.Tags, opt influxql.IteratorOpt
 // Next returns the next point from the iterator.
 func (itr *stringIterator) Next() *influxql.StringPoint {
 	for {
+		// Stop decoding blocks if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		seek := tsdb.EOF
 
 		if itr.cur != nil {
@@ -855,11 +906,11 @@ type stringCursor interface {
 	nextString() (t int64, v string)
 }
 
-func newStringCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor) stringCursor {
+func newStringCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) stringCursor {
 	if ascending {
-		return newStringAscendingCursor(seek, cacheValues, tsmKeyCursor)
+		return newStringAscendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 	}
-	return newStringDescendingCursor(seek, cacheValues, tsmKeyCursor)
+	return newStringDescendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 }
 
 type stringAscendingCursor struct {
@@ -874,10 +925,12 @@ type stringAscendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newStringAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *stringAscendingCursor {
-	c := &stringAscendingCursor{}
+func newStringAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *stringAscendingCursor {
+	c := &stringAscendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -887,6 +940,9 @@ func newStringAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyC
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]StringValue, 10)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadStringBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -931,17 +987,20 @@ func (c *stringAscendingCursor) nextString() (int64, string) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey < tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -962,6 +1021,7 @@ func (c *stringAscendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -978,10 +1038,12 @@ type stringDescendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newStringDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *stringDescendingCursor {
-	c := &stringDescendingCursor{}
+func newStringDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *stringDescendingCursor {
+	c := &stringDescendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -994,6 +1056,9 @@ func newStringDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *Key
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]StringValue, 1000)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadStringBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -1041,17 +1106,20 @@ func (c *stringDescendingCursor) nextString() (int64, string) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey > tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -1072,6 +1140,7 @@ func (c *stringDescendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -1134,6 +1203,11 @@ func newBooleanIterator(name string, tags influxql.Tags, opt influxql.IteratorOp
 // Next returns the next point from the iterator.
 func (itr *booleanIterator) Next() *influxql.BooleanPoint {
 	for {
+		// Stop decoding blocks if the query has been interrupted.
+		if itr.opt.Interrupted() {
+			return nil
+		}
+
 		seek := tsdb.EOF
 
 		if itr.cur != nil {
@@ -1187,11 +1261,11 @@ type booleanCursor interface {
 	nextBoolean() (t int64, v bool)
 }
 
-func newBooleanCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor) booleanCursor {
+func newBooleanCursor(seek int64, ascending bool, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) booleanCursor {
 	if ascending {
-		return newBooleanAscendingCursor(seek, cacheValues, tsmKeyCursor)
+		return newBooleanAscendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 	}
-	return newBooleanDescendingCursor(seek, cacheValues, tsmKeyCursor)
+	return newBooleanDescendingCursor(seek, cacheValues, tsmKeyCursor, stats)
 }
 
 type booleanAscendingCursor struct {
@@ -1206,10 +1280,12 @@ type booleanAscendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newBooleanAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *booleanAscendingCursor {
-	c := &booleanAscendingCursor{}
+func newBooleanAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *booleanAscendingCursor {
+	c := &booleanAscendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -1219,6 +1295,9 @@ func newBooleanAscendingCursor(seek int64, cacheValues Values, tsmKeyCursor *Key
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]BooleanValue, 10)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadBooleanBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -1263,17 +1342,20 @@ func (c *booleanAscendingCursor) nextBoolean() (int64, bool) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey < tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -1294,6 +1376,7 @@ func (c *booleanAscendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
@@ -1310,10 +1393,12 @@ type booleanDescendingCursor struct {
 		pos       int
 		keyCursor *KeyCursor
 	}
+
+	stats *influxql.IteratorStats
 }
 
-func newBooleanDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor) *booleanDescendingCursor {
-	c := &booleanDescendingCursor{}
+func newBooleanDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *KeyCursor, stats *influxql.IteratorStats) *booleanDescendingCursor {
+	c := &booleanDescendingCursor{stats: stats}
 
 	c.cache.values = cacheValues
 	c.cache.pos = sort.Search(len(c.cache.values), func(i int) bool {
@@ -1326,6 +1411,9 @@ func newBooleanDescendingCursor(seek int64, cacheValues Values, tsmKeyCursor *Ke
 	c.tsm.keyCursor = tsmKeyCursor
 	c.tsm.buf = make([]BooleanValue, 1000)
 	c.tsm.values, _ = c.tsm.keyCursor.ReadBooleanBlock(c.tsm.buf)
+	if len(c.tsm.values) > 0 {
+		c.stats.AddBlocks(1)
+	}
 	c.tsm.pos = sort.Search(len(c.tsm.values), func(i int) bool {
 		return c.tsm.values[i].Time().UnixNano() >= seek
 	})
@@ -1373,17 +1461,20 @@ func (c *booleanDescendingCursor) nextBoolean() (int64, bool) {
 	if ckey == tkey {
 		c.nextCache()
 		c.nextTSM()
+		c.stats.AddFileValues(1)
 		return tkey, tvalue
 	}
 
 	// Buffered cache key precedes that in TSM file.
 	if ckey != tsdb.EOF && (ckey > tkey || tkey == tsdb.EOF) {
 		c.nextCache()
+		c.stats.AddCacheValues(1)
 		return ckey, cvalue
 	}
 
 	// Buffered TSM key precedes that in cache.
 	c.nextTSM()
+	c.stats.AddFileValues(1)
 	return tkey, tvalue
 }
 
@@ -1404,6 +1495,7 @@ func (c *booleanDescendingCursor) nextTSM() {
 		if len(c.tsm.values) == 0 {
 			return
 		}
+		c.stats.AddBlocks(1)
 		c.tsm.pos = 0
 	}
 }
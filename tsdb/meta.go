@@ -190,6 +190,33 @@ func (d *DatabaseIndex) measurementsByExpr(expr influxql.Expr) (Measurements, er
 	return nil, fmt.Errorf("%#v", expr)
 }
 
+// stripTimeExpr returns expr with all time comparisons removed.
+// Returns nil if expr only compares time.
+func stripTimeExpr(expr influxql.Expr) influxql.Expr {
+	switch e := expr.(type) {
+	case *influxql.BinaryExpr:
+		if e.Op == influxql.AND || e.Op == influxql.OR {
+			lhs, rhs := stripTimeExpr(e.LHS), stripTimeExpr(e.RHS)
+			if lhs == nil {
+				return rhs
+			} else if rhs == nil {
+				return lhs
+			}
+			return &influxql.BinaryExpr{Op: e.Op, LHS: lhs, RHS: rhs}
+		}
+		if ref, ok := e.LHS.(*influxql.VarRef); ok && strings.ToLower(ref.Val) == "time" {
+			return nil
+		}
+		return e
+	case *influxql.ParenExpr:
+		if inner := stripTimeExpr(e.Expr); inner != nil {
+			return &influxql.ParenExpr{Expr: inner}
+		}
+		return nil
+	}
+	return expr
+}
+
 // measurementsByNameFilter returns the sorted measurements matching a name.
 func (d *DatabaseIndex) measurementsByNameFilter(op influxql.Token, val string, regex *regexp.Regexp) Measurements {
 	var measurements Measurements
@@ -403,6 +430,17 @@ func (m *Measurement) HasTagKey(k string) bool {
 	return hasTag
 }
 
+// series returns all series of the measurement.
+func (m *Measurement) series() []*Series {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	a := make([]*Series, 0, len(m.seriesByID))
+	for _, s := range m.seriesByID {
+		a = append(a, s)
+	}
+	return a
+}
+
 // HasSeries returns true if there is at least 1 series under this measurement
 func (m *Measurement) HasSeries() bool {
 	m.mu.RLock()
@@ -665,6 +703,11 @@ func mergeSeriesFilters(op influxql.Token, ids SeriesIDs, lfilters, rfilters Fil
 // idsForExpr will return a collection of series ids and a filter expression that should
 // be used to filter points from those series.
 func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (SeriesIDs, influxql.Expr, error) {
+	// String functions are evaluated against the field values of each point.
+	if isStringFunctionCall(n.LHS) || isStringFunctionCall(n.RHS) {
+		return m.seriesIDs, n, nil
+	}
+
 	name, ok := n.LHS.(*influxql.VarRef)
 	value := n.RHS
 	if !ok {
@@ -682,8 +725,11 @@ func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (SeriesIDs, influxql.Ex
 
 	// For fields, return all series IDs from this measurement and return
 	// the expression passed in, as the filter.
-	if m.HasField(name.Val) {
+	if name.Type != influxql.Tag && m.HasField(name.Val) {
 		return m.seriesIDs, n, nil
+	} else if name.Type != influxql.Unknown && name.Type != influxql.Tag {
+		// Type casts and field specifiers never match a tag.
+		return nil, nil, nil
 	}
 
 	tagVals, ok := m.seriesByTagKeyValue[name.Val]
@@ -799,6 +845,16 @@ func (m *Measurement) walkWhereForSeriesIds(expr influxql.Expr) (SeriesIDs, Filt
 
 		ids, _, err := m.idsForExpr(n)
 		return ids, nil, err
+	case *influxql.Call:
+		// A string function used as a condition is evaluated for every point.
+		if !isStringFunctionCall(n) {
+			return nil, nil, nil
+		}
+		filters := FilterExprs{}
+		for _, id := range m.seriesIDs {
+			filters[id] = n
+		}
+		return m.seriesIDs, filters, nil
 	case *influxql.ParenExpr:
 		// walk down the tree
 		return m.walkWhereForSeriesIds(n.Expr)
@@ -807,6 +863,12 @@ func (m *Measurement) walkWhereForSeriesIds(expr influxql.Expr) (SeriesIDs, Filt
 	}
 }
 
+// isStringFunctionCall returns true if expr is a call to a string function.
+func isStringFunctionCall(expr influxql.Expr) bool {
+	call, ok := expr.(*influxql.Call)
+	return ok && influxql.IsStringFunction(call.Name)
+}
+
 // expandExpr returns a list of expressions expanded by all possible tag combinations.
 func (m *Measurement) expandExpr(expr influxql.Expr) []tagSetExpr {
 	// Retrieve list of unique values for each tag.
@@ -1156,6 +1218,11 @@ func (s *Series) InitializeShards() {
 	s.shardIDs = make(map[uint64]bool)
 }
 
+// AssignShard adds a shard to the list of shards the series is written to.
+func (s *Series) AssignShard(shardID uint64) {
+	s.shardIDs[shardID] = true
+}
+
 // match returns true if all tags match the series' tags.
 func (s *Series) match(tags map[string]string) bool {
 	for k, v := range tags {
@@ -0,0 +1,402 @@
+package tsdb
+
+import (
+	"sort"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+)
+
+// PointSet holds points in memory and indexes them like a shard so they can
+// be queried together with shards. It's used for values read from the other
+// owners of a shard that the local replica doesn't have.
+type PointSet struct {
+	index  *DatabaseIndex
+	fields map[string]map[string]influxql.DataType // measurement name to field types
+	series map[string]map[int64]map[string]interface{}
+	n      int
+}
+
+// NewPointSet returns a new, empty PointSet.
+func NewPointSet() *PointSet {
+	return &PointSet{
+		index:  NewDatabaseIndex(),
+		fields: make(map[string]map[string]influxql.DataType),
+		series: make(map[string]map[int64]map[string]interface{}),
+	}
+}
+
+// Len returns the number of values in the set.
+func (s *PointSet) Len() int { return s.n }
+
+// Add adds the values of points to the set. A value for a series, field and
+// timestamp already in the set is not replaced.
+func (s *PointSet) Add(points []models.Point) {
+	for _, p := range points {
+		key := string(p.Key())
+
+		s.index.mu.Lock()
+		mm := s.index.CreateMeasurementIndexIfNotExists(p.Name())
+		s.index.mu.Unlock()
+
+		rows := s.series[key]
+		if rows == nil {
+			s.index.mu.Lock()
+			s.index.CreateSeriesIndexIfNotExists(p.Name(), NewSeries(key, p.Tags()))
+			s.index.mu.Unlock()
+
+			rows = make(map[int64]map[string]interface{})
+			s.series[key] = rows
+		}
+
+		types := s.fields[mm.Name]
+		if types == nil {
+			types = make(map[string]influxql.DataType)
+			s.fields[mm.Name] = types
+		}
+
+		row := rows[p.UnixNano()]
+		if row == nil {
+			row = make(map[string]interface{})
+			rows[p.UnixNano()] = row
+		}
+
+		for name, v := range p.Fields() {
+			if _, ok := row[name]; ok {
+				continue
+			}
+			row[name] = v
+			s.n++
+
+			if _, ok := types[name]; !ok {
+				types[name] = influxql.InspectDataType(v)
+				mm.SetFieldName(name)
+			}
+		}
+	}
+}
+
+// CreateIterator returns an iterator over the values of the set.
+func (s *PointSet) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+	if call, ok := opt.Expr.(*influxql.Call); ok {
+		refOpt := opt
+		refOpt.Expr = call.Args[0].(*influxql.VarRef)
+		itrs, err := s.createVarRefIterators(refOpt)
+		if err != nil {
+			return nil, err
+		}
+		return influxql.NewCallIterator(influxql.NewMergeIterator(itrs, opt), opt), nil
+	}
+
+	itrs, err := s.createVarRefIterators(opt)
+	if err != nil {
+		return nil, err
+	}
+	return influxql.NewSortedMergeIterator(itrs, opt), nil
+}
+
+// createVarRefIterators returns an iterator for every series matching opt.
+func (s *PointSet) createVarRefIterators(opt influxql.IteratorOptions) ([]influxql.Iterator, error) {
+	ref, _ := opt.Expr.(*influxql.VarRef)
+
+	// Retrieve non-time names from condition (includes tags).
+	conditionNames := influxql.ExprNames(opt.Condition)
+
+	var itrs []influxql.Iterator
+	for _, mm := range s.index.MeasurementsByName(influxql.Sources(opt.Sources).Names()) {
+		tagSets, err := mm.TagSets(opt.Dimensions, opt.Condition)
+		if err != nil {
+			return nil, err
+		}
+		tagSets = influxql.LimitTagSets(tagSets, opt.SLimit, opt.SOffset)
+
+		types := s.fields[mm.Name]
+		var conditionFields []string
+		for _, ref := range conditionNames {
+			if _, ok := types[ref.Val]; ok && ref.Type != influxql.Tag {
+				conditionFields = append(conditionFields, ref.Val)
+			}
+		}
+
+		for _, t := range tagSets {
+			for i, key := range t.SeriesKeys {
+				if itr := s.createSeriesIterator(ref, mm.Name, key, t.Filters[i], conditionFields, opt); itr != nil {
+					itrs = append(itrs, itr)
+				}
+			}
+		}
+	}
+	return itrs, nil
+}
+
+// createSeriesIterator returns an iterator for ref of a single series or nil
+// if the series doesn't have the field.
+func (s *PointSet) createSeriesIterator(ref *influxql.VarRef, name, key string, filter influxql.Expr, conditionFields []string, opt influxql.IteratorOptions) influxql.Iterator {
+	tags := influxql.NewTags(s.index.TagsForSeries(key))
+	types := s.fields[name]
+
+	// Tags cannot be iterated over as the main expression.
+	typ := influxql.DataType(influxql.Float)
+	if ref != nil {
+		if ref.Type == influxql.Tag {
+			return nil
+		} else if typ = types[ref.Val]; typ == influxql.Unknown {
+			return nil
+		}
+	}
+
+	// Order the timestamps within the time range.
+	rows := s.series[key]
+	times := make([]int64, 0, len(rows))
+	for t := range rows {
+		if t >= opt.StartTime && t <= opt.EndTime {
+			times = append(times, t)
+		}
+	}
+	sort.Sort(int64Slice(times))
+	if !opt.Ascending {
+		sort.Sort(sort.Reverse(int64Slice(times)))
+	}
+
+	dimTags := tags.Subset(opt.Dimensions)
+
+	var m map[string]interface{}
+	if filter != nil {
+		m = make(map[string]interface{}, len(conditionFields))
+	}
+
+	var itr pointsIterator
+	for _, t := range times {
+		row := rows[t]
+
+		// Without a main expression only the times with an auxiliary field
+		// are returned.
+		var value interface{}
+		if ref != nil {
+			if value = row[ref.Val]; value == nil {
+				continue
+			}
+		} else if !hasAuxField(row, opt.Aux, types) {
+			continue
+		}
+
+		// Evaluate condition, if one exists.
+		if filter != nil {
+			for _, f := range conditionFields {
+				m[f] = row[f]
+			}
+			if !influxql.EvalBool(filter, m) {
+				continue
+			}
+		}
+
+		// Field values are returned for auxiliary fields and tag values
+		// if the field doesn't exist.
+		var aux []interface{}
+		if len(opt.Aux) > 0 {
+			aux = make([]interface{}, len(opt.Aux))
+			for i, ref := range opt.Aux {
+				if _, ok := types[ref.Val]; ok && ref.Type != influxql.Tag {
+					aux[i] = row[ref.Val]
+				} else if v := tags.Value(ref.Val); v != "" && isTagRef(ref) {
+					aux[i] = v
+				}
+			}
+		}
+
+		itr.add(typ, name, dimTags, t, value, aux)
+	}
+	if ref == nil {
+		return itr.iterator(typ)
+	}
+	return influxql.NewCastIterator(itr.iterator(typ), ref.Type)
+}
+
+// FieldDimensions returns the unique fields and dimensions of the sources.
+func (s *PointSet) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+	fields = make(map[string]influxql.DataType)
+	dimensions = make(map[string]struct{})
+
+	for _, src := range sources {
+		switch m := src.(type) {
+		case *influxql.Measurement:
+			mm := s.index.Measurement(m.Name)
+			if mm == nil {
+				continue
+			}
+
+			for name, typ := range s.fields[mm.Name] {
+				fields[name] = mergeFieldType(fields[name], typ)
+			}
+			for _, key := range mm.TagKeys() {
+				dimensions[key] = struct{}{}
+			}
+		}
+	}
+	return
+}
+
+// SeriesKeys returns the series of the set matching opt.
+func (s *PointSet) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList, error) {
+	var seriesList influxql.SeriesList
+	for _, mm := range s.index.MeasurementsByName(influxql.Sources(opt.Sources).Names()) {
+		tagSets, err := mm.TagSets(opt.Dimensions, opt.Condition)
+		if err != nil {
+			return nil, err
+		}
+		tagSets = influxql.LimitTagSets(tagSets, opt.SLimit, opt.SOffset)
+
+		types := s.fields[mm.Name]
+		for _, t := range tagSets {
+			tagMap := make(map[string]string)
+			for k, v := range t.Tags {
+				if v != "" {
+					tagMap[k] = v
+				}
+			}
+
+			series := influxql.Series{
+				Name: mm.Name,
+				Tags: influxql.NewTags(tagMap),
+				Aux:  make([]influxql.DataType, len(opt.Aux)),
+			}
+
+			// Determine the aux field types.
+			for _, key := range t.SeriesKeys {
+				tags := influxql.NewTags(s.index.TagsForSeries(key))
+				for i, ref := range opt.Aux {
+					typ := influxql.Unknown
+					if ref.Type != influxql.Tag {
+						typ = types[ref.Val]
+					}
+					if typ == influxql.Unknown && isTagRef(ref) && tags.Value(ref.Val) != "" {
+						typ = influxql.String
+					}
+					series.Aux[i] = mergeFieldType(series.Aux[i], typ)
+				}
+			}
+			seriesList = append(seriesList, series)
+		}
+	}
+	return seriesList, nil
+}
+
+// hasAuxField returns true if row has a value for one of the auxiliary fields.
+func hasAuxField(row map[string]interface{}, aux []influxql.VarRef, types map[string]influxql.DataType) bool {
+	for _, ref := range aux {
+		if _, ok := types[ref.Val]; ok && ref.Type != influxql.Tag && row[ref.Val] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isTagRef returns true if ref can be resolved to a tag value.
+// Type casts and field specifiers only refer to fields.
+func isTagRef(ref influxql.VarRef) bool {
+	return ref.Type == influxql.Unknown || ref.Type == influxql.Tag
+}
+
+// pointsIterator collects the points of a series so they can be returned by
+// an iterator of the series' type.
+type pointsIterator struct {
+	floats   []influxql.FloatPoint
+	integers []influxql.IntegerPoint
+	strings  []influxql.StringPoint
+	booleans []influxql.BooleanPoint
+}
+
+// add appends a point. A nil value is only valid for float points.
+func (itr *pointsIterator) add(typ influxql.DataType, name string, tags influxql.Tags, t int64, v interface{}, aux []interface{}) {
+	switch typ {
+	case influxql.Float:
+		f, _ := v.(float64)
+		itr.floats = append(itr.floats, influxql.FloatPoint{Name: name, Tags: tags, Time: t, Value: f, Aux: aux})
+	case influxql.Integer:
+		itr.integers = append(itr.integers, influxql.IntegerPoint{Name: name, Tags: tags, Time: t, Value: v.(int64), Aux: aux})
+	case influxql.String:
+		itr.strings = append(itr.strings, influxql.StringPoint{Name: name, Tags: tags, Time: t, Value: v.(string), Aux: aux})
+	case influxql.Boolean:
+		itr.booleans = append(itr.booleans, influxql.BooleanPoint{Name: name, Tags: tags, Time: t, Value: v.(bool), Aux: aux})
+	}
+}
+
+// iterator returns an iterator over the points of type typ.
+func (itr *pointsIterator) iterator(typ influxql.DataType) influxql.Iterator {
+	switch typ {
+	case influxql.Integer:
+		return &integerSliceIterator{points: itr.integers}
+	case influxql.String:
+		return &stringSliceIterator{points: itr.strings}
+	case influxql.Boolean:
+		return &booleanSliceIterator{points: itr.booleans}
+	default:
+		return &floatSliceIterator{points: itr.floats}
+	}
+}
+
+type floatSliceIterator struct {
+	points []influxql.FloatPoint
+}
+
+func (itr *floatSliceIterator) Close() error { return nil }
+
+func (itr *floatSliceIterator) Next() *influxql.FloatPoint {
+	if len(itr.points) == 0 {
+		return nil
+	}
+	p := &itr.points[0]
+	itr.points = itr.points[1:]
+	return p
+}
+
+type integerSliceIterator struct {
+	points []influxql.IntegerPoint
+}
+
+func (itr *integerSliceIterator) Close() error { return nil }
+
+func (itr *integerSliceIterator) Next() *influxql.IntegerPoint {
+	if len(itr.points) == 0 {
+		return nil
+	}
+	p := &itr.points[0]
+	itr.points = itr.points[1:]
+	return p
+}
+
+type stringSliceIterator struct {
+	points []influxql.StringPoint
+}
+
+func (itr *stringSliceIterator) Close() error { return nil }
+
+func (itr *stringSliceIterator) Next() *influxql.StringPoint {
+	if len(itr.points) == 0 {
+		return nil
+	}
+	p := &itr.points[0]
+	itr.points = itr.points[1:]
+	return p
+}
+
+type booleanSliceIterator struct {
+	points []influxql.BooleanPoint
+}
+
+func (itr *booleanSliceIterator) Close() error { return nil }
+
+func (itr *booleanSliceIterator) Next() *influxql.BooleanPoint {
+	if len(itr.points) == 0 {
+		return nil
+	}
+	p := &itr.points[0]
+	itr.points = itr.points[1:]
+	return p
+}
+
+type int64Slice []int64
+
+func (a int64Slice) Len() int           { return len(a) }
+func (a int64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a int64Slice) Less(i, j int) bool { return a[i] < a[j] }
@@ -0,0 +1,114 @@
+package tsdb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/pkg/deep"
+	"github.com/influxdata/influxdb/tsdb"
+)
+
+// Ensure a value already in a point set is not replaced.
+func TestPointSet_Add(t *testing.T) {
+	s := tsdb.NewPointSet()
+	s.Add(MustParsePointsString(`
+cpu,host=serverA value=100 0
+cpu,host=serverA value=50,val2=5 10
+`))
+	s.Add(MustParsePointsString(`
+cpu,host=serverA value=200 0
+cpu,host=serverB value=25 0
+`))
+	if n := s.Len(); n != 4 {
+		t.Fatalf("unexpected len: %d", n)
+	}
+
+	itr, err := s.CreateIterator(influxql.IteratorOptions{
+		Expr:      influxql.MustParseExpr(`value`),
+		Sources:   []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+		Condition: influxql.MustParseExpr(`host = 'serverA'`),
+		Ascending: true,
+		StartTime: influxql.MinTime,
+		EndTime:   influxql.MaxTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+	fitr := itr.(influxql.FloatIterator)
+
+	if p := fitr.Next(); p == nil || p.Value != 100 {
+		t.Fatalf("unexpected point(0): %s", spew.Sdump(p))
+	}
+}
+
+// Ensure a point set can create iterators for its values.
+func TestPointSet_CreateIterator(t *testing.T) {
+	s := tsdb.NewPointSet()
+	s.Add(MustParsePointsString(`
+cpu,host=serverA,region=uswest value=100 0
+cpu,host=serverA,region=uswest value=50,val2=5 10
+cpu,host=serverB,region=uswest value=25 0
+`))
+
+	itr, err := s.CreateIterator(influxql.IteratorOptions{
+		Expr:       influxql.MustParseExpr(`value`),
+		Aux:        []influxql.VarRef{{Val: "val2"}},
+		Dimensions: []string{"host"},
+		Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+		Ascending:  true,
+		StartTime:  influxql.MinTime,
+		EndTime:    influxql.MaxTime,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+	fitr := itr.(influxql.FloatIterator)
+
+	if p := fitr.Next(); !deep.Equal(p, &influxql.FloatPoint{
+		Name:  "cpu",
+		Tags:  influxql.NewTags(map[string]string{"host": "serverA"}),
+		Time:  0,
+		Value: 100,
+		Aux:   []interface{}{nil},
+	}) {
+		t.Fatalf("unexpected point(0): %s", spew.Sdump(p))
+	}
+
+	if p := fitr.Next(); !deep.Equal(p, &influxql.FloatPoint{
+		Name:  "cpu",
+		Tags:  influxql.NewTags(map[string]string{"host": "serverA"}),
+		Time:  10,
+		Value: 50,
+		Aux:   []interface{}{float64(5)},
+	}) {
+		t.Fatalf("unexpected point(1): %s", spew.Sdump(p))
+	}
+
+	if p := fitr.Next(); !deep.Equal(p, &influxql.FloatPoint{
+		Name:  "cpu",
+		Tags:  influxql.NewTags(map[string]string{"host": "serverB"}),
+		Time:  0,
+		Value: 25,
+		Aux:   []interface{}{nil},
+	}) {
+		t.Fatalf("unexpected point(2): %s", spew.Sdump(p))
+	}
+
+	if p := fitr.Next(); p != nil {
+		t.Fatalf("unexpected point(3): %s", spew.Sdump(p))
+	}
+}
+
+// MustParsePointsString parses line protocol points. Panic on error.
+func MustParsePointsString(s string) []models.Point {
+	a, err := models.ParsePointsString(strings.TrimSpace(s))
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
@@ -6,10 +6,13 @@ import (
 	"log"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/influxdb/influxql"
 	"github.com/influxdata/influxdb/models"
+	"github.com/influxdata/influxdb/pkg/estimator/hll"
 	"github.com/influxdata/influxdb/services/meta"
 )
 
@@ -46,6 +49,33 @@ type QueryExecutor struct {
 		ExecuteStatement(stmt influxql.Statement) *influxql.Result
 	}
 
+	// Execute statements that copy and remove shards between data nodes.
+	CopierStatementExecutor interface {
+		ExecuteStatement(stmt influxql.Statement) *influxql.Result
+	}
+
+	// Execute statements relating to the shard rebalancer.
+	RebalancerStatementExecutor interface {
+		ExecuteStatement(stmt influxql.Statement) *influxql.Result
+	}
+
+	// Execute statements that compare shards with their replicas.
+	AntiEntropyStatementExecutor interface {
+		ExecuteStatement(stmt influxql.Statement) *influxql.Result
+	}
+
+	// Execute statements that inspect and control hinted handoff.
+	HintedHandoffStatementExecutor interface {
+		ExecuteStatement(stmt influxql.Statement) *influxql.Result
+	}
+
+	// Reads the shards of SELECT statements from their other owners when a
+	// read consistency level above one is requested. The returned points
+	// are the values the local shards don't have.
+	ShardReader interface {
+		ReadShards(shardIDs []uint64, opt ShardReadOptions, level ReadConsistency) (*PointSet, error)
+	}
+
 	IntoWriter interface {
 		WritePointsInto(p *IntoWriteRequest) error
 	}
@@ -135,6 +165,12 @@ func (q *QueryExecutor) Authorize(u *meta.UserInfo, query *influxql.Query, datab
 // It sends results down the passed in chan and closes it when done. It will close the chan
 // on the first statement that throws an error.
 func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int, closing chan struct{}) (<-chan *influxql.Result, error) {
+	return q.ExecuteQueryWithConsistency(query, database, chunkSize, ReadConsistencyOne, closing)
+}
+
+// ExecuteQueryWithConsistency executes a query like ExecuteQuery but reads the
+// shards of SELECT statements from as many of their owners as level requires.
+func (q *QueryExecutor) ExecuteQueryWithConsistency(query *influxql.Query, database string, chunkSize int, level ReadConsistency, closing chan struct{}) (<-chan *influxql.Result, error) {
 	// Execute each statement. Keep the iterator external so we can
 	// track how many of the statements were executed
 	results := make(chan *influxql.Result)
@@ -144,6 +180,7 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 
 		var i int
 		var stmt influxql.Statement
+	loop:
 		for i, stmt = range query.Statements {
 			// If a default database wasn't passed in by the caller, check the statement.
 			// Some types of statements have an associated default database, even if it
@@ -169,10 +206,12 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 			var res *influxql.Result
 			switch stmt := stmt.(type) {
 			case *influxql.SelectStatement:
-				if err := q.executeStatement(i, stmt, database, results, chunkSize, closing); err != nil {
-					results <- &influxql.Result{Err: err}
-					break
+				if err := q.executeStatement(i, stmt, database, results, chunkSize, level, closing); err != nil {
+					results <- &influxql.Result{StatementID: i, Err: err}
+					break loop
 				}
+			case *influxql.ExplainStatement:
+				res = q.executeExplainStatement(stmt, closing)
 			case *influxql.DropSeriesStatement:
 				// TODO: handle this in a cluster
 				res = q.executeDropSeriesStatement(stmt, database)
@@ -182,19 +221,29 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 				// TODO: handle this in a cluster
 				res = q.executeDropMeasurementStatement(stmt, database)
 			case *influxql.ShowMeasurementsStatement:
-				if err := q.executeStatement(i, stmt, database, results, chunkSize, closing); err != nil {
-					results <- &influxql.Result{Err: err}
-					break
+				if err := q.executeStatement(i, stmt, database, results, chunkSize, level, closing); err != nil {
+					results <- &influxql.Result{StatementID: i, Err: err}
+					break loop
 				}
 			case *influxql.ShowTagKeysStatement:
-				if err := q.executeStatement(i, stmt, database, results, chunkSize, closing); err != nil {
-					results <- &influxql.Result{Err: err}
-					break
+				if err := q.executeStatement(i, stmt, database, results, chunkSize, level, closing); err != nil {
+					results <- &influxql.Result{StatementID: i, Err: err}
+					break loop
 				}
 			case *influxql.ShowTagValuesStatement:
 				res = q.executeShowTagValuesStatement(stmt, database)
 			case *influxql.ShowFieldKeysStatement:
 				res = q.executeShowFieldKeysStatement(stmt, database)
+			case *influxql.ShowSeriesCardinalityStatement:
+				res = q.executeShowSeriesCardinalityStatement(stmt, database)
+			case *influxql.ShowMeasurementCardinalityStatement:
+				res = q.executeShowMeasurementCardinalityStatement(stmt, database)
+			case *influxql.ShowTagKeyCardinalityStatement:
+				res = q.executeShowTagKeyCardinalityStatement(stmt, database)
+			case *influxql.ShowTagValuesCardinalityStatement:
+				res = q.executeShowTagValuesCardinalityStatement(stmt, database)
+			case *influxql.ShowFieldKeyCardinalityStatement:
+				res = q.executeShowFieldKeyCardinalityStatement(stmt, database)
 			case *influxql.DeleteStatement:
 				res = &influxql.Result{Err: ErrInvalidQuery}
 			case *influxql.DropDatabaseStatement:
@@ -203,6 +252,27 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 			case *influxql.ShowStatsStatement, *influxql.ShowDiagnosticsStatement:
 				// Send monitor-related queries to the monitor service.
 				res = q.MonitorStatementExecutor.ExecuteStatement(stmt)
+			case *influxql.CopyShardStatement, *influxql.RemoveShardStatement:
+				// Send shard transfers to the copier which moves the shard's data.
+				res = q.CopierStatementExecutor.ExecuteStatement(stmt)
+			case *influxql.ShowRebalanceStatement:
+				// Send rebalancer queries to the rebalancer if it's enabled.
+				if q.RebalancerStatementExecutor == nil {
+					res = &influxql.Result{Err: ErrRebalancerDisabled}
+				} else {
+					res = q.RebalancerStatementExecutor.ExecuteStatement(stmt)
+				}
+			case *influxql.ShowShardDifferencesStatement:
+				// Send replica comparisons to the anti-entropy service if it's enabled.
+				if q.AntiEntropyStatementExecutor == nil {
+					res = &influxql.Result{Err: ErrAntiEntropyDisabled}
+				} else {
+					res = q.AntiEntropyStatementExecutor.ExecuteStatement(stmt)
+				}
+			case *influxql.ShowHintedHandoffStatement, *influxql.PurgeHintedHandoffStatement,
+				*influxql.PauseHintedHandoffStatement, *influxql.ResumeHintedHandoffStatement:
+				// Send hinted handoff queries to the local hinted handoff service.
+				res = q.HintedHandoffStatementExecutor.ExecuteStatement(stmt)
 			default:
 				// Delegate all other meta statements to a separate executor. They don't hit tsdb storage.
 				res = q.MetaClient.ExecuteStatement(stmt)
@@ -230,7 +300,40 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 }
 
 // PlanSelect creates an execution plan for the given SelectStatement and returns an Executor.
-func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int) (Executor, error) {
+// Iterators stop reading from shards once closing is closed.
+func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int, closing <-chan struct{}) (Executor, error) {
+	return q.planSelect(stmt, chunkSize, ReadConsistencyOne, closing)
+}
+
+// planSelect creates an execution plan for stmt reading shards at the read
+// consistency level.
+func (q *QueryExecutor) planSelect(stmt *influxql.SelectStatement, chunkSize int, level ReadConsistency, closing <-chan struct{}) (Executor, error) {
+	stmt, _, ic, opt, err := q.prepareSelect(stmt, level)
+	if err != nil {
+		return nil, err
+	}
+	opt.InterruptCh = closing
+
+	// Create a set of iterators from a selection.
+	itrs, err := influxql.Select(stmt, ic, &opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate a row emitter from the iterator set.
+	em := influxql.NewEmitter(itrs, stmt.TimeAscending())
+	em.Columns = stmt.ColumnNames()
+	em.OmitTime = stmt.OmitTime
+
+	// Wrap emitter in an adapter to conform to the Executor interface.
+	return (*emitterExecutor)(em), nil
+}
+
+// prepareSelect rewrites stmt for execution and returns the local shards it
+// will read from. Above a read consistency level of one, the values read from
+// the other owners of the shards are combined with the local shards by the
+// returned iterator creator.
+func (q *QueryExecutor) prepareSelect(stmt *influxql.SelectStatement, level ReadConsistency) (*influxql.SelectStatement, Shards, influxql.IteratorCreator, influxql.SelectOptions, error) {
 	// It is important to "stamp" this time so that everywhere we evaluate `now()` in the statement is EXACTLY the same `now`
 	now := time.Now().UTC()
 	opt := influxql.SelectOptions{}
@@ -248,7 +351,7 @@ func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int
 	// Expand regex sources to their actual source names.
 	sources, err := q.Store.ExpandSources(stmt.Sources)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, opt, err
 	}
 	stmt.Sources = sources
 
@@ -258,33 +361,83 @@ func (q *QueryExecutor) PlanSelect(stmt *influxql.SelectStatement, chunkSize int
 	// Remove "time" from fields list.
 	stmt.RewriteTimeFields()
 
-	// Filter only shards that contain date range.
-	shardIDs, err := q.MetaClient.ShardIDsByTimeRange(stmt.Sources, opt.MinTime, opt.MaxTime)
+	// Filter only shards that contain date range. System sources read
+	// the shards of every retention policy in the database.
+	shardSources := stmt.Sources
+	if stmt.Sources.HasSystemSource() {
+		if m, ok := stmt.Sources[0].(*influxql.Measurement); ok {
+			if shardSources, err = q.retentionPolicySources(m.Database); err != nil {
+				return nil, nil, nil, opt, err
+			}
+		}
+	}
+	shardIDs, err := q.MetaClient.ShardIDsByTimeRange(shardSources, opt.MinTime, opt.MaxTime)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, opt, err
+	}
+	shards := Shards(q.Store.Shards(shardIDs))
+
+	// Read the values missing from the local shards from the other owners.
+	var ic influxql.IteratorCreator = shards
+	if level > ReadConsistencyOne && !stmt.Sources.HasSystemSource() {
+		if q.ShardReader == nil {
+			return nil, nil, nil, opt, ErrReadConsistencyNotSupported
+		}
+
+		points, err := q.ShardReader.ReadShards(shardIDs, ShardReadOptions{
+			Sources:   stmt.Sources,
+			Condition: stmt.Condition,
+			MinTime:   opt.MinTime,
+			MaxTime:   opt.MaxTime,
+		}, level)
+		if err != nil {
+			return nil, nil, nil, opt, err
+		} else if points != nil && points.Len() > 0 {
+			ic = &shardsWithPoints{shards: shards, points: points}
+		}
 	}
-	shards := q.Store.Shards(shardIDs)
 
 	// Rewrite wildcards, if any exist.
-	tmp, err := stmt.RewriteWildcards(Shards(shards))
+	tmp, err := stmt.RewriteWildcards(ic)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, opt, err
 	}
-	stmt = tmp
+	return tmp, shards, ic, opt, nil
+}
 
-	// Create a set of iterators from a selection.
-	itrs, err := influxql.Select(stmt, Shards(shards), &opt)
+// executeExplainStatement returns the plan for a SELECT statement. If the
+// statement is being analyzed then it is executed and its statistics are
+// included in the plan.
+func (q *QueryExecutor) executeExplainStatement(stmt *influxql.ExplainStatement, closing <-chan struct{}) *influxql.Result {
+	sel, shards, _, opt, err := q.prepareSelect(stmt.Statement, ReadConsistencyOne)
 	if err != nil {
-		return nil, err
+		return &influxql.Result{Err: err}
 	}
 
-	// Generate a row emitter from the iterator set.
-	em := influxql.NewEmitter(itrs, stmt.TimeAscending())
-	em.Columns = stmt.ColumnNames()
-	em.OmitTime = stmt.OmitTime
+	var nodes []*influxql.ExplainNode
+	if stmt.Analyze {
+		opt.InterruptCh = closing
+		nodes, err = influxql.AnalyzeSelect(sel, shards, &opt, closing)
+	} else {
+		nodes, err = influxql.ExplainSelect(sel, shards, &opt)
+	}
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
 
-	// Wrap emitter in an adapter to conform to the Executor interface.
-	return (*emitterExecutor)(em), nil
+	ids := make([]string, len(shards))
+	for i, sh := range shards {
+		ids[i] = strconv.FormatUint(sh.id, 10)
+	}
+
+	row := &models.Row{Columns: []string{"QUERY PLAN"}}
+	row.Values = append(row.Values, []interface{}{"SHARDS: " + strings.Join(ids, ", ")})
+	for _, n := range nodes {
+		for _, line := range n.Lines() {
+			row.Values = append(row.Values, []interface{}{line})
+		}
+	}
+	return &influxql.Result{Series: models.Rows{row}}
 }
 
 // executeDropDatabaseStatement closes all local shards for the database and removes the directory. It then calls to the metastore to remove the database from there.
@@ -397,17 +550,19 @@ func (q *QueryExecutor) executeDropSeriesStatement(stmt *influxql.DropSeriesStat
 }
 
 func (q *QueryExecutor) executeShowSeriesStatement(stmt *influxql.ShowSeriesStatement, database string) *influxql.Result {
-	// Check for time in WHERE clause (not supported).
-	if influxql.HasTimeExpr(stmt.Condition) {
-		return &influxql.Result{Err: errors.New("SHOW SERIES doesn't support time in WHERE clause")}
-	}
-
 	// Find the database.
 	db := q.Store.DatabaseIndex(database)
 	if db == nil {
 		return &influxql.Result{}
 	}
 
+	// Find the shards in the time range, if there is one.
+	stmt.Condition = influxql.Reduce(stmt.Condition, &influxql.NowValuer{Now: time.Now().UTC()})
+	tr, err := q.newMetaTimeRange(database, stmt.Condition)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
 	// Expand regex expressions in the FROM clause.
 	sources, err := q.Store.ExpandSources(stmt.Sources)
 	if err != nil {
@@ -444,16 +599,21 @@ func (q *QueryExecutor) executeShowSeriesStatement(stmt *influxql.ShowSeriesStat
 			if filters.Len() > 0 {
 				return &influxql.Result{Err: errors.New("SHOW SERIES doesn't support fields in WHERE clause")}
 			}
-
-			// If no series matched, then go to the next measurement.
-			if len(ids) == 0 {
-				continue
-			}
 		} else {
 			// No WHERE clause so get all series IDs for this measurement.
 			ids = m.seriesIDs
 		}
 
+		// Filter out series without data in the time range.
+		if tr != nil {
+			ids = tr.filter(m, ids)
+		}
+
+		// If no series matched, then go to the next measurement.
+		if len(ids) == 0 {
+			continue
+		}
+
 		// Make a new row for this measurement.
 		r := &models.Row{
 			Name:    m.Name,
@@ -519,26 +679,21 @@ func (q *QueryExecutor) filterShowSeriesResult(limit, offset int, rows models.Ro
 	return filteredSeries
 }
 
-func (q *QueryExecutor) planStatement(stmt influxql.Statement, database string, chunkSize int) (Executor, error) {
+func (q *QueryExecutor) planStatement(stmt influxql.Statement, database string, chunkSize int, level ReadConsistency, closing <-chan struct{}) (Executor, error) {
 	switch stmt := stmt.(type) {
 	case *influxql.SelectStatement:
-		return q.PlanSelect(stmt, chunkSize)
+		return q.planSelect(stmt, chunkSize, level, closing)
 	case *influxql.ShowMeasurementsStatement:
-		return q.planShowMeasurements(stmt, database, chunkSize)
+		return q.planShowMeasurements(stmt, database, chunkSize, closing)
 	case *influxql.ShowTagKeysStatement:
-		return q.planShowTagKeys(stmt, database, chunkSize)
+		return q.planShowTagKeys(stmt, database, chunkSize, closing)
 	default:
 		return nil, fmt.Errorf("can't plan statement type: %v", stmt)
 	}
 }
 
 // planShowMeasurements converts the statement to a SELECT and executes it.
-func (q *QueryExecutor) planShowMeasurements(stmt *influxql.ShowMeasurementsStatement, database string, chunkSize int) (Executor, error) {
-	// Check for time in WHERE clause (not supported).
-	if influxql.HasTimeExpr(stmt.Condition) {
-		return nil, errors.New("SHOW MEASUREMENTS doesn't support time in WHERE clause")
-	}
-
+func (q *QueryExecutor) planShowMeasurements(stmt *influxql.ShowMeasurementsStatement, database string, chunkSize int, closing <-chan struct{}) (Executor, error) {
 	condition := stmt.Condition
 	if source, ok := stmt.Source.(*influxql.Measurement); ok {
 		var expr influxql.Expr
@@ -584,11 +739,11 @@ func (q *QueryExecutor) planShowMeasurements(stmt *influxql.ShowMeasurementsStat
 		return nil, err
 	}
 
-	return q.PlanSelect(ss, chunkSize)
+	return q.PlanSelect(ss, chunkSize, closing)
 }
 
 // planShowTagKeys creates an execution plan for a SHOW MEASUREMENTS statement and returns an Executor.
-func (q *QueryExecutor) planShowTagKeys(stmt *influxql.ShowTagKeysStatement, database string, chunkSize int) (Executor, error) {
+func (q *QueryExecutor) planShowTagKeys(stmt *influxql.ShowTagKeysStatement, database string, chunkSize int, closing <-chan struct{}) (Executor, error) {
 	// Check for time in WHERE clause (not supported).
 	if influxql.HasTimeExpr(stmt.Condition) {
 		return nil, errors.New("SHOW TAG KEYS doesn't support time in WHERE clause")
@@ -641,12 +796,12 @@ func (q *QueryExecutor) planShowTagKeys(stmt *influxql.ShowTagKeysStatement, dat
 		return nil, err
 	}
 
-	return q.PlanSelect(ss, chunkSize)
+	return q.PlanSelect(ss, chunkSize, closing)
 }
 
-func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statement, database string, results chan *influxql.Result, chunkSize int, closing chan struct{}) error {
+func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statement, database string, results chan *influxql.Result, chunkSize int, level ReadConsistency, closing chan struct{}) error {
 	// Plan statement execution.
-	e, err := q.planStatement(stmt, database, chunkSize)
+	e, err := q.planStatement(stmt, database, chunkSize, level, closing)
 	if err != nil {
 		return err
 	}
@@ -671,13 +826,22 @@ func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statemen
 		if ok && selectstmt.Target != nil {
 			isinto = true
 			// this is a into query. Write results back to database
-			writeerr = q.writeInto(row, selectstmt)
-			intoNum += int64(len(row.Values))
+			var n int
+			n, writeerr = q.writeInto(row, selectstmt)
+			intoNum += int64(n)
 		} else {
 			resultSent = true
 			results <- &influxql.Result{StatementID: statementID, Series: []*models.Row{row}}
 		}
 	}
+
+	// Results are incomplete if the query was interrupted.
+	select {
+	case <-closing:
+		return influxql.ErrQueryInterrupted
+	default:
+	}
+
 	if writeerr != nil {
 		return writeerr
 	} else if isinto {
@@ -703,7 +867,8 @@ func (q *QueryExecutor) executeStatement(statementID int, stmt influxql.Statemen
 	return nil
 }
 
-func (q *QueryExecutor) writeInto(row *models.Row, selectstmt *influxql.SelectStatement) error {
+// writeInto writes row to the target of selectstmt and returns the number of points written.
+func (q *QueryExecutor) writeInto(row *models.Row, selectstmt *influxql.SelectStatement) (int, error) {
 	// It might seem a bit weird that this is where we do this, since we will have to
 	// convert rows back to points. The Executors (both aggregate and raw) are complex
 	// enough that changing them to write back to the DB is going to be clumsy
@@ -717,12 +882,14 @@ func (q *QueryExecutor) writeInto(row *models.Row, selectstmt *influxql.SelectSt
 	}
 	intodb, err := intoDB(selectstmt)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	rp := intoRP(selectstmt)
 	points, err := convertRowToPoints(measurement, row)
 	if err != nil {
-		return err
+		return 0, err
+	} else if len(points) == 0 {
+		return 0, nil
 	}
 	req := &IntoWriteRequest{
 		Database:        intodb,
@@ -731,23 +898,25 @@ func (q *QueryExecutor) writeInto(row *models.Row, selectstmt *influxql.SelectSt
 	}
 	err = q.IntoWriter.WritePointsInto(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return len(points), nil
 }
 
 func (q *QueryExecutor) executeShowTagValuesStatement(stmt *influxql.ShowTagValuesStatement, database string) *influxql.Result {
-	// Check for time in WHERE clause (not supported).
-	if influxql.HasTimeExpr(stmt.Condition) {
-		return &influxql.Result{Err: errors.New("SHOW TAG VALUES doesn't support time in WHERE clause")}
-	}
-
 	// Find the database.
 	db := q.Store.DatabaseIndex(database)
 	if db == nil {
 		return &influxql.Result{}
 	}
 
+	// Find the shards in the time range, if there is one.
+	stmt.Condition = influxql.Reduce(stmt.Condition, &influxql.NowValuer{Now: time.Now().UTC()})
+	tr, err := q.newMetaTimeRange(database, stmt.Condition)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
 	// Expand regex expressions in the FROM clause.
 	sources, err := q.Store.ExpandSources(stmt.Sources)
 	if err != nil {
@@ -776,17 +945,22 @@ func (q *QueryExecutor) executeShowTagValuesStatement(stmt *influxql.ShowTagValu
 				return &influxql.Result{Err: err}
 			}
 
-			// If no series matched, then go to the next measurement.
-			if len(ids) == 0 {
-				continue
-			}
-
 			// TODO: check return of walkWhereForSeriesIds for fields
 		} else {
 			// No WHERE clause so get all series IDs for this measurement.
 			ids = m.seriesIDs
 		}
 
+		// Filter out series without data in the time range.
+		if tr != nil {
+			ids = tr.filter(m, ids)
+		}
+
+		// If no series matched, then go to the next measurement.
+		if len(ids) == 0 {
+			continue
+		}
+
 		for k, v := range m.tagValuesByKeyAndSeriesID(stmt.TagKeys, ids) {
 			_, ok := tagValues[k]
 			if !ok {
@@ -867,6 +1041,343 @@ func (q *QueryExecutor) executeShowFieldKeysStatement(stmt *influxql.ShowFieldKe
 	return result
 }
 
+// cardinalityScope is the database, shards and measurements a SHOW ... CARDINALITY
+// statement is executed against.
+type cardinalityScope struct {
+	db     *DatabaseIndex
+	shards []*Shard
+
+	// Sorted measurement names. Contains all measurements if the statement has no sources.
+	names []string
+}
+
+// newCardinalityScope returns the scope of a cardinality statement. Exact
+// statements read measurements from the database index while estimated
+// statements read them from the sketches of the local shards. Returns nil if
+// the database has no data.
+func (q *QueryExecutor) newCardinalityScope(name, database string, sources influxql.Sources, exact bool) (*cardinalityScope, error) {
+	if name == "" {
+		name = database
+	}
+	if name == "" {
+		return nil, errors.New("database name required")
+	}
+
+	dbi, err := q.MetaClient.Database(name)
+	if err != nil {
+		return nil, err
+	} else if dbi == nil {
+		return nil, ErrDatabaseNotFound(name)
+	}
+
+	// Find the database.
+	db := q.Store.DatabaseIndex(name)
+	if db == nil {
+		return nil, nil
+	}
+
+	// Sources are read from the database of the statement.
+	for _, src := range sources {
+		if m, ok := src.(*influxql.Measurement); ok {
+			m.Database = name
+		}
+	}
+
+	// Expand regex expressions in the FROM clause.
+	expanded, err := q.Store.ExpandSources(sources)
+	if err != nil {
+		return nil, err
+	} else if len(sources) != 0 && len(expanded) == 0 {
+		return nil, nil
+	}
+
+	var shardIDs []uint64
+	for _, si := range dbi.ShardInfos() {
+		shardIDs = append(shardIDs, si.ID)
+	}
+	scope := &cardinalityScope{db: db, shards: q.Store.Shards(shardIDs)}
+
+	if exact {
+		measurements, err := measurementsFromSourcesOrDB(db, expanded...)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range measurements {
+			scope.names = append(scope.names, m.Name)
+		}
+		return scope, nil
+	}
+
+	set := make(map[string]struct{})
+	if len(expanded) > 0 {
+		for _, src := range expanded {
+			m, ok := src.(*influxql.Measurement)
+			if !ok {
+				return nil, errors.New("identifiers in FROM clause must be measurement names")
+			}
+			set[m.Name] = struct{}{}
+		}
+	} else {
+		for _, sh := range scope.shards {
+			for _, name := range sh.Sketches().MeasurementNames() {
+				set[name] = struct{}{}
+			}
+		}
+	}
+	for name := range set {
+		scope.names = append(scope.names, name)
+	}
+	sort.Strings(scope.names)
+	return scope, nil
+}
+
+// estimate merges a sketch from each shard and returns the estimated cardinality.
+// fn may return nil if the shard has no sketch.
+func (s *cardinalityScope) estimate(fn func(c *CardinalitySketches) *hll.Plus) (int64, error) {
+	merged := hll.NewDefaultPlus()
+	for _, sh := range s.shards {
+		if h := fn(sh.Sketches()); h != nil {
+			if err := merged.Merge(h); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return int64(merged.Count()), nil
+}
+
+// countRows returns a row with the count of each measurement. fn returns the
+// exact count of a measurement and sketch returns its sketch in a shard.
+func (s *cardinalityScope) countRows(exact bool, fn func(m *Measurement) int, sketch func(c *CardinalitySketches, name string) *hll.Plus) (models.Rows, error) {
+	rows := make(models.Rows, 0, len(s.names))
+	for _, name := range s.names {
+		var n int64
+		if exact {
+			m := s.db.Measurement(name)
+			if m == nil {
+				continue
+			}
+			n = int64(fn(m))
+		} else {
+			v, err := s.estimate(func(c *CardinalitySketches) *hll.Plus { return sketch(c, name) })
+			if err != nil {
+				return nil, err
+			}
+			n = v
+		}
+
+		rows = append(rows, &models.Row{
+			Name:    name,
+			Columns: []string{"count"},
+			Values:  [][]interface{}{{n}},
+		})
+	}
+	return rows, nil
+}
+
+func (q *QueryExecutor) executeShowSeriesCardinalityStatement(stmt *influxql.ShowSeriesCardinalityStatement, database string) *influxql.Result {
+	scope, err := q.newCardinalityScope(stmt.Database, database, stmt.Sources, stmt.Exact)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if scope == nil {
+		return &influxql.Result{}
+	}
+
+	// Return one row per measurement if sources were given.
+	if len(stmt.Sources) > 0 {
+		rows, err := scope.countRows(stmt.Exact,
+			func(m *Measurement) int { return len(m.SeriesKeys()) },
+			(*CardinalitySketches).MeasurementSeries,
+		)
+		if err != nil {
+			return &influxql.Result{Err: err}
+		}
+		return &influxql.Result{Series: rows}
+	}
+
+	var n int64
+	if stmt.Exact {
+		n = int64(scope.db.SeriesN())
+	} else if n, err = scope.estimate((*CardinalitySketches).Series); err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{
+		Series: models.Rows{{Columns: []string{"count"}, Values: [][]interface{}{{n}}}},
+	}
+}
+
+func (q *QueryExecutor) executeShowMeasurementCardinalityStatement(stmt *influxql.ShowMeasurementCardinalityStatement, database string) *influxql.Result {
+	scope, err := q.newCardinalityScope(stmt.Database, database, nil, stmt.Exact)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if scope == nil {
+		return &influxql.Result{}
+	}
+
+	var n int64
+	if stmt.Exact {
+		n = int64(len(scope.names))
+	} else if n, err = scope.estimate((*CardinalitySketches).Measurements); err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{
+		Series: models.Rows{{Columns: []string{"count"}, Values: [][]interface{}{{n}}}},
+	}
+}
+
+func (q *QueryExecutor) executeShowTagKeyCardinalityStatement(stmt *influxql.ShowTagKeyCardinalityStatement, database string) *influxql.Result {
+	scope, err := q.newCardinalityScope(stmt.Database, database, stmt.Sources, stmt.Exact)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if scope == nil {
+		return &influxql.Result{}
+	}
+
+	rows, err := scope.countRows(stmt.Exact,
+		func(m *Measurement) int { return len(m.TagKeys()) },
+		(*CardinalitySketches).TagKeys,
+	)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{Series: rows}
+}
+
+func (q *QueryExecutor) executeShowTagValuesCardinalityStatement(stmt *influxql.ShowTagValuesCardinalityStatement, database string) *influxql.Result {
+	scope, err := q.newCardinalityScope(stmt.Database, database, stmt.Sources, stmt.Exact)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if scope == nil {
+		return &influxql.Result{}
+	}
+
+	keys := make([]string, len(stmt.TagKeys))
+	copy(keys, stmt.TagKeys)
+	sort.Strings(keys)
+
+	// Return a row per measurement with the count of each tag key.
+	result := &influxql.Result{Series: make(models.Rows, 0, len(scope.names))}
+	for _, name := range scope.names {
+		r := &models.Row{Name: name, Columns: []string{"key", "count"}}
+		for _, key := range keys {
+			var n int64
+			if stmt.Exact {
+				m := scope.db.Measurement(name)
+				if m == nil || !m.HasTagKey(key) {
+					continue
+				}
+				n = int64(len(m.TagValues(key)))
+			} else {
+				n, err = scope.estimate(func(c *CardinalitySketches) *hll.Plus { return c.TagValues(name, key) })
+				if err != nil {
+					return &influxql.Result{Err: err}
+				} else if n == 0 {
+					continue
+				}
+			}
+			r.Values = append(r.Values, []interface{}{key, n})
+		}
+
+		if len(r.Values) > 0 {
+			result.Series = append(result.Series, r)
+		}
+	}
+	return result
+}
+
+func (q *QueryExecutor) executeShowFieldKeyCardinalityStatement(stmt *influxql.ShowFieldKeyCardinalityStatement, database string) *influxql.Result {
+	scope, err := q.newCardinalityScope(stmt.Database, database, stmt.Sources, stmt.Exact)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	} else if scope == nil {
+		return &influxql.Result{}
+	}
+
+	rows, err := scope.countRows(stmt.Exact,
+		func(m *Measurement) int { return len(m.FieldNames()) },
+		(*CardinalitySketches).FieldKeys,
+	)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{Series: rows}
+}
+
+// retentionPolicySources returns a source for each retention policy of a database.
+func (q *QueryExecutor) retentionPolicySources(database string) (influxql.Sources, error) {
+	dbi, err := q.MetaClient.Database(database)
+	if err != nil {
+		return nil, err
+	} else if dbi == nil {
+		return nil, ErrDatabaseNotFound(database)
+	}
+
+	sources := make(influxql.Sources, 0, len(dbi.RetentionPolicies))
+	for _, rpi := range dbi.RetentionPolicies {
+		sources = append(sources, &influxql.Measurement{Database: database, RetentionPolicy: rpi.Name})
+	}
+	return sources, nil
+}
+
+// metaTimeRange restricts the series returned by a meta query to the series
+// written to shards which overlap the time range of its WHERE clause.
+type metaTimeRange struct {
+	shards   []*Shard
+	min, max int64
+}
+
+// newMetaTimeRange returns the time range of condition. Returns nil if the
+// condition does not restrict time.
+func (q *QueryExecutor) newMetaTimeRange(database string, condition influxql.Expr) (*metaTimeRange, error) {
+	if !influxql.HasTimeExpr(condition) {
+		return nil, nil
+	}
+
+	tr := &metaTimeRange{min: influxql.MinTime, max: influxql.MaxTime}
+	tmin, tmax := influxql.TimeRange(condition)
+	if !tmin.IsZero() {
+		tr.min = tmin.UnixNano()
+	}
+	if !tmax.IsZero() {
+		tr.max = tmax.UnixNano()
+	}
+
+	sources, err := q.retentionPolicySources(database)
+	if err != nil {
+		return nil, err
+	}
+	shardIDs, err := q.MetaClient.ShardIDsByTimeRange(sources, time.Unix(0, tr.min), time.Unix(0, tr.max))
+	if err != nil {
+		return nil, err
+	}
+	tr.shards = q.Store.Shards(shardIDs)
+	return tr, nil
+}
+
+// filter returns the ids of the series with data in the time range.
+func (tr *metaTimeRange) filter(m *Measurement, ids SeriesIDs) SeriesIDs {
+	series := make([]*Series, 0, len(ids))
+	for _, id := range ids {
+		if s := m.SeriesByID(id); s != nil {
+			series = append(series, s)
+		}
+	}
+
+	matched := make(map[uint64]struct{})
+	for _, sh := range tr.shards {
+		for _, s := range sh.seriesInTimeRange(series, tr.min, tr.max) {
+			matched[s.id] = struct{}{}
+		}
+	}
+
+	a := make(SeriesIDs, 0, len(matched))
+	for _, id := range ids {
+		if _, ok := matched[id]; ok {
+			a = append(a, id)
+		}
+	}
+	return a
+}
+
 // measurementsFromSourcesOrDB returns a list of measurements from the
 // sources passed in or, if sources is empty, a list of all
 // measurement names from the database passed in.
@@ -991,6 +1502,18 @@ var (
 	// ErrNotExecuted is returned when a statement is not executed in a query.
 	// This can occur when a previous statement in the same query has errored.
 	ErrNotExecuted = errors.New("not executed")
+
+	// ErrRebalancerDisabled is returned when SHOW REBALANCE is executed on a
+	// node without the rebalancer enabled.
+	ErrRebalancerDisabled = errors.New("rebalancer is not enabled")
+
+	// ErrAntiEntropyDisabled is returned when SHOW SHARD DIFFERENCES is
+	// executed on a node without anti-entropy enabled.
+	ErrAntiEntropyDisabled = errors.New("anti-entropy is not enabled")
+
+	// ErrReadConsistencyNotSupported is returned when a read consistency
+	// level above one is requested without a shard reader.
+	ErrReadConsistencyNotSupported = errors.New("read consistency level not supported")
 )
 
 // ErrDatabaseNotFound returns a database not found error for the given database name.
@@ -1023,6 +1546,28 @@ func convertRowToPoints(measurementName string, row *models.Row) ([]models.Point
 		return nil, errors.New("error finding time index in result")
 	}
 
+	// Dimensions are written as tags. Tags without a value are left off
+	// the series and a field can't be written with the same name as a tag.
+	// Such a column is only skipped if it has no values in the row.
+	tags := make(map[string]string, len(row.Tags))
+	for k, v := range row.Tags {
+		if v != "" {
+			tags[k] = v
+		}
+	}
+	for k := range tags {
+		i, ok := fieldIndexes[k]
+		if !ok {
+			continue
+		}
+		for _, v := range row.Values {
+			if v[i] != nil {
+				return nil, fmt.Errorf("field %s has the same name as a tag", k)
+			}
+		}
+		delete(fieldIndexes, k)
+	}
+
 	points := make([]models.Point, 0, len(row.Values))
 	for _, v := range row.Values {
 		vals := make(map[string]interface{})
@@ -1033,7 +1578,7 @@ func convertRowToPoints(measurementName string, row *models.Row) ([]models.Point
 			}
 		}
 
-		p, err := models.NewPoint(measurementName, row.Tags, vals, v[timeIndex].(time.Time))
+		p, err := models.NewPoint(measurementName, tags, vals, v[timeIndex].(time.Time))
 		if err != nil {
 			// Drop points that can't be stored
 			continue
@@ -1071,6 +1616,7 @@ func (e *emitterExecutor) execute(out chan *models.Row, closing <-chan struct{})
 
 	// Continually read rows from emitter until no more are available.
 	em := (*influxql.Emitter)(e)
+	defer em.Close()
 	for {
 		row := em.Emit()
 		if row == nil {
@@ -1079,7 +1625,7 @@ func (e *emitterExecutor) execute(out chan *models.Row, closing <-chan struct{})
 
 		select {
 		case <-closing:
-			break
+			return
 		case out <- row:
 		}
 	}
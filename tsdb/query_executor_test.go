@@ -2,7 +2,9 @@ package tsdb_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -72,6 +74,462 @@ func TestQueryExecutor_ExecuteQuery_Select_Wildcard_Intg(t *testing.T) {
 	}
 }
 
+// Ensure the query executor can join fields from multiple measurements.
+func TestQueryExecutor_ExecuteQuery_Select_Join_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA usage=10 0`,
+		`cpu,host=serverA usage=20 10`,
+		`cpu,host=serverB usage=30 10`,
+		`mem,host=serverA total=2 0`,
+		`mem,host=serverA total=4 10`,
+		`mem,host=serverB total=3 10`,
+	)
+
+	res := NewQueryExecutorStore(s).MustExecuteQueryStringJSON("db0", `SELECT cpu.usage / mem.total AS ratio FROM cpu, mem GROUP BY host`)
+	if res != `[{"series":[{"name":"cpu_mem","tags":{"host":"serverA"},"columns":["time","ratio"],"values":[["1970-01-01T00:00:00Z",5],["1970-01-01T00:00:10Z",5]]}]},{"series":[{"name":"cpu_mem","tags":{"host":"serverB"},"columns":["time","ratio"],"values":[["1970-01-01T00:00:10Z",10]]}]}]` {
+		t.Fatalf("unexpected results: %s", res)
+	}
+}
+
+// Ensure the query executor can sort aggregates by value across series.
+func TestQueryExecutor_ExecuteQuery_Select_OrderByValue_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverA value=2 10`,
+		`cpu,host=serverB value=3 20`,
+		`cpu,host=serverB value=5 30`,
+		`cpu,host=serverC value=4 10`,
+	)
+
+	res := NewQueryExecutorStore(s).MustExecuteQueryStringJSON("db0", `SELECT mean(value) FROM cpu WHERE time >= 0 AND time < 40s GROUP BY host ORDER BY mean DESC LIMIT 2`)
+	if res != `[{"series":[{"name":"cpu","tags":{"host":"serverB"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",4]]}]},{"series":[{"name":"cpu","tags":{"host":"serverC"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",4]]}]}]` {
+		t.Fatalf("unexpected results: %s", res)
+	}
+}
+
+// Ensure the query executor can cast field values and tell tags apart from
+// fields with the same name.
+func TestQueryExecutor_ExecuteQuery_Select_Cast_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA host="fieldA",value=1.5 0`,
+		`cpu,host=serverB value=2.5 10`,
+	)
+	s.MustCreateShardWithData("db0", "rp0", 1,
+		`cpu,host=serverA value=3i 3600`,
+		`cpu,host=serverC code="404" 3610`,
+		`cpu,host=serverC code="n/a" 3620`,
+	)
+
+	e := NewQueryExecutorStore(s)
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT value::integer FROM cpu`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:00Z",1],["1970-01-01T00:00:10Z",2],["1970-01-01T01:00:00Z",3]]}]}]`,
+		},
+		{
+			q:   `SELECT sum(value::integer) FROM cpu`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",6]]}]}]`,
+		},
+		{
+			q:   `SELECT host::tag, host::field FROM cpu WHERE host::tag = 'serverA'`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","host","host"],"values":[["1970-01-01T00:00:00Z","serverA","fieldA"]]}]}]`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host::tag = 'serverB'`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:10Z",2.5]]}]}]`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE value::integer = 2`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:10Z",2.5]]}]}]`,
+		},
+		{
+			q:   `SELECT code::integer FROM cpu WHERE code::integer >= 400`,
+			exp: `[{"series":[{"name":"cpu","columns":["time","code"],"values":[["1970-01-01T01:00:10Z",404]]}]}]`,
+		},
+	} {
+		if res := e.MustExecuteQueryStringJSON("db0", tt.q); res != tt.exp {
+			t.Errorf("%s: unexpected results: %s", tt.q, res)
+		}
+	}
+}
+
+// Ensure the query executor can apply string functions to fields and conditions.
+func TestQueryExecutor_ExecuteQuery_Select_StringFunctions_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`logs,host=serverA message="GET /index code=200",level="INFO" 0`,
+		`logs,host=serverA message="POST /login error code=500",level="Error" 10`,
+		`logs,host=serverB message="GET /health error",level="ERROR" 20`,
+	)
+
+	e := NewQueryExecutorStore(s)
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT upper(level), strlen(message) FROM logs WHERE contains(message, 'error')`,
+			exp: `[{"series":[{"name":"logs","columns":["time","upper","strlen"],"values":[["1970-01-01T00:00:10Z","ERROR",26],["1970-01-01T00:00:20Z","ERROR",17]]}]}]`,
+		},
+		{
+			q:   `SELECT regex_extract(message, /code=(\d+)/, 1) FROM logs WHERE lower(level) = 'error'`,
+			exp: `[{"series":[{"name":"logs","columns":["time","regex_extract"],"values":[["1970-01-01T00:00:10Z","500"],["1970-01-01T00:00:20Z",null]]}]}]`,
+		},
+		{
+			q:   `SELECT count(distinct(lower(level))) FROM logs`,
+			exp: `[{"series":[{"name":"logs","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}]`,
+		},
+	} {
+		if res := e.MustExecuteQueryStringJSON("db0", tt.q); res != tt.exp {
+			t.Errorf("%s: unexpected results: %s", tt.q, res)
+		}
+	}
+}
+
+// Ensure the query executor returns an error when a query is interrupted.
+func TestQueryExecutor_ExecuteQuery_Interrupt_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverA value=2 10`,
+	)
+
+	closing := make(chan struct{})
+	close(closing)
+
+	e := NewQueryExecutorStore(s)
+	ch, err := e.ExecuteQuery(MustParseQuery(`SELECT count(value) FROM cpu; SELECT value FROM cpu`), "db0", 0, closing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []*influxql.Result
+	for r := range ch {
+		results = append(results, r)
+	}
+	if len(results) != 2 {
+		t.Fatalf("unexpected result count: %d", len(results))
+	} else if results[0].Err != influxql.ErrQueryInterrupted {
+		t.Fatalf("unexpected error(0): %v", results[0].Err)
+	} else if results[1].Err != tsdb.ErrNotExecuted {
+		t.Fatalf("unexpected error(1): %v", results[1].Err)
+	}
+}
+
+// Ensure SELECT INTO keeps dimensions as tags and the types of fields.
+func TestQueryExecutor_ExecuteQuery_SelectInto_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA,region=east value=1,count=3i,msg="a" 0`,
+		`cpu,host=serverA,region=east value=2,count=5i,msg="b" 10`,
+		`mem,host=serverB free=4i,region="west" 0`,
+	)
+
+	e := NewQueryExecutorStore(s)
+	var written []string
+	e.IntoWriter.WritePointsIntoFn = func(p *tsdb.IntoWriteRequest) error {
+		if p.Database != "db0" || p.RetentionPolicy != "rp1" {
+			t.Fatalf("unexpected target: %s.%s", p.Database, p.RetentionPolicy)
+		}
+		for _, pt := range p.Points {
+			written = append(written, pt.String())
+		}
+		return nil
+	}
+
+	for _, tt := range []struct {
+		q      string
+		exp    string
+		points []string
+	}{
+		{
+			q:   `SELECT mean(*) INTO db0.rp1.:MEASUREMENT FROM /.*/ WHERE time < '1970-01-01T01:00:00Z' GROUP BY time(1h), *`,
+			exp: `[{"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",2]]}]}]`,
+			points: []string{
+				`cpu,host=serverA,region=east mean_count=4,mean_value=1.5 0`,
+				`mem,host=serverB mean_free=4 0`,
+			},
+		},
+		{
+			q:   `SELECT max(*), count(value) INTO db0.rp1.:MEASUREMENT FROM /.*/ WHERE time < '1970-01-01T01:00:00Z' GROUP BY time(1h), *`,
+			exp: `[{"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",2]]}]}]`,
+			points: []string{
+				`cpu,host=serverA,region=east count=2,max_count=5i,max_value=2 0`,
+				`mem,host=serverB max_free=4i 0`,
+			},
+		},
+		{
+			q:   `SELECT * INTO db0.rp1.:MEASUREMENT FROM /.*/ GROUP BY *`,
+			exp: `[{"series":[{"name":"result","columns":["time","written"],"values":[["1970-01-01T00:00:00Z",3]]}]}]`,
+			points: []string{
+				`cpu,host=serverA,region=east count=3i,msg="a",value=1 0`,
+				`cpu,host=serverA,region=east count=5i,msg="b",value=2 10000000000`,
+				`mem,host=serverB free=4i,region="west" 0`,
+			},
+		},
+		{
+			q:   `SELECT host, value INTO db0.rp1.cpu FROM cpu GROUP BY host`,
+			exp: `[{"error":"field host has the same name as a tag"}]`,
+		},
+	} {
+		written = nil
+		if res := e.MustExecuteQueryStringJSON("db0", tt.q); res != tt.exp {
+			t.Errorf("%s: unexpected results: %s", tt.q, res)
+		}
+		sort.Strings(written)
+		if !reflect.DeepEqual(written, tt.points) {
+			t.Errorf("%s: unexpected points:\n%s", tt.q, strings.Join(written, "\n"))
+		}
+	}
+}
+
+// Ensure the query executor can count series, tags and fields exactly and
+// estimate them across shards without counting them twice.
+func TestQueryExecutor_ExecuteQuery_ShowCardinality_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Write the same series to both shards.
+	for _, id := range []int{0, 1} {
+		s.MustCreateShardWithData("db0", "rp0", id,
+			`cpu,host=serverA,region=east value=1,load=2 0`,
+			`cpu,host=serverB,region=east value=2 10`,
+			`cpu,host=serverC,region=west value=3 20`,
+			`mem,host=serverA free=4 0`,
+		)
+	}
+
+	e := NewQueryExecutorStore(s)
+	e.MetaClient.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		sg := meta.ShardGroupInfo{ID: 1}
+		for _, id := range s.ShardIDs() {
+			sg.Shards = append(sg.Shards, meta.ShardInfo{ID: id})
+		}
+		return &meta.DatabaseInfo{
+			Name:                   name,
+			DefaultRetentionPolicy: DefaultRetentionPolicy,
+			RetentionPolicies:      []meta.RetentionPolicyInfo{{Name: DefaultRetentionPolicy, ShardGroups: []meta.ShardGroupInfo{sg}}},
+		}, nil
+	}
+
+	for _, exact := range []string{"", " EXACT"} {
+		for _, tt := range []struct {
+			q   string
+			exp string
+		}{
+			{
+				q:   `SHOW SERIES%s CARDINALITY`,
+				exp: `[{"series":[{"columns":["count"],"values":[[4]]}]}]`,
+			},
+			{
+				q:   `SHOW SERIES%s CARDINALITY ON db0 FROM /cpu|mem/`,
+				exp: `[{"series":[{"name":"cpu","columns":["count"],"values":[[3]]},{"name":"mem","columns":["count"],"values":[[1]]}]}]`,
+			},
+			{
+				q:   `SHOW MEASUREMENT%s CARDINALITY`,
+				exp: `[{"series":[{"columns":["count"],"values":[[2]]}]}]`,
+			},
+			{
+				q:   `SHOW TAG KEY%s CARDINALITY FROM cpu`,
+				exp: `[{"series":[{"name":"cpu","columns":["count"],"values":[[2]]}]}]`,
+			},
+			{
+				q:   `SHOW TAG VALUES%s CARDINALITY WITH KEY IN (region, host)`,
+				exp: `[{"series":[{"name":"cpu","columns":["key","count"],"values":[["host",3],["region",2]]},{"name":"mem","columns":["key","count"],"values":[["host",1]]}]}]`,
+			},
+			{
+				q:   `SHOW FIELD KEY%s CARDINALITY`,
+				exp: `[{"series":[{"name":"cpu","columns":["count"],"values":[[2]]},{"name":"mem","columns":["count"],"values":[[1]]}]}]`,
+			},
+		} {
+			q := fmt.Sprintf(tt.q, exact)
+			if res := e.MustExecuteQueryStringJSON("db0", q); res != tt.exp {
+				t.Errorf("%s: unexpected results: %s", q, res)
+			}
+		}
+	}
+}
+
+// Ensure meta queries with a time range only return series written to shards in the range.
+func TestQueryExecutor_ExecuteQuery_ShowTimeRange_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverB value=2 10`,
+	)
+	s.MustCreateShardWithData("db0", "rp0", 1,
+		`cpu,host=serverB value=3 3600`,
+		`mem,host=serverC free=4 3600`,
+	)
+
+	// Only the second shard overlaps times after 30m and no shard overlaps times after 2h.
+	e := NewQueryExecutorStore(s)
+	e.MetaClient.ShardIDsByTimeRangeFn = func(sources influxql.Sources, tmin, tmax time.Time) ([]uint64, error) {
+		if tmin.After(time.Unix(0, 0).Add(2 * time.Hour)) {
+			return nil, nil
+		} else if tmin.After(time.Unix(0, 0).Add(30 * time.Minute)) {
+			return []uint64{1}, nil
+		}
+		return []uint64{0, 1}, nil
+	}
+
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SHOW SERIES WHERE time > 40m`,
+			exp: `[{"series":[{"name":"cpu","columns":["_key","host"],"values":[["cpu,host=serverB","serverB"]]},{"name":"mem","columns":["_key","host"],"values":[["mem,host=serverC","serverC"]]}]}]`,
+		},
+		{
+			q:   `SHOW SERIES FROM cpu WHERE time < 40m AND host = 'serverA'`,
+			exp: `[{"series":[{"name":"cpu","columns":["_key","host"],"values":[["cpu,host=serverA","serverA"]]}]}]`,
+		},
+		{
+			q:   `SHOW TAG VALUES FROM cpu WITH KEY = host WHERE time > 40m`,
+			exp: `[{"series":[{"name":"hostTagValues","columns":["host"],"values":[["serverB"]]}]}]`,
+		},
+		{
+			q:   `SHOW MEASUREMENTS WHERE time > 40m AND host = 'serverC'`,
+			exp: `[{"series":[{"name":"measurements","columns":["name"],"values":[["mem"]]}]}]`,
+		},
+		{
+			q:   `SHOW MEASUREMENTS WHERE time > 40m`,
+			exp: `[{"series":[{"name":"measurements","columns":["name"],"values":[["cpu"],["mem"]]}]}]`,
+		},
+		{
+			q:   `SHOW SERIES WHERE time > now()`,
+			exp: `[{}]`,
+		},
+	} {
+		if res := e.MustExecuteQueryStringJSON("db0", tt.q); res != tt.exp {
+			t.Errorf("%s: unexpected results: %s", tt.q, res)
+		}
+	}
+}
+
+// Ensure meta queries can check that series have data in the time range.
+func TestQueryExecutor_ExecuteQuery_ShowTimeRange_CheckData_Intg(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+	s.EngineOptions.Config.MetaQueryCheckData = true
+
+	s.MustCreateShardWithData("db0", "rp0", 0,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverB value=2 3600`,
+	)
+
+	res := NewQueryExecutorStore(s).MustExecuteQueryStringJSON("db0", `SHOW TAG VALUES WITH KEY = host WHERE time > 30m`)
+	if res != `[{"series":[{"name":"hostTagValues","columns":["host"],"values":[["serverB"]]}]}]` {
+		t.Fatalf("unexpected results: %s", res)
+	}
+}
+
+// Ensure the query executor can explain a SELECT statement.
+func TestQueryExecutor_ExecuteQuery_Explain(t *testing.T) {
+	sh := MustOpenShard()
+	defer sh.Close()
+	sh.MustWritePointsString(`
+cpu,region=serverA value=1 0
+cpu,region=serverA value=2 10
+cpu,region=serverB value=3 20
+`)
+
+	e := NewQueryExecutor()
+	e.MetaClient.ShardIDsByTimeRangeFn = func(sources influxql.Sources, tmin, tmax time.Time) (a []uint64, err error) {
+		return []uint64{100}, nil
+	}
+	e.Store.ShardsFn = func(ids []uint64) []*tsdb.Shard {
+		return []*tsdb.Shard{sh.Shard}
+	}
+
+	res := e.MustExecuteQueryString("db0", `EXPLAIN SELECT max(value) FROM cpu WHERE region = 'serverA'`)
+	if len(res) != 1 || res[0].Err != nil || len(res[0].Series) != 1 {
+		t.Fatalf("unexpected results: %s", spew.Sdump(res))
+	}
+
+	row := res[0].Series[0]
+	if !reflect.DeepEqual(row.Columns, []string{"QUERY PLAN"}) {
+		t.Fatalf("unexpected columns: %v", row.Columns)
+	}
+	plan := planString(row.Values)
+	for _, s := range []string{
+		"EXPRESSION: max(value)",
+		"create_iterator",
+		"CONDITION: region = 'serverA'",
+		"NUMBER OF SERIES: 1",
+		"CACHED VALUES: 2",
+	} {
+		if !strings.Contains(plan, s) {
+			t.Fatalf("expected %q in plan:\n%s", s, plan)
+		}
+	}
+}
+
+// Ensure the query executor can execute and analyze a SELECT statement.
+func TestQueryExecutor_ExecuteQuery_ExplainAnalyze(t *testing.T) {
+	sh := MustOpenShard()
+	defer sh.Close()
+	sh.MustWritePointsString(`
+cpu,region=serverA value=1 0
+cpu,region=serverA value=2 10
+cpu,region=serverB value=3 20
+`)
+
+	e := NewQueryExecutor()
+	e.MetaClient.ShardIDsByTimeRangeFn = func(sources influxql.Sources, tmin, tmax time.Time) (a []uint64, err error) {
+		return []uint64{100}, nil
+	}
+	e.Store.ShardsFn = func(ids []uint64) []*tsdb.Shard {
+		return []*tsdb.Shard{sh.Shard}
+	}
+
+	res := e.MustExecuteQueryString("db0", `EXPLAIN ANALYZE SELECT value FROM cpu`)
+	if len(res) != 1 || res[0].Err != nil || len(res[0].Series) != 1 {
+		t.Fatalf("unexpected results: %s", spew.Sdump(res))
+	}
+
+	plan := planString(res[0].Series[0].Values)
+	for _, s := range []string{
+		"EXECUTION",
+		"ROWS RETURNED: 3",
+		"SERIES: 2",
+		"CACHE VALUES: 3",
+	} {
+		if !strings.Contains(plan, s) {
+			t.Fatalf("expected %q in plan:\n%s", s, plan)
+		}
+	}
+}
+
+// planString joins the lines of an EXPLAIN result.
+func planString(values [][]interface{}) string {
+	lines := make([]string, len(values))
+	for i, v := range values {
+		lines[i] = v[0].(string)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Ensure the query executor returns an empty set if no points are returned.
 /*
 func TestQueryExecutor_ExecuteQuery_Select_Empty(t *testing.T) {
@@ -432,14 +890,14 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.
 // IteratorCreator is a mockable implementation of SelectStatementExecutor.IteratorCreator.
 type IteratorCreator struct {
 	CreateIteratorFn  func(opt influxql.IteratorOptions) (influxql.Iterator, error)
-	FieldDimensionsFn func(sources influxql.Sources) (field, dimensions map[string]struct{}, err error)
+	FieldDimensionsFn func(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error)
 }
 
 func (ic *IteratorCreator) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator, error) {
 	return ic.CreateIteratorFn(opt)
 }
 
-func (ic *IteratorCreator) FieldDimensions(sources influxql.Sources) (field, dimensions map[string]struct{}, err error) {
+func (ic *IteratorCreator) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
 	return ic.FieldDimensionsFn(sources)
 }
 
@@ -0,0 +1,140 @@
+package tsdb
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// ErrInvalidReadConsistency is returned when parsing an unknown read
+// consistency level.
+var ErrInvalidReadConsistency = errors.New("invalid read consistency level")
+
+// ReadConsistency is the number of owners of a shard a query reads from.
+type ReadConsistency int
+
+const (
+	// ReadConsistencyOne reads the local replicas of shards only.
+	ReadConsistencyOne ReadConsistency = iota
+
+	// ReadConsistencyQuorum reads a quorum of the owners of each shard.
+	ReadConsistencyQuorum
+
+	// ReadConsistencyAll reads every owner of each shard.
+	ReadConsistencyAll
+)
+
+// ParseReadConsistency converts a consistency level string to a
+// ReadConsistency. Like writes, "any" and "one" are accepted and both read
+// the local replicas only. A blank level is the same as "one".
+func ParseReadConsistency(level string) (ReadConsistency, error) {
+	switch strings.ToLower(level) {
+	case "", "any", "one":
+		return ReadConsistencyOne, nil
+	case "quorum":
+		return ReadConsistencyQuorum, nil
+	case "all":
+		return ReadConsistencyAll, nil
+	default:
+		return 0, ErrInvalidReadConsistency
+	}
+}
+
+// Required returns the number of owners a read of a shard with n owners
+// must reach.
+func (c ReadConsistency) Required(n int) int {
+	switch c {
+	case ReadConsistencyQuorum:
+		return n/2 + 1
+	case ReadConsistencyAll:
+		return n
+	default:
+		return 1
+	}
+}
+
+// String returns the string representation of the level.
+func (c ReadConsistency) String() string {
+	switch c {
+	case ReadConsistencyQuorum:
+		return "quorum"
+	case ReadConsistencyAll:
+		return "all"
+	default:
+		return "one"
+	}
+}
+
+// ShardReadOptions limits the values read from the owners of shards to the
+// sources, condition and time range of a SELECT statement.
+type ShardReadOptions struct {
+	Sources   influxql.Sources
+	Condition influxql.Expr
+	MinTime   time.Time
+	MaxTime   time.Time
+}
+
+// shardsWithPoints is an iterator creator for the local shards combined with
+// the values read from other owners of the shards.
+type shardsWithPoints struct {
+	shards Shards
+	points *PointSet
+}
+
+// CreateIterator returns a single combined iterator for the shards and the points.
+func (ic *shardsWithPoints) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+	if influxql.Sources(opt.Sources).HasSystemSource() {
+		return ic.shards.CreateIterator(opt)
+	}
+
+	itrs, err := ic.shards.createIterators(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	itr, err := ic.points.CreateIterator(opt)
+	if err != nil {
+		influxql.Iterators(itrs).Close()
+		return nil, err
+	}
+	return mergeShardIterators(append(itrs, itr), opt), nil
+}
+
+// FieldDimensions returns the unique fields and dimensions of the shards and the points.
+func (ic *shardsWithPoints) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+	fields, dimensions, err = ic.shards.FieldDimensions(sources)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, d, err := ic.points.FieldDimensions(sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, typ := range f {
+		fields[k] = mergeFieldType(fields[k], typ)
+	}
+	for k := range d {
+		dimensions[k] = struct{}{}
+	}
+	return fields, dimensions, nil
+}
+
+// SeriesKeys returns the series of the shards and the points.
+func (ic *shardsWithPoints) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList, error) {
+	if influxql.Sources(opt.Sources).HasSystemSource() {
+		return ic.shards.SeriesKeys(opt)
+	}
+
+	a, err := ic.shards.SeriesKeys(opt)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ic.points.SeriesKeys(opt)
+	if err != nil {
+		return nil, err
+	}
+	return mergeSeriesLists(a, b), nil
+}
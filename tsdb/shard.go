@@ -59,6 +59,9 @@ type Shard struct {
 	mu                sync.RWMutex
 	measurementFields map[string]*MeasurementFields // measurement name to their fields
 
+	// estimates of the number of distinct series, tags and fields in the shard
+	sketches *CardinalitySketches
+
 	// expvar-based stats.
 	statMap *expvar.Map
 
@@ -80,27 +83,41 @@ func NewShard(id uint64, index *DatabaseIndex, path string, walPath string, opti
 		id:                id,
 		options:           options,
 		measurementFields: make(map[string]*MeasurementFields),
+		sketches:          NewCardinalitySketches(),
 
 		statMap:   statMap,
 		LogOutput: os.Stderr,
 	}
 }
 
+// ID returns the shard's ID.
+func (s *Shard) ID() uint64 { return s.id }
+
 // Path returns the path set on the shard when it was created.
 func (s *Shard) Path() string { return s.path }
 
+// Sketches returns the cardinality sketches of the shard.
+func (s *Shard) Sketches() *CardinalitySketches { return s.sketches }
+
 // PerformMaintenance gets called periodically to have the engine perform
 // any maintenance tasks like WAL flushing and compaction
 func (s *Shard) PerformMaintenance() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	s.engine.PerformMaintenance()
 }
 
 // Open initializes and opens the shard's store.
 func (s *Shard) Open() error {
-	if err := func() error {
-		s.mu.Lock()
-		defer s.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open()
+}
 
+// open opens the shard's engine and loads its metadata index.
+// Must be called with s.mu held.
+func (s *Shard) open() error {
+	if err := func() error {
 		s.index.mu.Lock()
 		defer s.index.mu.Unlock()
 
@@ -210,13 +227,20 @@ func (s *Shard) WritePoints(points []models.Point) error {
 
 	if len(seriesToAddShardTo) > 0 {
 		s.index.mu.Lock()
+		var added []*Series
 		for _, k := range seriesToAddShardTo {
 			ss := s.index.series[k]
 			if ss != nil {
 				ss.shardIDs[s.id] = true
+				added = append(added, ss)
 			}
 		}
 		s.index.mu.Unlock()
+
+		// series are added to the sketches the first time they are written to the shard
+		for _, ss := range added {
+			s.sketches.AddSeries(ss.measurement.Name, ss.Key, ss.Tags)
+		}
 	}
 
 	// add any new fields and keep track of what needs to be saved
@@ -264,6 +288,8 @@ func (s *Shard) WritePoints(points []models.Point) error {
 
 // DeleteSeries deletes a list of series.
 func (s *Shard) DeleteSeries(seriesKeys []string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.engine.DeleteSeries(seriesKeys)
 }
 
@@ -278,6 +304,7 @@ func (s *Shard) DeleteMeasurement(name string, seriesKeys []string) error {
 
 	// Remove entry from shard index.
 	delete(s.measurementFields, name)
+	s.sketches.DeleteMeasurement(name)
 
 	return nil
 }
@@ -314,6 +341,8 @@ func (s *Shard) createFieldsAndMeasurements(fieldsToCreate []*FieldCreate) (map[
 		// ensure the measurement is in the index and the field is there
 		measurement := s.index.CreateMeasurementIndexIfNotExists(f.Measurement)
 		measurement.SetFieldName(f.Field.Name)
+
+		s.sketches.AddField(f.Measurement, f.Field.Name)
 	}
 
 	return measurementsToSave, nil
@@ -372,26 +401,128 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]*SeriesCreate,
 	return seriesToCreate, fieldsToCreate, seriesToAddShardTo, nil
 }
 
+// seriesInTimeRange returns the series which have been written to the shard.
+// If meta-query-check-data is enabled only series with data between min and
+// max are returned. The caller must not hold the index lock.
+func (s *Shard) seriesInTimeRange(series []*Series, min, max int64) []*Series {
+	var a []*Series
+	s.index.mu.RLock()
+	for _, ss := range series {
+		if ss.shardIDs[s.id] {
+			a = append(a, ss)
+		}
+	}
+	s.index.mu.RUnlock()
+
+	if !s.options.Config.MetaQueryCheckData {
+		return a
+	}
+	checker, ok := s.engine.(SeriesDataChecker)
+	if !ok {
+		return a
+	}
+
+	filtered := a[:0]
+	for _, ss := range a {
+		if checker.SeriesHasDataInRange(ss.Key, min, max) {
+			filtered = append(filtered, ss)
+		}
+	}
+	return filtered
+}
+
 // SeriesCount returns the number of series buckets on the shard.
-func (s *Shard) SeriesCount() (int, error) { return s.engine.SeriesCount() }
+func (s *Shard) SeriesCount() (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.engine.SeriesCount()
+}
+
+// Digest returns a summary of the values of every series key and field in the shard.
+func (s *Shard) Digest() (Digest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.engine.(Digester)
+	if !ok {
+		return nil, ErrDigestNotSupported
+	}
+	return d.Digest()
+}
+
+// Points returns the values of the digest keys between min and max,
+// inclusive, as points.
+func (s *Shard) Points(keys []string, min, max int64) ([]models.Point, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.engine.(Digester)
+	if !ok {
+		return nil, ErrDigestNotSupported
+	}
+	return d.Points(keys, min, max)
+}
 
 // WriteTo writes the shard's data to w.
 func (s *Shard) WriteTo(w io.Writer) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	n, err := s.engine.WriteTo(w)
 	s.statMap.Add(statWriteBytes, int64(n))
 	return n, err
 }
 
+// Restore adds the data files of a tar archive written by the engine's Backup
+// to the shard. Restored files never replace the shard's existing files.
+func (s *Shard) Restore(r io.Reader) error {
+	if err := func() error {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.engine.Restore(r, s.path)
+	}(); err != nil {
+		return err
+	}
+
+	// Add the series and fields of the restored files to the index.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	return s.engine.LoadMetadataIndex(s, s.index, s.measurementFields)
+}
+
 // CreateIterator returns an iterator for the data in the shard.
 func (s *Shard) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.engine.CreateIterator(opt)
 }
 
+// IteratorCost estimates the work required to create an iterator for opt.
+// Returns a zero cost if the engine does not support estimation.
+func (s *Shard) IteratorCost(opt influxql.IteratorOptions) (influxql.IteratorCost, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	est, ok := s.engine.(influxql.IteratorCostEstimator)
+	if !ok {
+		return influxql.IteratorCost{NumShards: 1}, nil
+	}
+	cost, err := est.IteratorCost(opt)
+	if err != nil {
+		return influxql.IteratorCost{}, err
+	}
+	cost.NumShards = 1
+	return cost, nil
+}
+
 // FieldDimensions returns unique sets of fields and dimensions across a list of sources.
-func (s *Shard) FieldDimensions(sources influxql.Sources) (fields, dimensions map[string]struct{}, err error) {
-	fields = make(map[string]struct{})
+// Fields are returned with their type. A field with different types in different
+// measurements is returned with the type that it is cast to when it is read.
+func (s *Shard) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+	fields = make(map[string]influxql.DataType)
 	dimensions = make(map[string]struct{})
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	for _, src := range sources {
 		switch m := src.(type) {
 		case *influxql.Measurement:
@@ -402,8 +533,15 @@ func (s *Shard) FieldDimensions(sources influxql.Sources) (fields, dimensions ma
 			}
 
 			// Append fields and dimensions.
+			mf := s.measurementFields[m.Name]
 			for _, name := range mm.FieldNames() {
-				fields[name] = struct{}{}
+				typ := influxql.Unknown
+				if mf != nil {
+					if f := mf.Fields[name]; f != nil {
+						typ = f.Type
+					}
+				}
+				fields[name] = mergeFieldType(fields[name], typ)
 			}
 			for _, key := range mm.TagKeys() {
 				dimensions[key] = struct{}{}
@@ -416,6 +554,8 @@ func (s *Shard) FieldDimensions(sources influxql.Sources) (fields, dimensions ma
 
 // SeriesKeys returns a list of series in the shard.
 func (s *Shard) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.engine.SeriesKeys(opt)
 }
 
@@ -432,6 +572,15 @@ func (a Shards) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator,
 		return a.createSystemIterator(opt)
 	}
 
+	itrs, err := a.createIterators(opt)
+	if err != nil {
+		return nil, err
+	}
+	return mergeShardIterators(itrs, opt), nil
+}
+
+// createIterators returns an iterator for each shard.
+func (a Shards) createIterators(opt influxql.IteratorOptions) ([]influxql.Iterator, error) {
 	// Create iterators for each shard.
 	// Ensure that they are closed if an error occurs.
 	itrs := make([]influxql.Iterator, 0, len(a))
@@ -448,10 +597,13 @@ func (a Shards) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator,
 		influxql.Iterators(itrs).Close()
 		return nil, err
 	}
+	return itrs, nil
+}
 
-	// Merge into a single iterator.
+// mergeShardIterators merges the iterators of multiple shards into a single iterator.
+func mergeShardIterators(itrs []influxql.Iterator, opt influxql.IteratorOptions) influxql.Iterator {
 	if opt.MergeSorted() {
-		return influxql.NewSortedMergeIterator(itrs, opt), nil
+		return influxql.NewSortedMergeIterator(itrs, opt)
 	}
 
 	itr := influxql.NewMergeIterator(itrs, opt)
@@ -463,7 +615,7 @@ func (a Shards) CreateIterator(opt influxql.IteratorOptions) (influxql.Iterator,
 			}
 		}
 	}
-	return influxql.NewCallIterator(itr, opt), nil
+	return influxql.NewCallIterator(itr, opt)
 }
 
 // createSystemIterator returns an iterator for a system source.
@@ -484,6 +636,23 @@ func (a Shards) createSystemIterator(opt influxql.IteratorOptions) (influxql.Ite
 	}
 }
 
+// IteratorCost returns the combined cost estimate across all shards.
+func (a Shards) IteratorCost(opt influxql.IteratorOptions) (influxql.IteratorCost, error) {
+	var cost influxql.IteratorCost
+	if influxql.Sources(opt.Sources).HasSystemSource() {
+		return cost, nil
+	}
+
+	for _, sh := range a {
+		c, err := sh.IteratorCost(opt)
+		if err != nil {
+			return influxql.IteratorCost{}, err
+		}
+		cost = cost.Combine(c)
+	}
+	return cost, nil
+}
+
 // SeriesKeys returns a list of series in in all shards in a. If a series
 // exists in multiple shards in a, all instances will be combined into a single
 // Series by calling Combine on it.
@@ -497,13 +666,21 @@ func (a Shards) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList, e
 		return []influxql.Series{{Aux: []influxql.DataType{influxql.String}}}, nil
 	}
 
-	seriesMap := make(map[string]influxql.Series)
+	lists := make([]influxql.SeriesList, 0, len(a))
 	for _, sh := range a {
 		series, err := sh.SeriesKeys(opt)
 		if err != nil {
 			return nil, err
 		}
+		lists = append(lists, series)
+	}
+	return mergeSeriesLists(lists...), nil
+}
 
+// mergeSeriesLists combines the series of multiple lists into a single sorted list.
+func mergeSeriesLists(lists ...influxql.SeriesList) influxql.SeriesList {
+	seriesMap := make(map[string]influxql.Series)
+	for _, series := range lists {
 		for _, s := range series {
 			cur, ok := seriesMap[s.ID()]
 			if ok {
@@ -519,7 +696,7 @@ func (a Shards) SeriesKeys(opt influxql.IteratorOptions) (influxql.SeriesList, e
 		seriesList = append(seriesList, s)
 	}
 	sort.Sort(influxql.SeriesList(seriesList))
-	return influxql.SeriesList(seriesList), nil
+	return influxql.SeriesList(seriesList)
 }
 
 // createMeasurementsIterator returns an iterator for all measurement names.
@@ -561,8 +738,8 @@ func (a Shards) createTagKeysIterator(opt influxql.IteratorOptions) (influxql.It
 }
 
 // FieldDimensions returns the unique fields and dimensions across a list of sources.
-func (a Shards) FieldDimensions(sources influxql.Sources) (fields, dimensions map[string]struct{}, err error) {
-	fields = make(map[string]struct{})
+func (a Shards) FieldDimensions(sources influxql.Sources) (fields map[string]influxql.DataType, dimensions map[string]struct{}, err error) {
+	fields = make(map[string]influxql.DataType)
 	dimensions = make(map[string]struct{})
 
 	for _, sh := range a {
@@ -570,8 +747,8 @@ func (a Shards) FieldDimensions(sources influxql.Sources) (fields, dimensions ma
 		if err != nil {
 			return nil, nil, err
 		}
-		for k := range f {
-			fields[k] = struct{}{}
+		for k, typ := range f {
+			fields[k] = mergeFieldType(fields[k], typ)
 		}
 		for k := range d {
 			dimensions[k] = struct{}{}
@@ -580,6 +757,15 @@ func (a Shards) FieldDimensions(sources influxql.Sources) (fields, dimensions ma
 	return
 }
 
+// mergeFieldType returns the type of a field that has been seen as both a and b.
+// Mixed types use the same order as iterator casting: float > integer > string > boolean.
+func mergeFieldType(a, b influxql.DataType) influxql.DataType {
+	if a == influxql.Unknown || (b != influxql.Unknown && b < a) {
+		return b
+	}
+	return a
+}
+
 // MeasurementFields holds the fields of a measurement and their codec.
 type MeasurementFields struct {
 	Fields map[string]*Field `json:"fields"`
@@ -924,17 +1110,35 @@ func NewMeasurementIterator(sh *Shard, opt influxql.IteratorOptions) (*Measureme
 		itr.source, _ = opt.Sources[0].(*influxql.Measurement)
 	}
 
+	// Time is checked against the series in the shard instead of the index.
+	condition := opt.Condition
+	hasTime := influxql.HasTimeExpr(condition)
+	if hasTime {
+		condition = stripTimeExpr(condition)
+	}
+
 	// Retrieve measurements from shard. Filter if condition specified.
-	if opt.Condition == nil {
+	if condition == nil {
 		itr.mms = sh.index.Measurements()
 	} else {
-		mms, err := sh.index.measurementsByExpr(opt.Condition)
+		mms, err := sh.index.measurementsByExpr(condition)
 		if err != nil {
 			return nil, err
 		}
 		itr.mms = mms
 	}
 
+	// Only return measurements with series in the time range.
+	if hasTime {
+		mms := itr.mms[:0]
+		for _, mm := range itr.mms {
+			if len(sh.seriesInTimeRange(mm.series(), opt.StartTime, opt.EndTime)) > 0 {
+				mms = append(mms, mm)
+			}
+		}
+		itr.mms = mms
+	}
+
 	// Sort measurements by name.
 	sort.Sort(itr.mms)
 
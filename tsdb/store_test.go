@@ -1,6 +1,7 @@
 package tsdb_test
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -67,6 +68,89 @@ func TestStore_DeleteShard(t *testing.T) {
 	}
 }
 
+// Ensure the store can restore a shard from another store's backup.
+func TestStore_RestoreShard(t *testing.T) {
+	src := MustOpenStore()
+	defer src.Close()
+	src.MustCreateShardWithData("db0", "rp0", 1,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverB value=2 10`,
+	)
+
+	var buf bytes.Buffer
+	if err := src.BackupShard(1, time.Time{}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := MustOpenStore()
+	defer dst.Close()
+
+	// Restoring requires the shard to exist.
+	if err := dst.RestoreShard(1, bytes.NewReader(buf.Bytes())); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Restore the shard and verify its series are indexed.
+	if err := dst.CreateShard("db0", "rp0", 1); err != nil {
+		t.Fatal(err)
+	} else if err := dst.RestoreShard(1, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	} else if n := dst.DatabaseIndex("db0").SeriesN(); n != 2 {
+		t.Fatalf("unexpected series count: %d", n)
+	} else if m := dst.Measurement("db0", "cpu"); m == nil {
+		t.Fatal("expected measurement")
+	}
+
+	// An archive truncated in the middle of a file is rejected.
+	if err := dst.RestoreShard(1, bytes.NewReader(buf.Bytes()[:520])); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// Ensure the cardinality sketches of a shard are rebuilt when the store is reopened.
+func TestStore_Open_CardinalitySketches(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 1,
+		`cpu,host=serverA value=1 0`,
+		`cpu,host=serverB value=2 10`,
+		`cpu,host=serverC value=3,load=4 20`,
+	)
+
+	// Reopen the store and verify the estimates.
+	s, err := ReopenStore(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	sketches := s.Shard(1).Sketches()
+	if n := sketches.Series().Count(); n != 3 {
+		t.Fatalf("unexpected series cardinality: %d", n)
+	} else if n := sketches.TagValues("cpu", "host").Count(); n != 3 {
+		t.Fatalf("unexpected tag value cardinality: %d", n)
+	} else if n := sketches.FieldKeys("cpu").Count(); n != 2 {
+		t.Fatalf("unexpected field key cardinality: %d", n)
+	}
+}
+
+// Ensure series already in the database index are added to the sketches of
+// each shard they are written to.
+func TestStore_WriteToShard_CardinalitySketches(t *testing.T) {
+	s := MustOpenStore()
+	defer s.Close()
+
+	s.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=serverA value=1 0`)
+	s.MustCreateShardWithData("db0", "rp0", 2, `cpu,host=serverA value=2 10`)
+
+	if n := s.Shard(2).Sketches().Series().Count(); n != 1 {
+		t.Fatalf("unexpected series cardinality: %d", n)
+	} else if n := s.Shard(2).Sketches().TagValues("cpu", "host").Count(); n != 1 {
+		t.Fatalf("unexpected tag value cardinality: %d", n)
+	}
+}
+
 // Ensure the store reports an error when it can't open a database directory.
 func TestStore_Open_InvalidDatabaseFile(t *testing.T) {
 	s := NewStore()